@@ -16,7 +16,7 @@ package atasmart
 import (
 	"fmt"
 
-	"github.com/openebs/smart/utilities"
+	"github.com/openebs/smart/internal/utilities"
 )
 
 // Table 47 of  T13/2161-D Revision 5
@@ -66,6 +66,7 @@ var ataMinorVersions = map[uint16]string{
 	0x005e: "ACS-4 T13/BSR INCITS 529 revision 5",
 	0x006d: "ACS-3 T13/2161-D revision 5",
 	0x0082: "ACS-2 published, ANSI INCITS 482-2012",
+	0x009c: "ACS-4 published, ANSI INCITS 529-2018",
 	0x0107: "ATA8-ACS T13/1699-D revision 2d",
 	0x010a: "ACS-3 published, ANSI INCITS 522-2014",
 	0x0110: "ACS-2 T13/2015-D revision 3",
@@ -74,27 +75,47 @@ var ataMinorVersions = map[uint16]string{
 
 // IdentDevData struct is an ATA IDENTIFY DEVICE struct. ATA8-ACS defines this as a page of 16-bit words.
 type IdentDevData struct {
-	_              [10]uint16  // ...
-	SerialNumber   [20]byte    // Word 10..19, device serial number, padded with spaces (20h).
-	_              [3]uint16   // ...
-	FirmwareRev    [8]byte     // Word 23..26, device firmware revision, padded with spaces (20h).
-	ModelNumber    [40]byte    // Word 27..46, device model number, padded with spaces (20h).
-	_              [33]uint16  // ...
-	MajorVer       uint16      // Word 80, major version number.
-	MinorVer       uint16      // Word 81, minor version number.
-	_              [3]uint16   // ...
-	Word85         uint16      // Word 85, supported commands and feature sets.
-	_              uint16      // ...
-	Word87         uint16      // Word 87, supported commands and feature sets.
-	_              [18]uint16  // ...
-	SectorSize     uint16      // Word 106, Logical/physical sector size.
-	_              [1]uint16   // ...
-	WWN            [4]uint16   // Word 108..111, WWN (World Wide Name).
-	_              [105]uint16 // ...
-	RotationRate   uint16      // Word 217, nominal media rotation rate.
-	_              [4]uint16   // ...
-	TransportMajor uint16      // Word 222, transport major version number.
-	_              [33]uint16  // ...
+	_              [10]uint16 // ...
+	SerialNumber   [20]byte   // Word 10..19, device serial number, padded with spaces (20h).
+	_              [3]uint16  // ...
+	FirmwareRev    [8]byte    // Word 23..26, device firmware revision, padded with spaces (20h).
+	ModelNumber    [40]byte   // Word 27..46, device model number, padded with spaces (20h).
+	_              uint16     // ...
+	Word48         uint16     // Word 48, trusted computing feature set options.
+	_              [10]uint16 // ...
+	Word59         uint16     // Word 59, sanitize feature set and sectors per DRQ data block.
+	_              [9]uint16  // ...
+	Word69         uint16     // Word 69, additional supported features (zoned capabilities).
+	_              [6]uint16  // ...
+	Word76         uint16     // Word 76, Serial ATA capabilities.
+	_              [3]uint16  // ...
+	MajorVer       uint16     // Word 80, major version number.
+	MinorVer       uint16     // Word 81, minor version number.
+	Word82         uint16     // Word 82, supported commands and feature sets.
+	Word83         uint16     // Word 83, supported commands and feature sets.
+	Word84         uint16     // Word 84, supported commands and feature sets.
+	Word85         uint16     // Word 85, commands and feature sets enabled.
+	Word86         uint16     // Word 86, commands and feature sets enabled.
+	Word87         uint16     // Word 87, commands and feature sets enabled.
+	_              [18]uint16 // ...
+	SectorSize     uint16     // Word 106, Logical/physical sector size.
+	_              [1]uint16  // ...
+	WWN            [4]uint16  // Word 108..111, WWN (World Wide Name).
+	_              [7]uint16  // ...
+	Word119        uint16     // Word 119, supported commands and feature sets.
+	Word120        uint16     // Word 120, commands and feature sets enabled.
+	_              [7]uint16  // ...
+	Word128        uint16     // Word 128, security status.
+	_              [40]uint16 // ...
+	Word169        uint16     // Word 169, DATA SET MANAGEMENT (TRIM) support.
+	_              [36]uint16 // ...
+	Word206        uint16     // Word 206, SCT Command Transport.
+	_              [10]uint16 // ...
+	RotationRate   uint16     // Word 217, nominal media rotation rate.
+	_              [4]uint16  // ...
+	TransportMajor uint16     // Word 222, transport major version number.
+	TransportMinor uint16     // Word 223, transport minor version number.
+	_              [32]uint16 // ...
 } // 512 bytes
 
 // swapByteOrder swaps the order of every second byte in a byte slice (modifies slice in-place).
@@ -123,13 +144,27 @@ func (d *IdentDevData) GetFirmwareRevision() []byte {
 	return d.swapByteOrder(d.FirmwareRev[:])
 }
 
-// GetWWN returns the worldwide unique name for a disk
+// GetWWNValue returns the 64-bit worldwide name of a disk from an ATA IDENTIFY command. The second
+// return value is false if the device does not report a valid WWN (the NAA field must be 5h).
+func (d *IdentDevData) GetWWNValue() (uint64, bool) {
+	wwn := uint64(d.WWN[0])<<48 | uint64(d.WWN[1])<<32 | uint64(d.WWN[2])<<16 | uint64(d.WWN[3])
+
+	if wwn>>60 != 0x5 {
+		return wwn, false
+	}
+
+	return wwn, true
+}
+
+// GetWWN returns the worldwide unique name for a disk in the canonical "naa." format, as used
+// by udev and multipath, or an empty string if the device does not report a valid WWN.
 func (d *IdentDevData) GetWWN() string {
-	NAA := d.WWN[0] >> 12
-	IEEEOUI := (uint32(d.WWN[0]&0x0fff) << 12) | (uint32(d.WWN[1]) >> 4)
-	UniqueID := ((uint64(d.WWN[1]) & 0xf) << 32) | (uint64(d.WWN[2]) << 16) | uint64(d.WWN[3])
+	wwn, ok := d.GetWWNValue()
+	if !ok {
+		return ""
+	}
 
-	return fmt.Sprintf("%x %06x %09x", NAA, IEEEOUI, UniqueID)
+	return fmt.Sprintf("naa.%016x", wwn)
 }
 
 // GetSectorSize returns logical and physical sector sizes of a disk
@@ -146,6 +181,12 @@ func (d *IdentDevData) GetSectorSize() (uint16, uint16) {
 	return LogSec, PhySec
 }
 
+// GetRotationRate returns the nominal media rotation rate in RPM (0 for solid state devices or when
+// not reported) along with a human-readable form.
+func (d *IdentDevData) GetRotationRate() (uint16, string) {
+	return utilities.DecodeRotationRate(d.RotationRate)
+}
+
 // GetATAMajorVersion returns the ATA major version from an ATA IDENTIFY command.
 func (d *IdentDevData) GetATAMajorVersion() (s string) {
 	if (d.MajorVer == 0) || (d.MajorVer == 0xffff) {
@@ -174,6 +215,12 @@ func (d *IdentDevData) GetATAMajorVersion() (s string) {
 		s = "ACS-2"
 	case 10:
 		s = "ACS-3"
+	case 11:
+		s = "ACS-4"
+	case 12:
+		s = "ACS-5"
+	default:
+		s = fmt.Sprintf("unknown (%#04x)", d.MajorVer)
 	}
 
 	return
@@ -190,7 +237,24 @@ func (d *IdentDevData) GetATAMinorVersion() string {
 		return s
 	}
 
-	return "unknown"
+	return fmt.Sprintf("unknown (%#04x)", d.MinorVer)
+}
+
+// GetTransportMinorVersion returns the transport minor version from an ATA IDENTIFY command.
+func (d *IdentDevData) GetTransportMinorVersion() string {
+	if (d.TransportMinor == 0) || (d.TransportMinor == 0xffff) {
+		return "This device does not report transport minor version"
+	}
+
+	// Like the ATA minor version, the transport minor version word is not a bitmask
+	switch d.TransportMinor {
+	case 0x0021:
+		return "ATA8-AST T13/1697-D revision 0b"
+	case 0x0051:
+		return "ATA8-AST T13/1697-D revision 1"
+	}
+
+	return fmt.Sprintf("unknown (%#04x)", d.TransportMinor)
 }
 
 // Transport returns the type of ata transport being used such as serial ATA, parallel ATA.
@@ -223,6 +287,12 @@ func (d *IdentDevData) Transport() (s string) {
 			s += " SATA 3.1"
 		case 7:
 			s += " SATA 3.2"
+		case 8:
+			s += " SATA 3.3"
+		case 9:
+			s += " SATA 3.4"
+		case 10:
+			s += " SATA 3.5"
 		default:
 			s += fmt.Sprintf(" SATA (%#03x)", d.TransportMajor&0x0fff)
 		}
@@ -0,0 +1,140 @@
+/*
+Copyright 2018 The OpenEBS Authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Decoded SMART attribute collection.
+
+package atasmart
+
+import "sort"
+
+// Attr is a SMART attribute decoded using the drive database
+type Attr struct {
+	ID        uint8
+	Name      string
+	Flags     uint16
+	Value     uint8   // normalized current value
+	Worst     uint8   // worst normalized value
+	Threshold uint8   // failure threshold for the normalized value, 0 if not reported
+	RawBytes  [6]byte // 6-byte raw value as reported by the device
+	Raw       uint64  // raw value interpreted according to the attribute's raw format
+	RawString string  // interpreted raw value as displayed by smartctl, e.g. "34 (Min/Max 20/45)"
+
+	FailingNow   bool // normalized value is at or below the threshold
+	FailedInPast bool // worst value is at or below the threshold, but the current value is not
+}
+
+// AttrCollection is a collection of SMART attributes ordered by attribute ID
+type AttrCollection []Attr
+
+// NewAttrCollection decodes the attributes of a SMART READ DATA page, using the drive database
+// entry for the given model and firmware revision to name and interpret them. Thresholds are
+// taken from the SMART READ THRESHOLDS page, which may be nil.
+func NewAttrCollection(page *SmartPage, thresholds *SmartThresholdPage, model, firmware string) AttrCollection {
+	var attrs AttrCollection
+
+	for i := range page.Attrs {
+		entry := &page.Attrs[i]
+		if entry.ID == 0 {
+			continue
+		}
+
+		def := LookupAttrDef(model, firmware, entry.ID)
+		attr := Attr{
+			ID:        entry.ID,
+			Name:      def.Name,
+			Flags:     entry.Flags,
+			Value:     entry.Value,
+			Worst:     entry.Worst,
+			RawBytes:  entry.VendorBytes,
+			Raw:       def.DecodeRaw(entry),
+			RawString: def.FormatRaw(entry),
+		}
+		if thresholds != nil {
+			attr.Threshold, _ = thresholds.Threshold(entry.ID)
+		}
+
+		// A threshold of 0 means the attribute can never fail
+		if attr.Threshold != 0 {
+			attr.FailingNow = attr.Value <= attr.Threshold
+			attr.FailedInPast = !attr.FailingNow && attr.Worst <= attr.Threshold
+		}
+
+		attrs = append(attrs, attr)
+	}
+
+	sort.Slice(attrs, func(i, j int) bool { return attrs[i].ID < attrs[j].ID })
+
+	return attrs
+}
+
+// WhenFailed returns the WHEN_FAILED column of smartctl for the attribute: "FAILING_NOW",
+// "In_the_past" or "-"
+func (a Attr) WhenFailed() string {
+	switch {
+	case a.FailingNow:
+		return "FAILING_NOW"
+	case a.FailedInPast:
+		return "In_the_past"
+	}
+
+	return "-"
+}
+
+// Failing returns the attributes which are failing now
+func (c AttrCollection) Failing() AttrCollection {
+	return c.Filter(func(a Attr) bool { return a.FailingNow })
+}
+
+// Get returns the attribute with the given ID
+func (c AttrCollection) Get(id uint8) (Attr, bool) {
+	i := sort.Search(len(c), func(i int) bool { return c[i].ID >= id })
+	if i < len(c) && c[i].ID == id {
+		return c[i], true
+	}
+
+	return Attr{}, false
+}
+
+// GetByName returns the first attribute with the given name, e.g. Reallocated_Sector_Ct
+func (c AttrCollection) GetByName(name string) (Attr, bool) {
+	for _, attr := range c {
+		if attr.Name == name {
+			return attr, true
+		}
+	}
+
+	return Attr{}, false
+}
+
+// IDs returns the IDs of all attributes in the collection in ascending order
+func (c AttrCollection) IDs() []uint8 {
+	ids := make([]uint8, len(c))
+	for i, attr := range c {
+		ids[i] = attr.ID
+	}
+
+	return ids
+}
+
+// Filter returns the attributes for which keep returns true
+func (c AttrCollection) Filter(keep func(Attr) bool) AttrCollection {
+	var attrs AttrCollection
+
+	for _, attr := range c {
+		if keep(attr) {
+			attrs = append(attrs, attr)
+		}
+	}
+
+	return attrs
+}
@@ -0,0 +1,149 @@
+/*
+Copyright 2018 The OpenEBS Authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Byte-order tests of the ATA parsers, run under both little- and big-endian decoding.
+
+package atasmart
+
+import (
+	"bytes"
+	"encoding/binary"
+	"strings"
+	"testing"
+)
+
+var byteOrders = []binary.ByteOrder{binary.LittleEndian, binary.BigEndian}
+
+// reencode writes a decoded structure back with the given byte order, producing the sector a
+// device using that order for its words would return
+func reencode(t *testing.T, order binary.ByteOrder, data interface{}) []byte {
+	t.Helper()
+
+	var buf bytes.Buffer
+	if err := binary.Write(&buf, order, data); err != nil {
+		t.Fatal(err)
+	}
+
+	return buf.Bytes()
+}
+
+// checkIdentify checks the fields of the identify.bin fixture which depend on the byte order
+func checkIdentify(t *testing.T, d *IdentDevData) {
+	t.Helper()
+
+	strs := []struct{ field, got, want string }{
+		{"serial", string(d.GetSerialNumber()), "WD-WCC4E1234567"},
+		{"model", string(d.GetModelNumber()), "WDC WD40EFRX-68N32N0"},
+		{"firmware", string(d.GetFirmwareRevision()), "82.00A82"},
+		{"WWN", d.GetWWN(), "naa.50014ee2b5a3c7d1"},
+		{"ATA version", d.GetATAMajorVersion(), "ACS-2"},
+	}
+	for _, s := range strs {
+		if got := strings.TrimSpace(s.got); got != s.want {
+			t.Errorf("%s = %q, want %q", s.field, got, s.want)
+		}
+	}
+
+	if logical, physical := d.GetSectorSize(); logical != 512 || physical != 4096 {
+		t.Errorf("sector size = %d/%d, want 512/4096", logical, physical)
+	}
+
+	if rate, _ := d.GetRotationRate(); rate != 5400 {
+		t.Errorf("rotation rate = %d, want 5400", rate)
+	}
+}
+
+func TestParseIdentDevDataFixture(t *testing.T) {
+	d, err := ParseIdentDevData(readFixture(t, "identify.bin"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	checkIdentify(t, &d)
+}
+
+// TestParseSectorByteOrder decodes the IDENTIFY fixture written in each byte order with the same
+// order, as a host of that byte order would see its own data, and checks that the 16-bit words
+// and the byte-swapped strings come out the same.
+func TestParseSectorByteOrder(t *testing.T) {
+	want, err := ParseIdentDevData(readFixture(t, "identify.bin"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, order := range byteOrders {
+		t.Run(order.String(), func(t *testing.T) {
+			var d IdentDevData
+			if err := parseSector(reencode(t, order, &want), order, &d); err != nil {
+				t.Fatal(err)
+			}
+
+			checkIdentify(t, &d)
+		})
+	}
+}
+
+// TestParseIdentDevDataWrongOrder makes sure the fixture catches a parser decoding the words in
+// the host byte order on a big-endian host
+func TestParseIdentDevDataWrongOrder(t *testing.T) {
+	var d IdentDevData
+	if err := parseSector(readFixture(t, "identify.bin"), binary.BigEndian, &d); err != nil {
+		t.Fatal(err)
+	}
+
+	if d.GetWWN() == "naa.50014ee2b5a3c7d1" {
+		t.Error("WWN decoded big-endian matches the little-endian value")
+	}
+	if rate, _ := d.GetRotationRate(); rate == 5400 {
+		t.Error("rotation rate decoded big-endian matches the little-endian value")
+	}
+}
+
+func TestParseSmartPageByteOrder(t *testing.T) {
+	want, err := ParseSmartPage(readFixture(t, "smart_data.bin"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	thresholds, err := ParseSmartThresholdPage(readFixture(t, "smart_thresholds.bin"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, order := range byteOrders {
+		t.Run(order.String(), func(t *testing.T) {
+			var page SmartPage
+			if err := parseSector(reencode(t, order, &want), order, &page); err != nil {
+				t.Fatal(err)
+			}
+
+			if page.Version != 0x0010 {
+				t.Errorf("version = %#04x, want 0x0010", page.Version)
+			}
+
+			attrs := NewAttrCollection(&page, &thresholds, "WDC WD40EFRX-68N32N0", "82.00A82")
+			raws := map[uint8]uint64{5: 8, 9: 12345, 197: 2}
+			for _, attr := range attrs {
+				if want, ok := raws[attr.ID]; ok && attr.Raw != want {
+					t.Errorf("attribute %d raw = %d, want %d", attr.ID, attr.Raw, want)
+				}
+				if attr.ID == 5 && (attr.Flags != 0x0033 || attr.Threshold != 140) {
+					t.Errorf("attribute 5 flags/threshold = %#04x/%d, want 0x0033/140", attr.Flags, attr.Threshold)
+				}
+			}
+
+			if temp, ok := attrs.Get(194); !ok || temp.Raw&0xff != 38 {
+				t.Errorf("temperature attribute = %+v, want 38 in the low byte", temp)
+			}
+		})
+	}
+}
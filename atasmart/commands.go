@@ -17,5 +17,55 @@ package atasmart
 
 const (
 	// ATA command
-	AtaIdentifyDevice = 0xec
+	AtaReadVerifySectors    = 0x40
+	AtaReadLogExt           = 0x2f
+	AtaReadVerifySectorsExt = 0x42
+	AtaTrustedReceive       = 0x5c
+	AtaSmart                = 0xb0
+	AtaSanitizeDevice       = 0xb4
+	AtaStandbyImmediate     = 0xe0
+	AtaIdleImmediate        = 0xe1
+	AtaStandby              = 0xe2
+	AtaIdle                 = 0xe3
+	AtaIdentifyDevice       = 0xec
+	AtaSetFeatures          = 0xef
+	AtaSecurityEraseUnit    = 0xf4
+
+	// ATA SMART feature register values
+	SmartReadData            = 0xd0
+	SmartReadThresholds      = 0xd1
+	SmartExecuteOfflineImmed = 0xd4
+	SmartReadLog             = 0xd5
+	SmartWriteLog            = 0xd6
+	SmartReturnStatus        = 0xda
+
+	// SMART EXECUTE OFF-LINE IMMEDIATE subcommands (LBA low register)
+	SelfTestShort      = 0x01
+	SelfTestExtended   = 0x02
+	SelfTestConveyance = 0x03
+	SelfTestAbort      = 0x7f
+
+	// SET FEATURES subcommands (feature register) and EPC functions (LBA 3:0)
+	SetFeaturesEPC            = 0x4a
+	EPCSetPowerConditionTimer = 0x02
+
+	// General Purpose Logging log addresses
+	PowerConditionsLog = 0x08
+
+	// SCT Command Transport log addresses
+	SCTCommandStatusLog = 0xe0
+	SCTDataTransferLog  = 0xe1
+
+	// SCT action and function codes, and data table identifiers
+	SCTActionDataTable   = 0x0005
+	SCTFunctionReadTable = 0x0001
+	SCTTableTempHistory  = 0x0002
+
+	// ATA SMART signature, must be set in LBA mid/high for all SMART commands
+	SmartLBAMid  = 0x4f
+	SmartLBAHigh = 0xc2
+
+	// LBA mid/high returned by SMART RETURN STATUS when a threshold is exceeded
+	SmartLBAMidExceeded  = 0xf4
+	SmartLBAHighExceeded = 0x2c
 )
@@ -0,0 +1,223 @@
+/*
+Copyright 2018 The OpenEBS Authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Drive database for SMART attribute names and raw value formats.
+// Modelled after the smartmontools drivedb.h "-v ID,FORMAT,NAME" presets.
+
+package atasmart
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// RawFormat describes how the 6-byte raw value of an attribute is interpreted
+type RawFormat int
+
+// Raw value formats
+const (
+	RawFormat48    RawFormat = iota // 48-bit little-endian integer
+	RawFormatHex48                  // 48-bit value, displayed as hex
+	RawFormat24                     // lowest 24 bits only, upper bytes are vendor specific
+	RawFormat16                     // lowest 16 bits only, upper bytes are vendor specific
+	RawFormatTemp                   // temperature in the lowest byte, min/max in the upper bytes
+)
+
+// AttrDef is the definition of a SMART attribute
+type AttrDef struct {
+	Name   string
+	Format RawFormat
+}
+
+// DriveDBEntry holds the attribute definitions overriding the defaults for a family of drives
+type DriveDBEntry struct {
+	Family        string
+	ModelRegexp   *regexp.Regexp
+	FirmwareRegex *regexp.Regexp // nil matches every firmware
+	Attrs         map[uint8]AttrDef
+}
+
+// defaultAttrDefs are the attribute definitions used when no drive database entry overrides them.
+// Names are unique, so that AttrCollection.GetByName finds a single attribute; the vendor
+// specific meanings of the other IDs belong in driveDB entries.
+var defaultAttrDefs = map[uint8]AttrDef{
+	1:   {"Raw_Read_Error_Rate", RawFormat48},
+	2:   {"Throughput_Performance", RawFormat48},
+	3:   {"Spin_Up_Time", RawFormat16},
+	4:   {"Start_Stop_Count", RawFormat48},
+	5:   {"Reallocated_Sector_Ct", RawFormat16},
+	7:   {"Seek_Error_Rate", RawFormat48},
+	8:   {"Seek_Time_Performance", RawFormat48},
+	9:   {"Power_On_Hours", RawFormat24},
+	10:  {"Spin_Retry_Count", RawFormat48},
+	11:  {"Calibration_Retry_Count", RawFormat48},
+	12:  {"Power_Cycle_Count", RawFormat48},
+	170: {"Available_Reservd_Space", RawFormat48},
+	171: {"Program_Fail_Count", RawFormat48},
+	172: {"Erase_Fail_Count", RawFormat48},
+	174: {"Unexpect_Power_Loss_Ct", RawFormat48},
+	177: {"Wear_Leveling_Count", RawFormat48},
+	183: {"Runtime_Bad_Block", RawFormat48},
+	184: {"End-to-End_Error", RawFormat48},
+	187: {"Reported_Uncorrect", RawFormat48},
+	188: {"Command_Timeout", RawFormat48},
+	189: {"High_Fly_Writes", RawFormat48},
+	190: {"Airflow_Temperature_Cel", RawFormatTemp},
+	191: {"G-Sense_Error_Rate", RawFormat48},
+	192: {"Power-Off_Retract_Count", RawFormat48},
+	193: {"Load_Cycle_Count", RawFormat48},
+	194: {"Temperature_Celsius", RawFormatTemp},
+	195: {"Hardware_ECC_Recovered", RawFormat48},
+	196: {"Reallocated_Event_Count", RawFormat16},
+	197: {"Current_Pending_Sector", RawFormat48},
+	198: {"Offline_Uncorrectable", RawFormat48},
+	199: {"UDMA_CRC_Error_Count", RawFormat48},
+	200: {"Multi_Zone_Error_Rate", RawFormat48},
+	220: {"Disk_Shift", RawFormat48},
+	222: {"Loaded_Hours", RawFormat48},
+	223: {"Load_Retry_Count", RawFormat48},
+	224: {"Load_Friction", RawFormat48},
+	226: {"Load-in_Time", RawFormat48},
+	233: {"Media_Wearout_Indicator", RawFormat48},
+	240: {"Head_Flying_Hours", RawFormat24},
+	241: {"Total_LBAs_Written", RawFormat48},
+	242: {"Total_LBAs_Read", RawFormat48},
+}
+
+// driveDB is the list of drive database entries, the first matching entry wins
+var driveDB = []DriveDBEntry{
+	{
+		Family:      "Western Digital / SanDisk SATA SSDs",
+		ModelRegexp: regexp.MustCompile(`^(WDC )?WDS[0-9]{3,4}[GT][0-9][A-Z0-9]{2}|^SanDisk SD[A-Z0-9]+`),
+		Attrs: map[uint8]AttrDef{
+			165: {"Total_Write/Erase_Count", RawFormat48},
+			166: {"Min_W/E_Cycle", RawFormat48},
+			167: {"Min_Bad_Block/Die", RawFormat48},
+			168: {"Maximum_Erase_Cycle", RawFormat48},
+			169: {"Total_Bad_Block", RawFormat48},
+			170: {"Unknown_Marvell_Attr", RawFormat48},
+			173: {"Avg_Write/Erase_Count", RawFormat48},
+			230: {"Media_Wearout_Indicator", RawFormatHex48},
+			232: {"Available_Reservd_Space", RawFormat48},
+			233: {"NAND_GB_Written_TLC", RawFormat48},
+			234: {"NAND_GB_Written_SLC", RawFormat48},
+			241: {"Host_Writes_GiB", RawFormat48},
+			242: {"Host_Reads_GiB", RawFormat48},
+			244: {"Temp_Throttle_Status", RawFormat48},
+		},
+	},
+	{
+		Family:      "HGST / WD Ultrastar helium drives",
+		ModelRegexp: regexp.MustCompile(`^(HGST |WDC )?(HUH72|WUH72)[0-9]{4}`),
+		Attrs: map[uint8]AttrDef{
+			22: {"Helium_Level", RawFormat48},
+		},
+	},
+	{
+		Family:      "Western Digital SATA HDDs",
+		ModelRegexp: regexp.MustCompile(`^(WDC )?WD[0-9]{3,5}[A-Z]{2}[A-Z0-9]{2,4}`),
+		Attrs: map[uint8]AttrDef{
+			// Power-on time is reported in hours on all but very old drives
+			9: {"Power_On_Hours", RawFormat48},
+		},
+	},
+}
+
+// RegisterDriveDBEntry adds a drive database entry, taking precedence over the built-in entries.
+func RegisterDriveDBEntry(family, modelRegexp, firmwareRegexp string, attrs map[uint8]AttrDef) error {
+	entry := DriveDBEntry{Family: family, Attrs: attrs}
+
+	re, err := regexp.Compile(modelRegexp)
+	if err != nil {
+		return fmt.Errorf("invalid model regexp %q: %v", modelRegexp, err)
+	}
+	entry.ModelRegexp = re
+
+	if firmwareRegexp != "" {
+		re, err = regexp.Compile(firmwareRegexp)
+		if err != nil {
+			return fmt.Errorf("invalid firmware regexp %q: %v", firmwareRegexp, err)
+		}
+		entry.FirmwareRegex = re
+	}
+
+	driveDB = append([]DriveDBEntry{entry}, driveDB...)
+
+	return nil
+}
+
+// LookupDriveDB returns the drive database entry matching a model and firmware revision, if any.
+func LookupDriveDB(model, firmware string) (DriveDBEntry, bool) {
+	model = strings.TrimSpace(model)
+	firmware = strings.TrimSpace(firmware)
+
+	for _, entry := range driveDB {
+		if !entry.ModelRegexp.MatchString(model) {
+			continue
+		}
+		if entry.FirmwareRegex != nil && !entry.FirmwareRegex.MatchString(firmware) {
+			continue
+		}
+		return entry, true
+	}
+
+	return DriveDBEntry{}, false
+}
+
+// LookupAttrDef returns the definition of an attribute for a given model and firmware revision.
+// Unknown attributes are named "Unknown_Attribute" and decoded as raw48.
+func LookupAttrDef(model, firmware string, id uint8) AttrDef {
+	if entry, ok := LookupDriveDB(model, firmware); ok {
+		if def, ok := entry.Attrs[id]; ok {
+			return def
+		}
+	}
+
+	if def, ok := defaultAttrDefs[id]; ok {
+		return def
+	}
+
+	return AttrDef{"Unknown_Attribute", RawFormat48}
+}
+
+// DecodeRaw interprets the raw value of an attribute according to its format
+func (def AttrDef) DecodeRaw(a *SmartAttr) uint64 {
+	raw := a.RawValue()
+
+	switch def.Format {
+	case RawFormat24:
+		return raw & 0xffffff
+	case RawFormat16:
+		return raw & 0xffff
+	case RawFormatTemp:
+		return raw & 0xff
+	}
+
+	return raw
+}
+
+// FormatRaw returns the interpreted raw value of an attribute as a string
+func (def AttrDef) FormatRaw(a *SmartAttr) string {
+	switch def.Format {
+	case RawFormatHex48:
+		return fmt.Sprintf("%#012x", a.RawValue())
+	case RawFormatTemp:
+		lo, hi := a.VendorBytes[2], a.VendorBytes[4]
+		if lo != 0 && hi != 0 && lo <= hi {
+			return fmt.Sprintf("%d (Min/Max %d/%d)", def.DecodeRaw(a), lo, hi)
+		}
+	}
+
+	return fmt.Sprintf("%d", def.DecodeRaw(a))
+}
@@ -0,0 +1,95 @@
+/*
+Copyright 2018 The OpenEBS Authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Decoding of the Extended Power Conditions (EPC) feature set.
+
+package atasmart
+
+import (
+	"encoding/binary"
+	"fmt"
+)
+
+// PowerCondition is a power condition descriptor of the Power Conditions log. Timers are in
+// units of 100 millisecs.
+type PowerCondition struct {
+	Name           string // Idle_a, Idle_b, Idle_c, Standby_y or Standby_z
+	Supported      bool
+	Saveable       bool
+	Changeable     bool
+	DefaultEnabled bool
+	SavedEnabled   bool
+	CurrentEnabled bool
+	DefaultTimer   uint32
+	SavedTimer     uint32
+	CurrentTimer   uint32
+	RecoveryTime   uint32 // nominal recovery time to the active state
+	MinimumTimer   uint32
+	MaximumTimer   uint32
+}
+
+// PowerConditionDescLen is the length of a power condition descriptor
+const PowerConditionDescLen = 64
+
+// powerConditionDescs lists the power conditions of the Power Conditions log, with the page and
+// offset of their descriptor
+var powerConditionDescs = []struct {
+	name   string
+	page   int
+	offset int
+}{
+	{"Idle_a", 0, 0},
+	{"Idle_b", 0, 64},
+	{"Idle_c", 0, 128},
+	{"Standby_y", 1, 384},
+	{"Standby_z", 1, 448},
+}
+
+// EPCSupported reports whether the device supports the Extended Power Conditions feature set
+func (d *IdentDevData) EPCSupported() bool {
+	return d.Word119&0xc000 == 0x4000 && d.Word119&0x0080 != 0
+}
+
+// EPCEnabled reports whether the Extended Power Conditions feature set is enabled
+func (d *IdentDevData) EPCEnabled() bool {
+	return d.Word120&0xc000 == 0x4000 && d.Word120&0x0080 != 0
+}
+
+// ParsePowerConditions decodes the two pages of the Power Conditions log (GPL log 08h)
+func ParsePowerConditions(buf []byte) ([]PowerCondition, error) {
+	if len(buf) < 2*SectorSize {
+		return nil, fmt.Errorf("short Power Conditions log (%d bytes)", len(buf))
+	}
+
+	conditions := make([]PowerCondition, 0, len(powerConditionDescs))
+	for _, desc := range powerConditionDescs {
+		b := buf[desc.page*SectorSize+desc.offset:][:PowerConditionDescLen]
+		conditions = append(conditions, PowerCondition{
+			Name:           desc.name,
+			Supported:      b[1]&0x80 != 0,
+			Saveable:       b[1]&0x40 != 0,
+			Changeable:     b[1]&0x20 != 0,
+			DefaultEnabled: b[1]&0x10 != 0,
+			SavedEnabled:   b[1]&0x08 != 0,
+			CurrentEnabled: b[1]&0x04 != 0,
+			DefaultTimer:   binary.LittleEndian.Uint32(b[4:]),
+			SavedTimer:     binary.LittleEndian.Uint32(b[8:]),
+			CurrentTimer:   binary.LittleEndian.Uint32(b[12:]),
+			RecoveryTime:   binary.LittleEndian.Uint32(b[16:]),
+			MinimumTimer:   binary.LittleEndian.Uint32(b[20:]),
+			MaximumTimer:   binary.LittleEndian.Uint32(b[24:]),
+		})
+	}
+
+	return conditions, nil
+}
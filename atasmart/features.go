@@ -0,0 +1,181 @@
+/*
+Copyright 2018 The OpenEBS Authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Decoding of the command and feature sets of ATA IDENTIFY DEVICE words 82..87 and 119..120.
+
+package atasmart
+
+// FeatureSet reports whether a command or feature set is supported and enabled. Feature sets
+// which cannot be disabled are enabled whenever they are supported.
+type FeatureSet struct {
+	Supported bool
+	Enabled   bool
+}
+
+// FeatureSupport is the command and feature set support of a device, as reported by words
+// 82..87 and 119..120 of ATA IDENTIFY DEVICE
+type FeatureSupport struct {
+	SMART             FeatureSet
+	Security          FeatureSet
+	PowerManagement   FeatureSet
+	Packet            FeatureSet
+	WriteCache        FeatureSet // volatile write cache
+	ReadLookAhead     FeatureSet
+	HPA               FeatureSet // Host Protected Area
+	WriteBuffer       FeatureSet
+	ReadBuffer        FeatureSet
+	NOP               FeatureSet
+	DownloadMicrocode FeatureSet
+	APM               FeatureSet // Advanced Power Management
+	PUIS              FeatureSet // Power-Up In Standby
+	SpinUpSetFeatures FeatureSet // SET FEATURES required to spin up after power-up
+	LBA48             FeatureSet // 48-bit Address feature set
+	FlushCache        FeatureSet
+	FlushCacheExt     FeatureSet
+	SMARTErrorLog     FeatureSet // SMART error logging
+	SMARTSelfTest     FeatureSet
+	MediaSerialNumber FeatureSet
+	Streaming         FeatureSet
+	GPL               FeatureSet // General Purpose Logging
+	WriteFUAExt       FeatureSet // WRITE DMA FUA EXT and WRITE MULTIPLE FUA EXT
+	WWN               FeatureSet // World Wide Name
+	IdleUnload        FeatureSet // IDLE IMMEDIATE with UNLOAD
+	WriteReadVerify   FeatureSet
+	WriteUncExt       FeatureSet // WRITE UNCORRECTABLE EXT
+	LogDMAExt         FeatureSet // READ LOG DMA EXT and WRITE LOG DMA EXT
+	MicrocodeOffsets  FeatureSet // DOWNLOAD MICROCODE with offsets
+	FreeFall          FeatureSet // Free-fall Control
+	SenseData         FeatureSet // Sense Data Reporting
+	EPC               FeatureSet // Extended Power Conditions
+	AMAXAddr          FeatureSet // Accessible Max Address Configuration
+	DSN               FeatureSet // Device Statistics Notification
+}
+
+// NamedFeatureSet is a feature set along with its name
+type NamedFeatureSet struct {
+	Name string
+	FeatureSet
+}
+
+// validWord reports whether a word with a 01b signature in bits 15:14 is valid
+func validWord(w uint16) bool {
+	return w&0xc000 == 0x4000
+}
+
+// feature decodes a feature set from its bit in a supported and an enabled word
+func feature(supported, enabled uint16, bit uint) FeatureSet {
+	return FeatureSet{
+		Supported: supported&(1<<bit) != 0,
+		Enabled:   enabled&(1<<bit) != 0,
+	}
+}
+
+// Features decodes the command and feature set support of words 82..87 and 119..120. Words
+// whose signature or validity bit is not set are ignored.
+func (d *IdentDevData) Features() FeatureSupport {
+	// Words 82 and 83 are valid when word 83 has its signature, words 85, 86 and 87 when word
+	// 87 has its, and words 84, 119 and 120 when they have theirs
+	var w82, w83, w84, w85, w86, w87, w119, w120 uint16
+	if validWord(d.Word83) {
+		w82, w83 = d.Word82, d.Word83
+		if validWord(d.Word84) {
+			w84 = d.Word84
+		}
+	}
+	if validWord(d.Word87) {
+		w85, w86, w87 = d.Word85, d.Word86, d.Word87
+	}
+	if validWord(d.Word119) {
+		w119 = d.Word119
+	}
+	if validWord(d.Word120) {
+		w120 = d.Word120
+	}
+
+	return FeatureSupport{
+		SMART:             feature(w82, w85, 0),
+		Security:          feature(w82, w85, 1),
+		PowerManagement:   feature(w82, w85, 3),
+		Packet:            feature(w82, w85, 4),
+		WriteCache:        feature(w82, w85, 5),
+		ReadLookAhead:     feature(w82, w85, 6),
+		HPA:               feature(w82, w85, 10),
+		WriteBuffer:       feature(w82, w85, 12),
+		ReadBuffer:        feature(w82, w85, 13),
+		NOP:               feature(w82, w85, 14),
+		DownloadMicrocode: feature(w83, w86, 0),
+		APM:               feature(w83, w86, 3),
+		PUIS:              feature(w83, w86, 5),
+		SpinUpSetFeatures: feature(w83, w86, 6),
+		LBA48:             feature(w83, w86, 10),
+		FlushCache:        feature(w83, w86, 12),
+		FlushCacheExt:     feature(w83, w86, 13),
+		SMARTErrorLog:     feature(w84, w87, 0),
+		SMARTSelfTest:     feature(w84, w87, 1),
+		MediaSerialNumber: feature(w84, w87, 2),
+		Streaming:         feature(w84, w84, 4),
+		GPL:               feature(w84, w87, 5),
+		WriteFUAExt:       feature(w84, w87, 6),
+		WWN:               feature(w84, w87, 8),
+		IdleUnload:        feature(w84, w87, 13),
+		WriteReadVerify:   feature(w119, w120, 1),
+		WriteUncExt:       feature(w119, w120, 2),
+		LogDMAExt:         feature(w119, w120, 3),
+		MicrocodeOffsets:  feature(w119, w120, 4),
+		FreeFall:          feature(w119, w120, 5),
+		SenseData:         feature(w119, w120, 6),
+		EPC:               feature(w119, w120, 7),
+		AMAXAddr:          feature(w119, w119, 8),
+		DSN:               feature(w119, w120, 9),
+	}
+}
+
+// List returns the feature sets in the order of their IDENTIFY DEVICE words and bits
+func (f FeatureSupport) List() []NamedFeatureSet {
+	return []NamedFeatureSet{
+		{"SMART", f.SMART},
+		{"Security", f.Security},
+		{"Power Management", f.PowerManagement},
+		{"PACKET", f.Packet},
+		{"Volatile Write Cache", f.WriteCache},
+		{"Read Look-Ahead", f.ReadLookAhead},
+		{"Host Protected Area", f.HPA},
+		{"WRITE BUFFER", f.WriteBuffer},
+		{"READ BUFFER", f.ReadBuffer},
+		{"NOP", f.NOP},
+		{"DOWNLOAD MICROCODE", f.DownloadMicrocode},
+		{"Advanced Power Management", f.APM},
+		{"Power-Up In Standby", f.PUIS},
+		{"SET FEATURES Spin-Up", f.SpinUpSetFeatures},
+		{"48-bit Address", f.LBA48},
+		{"FLUSH CACHE", f.FlushCache},
+		{"FLUSH CACHE EXT", f.FlushCacheExt},
+		{"SMART Error Logging", f.SMARTErrorLog},
+		{"SMART Self-Test", f.SMARTSelfTest},
+		{"Media Serial Number", f.MediaSerialNumber},
+		{"Streaming", f.Streaming},
+		{"General Purpose Logging", f.GPL},
+		{"WRITE DMA FUA EXT", f.WriteFUAExt},
+		{"World Wide Name", f.WWN},
+		{"IDLE IMMEDIATE with UNLOAD", f.IdleUnload},
+		{"Write-Read-Verify", f.WriteReadVerify},
+		{"WRITE UNCORRECTABLE EXT", f.WriteUncExt},
+		{"READ/WRITE LOG DMA EXT", f.LogDMAExt},
+		{"DOWNLOAD MICROCODE Offsets", f.MicrocodeOffsets},
+		{"Free-fall Control", f.FreeFall},
+		{"Sense Data Reporting", f.SenseData},
+		{"Extended Power Conditions", f.EPC},
+		{"Accessible Max Address Configuration", f.AMAXAddr},
+		{"Device Statistics Notification", f.DSN},
+	}
+}
@@ -0,0 +1,106 @@
+/*
+Copyright 2018 The OpenEBS Authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Database of drive models and firmware revisions with known issues.
+
+package atasmart
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"sync"
+)
+
+// FirmwareWarning is a known issue of a family of drives, limited to some firmware revisions
+type FirmwareWarning struct {
+	Family        string
+	ModelRegexp   *regexp.Regexp
+	FirmwareRegex *regexp.Regexp // nil matches every firmware
+	Warning       string
+}
+
+// firmwareWarnings holds the known issues, as reported by the warnings of smartmontools' drivedb,
+// and those added with RegisterFirmwareWarning
+var (
+	firmwareWarningsMu sync.RWMutex
+	firmwareWarnings   = []FirmwareWarning{
+		{
+			Family:        "Crucial/Micron RealSSD m4/C400",
+			ModelRegexp:   regexp.MustCompile(`^(C400-MTFDD[AK]|M4-CT)(064|128|256|512)(M4SSD[23]|MAA|MAM)$`),
+			FirmwareRegex: regexp.MustCompile(`^000[129]$`),
+			Warning:       "This drive may hang after 5184 hours of power-on time, firmware 0309 or later fixes it",
+		},
+		{
+			Family:        "Seagate Barracuda 7200.11",
+			ModelRegexp:   regexp.MustCompile(`^ST3(160813|320613|500320|640323|750330|1000340|1500341)AS?$`),
+			FirmwareRegex: regexp.MustCompile(`^(AD14|SD1[5-9]|SD81)$`),
+			Warning:       "This drive may become inaccessible after a power cycle, firmware SD1A fixes it",
+		},
+		{
+			Family:        "Samsung SpinPoint F4 EG",
+			ModelRegexp:   regexp.MustCompile(`^SAMSUNG HD(155|204)UI$`),
+			FirmwareRegex: regexp.MustCompile(`^1AQ10001$`),
+			Warning:       "Reading the identification data while writing may corrupt data, firmware 1AQ10003 fixes it",
+		},
+	}
+)
+
+// RegisterFirmwareWarning adds a known issue for the drives matching a model and firmware revision.
+// It is safe to call while the warnings are looked up.
+func RegisterFirmwareWarning(family, modelRegexp, firmwareRegexp, warning string) error {
+	entry := FirmwareWarning{Family: family, Warning: warning}
+
+	re, err := regexp.Compile(modelRegexp)
+	if err != nil {
+		return fmt.Errorf("invalid model regexp %q: %v", modelRegexp, err)
+	}
+	entry.ModelRegexp = re
+
+	if firmwareRegexp != "" {
+		re, err = regexp.Compile(firmwareRegexp)
+		if err != nil {
+			return fmt.Errorf("invalid firmware regexp %q: %v", firmwareRegexp, err)
+		}
+		entry.FirmwareRegex = re
+	}
+
+	firmwareWarningsMu.Lock()
+	firmwareWarnings = append(firmwareWarnings, entry)
+	firmwareWarningsMu.Unlock()
+
+	return nil
+}
+
+// LookupFirmwareWarnings returns the known issues of a drive model and firmware revision.
+func LookupFirmwareWarnings(model, firmware string) []string {
+	var warnings []string
+
+	model = strings.TrimSpace(model)
+	firmware = strings.TrimSpace(firmware)
+
+	firmwareWarningsMu.RLock()
+	defer firmwareWarningsMu.RUnlock()
+
+	for _, entry := range firmwareWarnings {
+		if !entry.ModelRegexp.MatchString(model) {
+			continue
+		}
+		if entry.FirmwareRegex != nil && !entry.FirmwareRegex.MatchString(firmware) {
+			continue
+		}
+		warnings = append(warnings, entry.Family+": "+entry.Warning)
+	}
+
+	return warnings
+}
@@ -0,0 +1,93 @@
+/*
+Copyright 2018 The OpenEBS Authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// ATA command names, used in error messages and debug traces.
+
+package atasmart
+
+import "fmt"
+
+// ataCommandNames maps ATA command codes to their names (ACS-3)
+var ataCommandNames = map[uint8]string{
+	0x06: "DATA SET MANAGEMENT",
+	0x20: "READ SECTORS",
+	0x24: "READ SECTORS EXT",
+	0x25: "READ DMA EXT",
+	0x2f: "READ LOG EXT",
+	0x30: "WRITE SECTORS",
+	0x34: "WRITE SECTORS EXT",
+	0x35: "WRITE DMA EXT",
+	0x3f: "WRITE LOG EXT",
+	0x40: "READ VERIFY SECTORS",
+	0x42: "READ VERIFY SECTORS EXT",
+	0x47: "READ LOG DMA EXT",
+	0x57: "WRITE LOG DMA EXT",
+	0x5c: "TRUSTED RECEIVE",
+	0x5e: "TRUSTED SEND",
+	0x60: "READ FPDMA QUEUED",
+	0x61: "WRITE FPDMA QUEUED",
+	0x90: "EXECUTE DEVICE DIAGNOSTIC",
+	0x92: "DOWNLOAD MICROCODE",
+	0xb0: "SMART",
+	0xb4: "SANITIZE DEVICE",
+	0xc8: "READ DMA",
+	0xca: "WRITE DMA",
+	0xe0: "STANDBY IMMEDIATE",
+	0xe1: "IDLE IMMEDIATE",
+	0xe2: "STANDBY",
+	0xe3: "IDLE",
+	0xe4: "READ BUFFER",
+	0xe5: "CHECK POWER MODE",
+	0xe7: "FLUSH CACHE",
+	0xe8: "WRITE BUFFER",
+	0xea: "FLUSH CACHE EXT",
+	0xec: "IDENTIFY DEVICE",
+	0xef: "SET FEATURES",
+	0xf1: "SECURITY SET PASSWORD",
+	0xf2: "SECURITY UNLOCK",
+	0xf3: "SECURITY ERASE PREPARE",
+	0xf4: "SECURITY ERASE UNIT",
+	0xf5: "SECURITY FREEZE LOCK",
+	0xf6: "SECURITY DISABLE PASSWORD",
+}
+
+// smartFeatureNames maps the feature register values of the SMART command to their names
+var smartFeatureNames = map[uint16]string{
+	0xd0: "READ DATA",
+	0xd1: "READ THRESHOLDS",
+	0xd2: "ENABLE/DISABLE ATTRIBUTE AUTOSAVE",
+	0xd4: "EXECUTE OFF-LINE IMMEDIATE",
+	0xd5: "READ LOG",
+	0xd6: "WRITE LOG",
+	0xd8: "ENABLE OPERATIONS",
+	0xd9: "DISABLE OPERATIONS",
+	0xda: "RETURN STATUS",
+}
+
+// CommandName returns the name of an ATA command, including the SMART subcommand selected by
+// the feature register, e.g. "SMART READ DATA".
+func CommandName(command uint8, feature uint16) string {
+	name, ok := ataCommandNames[command]
+	if !ok {
+		return fmt.Sprintf("ATA command %#02x", command)
+	}
+
+	if command == AtaSmart {
+		if subcommand, ok := smartFeatureNames[feature]; ok {
+			return name + " " + subcommand
+		}
+		return fmt.Sprintf("%s (feature %#02x)", name, feature)
+	}
+
+	return name
+}
@@ -0,0 +1,95 @@
+/*
+Copyright 2018 The OpenEBS Authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Decoding of raw ATA response buffers, independent of the transport used to fetch them.
+
+package atasmart
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+)
+
+// SectorSize is the size of the ATA IDENTIFY and SMART response data
+const SectorSize = 512
+
+// parseSector decodes a 512-byte ATA response into the given structure using the given byte order
+// for its multi-byte fields. ATA data is made of little-endian 16-bit words regardless of the host,
+// while strings are stored byte-swapped within each word and are left as is (see swapByteOrder).
+func parseSector(buf []byte, order binary.ByteOrder, data interface{}) error {
+	if len(buf) < SectorSize {
+		return fmt.Errorf("short ATA response (%d bytes)", len(buf))
+	}
+
+	return binary.Read(bytes.NewReader(buf[:SectorSize]), order, data)
+}
+
+// ParseIdentDevData decodes an ATA IDENTIFY DEVICE response
+func ParseIdentDevData(buf []byte) (IdentDevData, error) {
+	var identifyBuf IdentDevData
+
+	err := parseSector(buf, binary.LittleEndian, &identifyBuf)
+
+	return identifyBuf, err
+}
+
+// ChecksumError is returned when the checksum of a SMART data structure is wrong, which usually
+// means the response was corrupted, e.g. by a flaky USB bridge
+type ChecksumError struct {
+	Structure string // name of the data structure, e.g. "SMART READ DATA"
+	Sum       uint8  // sum of the 512 bytes of the structure, 0 when it is valid
+}
+
+func (e ChecksumError) Error() string {
+	return fmt.Sprintf("%s checksum error (sum %#02x)", e.Structure, e.Sum)
+}
+
+// verifyChecksum checks that the 512 bytes of a SMART data structure add up to 0, the last byte
+// being the two's complement checksum of the others
+func verifyChecksum(structure string, buf []byte) error {
+	var sum uint8
+	for _, b := range buf[:SectorSize] {
+		sum += b
+	}
+
+	if sum != 0 {
+		return ChecksumError{Structure: structure, Sum: sum}
+	}
+
+	return nil
+}
+
+// ParseSmartPage decodes an ATA SMART READ DATA response. A ChecksumError is returned along with
+// the decoded page if the page checksum is wrong.
+func ParseSmartPage(buf []byte) (SmartPage, error) {
+	var smartBuf SmartPage
+
+	if err := parseSector(buf, binary.LittleEndian, &smartBuf); err != nil {
+		return smartBuf, err
+	}
+
+	return smartBuf, verifyChecksum("SMART READ DATA", buf)
+}
+
+// ParseSmartThresholdPage decodes an ATA SMART READ THRESHOLDS response. A ChecksumError is
+// returned along with the decoded page if the page checksum is wrong.
+func ParseSmartThresholdPage(buf []byte) (SmartThresholdPage, error) {
+	var thresholdBuf SmartThresholdPage
+
+	if err := parseSector(buf, binary.LittleEndian, &thresholdBuf); err != nil {
+		return thresholdBuf, err
+	}
+
+	return thresholdBuf, verifyChecksum("SMART READ THRESHOLDS", buf)
+}
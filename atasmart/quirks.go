@@ -0,0 +1,120 @@
+/*
+Copyright 2018 The OpenEBS Authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Quirks overriding how the data reported by some drive models is interpreted.
+
+package atasmart
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"sync"
+)
+
+// Quirk is a set of flags changing how the data of a drive is interpreted
+type Quirk uint32
+
+// Quirk flags
+const (
+	QuirkSwappedSerial Quirk = 1 << iota // serial number is not byte-swapped within each word
+	QuirkSwappedModel                    // model number is not byte-swapped within each word
+	QuirkBogusWWN                        // WWN is not unique, e.g. the same for every drive
+)
+
+// QuirkEntry holds the quirks of the drives matching a model and firmware revision
+type QuirkEntry struct {
+	ModelRegexp   *regexp.Regexp
+	FirmwareRegex *regexp.Regexp // nil matches every firmware
+	Quirks        Quirk
+	IgnoreAttrs   []uint8 // attributes which are meaningless for the drive and must be dropped
+}
+
+// quirkDB holds the quirks of the known drives. It is empty by default, entries are added with
+// RegisterQuirk for the drives found to need them.
+var (
+	quirkDBMu sync.RWMutex
+	quirkDB   []QuirkEntry
+)
+
+// RegisterQuirk adds quirks for the drives matching a model and firmware revision. It is safe to
+// call while the quirks are looked up.
+func RegisterQuirk(modelRegexp, firmwareRegexp string, quirks Quirk, ignoreAttrs ...uint8) error {
+	entry := QuirkEntry{Quirks: quirks, IgnoreAttrs: ignoreAttrs}
+
+	re, err := regexp.Compile(modelRegexp)
+	if err != nil {
+		return fmt.Errorf("invalid model regexp %q: %v", modelRegexp, err)
+	}
+	entry.ModelRegexp = re
+
+	if firmwareRegexp != "" {
+		re, err = regexp.Compile(firmwareRegexp)
+		if err != nil {
+			return fmt.Errorf("invalid firmware regexp %q: %v", firmwareRegexp, err)
+		}
+		entry.FirmwareRegex = re
+	}
+
+	quirkDBMu.Lock()
+	quirkDB = append(quirkDB, entry)
+	quirkDBMu.Unlock()
+
+	return nil
+}
+
+// LookupQuirks returns the quirks of a drive model and firmware revision, merged from all the
+// matching entries. The model and firmware are matched as reported, before any quirk is applied.
+func LookupQuirks(model, firmware string) QuirkEntry {
+	var quirks QuirkEntry
+
+	model = strings.TrimSpace(model)
+	firmware = strings.TrimSpace(firmware)
+
+	quirkDBMu.RLock()
+	defer quirkDBMu.RUnlock()
+
+	for _, entry := range quirkDB {
+		if !entry.ModelRegexp.MatchString(model) {
+			continue
+		}
+		if entry.FirmwareRegex != nil && !entry.FirmwareRegex.MatchString(firmware) {
+			continue
+		}
+		quirks.Quirks |= entry.Quirks
+		quirks.IgnoreAttrs = append(quirks.IgnoreAttrs, entry.IgnoreAttrs...)
+	}
+
+	return quirks
+}
+
+// Has reports whether the quirk flag q is set
+func (e QuirkEntry) Has(q Quirk) bool {
+	return e.Quirks&q != 0
+}
+
+// Apply drops the ignored attributes of a drive from an attribute collection
+func (e QuirkEntry) Apply(attrs AttrCollection) AttrCollection {
+	if len(e.IgnoreAttrs) == 0 {
+		return attrs
+	}
+
+	return attrs.Filter(func(a Attr) bool {
+		for _, id := range e.IgnoreAttrs {
+			if a.ID == id {
+				return false
+			}
+		}
+		return true
+	})
+}
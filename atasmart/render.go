@@ -0,0 +1,68 @@
+/*
+Copyright 2018 The OpenEBS Authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// smartctl style rendering of SMART attributes.
+
+package atasmart
+
+import (
+	"fmt"
+	"io"
+)
+
+// SMART attribute flags
+const (
+	AttrFlagPrefailure = 0x0001 // failure of the attribute predicts imminent drive failure
+	AttrFlagOnline     = 0x0002 // attribute is updated during normal operation
+)
+
+// Type returns the TYPE column of smartctl for the attribute: "Pre-fail" or "Old_age"
+func (a Attr) Type() string {
+	if a.Flags&AttrFlagPrefailure != 0 {
+		return "Pre-fail"
+	}
+
+	return "Old_age"
+}
+
+// Updated returns the UPDATED column of smartctl for the attribute: "Always" or "Offline"
+func (a Attr) Updated() string {
+	if a.Flags&AttrFlagOnline != 0 {
+		return "Always"
+	}
+
+	return "Offline"
+}
+
+// WriteTable writes the attributes to w as the familiar smartctl -A attribute table
+func (c AttrCollection) WriteTable(w io.Writer) error {
+	if _, err := fmt.Fprintln(w, "ID# ATTRIBUTE_NAME          FLAG     VALUE WORST THRESH TYPE      UPDATED  WHEN_FAILED RAW_VALUE"); err != nil {
+		return err
+	}
+
+	for _, attr := range c {
+		whenFailed := attr.WhenFailed()
+		if whenFailed == "-" {
+			whenFailed = "    -"
+		}
+
+		_, err := fmt.Fprintf(w, "%3d %-24s0x%04x   %03d   %03d   %03d    %-10s%-9s%-12s%s\n",
+			attr.ID, attr.Name, attr.Flags, attr.Value, attr.Worst, attr.Threshold,
+			attr.Type(), attr.Updated(), whenFailed, attr.RawString)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
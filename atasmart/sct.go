@@ -0,0 +1,83 @@
+/*
+Copyright 2018 The OpenEBS Authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// SCT Command Transport data structures.
+
+package atasmart
+
+import (
+	"encoding/binary"
+	"fmt"
+)
+
+// SCTTempNone is the temperature of the SCT temperature history entries without a value
+const SCTTempNone = -128
+
+// SCTTempHistory is the SCT temperature history data table (table 0002h). Temperatures are in
+// degrees Celsius.
+type SCTTempHistory struct {
+	FormatVersion  uint16
+	SamplingPeriod uint16 // minutes between two temperature samples
+	Interval       uint16 // minutes between two history entries
+	MaxOpLimit     int8   // maximum recommended operating temperature
+	OverLimit      int8   // maximum temperature limit
+	MinOpLimit     int8   // minimum recommended operating temperature
+	UnderLimit     int8   // minimum temperature limit
+
+	// Entries holds the history oldest first, the last entry being the most recent one.
+	// Entries without a value are SCTTempNone.
+	Entries []int8
+}
+
+// SCTDataTableCommand returns the SCT command key sector reading a data table, to be written to
+// the SCT command/status log
+func SCTDataTableCommand(table uint16) []byte {
+	key := make([]byte, SectorSize)
+
+	binary.LittleEndian.PutUint16(key[0:], SCTActionDataTable)
+	binary.LittleEndian.PutUint16(key[2:], SCTFunctionReadTable)
+	binary.LittleEndian.PutUint16(key[4:], table)
+
+	return key
+}
+
+// ParseSCTTempHistory parses an SCT temperature history data table
+func ParseSCTTempHistory(b []byte) (SCTTempHistory, error) {
+	var h SCTTempHistory
+
+	if len(b) < SectorSize {
+		return h, fmt.Errorf("SCT temperature history too short: %d bytes", len(b))
+	}
+
+	h.FormatVersion = binary.LittleEndian.Uint16(b[0:])
+	h.SamplingPeriod = binary.LittleEndian.Uint16(b[2:])
+	h.Interval = binary.LittleEndian.Uint16(b[4:])
+	h.MaxOpLimit = int8(b[6])
+	h.OverLimit = int8(b[7])
+	h.MinOpLimit = int8(b[8])
+	h.UnderLimit = int8(b[9])
+
+	// The entries are a circular buffer whose last updated entry is at the CB index
+	size := int(binary.LittleEndian.Uint16(b[30:]))
+	index := int(binary.LittleEndian.Uint16(b[32:]))
+	if size == 0 || 34+size > len(b) || index >= size {
+		return h, fmt.Errorf("invalid SCT temperature history buffer (size %d, index %d)", size, index)
+	}
+
+	entries := b[34 : 34+size]
+	for i := 1; i <= size; i++ {
+		h.Entries = append(h.Entries, int8(entries[(index+i)%size]))
+	}
+
+	return h, nil
+}
@@ -0,0 +1,88 @@
+/*
+Copyright 2018 The OpenEBS Authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// ATA SMART data structures.
+
+package atasmart
+
+// SmartAttr is an individual SMART attribute entry (12 bytes) from the SMART READ DATA response
+type SmartAttr struct {
+	ID          uint8
+	Flags       uint16
+	Value       uint8   // normalized current value
+	Worst       uint8   // worst normalized value
+	VendorBytes [6]byte // raw value
+	Reserved    uint8
+}
+
+// SmartPage is the 512-byte SMART READ DATA response
+type SmartPage struct {
+	Version        uint16
+	Attrs          [30]SmartAttr
+	OfflineStatus  uint8     // off-line data collection status
+	SelfTestStatus uint8     // self-test execution status
+	_              [147]byte // self-test and capability fields
+	Checksum       uint8
+} // 512 bytes
+
+// SmartThreshold is an individual SMART attribute threshold entry (12 bytes) from the
+// SMART READ THRESHOLDS response
+type SmartThreshold struct {
+	ID        uint8
+	Threshold uint8
+	Reserved  [10]byte
+}
+
+// SmartThresholdPage is the 512-byte SMART READ THRESHOLDS response
+type SmartThresholdPage struct {
+	Version    uint16
+	Thresholds [30]SmartThreshold
+	_          [149]byte
+	Checksum   uint8
+} // 512 bytes
+
+// Threshold returns the failure threshold of the attribute with the given ID
+func (p *SmartThresholdPage) Threshold(id uint8) (uint8, bool) {
+	for _, t := range p.Thresholds {
+		if t.ID == id && id != 0 {
+			return t.Threshold, true
+		}
+	}
+
+	return 0, false
+}
+
+// RawValue returns the 48-bit raw value of an attribute as a little-endian integer
+func (a *SmartAttr) RawValue() uint64 {
+	var raw uint64
+
+	for i := len(a.VendorBytes) - 1; i >= 0; i-- {
+		raw = raw<<8 | uint64(a.VendorBytes[i])
+	}
+
+	return raw
+}
+
+// SelfTestInProgress reports whether the device is currently executing a self-test
+func (p *SmartPage) SelfTestInProgress() bool {
+	return p.SelfTestStatus>>4 == 0xf
+}
+
+// SelfTestRemaining returns the percentage of the running self-test which remains to be done
+func (p *SmartPage) SelfTestRemaining() int {
+	if !p.SelfTestInProgress() {
+		return 0
+	}
+
+	return int(p.SelfTestStatus&0x0f) * 10
+}
@@ -0,0 +1,204 @@
+/*
+Copyright 2018 The OpenEBS Authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package blockdevice maps the disk data to the sections of the spec of the OpenEBS
+// BlockDevice custom resource, so that NDM and the cStor pool tooling can populate the
+// resources from this library. The types mirror the JSON field names of the resource without
+// depending on its API module.
+package blockdevice
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/openebs/smart/render"
+)
+
+// Device types of the details section
+const (
+	DeviceTypeDisk = "disk"
+)
+
+// Drive types of the details section
+const (
+	DriveTypeHDD     = "HDD"
+	DriveTypeSSD     = "SSD"
+	DriveTypeUnknown = "Unknown"
+)
+
+// Kinds of the devlinks section
+const (
+	DevLinkByID   = "by-id"
+	DevLinkByPath = "by-path"
+)
+
+// Capacity is the capacity section of a BlockDevice
+type Capacity struct {
+	Storage            uint64 `json:"storage"` // bytes
+	PhysicalSectorSize uint32 `json:"physicalSectorSize"`
+	LogicalSectorSize  uint32 `json:"logicalSectorSize"`
+}
+
+// Details is the details section of a BlockDevice
+type Details struct {
+	DeviceType         string `json:"deviceType"`
+	DriveType          string `json:"driveType"`
+	LogicalBlockSize   uint32 `json:"logicalBlockSize"`
+	PhysicalBlockSize  uint32 `json:"physicalBlockSize"`
+	HardwareSectorSize uint32 `json:"hardwareSectorSize"`
+	Model              string `json:"model"`
+	Compliance         string `json:"compliance"` // standard the device conforms to, e.g. SPC-4 or ACS-3
+	Serial             string `json:"serial"`
+	Vendor             string `json:"vendor"`
+	FirmwareRevision   string `json:"firmwareRevision"`
+}
+
+// DevLink is an entry of the devlinks section of a BlockDevice
+type DevLink struct {
+	Kind  string   `json:"kind"` // DevLinkByID or DevLinkByPath
+	Links []string `json:"links"`
+}
+
+// DiskAttributes is the diskAttributes section of a BlockDevice, holding what the library
+// reads from the device itself
+type DiskAttributes struct {
+	RotationRate      uint16   `json:"rotationRate"` // rpm, 0 for solid state or unreported
+	WWN               string   `json:"wwn,omitempty"`
+	Transport         string   `json:"transport,omitempty"`
+	SMARTSupported    bool     `json:"smartSupported"`
+	SelfTestFailed    bool     `json:"selfTestFailed"`
+	FailingAttributes []string `json:"failingAttributes,omitempty"` // names of the SMART attributes at or below their threshold
+}
+
+// Spec holds the sections of the spec of a BlockDevice filled from the disk data
+type Spec struct {
+	Path           string         `json:"path"`
+	Capacity       Capacity       `json:"capacity"`
+	Details        Details        `json:"details"`
+	DevLinks       []DevLink      `json:"devlinks"`
+	DiskAttributes DiskAttributes `json:"diskAttributes"`
+}
+
+// FromReport returns the BlockDevice sections of a disk report
+func FromReport(r render.Report) Spec {
+	attr := r.Attr
+	s := Spec{
+		Path: r.Device,
+		Capacity: Capacity{
+			Storage:            attr.UserCapacity,
+			PhysicalSectorSize: uint32(attr.PBSize),
+			LogicalSectorSize:  uint32(attr.LBSize),
+		},
+		Details: Details{
+			DeviceType:         DeviceTypeDisk,
+			DriveType:          driveType(r),
+			LogicalBlockSize:   uint32(attr.LBSize),
+			PhysicalBlockSize:  uint32(attr.PBSize),
+			HardwareSectorSize: uint32(attr.LBSize),
+			Model:              strings.TrimSpace(attr.ModelNumber),
+			Compliance:         compliance(r),
+			Serial:             strings.TrimSpace(attr.SerialNumber),
+			Vendor:             string(bytes.TrimRight(attr.SCSIInquiry.VendorID[:], " \x00")),
+			FirmwareRevision:   strings.TrimSpace(attr.FirmwareRevision),
+		},
+		DevLinks: []DevLink{},
+		DiskAttributes: DiskAttributes{
+			RotationRate:   attr.RotationRate,
+			WWN:            attr.LuWWNDeviceID,
+			Transport:      attr.Transport,
+			SMARTSupported: attr.SMARTSupported,
+			SelfTestFailed: r.SelfTest != nil && r.SelfTest.Failed(),
+		},
+	}
+	if s.DiskAttributes.WWN == "" && attr.WWN != 0 {
+		s.DiskAttributes.WWN = fmt.Sprintf("naa.%016x", attr.WWN)
+	}
+
+	if len(attr.ByIDLinks) > 0 {
+		s.DevLinks = append(s.DevLinks, DevLink{Kind: DevLinkByID, Links: attr.ByIDLinks})
+	}
+	if len(attr.ByPathLinks) > 0 {
+		s.DevLinks = append(s.DevLinks, DevLink{Kind: DevLinkByPath, Links: attr.ByPathLinks})
+	}
+
+	for _, a := range r.Attrs.Failing() {
+		s.DiskAttributes.FailingAttributes = append(s.DiskAttributes.FailingAttributes, a.Name)
+	}
+
+	return s
+}
+
+// driveType returns whether a disk is rotational, from its rotation rate reported by ATA IDENTIFY
+// or, for SCSI disks, by the Block Device Characteristics VPD page
+func driveType(r render.Report) string {
+	switch {
+	case r.Attr.RotationRate > 0:
+		return DriveTypeHDD
+	case r.Attr.RotationRateStr == "Solid State Device", r.Attr.Bus == "nvme":
+		return DriveTypeSSD
+	}
+	return DriveTypeUnknown
+}
+
+// compliance returns the command set standard of a disk, the ATA version of ATA disks and the
+// first standard of the version descriptors of SCSI disks
+func compliance(r render.Report) string {
+	if r.Attr.ATAMajorVersion != "" {
+		return r.Attr.ATAMajorVersion
+	}
+	if standards := r.Attr.SCSIInquiry.Standards(); len(standards) > 0 {
+		return standards[0]
+	}
+	return ""
+}
+
+// Unstructured returns the sections as an unstructured map, e.g. to set them in the "spec" of
+// an unstructured.Unstructured BlockDevice. Integers are int64 as unstructured objects require.
+func (s Spec) Unstructured() (map[string]interface{}, error) {
+	data, err := json.Marshal(s)
+	if err != nil {
+		return nil, err
+	}
+
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	var m map[string]interface{}
+	if err := dec.Decode(&m); err != nil {
+		return nil, err
+	}
+
+	return unstructuredNumbers(m).(map[string]interface{}), nil
+}
+
+// unstructuredNumbers replaces the json.Numbers of a decoded JSON value by int64 or float64
+func unstructuredNumbers(v interface{}) interface{} {
+	switch v := v.(type) {
+	case map[string]interface{}:
+		for k, e := range v {
+			v[k] = unstructuredNumbers(e)
+		}
+	case []interface{}:
+		for i, e := range v {
+			v[i] = unstructuredNumbers(e)
+		}
+	case json.Number:
+		if i, err := v.Int64(); err == nil {
+			return i
+		}
+		f, _ := v.Float64()
+		return f
+	}
+	return v
+}
@@ -0,0 +1,124 @@
+/*
+Copyright 2018 The OpenEBS Authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Admin endpoint of the monitor daemon, serving its runtime statistics and profiles.
+
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/pprof"
+	"os"
+	"runtime"
+	"time"
+
+	"github.com/openebs/smart/monitor"
+)
+
+// adminStats are the runtime statistics served on /debug/stats
+type adminStats struct {
+	Goroutines int           `json:"goroutines"`
+	OpenFDs    int           `json:"open_fds"` // -1 if unknown
+	HeapAlloc  uint64        `json:"heap_alloc"`
+	Uptime     string        `json:"uptime"`
+	Monitor    monitor.Stats `json:"monitor"`
+}
+
+// openFDs returns the number of file descriptors the process has open, -1 if unknown
+func openFDs() int {
+	entries, err := os.ReadDir("/proc/self/fd")
+	if err != nil {
+		return -1
+	}
+
+	// The descriptor reading the directory is listed too
+	return len(entries) - 1
+}
+
+// adminHandler serves the runtime statistics of the daemon and m on /debug/stats, and the
+// profiles of net/http/pprof on /debug/pprof if withPprof is set
+func adminHandler(m *monitor.Monitor, withPprof bool) http.Handler {
+	started := time.Now()
+	mux := http.NewServeMux()
+
+	mux.HandleFunc("/debug/stats", func(w http.ResponseWriter, r *http.Request) {
+		var mem runtime.MemStats
+		runtime.ReadMemStats(&mem)
+
+		stats := adminStats{
+			Goroutines: runtime.NumGoroutine(),
+			OpenFDs:    openFDs(),
+			HeapAlloc:  mem.HeapAlloc,
+			Uptime:     time.Since(started).Round(time.Second).String(),
+			Monitor:    m.Stats(),
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		enc.Encode(stats)
+	})
+
+	if withPprof {
+		mux.HandleFunc("/debug/pprof/", pprof.Index)
+		mux.HandleFunc("/debug/pprof/cmdline", pprof.Cmdline)
+		mux.HandleFunc("/debug/pprof/profile", pprof.Profile)
+		mux.HandleFunc("/debug/pprof/symbol", pprof.Symbol)
+		mux.HandleFunc("/debug/pprof/trace", pprof.Trace)
+	}
+
+	return mux
+}
+
+// adminListener returns the listener of the admin endpoint: the socket passed by systemd
+// socket activation, or a new one on addr, nil if neither is set. The endpoint has no
+// authentication, so the address must be a loopback address unless remote is set.
+func adminListener(addr string, remote bool) (net.Listener, error) {
+	l, err := activatedListener()
+	if err != nil {
+		return nil, err
+	}
+	if l == nil {
+		if addr == "" {
+			return nil, nil
+		}
+		if l, err = net.Listen("tcp", addr); err != nil {
+			return nil, err
+		}
+	}
+	if tcpAddr, ok := l.Addr().(*net.TCPAddr); !remote && (!ok || !tcpAddr.IP.IsLoopback()) {
+		l.Close()
+		return nil, fmt.Errorf("%s is not a loopback address, see -admin-remote", l.Addr())
+	}
+
+	return l, nil
+}
+
+// serveAdmin serves the admin endpoint on l until ctx is done
+func serveAdmin(ctx context.Context, l net.Listener, m *monitor.Monitor, withPprof bool) error {
+	server := &http.Server{Handler: adminHandler(m, withPprof), ReadHeaderTimeout: 10 * time.Second}
+	go func() {
+		<-ctx.Done()
+		server.Close()
+	}()
+
+	if err := server.Serve(l); err != http.ErrServerClosed {
+		return err
+	}
+
+	return nil
+}
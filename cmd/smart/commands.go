@@ -0,0 +1,760 @@
+/*
+Copyright 2018 The OpenEBS Authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Subcommands of the smart command line tool.
+
+package main
+
+import (
+	"context"
+	"crypto/tls"
+	"flag"
+	"fmt"
+	"net"
+	"os"
+	"os/signal"
+	"sort"
+	"strings"
+	"syscall"
+	"text/tabwriter"
+	"time"
+
+	"github.com/openebs/smart/helper"
+	"github.com/openebs/smart/internal/utilities"
+	"github.com/openebs/smart/monitor"
+	"github.com/openebs/smart/render"
+	"github.com/openebs/smart/scsismart"
+	"github.com/openebs/smart/smartctl"
+	"github.com/openebs/smart/smartd"
+	"github.com/openebs/smart/smartinfo"
+	"github.com/openebs/smart/systemd"
+	"github.com/openebs/smart/thermal"
+)
+
+// subcommands maps the subcommand names to their implementation, which returns the exit status
+var subcommands = map[string]func(args []string) int{
+	"crosscheck":   crossCheck,
+	"helper":       runHelper,
+	"info":         info,
+	"monitor":      runMonitor,
+	"scan":         scan,
+	"soak":         soak,
+	"telemetry":    telemetry,
+	"temp-history": tempHistory,
+	"test":         selfTest,
+}
+
+// hiddenSubcommands are the diagnostic subcommands left out of the usage
+var hiddenSubcommands = map[string]bool{
+	"soak": true,
+}
+
+// usage prints the usage of the flags and the subcommands which are not hidden
+func usage() {
+	var names []string
+	for name := range subcommands {
+		if !hiddenSubcommands[name] {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+
+	fmt.Fprintln(flag.CommandLine.Output(), "usage: smart [flags]\n       smart <command> [args]")
+	fmt.Fprintf(flag.CommandLine.Output(), "\ncommands: %s\n\nflags:\n", strings.Join(names, ", "))
+	flag.PrintDefaults()
+}
+
+// Exit statuses of the test subcommand, besides 0 when the self-test passed
+const (
+	exitError       = 1 // the self-test could not be run
+	exitUsage       = 2
+	exitTestFailed  = 4
+	exitTestAborted = 5   // the device reports the self-test aborted by the host or interrupted
+	exitInterrupted = 130 // waiting interrupted by Ctrl-C, the self-test keeps running
+)
+
+// selfTestTypes maps the -type values of the test subcommand to self-test types
+var selfTestTypes = map[string]scsismart.SelfTestType{
+	"short":      scsismart.SelfTestShort,
+	"long":       scsismart.SelfTestExtended,
+	"extended":   scsismart.SelfTestExtended,
+	"conveyance": scsismart.SelfTestConveyance,
+	"abort":      scsismart.SelfTestAbort,
+}
+
+// helperSocketEnv is the environment variable holding the socket of the privileged helper to
+// send the device commands through, or its tcp://host:port address on the network
+const helperSocketEnv = "SMART_HELPER_SOCKET"
+
+// Environment variables holding the credentials used to connect to the helper
+const (
+	helperTokenFileEnv = "SMART_HELPER_TOKEN_FILE"
+	helperCAEnv        = "SMART_HELPER_CA"   // CA certificates verifying a helper on the network
+	helperCertEnv      = "SMART_HELPER_CERT" // client certificate for a helper requiring one
+	helperKeyEnv       = "SMART_HELPER_KEY"
+)
+
+// dryRunUsage is the usage of the -dry-run flags
+const dryRunUsage = "print the commands which would change the state of the devices instead of sending them"
+
+// enableDryRun reports the commands changing the state of the devices instead of sending them,
+// sending the others as before
+func enableDryRun() {
+	scsismart.SetExecutor(&scsismart.DryRunExecutor{Executor: scsismart.CurrentExecutor(), Output: os.Stdout})
+}
+
+// useHelper sends the device commands of the process through the helper listening on socket
+func useHelper(socket string) error {
+	var opts helper.DialOptions
+	if file := os.Getenv(helperTokenFileEnv); file != "" {
+		token, err := helper.ReadToken(file)
+		if err != nil {
+			return err
+		}
+		opts.Token = token
+	}
+	if strings.HasPrefix(socket, "tcp://") {
+		config, err := helper.ClientTLSConfig(os.Getenv(helperCAEnv), os.Getenv(helperCertEnv), os.Getenv(helperKeyEnv))
+		if err != nil {
+			return err
+		}
+		opts.TLS = config
+	}
+
+	client, err := helper.DialWithOptions(socket, opts)
+	if err != nil {
+		return err
+	}
+	scsismart.SetExecutor(client)
+
+	return nil
+}
+
+// activatedListener returns the socket passed by systemd socket activation, or nil if the
+// process was not socket activated. A service has a single socket, any other is closed.
+func activatedListener() (net.Listener, error) {
+	listeners, err := systemd.Listeners()
+	if err != nil || len(listeners) == 0 {
+		return nil, err
+	}
+	for _, extra := range listeners[1:] {
+		fmt.Fprintf(os.Stderr, "warning: ignoring the extra activated socket %s\n", extra.Addr())
+		extra.Close()
+	}
+
+	return listeners[0], nil
+}
+
+// runHelper runs the privileged helper executing the device commands of unprivileged processes
+func runHelper(args []string) int {
+	fs := flag.NewFlagSet("helper", flag.ExitOnError)
+	socket := fs.String("socket", helper.DefaultSocket, "unix socket to listen on, unless systemd passes one")
+	mode := fs.Uint("mode", 0660, "file mode of the socket, which controls who may use the helper")
+	allowDestructive := fs.Bool("allowDestructive", false, "allow commands other than the read-only queries, which may write to or erase the media")
+	listen := fs.String("listen", "", "TCP address to listen on with TLS instead of the socket, e.g. :7001")
+	tlsCert := fs.String("tls-cert", "", "certificate of the helper on the network")
+	tlsKey := fs.String("tls-key", "", "private key of -tls-cert")
+	clientCA := fs.String("tls-client-ca", "", "CA certificates the clients must present a certificate from")
+	tokenFile := fs.String("token-file", "", "file holding the token clients must send")
+	fs.Parse(args)
+
+	opts := helper.Options{AllowDestructive: *allowDestructive}
+	if *tokenFile != "" {
+		token, err := helper.ReadToken(*tokenFile)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			return 1
+		}
+		opts.Token = token
+	}
+
+	// Anyone reaching the address could send commands to the devices otherwise
+	if *listen != "" && *clientCA == "" && opts.Token == "" {
+		fmt.Fprintln(os.Stderr, "-listen needs -tls-client-ca or -token-file to authenticate the clients")
+		return 2
+	}
+
+	// A socket passed by systemd replaces the one of -socket or -listen, whose mode or address
+	// is set by the socket unit. It is still served with TLS if -listen is set.
+	l, err := activatedListener()
+	switch {
+	case err != nil:
+	case *listen != "":
+		var config *tls.Config
+		if config, err = helper.ServerTLSConfig(*tlsCert, *tlsKey, *clientCA); err != nil {
+			break
+		}
+		if l != nil {
+			l, err = helper.NewTLSListener(l, config)
+		} else {
+			l, err = helper.ListenTLS(*listen, config)
+		}
+	case l == nil:
+		l, err = helper.Listen(*socket, os.FileMode(*mode))
+	}
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	go func() {
+		<-ctx.Done()
+		l.Close()
+	}()
+
+	if _, err := systemd.Notify(systemd.StateReady); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+	}
+
+	err = helper.Serve(l, opts)
+	if ctx.Err() == nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+
+	return 0
+}
+
+// yesNo renders a capability of the scan matrix
+func yesNo(b bool) string {
+	if b {
+		return "yes"
+	}
+	return "no"
+}
+
+// deviceMatches is a repeatable flag of devices matched by path, wwn, serial or model
+type deviceMatches []smartinfo.DeviceMatch
+
+func (m *deviceMatches) String() string {
+	return fmt.Sprint(*m)
+}
+
+func (m *deviceMatches) Set(s string) error {
+	match, err := smartinfo.ParseDeviceMatch(s)
+	if err != nil {
+		return err
+	}
+	*m = append(*m, match)
+	return nil
+}
+
+// ignoreUsage is the usage of the -ignore flags
+const ignoreUsage = "ignore the devices matching path=,wwn=,serial=,model= patterns, e.g. model='JMicron*' (repeatable)"
+
+// raidUsage is the usage of the -raid flags
+const raidUsage = "include the physical drives behind RAID controllers"
+
+// scan lists the devices and, with -probe, the matrix of what can be monitored on each. With
+// -sort or -filter, it lists the health of the devices instead.
+func scan(args []string) int {
+	fs := flag.NewFlagSet("scan", flag.ExitOnError)
+	probe := fs.Bool("probe", false, "identify each device and report its SMART and self-test support")
+	all := fs.Bool("all", false, "include loop, ram, device-mapper and md devices")
+	raid := fs.Bool("raid", false, raidUsage)
+	sortKey := fs.String("sort", "", "list the health of the devices sorted by device, size, model, health or temperature, descending with a - prefix")
+	var ignore deviceMatches
+	fs.Var(&ignore, "ignore", ignoreUsage)
+	var filters filterFlags
+	fs.Var(&filters, "filter", "list the health of the devices matching an expression, e.g. health!=passed or size>4TB (repeatable)")
+	fs.Parse(args)
+
+	opts := smartinfo.ScanOptions{IncludePseudo: *all, IncludeRAID: *raid, Ignore: ignore}
+	if *sortKey != "" || len(filters.filters) > 0 {
+		return scanHealth(opts, *sortKey, filters.filters)
+	}
+	if !*probe {
+		scanDevices(opts)
+		return 0
+	}
+
+	results, err := smartinfo.ProbeDevices(opts)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+
+	tw := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "DEVICE\tSTATUS\tTRANSPORT\tSMART\tENABLED\tSELF-TEST\tMODEL\tNOTE")
+	for _, r := range results {
+		var note string
+		switch {
+		case r.RAIDController != "":
+			note = fmt.Sprintf("logical volume of a %s RAID controller, its disks need controller pass-through", r.RAIDController)
+		case r.Err != nil:
+			note = r.Err.Error()
+		}
+
+		if r.Status != smartinfo.DeviceStatusOK {
+			fmt.Fprintf(tw, "%s\t%s\t-\t-\t-\t-\t-\t%s\n", r.Name, r.Status, note)
+			continue
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\t%s\t%s\t%s\n", r.Name, r.Status, r.Transport,
+			yesNo(r.SMART), yesNo(r.SMARTEnabled), yesNo(r.SelfTest), r.Model, note)
+	}
+	if err := tw.Flush(); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+
+	return 0
+}
+
+// scanHealth lists the health of the devices selected by filters, sorted on a key
+func scanHealth(opts smartinfo.ScanOptions, sortKey string, filters []snapshotFilter) int {
+	// the sort key is checked before the devices are queried
+	if err := sortSnapshots(nil, sortKey); sortKey != "" && err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return exitUsage
+	}
+
+	snapshots, err := smartinfo.ScanHealth(context.Background(), opts, smartinfo.CollectOptions{})
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return exitError
+	}
+
+	snapshots = filterSnapshots(snapshots, filters)
+	if sortKey != "" {
+		sortSnapshots(snapshots, sortKey)
+	}
+
+	tw := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "DEVICE\tPROTOCOL\tSIZE\tHEALTH\tSCORE\tTEMP\tMODEL\tNOTE")
+	for _, s := range snapshots {
+		temp := "-"
+		if s.HasTemperature {
+			temp = fmt.Sprintf("%d C", s.Temperature)
+		}
+		note := strings.Join(s.Reasons, "; ")
+		if s.Err != nil {
+			note = s.Err.Error()
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%d\t%s\t%s\t%s\n", s.Device, s.Protocol, utilities.ConvertBytes(s.Capacity),
+			strings.ToUpper(s.Verdict), s.Score, temp, s.Model, note)
+	}
+	if err := tw.Flush(); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return exitError
+	}
+
+	return 0
+}
+
+// runMonitor polls the devices until interrupted, writing their events to stdout or a file
+// such as a named pipe, as text or as newline-delimited JSON, and to the -sink metric sinks
+func runMonitor(args []string) int {
+	fs := flag.NewFlagSet("monitor", flag.ExitOnError)
+	interval := fs.Duration("interval", monitor.DefaultInterval, "time between two polls of the devices")
+	format := fs.String("format", "text", "output format, text or ndjson")
+	output := fs.String("output", "", "file or named pipe to write the events to instead of stdout")
+	var sinkFlags sinkTargets
+	fs.Var(&sinkFlags, "sink", sinkUsage)
+	smartdConf := fs.String("smartd-conf", "", "smartd.conf to read the devices, self-test schedule and temperature limits from")
+	overrides := fs.String("overrides", "", "JSON file of per-device timeouts, pass-through types, disabled probes and ignored attributes")
+	var ignore deviceMatches
+	fs.Var(&ignore, "ignore", ignoreUsage)
+	raid := fs.Bool("raid", false, raidUsage)
+	dryRun := fs.Bool("dry-run", false, dryRunUsage+", e.g. the scheduled self-tests")
+	adminListen := fs.String("admin-listen", "", "address to serve the runtime statistics of the monitor on, at /debug/stats, e.g. localhost:9101, unless systemd passes a socket")
+	withPprof := fs.Bool("pprof", false, "also serve the profiles of the monitor at /debug/pprof on the -admin-listen address")
+	adminRemote := fs.Bool("admin-remote", false, "allow a non-loopback -admin-listen address, which serves the endpoint without authentication")
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "usage: smart monitor [flags]")
+		fs.PrintDefaults()
+		printSinkUsage(fs.Output())
+	}
+	fs.Parse(args)
+
+	adminL, err := adminListener(*adminListen, *adminRemote)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "admin endpoint: %v\n", err)
+		return 1
+	}
+	if (*withPprof || *adminRemote) && adminL == nil {
+		fmt.Fprintln(os.Stderr, "-pprof and -admin-remote require -admin-listen or a socket passed by systemd")
+		return 2
+	}
+	if adminL != nil {
+		defer adminL.Close()
+	}
+
+	if *format != "text" && *format != "ndjson" {
+		fs.Usage()
+		return 2
+	}
+
+	out := os.Stdout
+	if *output != "" {
+		// Opening a named pipe blocks until a reader opens it
+		f, err := os.OpenFile(*output, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0644)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			return 1
+		}
+		defer f.Close()
+		out = f
+	}
+
+	var opts monitor.Options
+	if *smartdConf != "" {
+		config, err := smartd.ParseFile(*smartdConf)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			return 1
+		}
+		translation, err := smartd.Translate(config)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "%s: %v\n", *smartdConf, err)
+			return 1
+		}
+		for _, warning := range translation.Warnings {
+			fmt.Fprintf(os.Stderr, "%s: %s\n", *smartdConf, warning)
+		}
+		opts = translation.Options
+	}
+	if *overrides != "" {
+		o, err := smartinfo.ParseOverridesFile(*overrides)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			return 1
+		}
+		// The overrides of the file apply after those of smartd.conf, so they win
+		opts.Overrides = append(opts.Overrides, o...)
+	}
+	opts.Scan.Ignore = append(opts.Scan.Ignore, ignore...)
+	opts.Scan.IncludeRAID = *raid
+	if *dryRun {
+		enableDryRun()
+	}
+	opts.Interval = *interval
+	opts.EventBuffer = 64
+	// The watchdog is pinged once a poll completes, so WatchdogSec= must exceed the interval
+	// plus the time a poll takes
+	if watchdog := systemd.WatchdogInterval(); watchdog != 0 {
+		if *interval >= watchdog {
+			fmt.Fprintf(os.Stderr, "warning: the poll interval %v is not below the watchdog timeout %v\n", *interval, watchdog)
+		}
+		opts.PollDone = func() {
+			if err := systemd.PingWatchdog(); err != nil {
+				fmt.Fprintln(os.Stderr, err)
+			}
+		}
+	}
+
+	sinks, err := openSinks(sinkFlags)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	m := monitor.New(opts)
+	done := make(chan error, 1)
+	go func() { done <- m.Run(ctx) }()
+
+	events := m.Events()
+	if len(sinks) > 0 {
+		events = forwardToSinks(ctx, events, sinks)
+	}
+
+	if adminL != nil {
+		go func() {
+			if err := serveAdmin(ctx, adminL, m, *withPprof); err != nil {
+				fmt.Fprintf(os.Stderr, "admin endpoint: %v\n", err)
+			}
+		}()
+	}
+
+	// Under systemd with Type=notify, the service is ready once the monitor runs
+	if _, err := systemd.Notify(systemd.StateReady); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+	}
+	defer systemd.Notify(systemd.StateStopping)
+
+	if *format == "ndjson" {
+		err = monitor.WriteNDJSON(ctx, out, events)
+	} else {
+		for event := range events {
+			if _, err = fmt.Fprintf(out, "%s %s %s\n", event.Time.Format(time.RFC3339), event.Type, event.Device); err != nil {
+				break
+			}
+		}
+	}
+	stop()
+
+	if runErr := <-done; err == nil && runErr != context.Canceled {
+		err = runErr
+	}
+	if err != nil && err != context.Canceled {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+
+	return 0
+}
+
+// tempHistory prints the temperature history of a device as CSV or JSON
+func tempHistory(args []string) int {
+	fs := flag.NewFlagSet("temp-history", flag.ExitOnError)
+	format := fs.String("format", "csv", "output format, csv or json")
+	since := fs.Duration("since", 0, "only print the samples of the last duration, e.g. 24h")
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "usage: smart temp-history [-format csv|json] [-since duration] <device>")
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+
+	if fs.NArg() != 1 {
+		fs.Usage()
+		return 2
+	}
+
+	devPath, err := utilities.ResolveDevice(fs.Arg(0))
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+
+	series, err := thermal.ReadDevice(devPath)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+	if *since > 0 {
+		series = series.Between(time.Now().Add(-*since), time.Time{})
+	}
+
+	switch *format {
+	case "csv":
+		err = series.WriteCSV(os.Stdout)
+	case "json":
+		err = series.WriteJSON(os.Stdout)
+	default:
+		err = fmt.Errorf("unknown format %q", *format)
+	}
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+
+	return 0
+}
+
+// progressBar renders the progress of a self-test, with its estimated time left once known
+func progressBar(done int, eta time.Duration) string {
+	const width = 40
+
+	filled := done * width / 100
+	bar := fmt.Sprintf("[%s%s] %3d%%", strings.Repeat("#", filled), strings.Repeat(" ", width-filled), done)
+	if eta > 0 {
+		bar += fmt.Sprintf(" ETA %s", eta.Round(time.Second))
+	}
+
+	return bar
+}
+
+// waitSelfTest polls the status of a running self-test until it completes, rendering its
+// progress on stderr
+func waitSelfTest(ctx context.Context, tester scsismart.SelfTester, interval time.Duration) (scsismart.SelfTestStatus, error) {
+	start := time.Now()
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		status, err := tester.SelfTestStatus()
+		if err != nil {
+			return status, err
+		}
+		if !status.InProgress {
+			fmt.Fprintf(os.Stderr, "\r%s\n", progressBar(100, 0))
+			return status, nil
+		}
+
+		// The remaining percentage is reported in steps of 10%, the time left is extrapolated
+		// from the time the steps done so far took
+		done := 100 - status.Remaining
+		var eta time.Duration
+		if done > 0 {
+			eta = time.Since(start) * time.Duration(status.Remaining) / time.Duration(done)
+		}
+		fmt.Fprintf(os.Stderr, "\r%s", progressBar(done, eta))
+
+		select {
+		case <-ctx.Done():
+			fmt.Fprintln(os.Stderr)
+			return status, ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
+
+// selfTest starts a self-test on a device and, with -wait, waits for its result
+func selfTest(args []string) int {
+	fs := flag.NewFlagSet("test", flag.ExitOnError)
+	testType := fs.String("type", "short", "self-test to run: short, long, conveyance or abort")
+	wait := fs.Bool("wait", false, "wait for the self-test to complete and exit with a status reflecting its result")
+	interval := fs.Duration("interval", 10*time.Second, "time between two polls of the self-test progress with -wait")
+	dryRun := fs.Bool("dry-run", false, dryRunUsage)
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "usage: smart test <device> [-type short|long|conveyance|abort] [-wait] [-interval duration] [-dry-run]")
+		fs.PrintDefaults()
+		fmt.Fprintln(fs.Output(), "\nWith -wait the exit status is 0 if the self-test passed, 4 if it failed and 5 if the device\nreports it aborted or interrupted. Ctrl-C stops waiting with exit status 130 and leaves the\nself-test running on the device.")
+	}
+
+	// The device may come before or after the flags
+	fs.Parse(args)
+	devPath := fs.Arg(0)
+	if fs.NArg() > 0 {
+		fs.Parse(fs.Args()[1:])
+	}
+	if devPath == "" || fs.NArg() != 0 {
+		fs.Usage()
+		return exitUsage
+	}
+
+	test, ok := selfTestTypes[*testType]
+	if !ok || *interval <= 0 {
+		fs.Usage()
+		return exitUsage
+	}
+
+	devPath, err := utilities.ResolveDevice(devPath)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return exitError
+	}
+
+	if *dryRun {
+		enableDryRun()
+	}
+	d, err := scsismart.DetectSCSITypeWithOptions(devPath, scsismart.DetectOptions{})
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return exitError
+	}
+	defer d.Close()
+
+	tester, ok := d.(scsismart.SelfTester)
+	if !ok {
+		fmt.Fprintf(os.Stderr, "%s cannot run self-tests\n", devPath)
+		return exitError
+	}
+
+	if err := tester.StartSelfTest(test); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return exitError
+	}
+	// No self-test runs in a dry run, there is nothing to wait for
+	if !*wait || test == scsismart.SelfTestAbort || *dryRun {
+		return 0
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	status, err := waitSelfTest(ctx, tester, *interval)
+	switch {
+	case err == context.Canceled:
+		fmt.Fprintln(os.Stderr, "stopped waiting, the self-test keeps running on the device")
+		return exitInterrupted
+	case err != nil:
+		fmt.Fprintln(os.Stderr, err)
+		return exitError
+	case status.Failed():
+		fmt.Printf("self-test failed (status %d)\n", status.Result)
+		return exitTestFailed
+	case status.Result != 0:
+		fmt.Printf("self-test aborted or interrupted (status %d)\n", status.Result)
+		return exitTestAborted
+	}
+
+	fmt.Println("self-test passed")
+	return 0
+}
+
+// exitDiscrepancies is the exit status of the crosscheck subcommand when the library and
+// smartctl disagree on values which do not change over time
+const exitDiscrepancies = 3
+
+// crossCheck compares the values the library reads from a device with the ones smartctl
+// reports for it, to validate the parsers against the reference implementation
+func crossCheck(args []string) int {
+	fs := flag.NewFlagSet("crosscheck", flag.ExitOnError)
+	jsonPath := fs.String("json", "", "file holding the output of smartctl --json -a for the device, - for stdin, smartctl is run if empty")
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "usage: smart crosscheck [-json file] <device>")
+		fs.PrintDefaults()
+		fmt.Fprintln(fs.Output(), "\nThe exit status is 3 if values other than the volatile ones differ.")
+	}
+	fs.Parse(args)
+
+	if fs.NArg() != 1 {
+		fs.Usage()
+		return exitUsage
+	}
+
+	devPath, err := utilities.ResolveDevice(fs.Arg(0))
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return exitError
+	}
+
+	var out *smartctl.Output
+	switch *jsonPath {
+	case "":
+		out, err = smartctl.Run(devPath)
+	case "-":
+		out, err = smartctl.Parse(os.Stdin)
+	default:
+		var f *os.File
+		if f, err = os.Open(*jsonPath); err == nil {
+			out, err = smartctl.Parse(f)
+			f.Close()
+		}
+	}
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return exitError
+	}
+
+	d, err := scsismart.DetectSCSITypeWithOptions(devPath, scsismart.DetectOptions{})
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return exitError
+	}
+	defer d.Close()
+
+	report, err := render.Collect(devPath, d)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return exitError
+	}
+	for _, warning := range report.Warnings {
+		fmt.Fprintln(os.Stderr, warning)
+	}
+
+	status := 0
+	for _, discrepancy := range smartctl.Compare(report, out) {
+		fmt.Println(discrepancy)
+		if !discrepancy.Volatile {
+			status = exitDiscrepancies
+		}
+	}
+
+	return status
+}
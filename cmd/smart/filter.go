@@ -0,0 +1,221 @@
+/*
+Copyright 2018 The OpenEBS Authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Sorting and filtering of the health snapshots listed by the scan subcommand.
+
+package main
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/openebs/smart/smartinfo"
+)
+
+// healthRanks orders the verdicts from the worst, so that sorting by health lists the failing
+// drives first
+var healthRanks = map[string]int{
+	smartinfo.VerdictFailed:  0,
+	smartinfo.VerdictWarning: 1,
+	smartinfo.VerdictUnknown: 2,
+	smartinfo.VerdictPassed:  3,
+}
+
+// snapshotLess compares two snapshots on a sort key
+var snapshotLess = map[string]func(a, b smartinfo.HealthSnapshot) bool{
+	"device": func(a, b smartinfo.HealthSnapshot) bool { return a.Device < b.Device },
+	"size":   func(a, b smartinfo.HealthSnapshot) bool { return a.Capacity < b.Capacity },
+	"model":  func(a, b smartinfo.HealthSnapshot) bool { return a.Model < b.Model },
+	"health": func(a, b smartinfo.HealthSnapshot) bool {
+		if ra, rb := healthRanks[a.Verdict], healthRanks[b.Verdict]; ra != rb {
+			return ra < rb
+		}
+		return a.Score < b.Score
+	},
+	// drives without temperature sort first
+	"temperature": func(a, b smartinfo.HealthSnapshot) bool {
+		if a.HasTemperature != b.HasTemperature {
+			return !a.HasTemperature
+		}
+		return a.Temperature < b.Temperature
+	},
+}
+
+// sortSnapshots sorts snapshots on a key, in descending order if the key starts with -
+func sortSnapshots(snapshots []smartinfo.HealthSnapshot, key string) error {
+	desc := strings.HasPrefix(key, "-")
+	less, ok := snapshotLess[strings.TrimPrefix(key, "-")]
+	if !ok {
+		return fmt.Errorf("unknown sort key %q, expecting device, size, model, health or temperature", key)
+	}
+
+	sort.SliceStable(snapshots, func(i, j int) bool {
+		if desc {
+			return less(snapshots[j], snapshots[i])
+		}
+		return less(snapshots[i], snapshots[j])
+	})
+	return nil
+}
+
+// filterExpr matches a filter expression, e.g. health!=PASSED or size>4TB
+var filterExpr = regexp.MustCompile(`^\s*([a-z]+)\s*(==|=|!=|<=|>=|<|>)\s*(.*?)\s*$`)
+
+// snapshotFilter selects snapshots
+type snapshotFilter func(s smartinfo.HealthSnapshot) bool
+
+// stringFields are the fields of a snapshot compared as strings, case insensitively
+var stringFields = map[string]func(s smartinfo.HealthSnapshot) string{
+	"device":   func(s smartinfo.HealthSnapshot) string { return s.Device },
+	"protocol": func(s smartinfo.HealthSnapshot) string { return s.Protocol },
+	"model":    func(s smartinfo.HealthSnapshot) string { return s.Model },
+	"serial":   func(s smartinfo.HealthSnapshot) string { return s.Serial },
+	"health":   func(s smartinfo.HealthSnapshot) string { return s.Verdict },
+}
+
+// numberFields are the fields of a snapshot compared as numbers, with whether the snapshot has
+// a value for them
+var numberFields = map[string]func(s smartinfo.HealthSnapshot) (float64, bool){
+	"size": func(s smartinfo.HealthSnapshot) (float64, bool) { return float64(s.Capacity), s.Capacity > 0 },
+	"temperature": func(s smartinfo.HealthSnapshot) (float64, bool) {
+		return float64(s.Temperature), s.HasTemperature
+	},
+	"score": func(s smartinfo.HealthSnapshot) (float64, bool) {
+		return float64(s.Score), s.Verdict != smartinfo.VerdictUnknown
+	},
+	"life": func(s smartinfo.HealthSnapshot) (float64, bool) {
+		return float64(s.LifeUsedPercent), s.LifeUsedPercent >= 0
+	},
+}
+
+// parseFilter parses a filter expression comparing a field of the snapshots to a value. Strings
+// only support = and !=, sizes take decimal (KB, MB, GB, TB) or binary (KiB ... TiB) units.
+// Snapshots without a value for a numeric field never match.
+func parseFilter(expr string) (snapshotFilter, error) {
+	m := filterExpr.FindStringSubmatch(expr)
+	if m == nil {
+		return nil, fmt.Errorf("invalid filter %q, expecting field, operator and value, e.g. health!=passed", expr)
+	}
+	field, op, value := m[1], m[2], m[3]
+	if field == "temp" {
+		field = "temperature"
+	}
+
+	if get, ok := stringFields[field]; ok {
+		switch op {
+		case "=", "==":
+			return func(s smartinfo.HealthSnapshot) bool { return strings.EqualFold(get(s), value) }, nil
+		case "!=":
+			return func(s smartinfo.HealthSnapshot) bool { return !strings.EqualFold(get(s), value) }, nil
+		}
+		return nil, fmt.Errorf("invalid filter %q, %s only supports = and !=", expr, field)
+	}
+
+	get, ok := numberFields[field]
+	if !ok {
+		return nil, fmt.Errorf("invalid filter %q, unknown field %s", expr, field)
+	}
+	want, err := parseFilterNumber(value, field == "size")
+	if err != nil {
+		return nil, fmt.Errorf("invalid filter %q: %v", expr, err)
+	}
+
+	return func(s smartinfo.HealthSnapshot) bool {
+		v, ok := get(s)
+		if !ok {
+			return false
+		}
+		switch op {
+		case "=", "==":
+			return v == want
+		case "!=":
+			return v != want
+		case "<":
+			return v < want
+		case "<=":
+			return v <= want
+		case ">":
+			return v > want
+		}
+		return v >= want
+	}, nil
+}
+
+// sizeUnits are the multipliers of the units of sizes in filters
+var sizeUnits = map[string]float64{
+	"": 1, "b": 1,
+	"k": 1e3, "kb": 1e3, "kib": 1 << 10,
+	"m": 1e6, "mb": 1e6, "mib": 1 << 20,
+	"g": 1e9, "gb": 1e9, "gib": 1 << 30,
+	"t": 1e12, "tb": 1e12, "tib": 1 << 40,
+	"p": 1e15, "pb": 1e15, "pib": 1 << 50,
+}
+
+// parseFilterNumber parses the value of a numeric filter, with a unit if it is a size
+func parseFilterNumber(value string, size bool) (float64, error) {
+	number := strings.TrimRight(value, "abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ")
+	unit := strings.ToLower(value[len(number):])
+
+	v, err := strconv.ParseFloat(number, 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid number %q", value)
+	}
+	if unit == "" {
+		return v, nil
+	}
+
+	multiplier, ok := sizeUnits[unit]
+	if !size || !ok {
+		return 0, fmt.Errorf("invalid unit %q", unit)
+	}
+	return v * multiplier, nil
+}
+
+// filterSnapshots returns the snapshots selected by all the filters
+func filterSnapshots(snapshots []smartinfo.HealthSnapshot, filters []snapshotFilter) []smartinfo.HealthSnapshot {
+	var selected []smartinfo.HealthSnapshot
+	for _, s := range snapshots {
+		keep := true
+		for _, f := range filters {
+			keep = keep && f(s)
+		}
+		if keep {
+			selected = append(selected, s)
+		}
+	}
+
+	return selected
+}
+
+// filterFlags is a repeatable flag of filter expressions, which must all match
+type filterFlags struct {
+	exprs   []string
+	filters []snapshotFilter
+}
+
+func (f *filterFlags) String() string {
+	return strings.Join(f.exprs, " ")
+}
+
+func (f *filterFlags) Set(expr string) error {
+	filter, err := parseFilter(expr)
+	if err != nil {
+		return err
+	}
+	f.exprs = append(f.exprs, expr)
+	f.filters = append(f.filters, filter)
+	return nil
+}
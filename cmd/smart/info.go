@@ -0,0 +1,195 @@
+/*
+Copyright 2018 The OpenEBS Authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// The info subcommand, querying a list of devices in one run.
+
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"sync"
+
+	"github.com/openebs/smart/internal/utilities"
+	"github.com/openebs/smart/render"
+	"github.com/openebs/smart/schema"
+	"github.com/openebs/smart/scsismart"
+)
+
+// infoWorkers is the number of devices queried concurrently by the info subcommand
+const infoWorkers = 8
+
+// info queries the devices given as arguments or listed in a file, one identifier per line, and
+// writes their combined reports. The exit status is 1 if any device could not be queried.
+func info(args []string) int {
+	fs := flag.NewFlagSet("info", flag.ExitOnError)
+	devicesFrom := fs.String("devices-from", "", "file listing the devices one per line, - for stdin")
+	format := fs.String("format", "json", "output format, json or text")
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "usage: smart info [-devices-from file] [-format json|text] [device ...]")
+		fs.PrintDefaults()
+		fmt.Fprintln(fs.Output(), "\nDevices are paths, /dev/disk links, WWNs or UUID=<uuid>. Blank lines and lines starting with # are skipped.")
+	}
+	fs.Parse(args)
+
+	if *format != "json" && *format != "text" {
+		fs.Usage()
+		return exitUsage
+	}
+
+	devices := fs.Args()
+	if *devicesFrom != "" {
+		in := os.Stdin
+		if *devicesFrom != "-" {
+			f, err := os.Open(*devicesFrom)
+			if err != nil {
+				fmt.Fprintln(os.Stderr, err)
+				return exitError
+			}
+			defer f.Close()
+			in = f
+		}
+
+		listed, err := readDeviceList(in)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "%s: %v\n", *devicesFrom, err)
+			return exitError
+		}
+		devices = append(devices, listed...)
+	}
+	if len(devices) == 0 {
+		fs.Usage()
+		return exitUsage
+	}
+
+	reports, errs := collectReports(devices)
+
+	var err error
+	if *format == "json" {
+		batch := schema.Batch{SchemaVersion: schema.Version, Disks: []schema.Disk{}}
+		for i, device := range devices {
+			if errs[i] != nil {
+				batch.Errors = append(batch.Errors, deviceError(device, errs[i]))
+				continue
+			}
+			batch.Disks = append(batch.Disks, schema.FromReport(reports[i]))
+		}
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		err = enc.Encode(batch)
+	} else {
+		for i, device := range devices {
+			if errs[i] != nil {
+				fmt.Fprintf(os.Stderr, "%s: %v\n", device, errs[i])
+				continue
+			}
+			fmt.Printf("=== %s\n", device)
+			if err = render.WriteText(os.Stdout, reports[i]); err != nil {
+				break
+			}
+			fmt.Println()
+		}
+	}
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return exitError
+	}
+
+	for _, err := range errs {
+		if err != nil {
+			return exitError
+		}
+	}
+	return 0
+}
+
+// readDeviceList reads newline separated device identifiers, skipping blank and comment lines
+func readDeviceList(r io.Reader) ([]string, error) {
+	var devices []string
+
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		devices = append(devices, line)
+	}
+
+	return devices, scanner.Err()
+}
+
+// collectReports queries the reports of devices concurrently, returning them in the order of
+// devices along with the reason each device could not be queried
+func collectReports(devices []string) ([]render.Report, []error) {
+	var (
+		wg      sync.WaitGroup
+		sem     = make(chan struct{}, infoWorkers)
+		reports = make([]render.Report, len(devices))
+		errs    = make([]error, len(devices))
+	)
+
+	for i, device := range devices {
+		wg.Add(1)
+		sem <- struct{}{}
+		go func(i int, device string) {
+			defer wg.Done()
+			defer func() { <-sem }()
+			reports[i], errs[i] = collectReport(device)
+		}(i, device)
+	}
+
+	wg.Wait()
+
+	return reports, errs
+}
+
+// resolveError is the failure to resolve a device identifier to a device
+type resolveError struct {
+	err error
+}
+
+func (e resolveError) Error() string {
+	return e.err.Error()
+}
+
+// deviceError returns the schema of the error of a device
+func deviceError(device string, err error) schema.DeviceError {
+	e := schema.FromError(device, err)
+	if _, ok := err.(resolveError); ok {
+		e.Code, e.Category = "RESOLVE_FAILED", schema.CategoryNotFound
+	}
+
+	return e
+}
+
+// collectReport resolves a device identifier and queries the report of the device
+func collectReport(device string) (render.Report, error) {
+	devPath, err := utilities.ResolveDevice(device)
+	if err != nil {
+		return render.Report{}, resolveError{err}
+	}
+
+	d, err := scsismart.DetectSCSITypeWithOptions(devPath, scsismart.DetectOptions{})
+	if err != nil {
+		return render.Report{}, err
+	}
+	defer d.Close()
+
+	return render.Collect(devPath, d)
+}
@@ -0,0 +1,173 @@
+/*
+Copyright 2018 The OpenEBS Authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// OpenEBS smartinfo go library for disks.
+
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"runtime"
+
+	"github.com/openebs/smart/internal/ioctl"
+	"github.com/openebs/smart/internal/utilities"
+	"github.com/openebs/smart/render"
+	"github.com/openebs/smart/schema"
+	"github.com/openebs/smart/scsismart"
+	"github.com/openebs/smart/smartinfo"
+)
+
+func scanDevices(opts smartinfo.ScanOptions) {
+	devices, err := smartinfo.ScanDevicesE(opts)
+	if err != nil {
+		fmt.Println(err)
+		os.Exit(1)
+	}
+
+	for _, device := range devices {
+		if device.Err != nil {
+			fmt.Printf("%s: %s (%v)\n", device.Name, device.Status, device.Err)
+			continue
+		}
+		fmt.Printf("%s: %s\n", device.Name, device.Status)
+	}
+}
+
+// spin spins the media of a device down or up
+func spin(d scsismart.Dev, down bool) error {
+	spinner, ok := d.(scsismart.Spinner)
+	if !ok {
+		return fmt.Errorf("device cannot be spun down or up")
+	}
+
+	if down {
+		return spinner.SpinDown()
+	}
+
+	return spinner.SpinUp()
+}
+
+func main() {
+	// Device commands go through a privileged helper when its socket is given in the environment
+	if socket := os.Getenv(helperSocketEnv); socket != "" {
+		if err := useHelper(socket); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+	}
+
+	// Subcommands write machine readable output, so they are dispatched before the banner
+	if len(os.Args) > 1 {
+		if command, ok := subcommands[os.Args[1]]; ok {
+			os.Exit(command(os.Args[2:]))
+		}
+	}
+
+	devPath := flag.String("devPath", "", "SATA device path from which to read SMART attributes, e.g., /dev/sda, a /dev/disk/by-id or by-path link, a WWN or UUID=<uuid>")
+	devScan := flag.Bool("devScan", false, "scan for devices that support smart")
+	html := flag.Bool("html", false, "print the disk report of -devPath as HTML")
+	jsonOut := flag.Bool("json", false, "print the disk report of -devPath as JSON, see the schema package")
+	spinDown := flag.Bool("spinDown", false, "spin down -devPath, e.g. before pulling it")
+	spinUp := flag.Bool("spinUp", false, "spin up -devPath, e.g. before running long diagnostics")
+	dryRun := flag.Bool("dry-run", false, dryRunUsage)
+	flag.Usage = usage
+	flag.Parse()
+
+	if *dryRun {
+		enableDryRun()
+	}
+
+	// The banner would make the JSON output unparsable
+	if !*jsonOut {
+		fmt.Println("OpenEBS smart go library")
+		fmt.Printf("Built with %s on %s (%s)\n\n", runtime.Version(), runtime.GOOS, runtime.GOARCH)
+	}
+
+	// check if required permissions are set or not, the helper has them otherwise
+	if os.Getenv(helperSocketEnv) == "" {
+		if err := ioctl.CapabilitiesCheck(); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+		}
+	}
+
+	if *devPath != "" {
+		var (
+			d   scsismart.Dev // interface
+			err error
+		)
+
+		// With -json, the failure to query the device is reported as a structured error
+		device := *devPath
+		fail := func(err error) {
+			if *jsonOut {
+				enc := json.NewEncoder(os.Stdout)
+				enc.SetIndent("", "  ")
+				enc.Encode(deviceError(device, err))
+			} else {
+				fmt.Println(err)
+			}
+			os.Exit(1)
+		}
+
+		*devPath, err = utilities.ResolveDevice(*devPath)
+		if err != nil {
+			fail(resolveError{err})
+		}
+
+		d, err = scsismart.DetectSCSITypeWithOptions(*devPath, scsismart.DetectOptions{
+			AllowPowerManagement: *spinDown || *spinUp,
+		})
+
+		if err != nil {
+			fail(err)
+		}
+
+		defer d.Close()
+
+		if *spinDown || *spinUp {
+			if err := spin(d, *spinDown); err != nil {
+				fmt.Println(err)
+				os.Exit(1)
+			}
+			return
+		}
+
+		report, err := render.Collect(*devPath, d)
+		if err != nil {
+			d.Close()
+			fail(err)
+		}
+
+		if *html {
+			err = render.WriteHTML(os.Stdout, report)
+		} else if *jsonOut {
+			enc := json.NewEncoder(os.Stdout)
+			enc.SetIndent("", "  ")
+			err = enc.Encode(schema.FromReport(report))
+		} else {
+			err = render.WriteText(os.Stdout, report)
+		}
+		if err != nil {
+			fmt.Println(err)
+			os.Exit(1)
+		}
+	} else if *devScan {
+		scanDevices(smartinfo.ScanOptions{})
+	} else {
+		flag.PrintDefaults()
+		os.Exit(1)
+	}
+}
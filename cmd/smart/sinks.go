@@ -0,0 +1,154 @@
+/*
+Copyright 2018 The OpenEBS Authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Forwarding of the monitor events to the metric sinks.
+
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/openebs/smart/monitor"
+	"github.com/openebs/smart/sink"
+)
+
+// sinkUsage is the usage of the -sink flags
+const sinkUsage = "also write the events as metric points to a sink, as name=target, e.g. graphite=localhost (repeatable)"
+
+// sinkTargetUsage describes the targets of the sinks registered by the sink package
+var sinkTargetUsage = map[string]string{
+	"influx":   "- for stdout, a file to append to or an http(s) write URL, e.g. http://localhost:8086/write?db=smart",
+	"graphite": "host[:port] of a Graphite plaintext listener, port " + sink.GraphitePort + " by default",
+	"statsd":   "host[:port] of a StatsD server, port " + sink.StatsDPort + " by default",
+}
+
+// printSinkUsage lists the registered sinks and their targets
+func printSinkUsage(w io.Writer) {
+	fmt.Fprintln(w, "\nSinks of -sink name=target:")
+	for _, name := range sink.Names() {
+		fmt.Fprintf(w, "  %s\n", name)
+		if usage, ok := sinkTargetUsage[name]; ok {
+			fmt.Fprintf(w, "    \t%s\n", usage)
+		}
+	}
+}
+
+// sinkTarget is a sink name and the target it writes to
+type sinkTarget struct {
+	name, target string
+}
+
+// sinkTargets holds the -sink flags
+type sinkTargets []sinkTarget
+
+func (s *sinkTargets) String() string {
+	return fmt.Sprint(*s)
+}
+
+func (s *sinkTargets) Set(v string) error {
+	parts := strings.SplitN(v, "=", 2)
+	if len(parts) != 2 || parts[0] == "" {
+		return fmt.Errorf("sink %q is not name=target", v)
+	}
+	*s = append(*s, sinkTarget{name: parts[0], target: parts[1]})
+	return nil
+}
+
+// openSinks opens the sinks of the -sink flags, closing the ones already open on error
+func openSinks(targets sinkTargets) ([]sink.Sink, error) {
+	var sinks []sink.Sink
+	for _, t := range targets {
+		s, err := sink.Open(t.name, t.target)
+		if err != nil {
+			closeSinks(sinks)
+			return nil, fmt.Errorf("sink %s=%s: %w", t.name, t.target, err)
+		}
+		sinks = append(sinks, s)
+	}
+
+	return sinks, nil
+}
+
+// closeSinks closes sinks, reporting the errors
+func closeSinks(sinks []sink.Sink) {
+	for _, s := range sinks {
+		if err := s.Close(); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+		}
+	}
+}
+
+// eventPoint returns the metric point of a monitor event. Its fields depend on the event type,
+// so that the events of a device land on distinct Graphite and StatsD paths.
+func eventPoint(e monitor.Event) sink.Point {
+	p := sink.Point{
+		Measurement: "smart_event",
+		Tags:        map[string]string{"device": e.Device, "fingerprint": e.Fingerprint, "type": e.Type.String()},
+		Fields:      make(map[string]interface{}),
+		Time:        e.Time,
+	}
+
+	switch e.Type {
+	case monitor.DeviceAdded, monitor.DeviceRemoved:
+		p.Fields["present"] = e.Type == monitor.DeviceAdded
+	case monitor.AttributeChanged, monitor.HealthDegraded:
+		p.Tags["id"] = strconv.Itoa(int(e.Attr.ID))
+		p.Tags["name"] = e.Attr.Name
+		p.Fields["value"] = int64(e.Attr.Value)
+		p.Fields["raw"] = e.Attr.Raw
+		p.Fields["delta"] = e.Delta
+		p.Fields["degraded"] = e.Type == monitor.HealthDegraded
+	case monitor.SelfTestCompleted:
+		p.Fields["self_test_status"] = int64(e.SelfTestStatus)
+	case monitor.TemperatureAlert:
+		p.Fields["temperature"] = int64(e.Temperature)
+		p.Fields["temperature_level"] = int64(e.TemperatureLevel)
+	case monitor.CriticalWarningChanged:
+		p.Fields["critical_warning"] = int64(e.CriticalWarning)
+	}
+
+	return p
+}
+
+// forwardToSinks writes the events to the sinks and passes them on through the returned
+// channel, which is closed with events or once ctx is done. A sink which fails is reported and
+// still written to, as a monitoring system may come back. The sinks are closed on return.
+func forwardToSinks(ctx context.Context, events <-chan monitor.Event, sinks []sink.Sink) <-chan monitor.Event {
+	out := make(chan monitor.Event, cap(events))
+
+	go func() {
+		defer close(out)
+		defer closeSinks(sinks)
+
+		for event := range events {
+			points := []sink.Point{eventPoint(event)}
+			for _, s := range sinks {
+				if err := s.Write(points); err != nil {
+					fmt.Fprintf(os.Stderr, "sink: %v\n", err)
+				}
+			}
+			select {
+			case out <- event:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	return out
+}
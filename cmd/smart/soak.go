@@ -0,0 +1,297 @@
+/*
+Copyright 2018 The OpenEBS Authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Soak test hammering a device with the commands of the daemon.
+
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"math/bits"
+	"os"
+	"os/signal"
+	"runtime"
+	"sync"
+	"syscall"
+	"text/tabwriter"
+	"time"
+
+	"github.com/openebs/smart/internal/utilities"
+	"github.com/openebs/smart/scsismart"
+)
+
+// fakeDevice is the device name the soak test uses with the fake backend
+const fakeDevice = "/dev/fake"
+
+// latencyBuckets is the number of power of two microsecond latency buckets, the last one
+// holding all the latencies over about 30s
+const latencyBuckets = 26
+
+// opStats are the statistics of an operation of the soak test
+type opStats struct {
+	count, errors uint64
+	total, max    time.Duration
+	buckets       [latencyBuckets]uint64
+}
+
+// record adds the outcome of an operation
+func (s *opStats) record(latency time.Duration, err error) {
+	s.count++
+	if err != nil {
+		s.errors++
+	}
+	s.total += latency
+	if latency > s.max {
+		s.max = latency
+	}
+
+	bucket := bits.Len64(uint64(latency / time.Microsecond))
+	if bucket >= latencyBuckets {
+		bucket = latencyBuckets - 1
+	}
+	s.buckets[bucket]++
+}
+
+// percentile returns the upper bound of the bucket holding the p-th percentile latency
+func (s *opStats) percentile(p float64) time.Duration {
+	target := uint64(float64(s.count) * p / 100)
+	var n uint64
+	for i, c := range s.buckets {
+		n += c
+		if n > target {
+			return time.Duration(uint64(1)<<uint(i)) * time.Microsecond
+		}
+	}
+	return s.max
+}
+
+// resources is a sample of the resources used by the process
+type resources struct {
+	fds        int
+	heap       uint64
+	goroutines int
+}
+
+// sampleResources returns the resources the process uses, fds is -1 if they cannot be counted
+func sampleResources() resources {
+	var mem runtime.MemStats
+	runtime.ReadMemStats(&mem)
+
+	r := resources{fds: -1, heap: mem.HeapAlloc, goroutines: runtime.NumGoroutine()}
+	if entries, err := os.ReadDir("/proc/self/fd"); err == nil {
+		r.fds = len(entries)
+	}
+	return r
+}
+
+// soakRun holds the statistics of a soak test shared by its workers
+type soakRun struct {
+	device   string
+	opts     scsismart.DetectOptions
+	interval time.Duration
+
+	mu    sync.Mutex
+	ops   map[string]*opStats
+	order []string
+	peak  resources
+}
+
+// timed runs an operation and records its latency and outcome
+func (r *soakRun) timed(name string, op func() error) error {
+	start := time.Now()
+	err := op()
+	latency := time.Since(start)
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	s, ok := r.ops[name]
+	if !ok {
+		s = &opStats{}
+		r.ops[name] = s
+		r.order = append(r.order, name)
+	}
+	s.record(latency, err)
+
+	return err
+}
+
+// iterate opens the device, identifies it, reads its SMART attributes and a log page as the
+// daemon does on each poll, and closes it
+func (r *soakRun) iterate() {
+	var d scsismart.Dev
+	err := r.timed("open", func() (err error) {
+		d, err = scsismart.Detect(r.device, r.opts)
+		return err
+	})
+	if err != nil {
+		return
+	}
+	defer d.Close()
+
+	r.timed("identify", func() error {
+		_, err := d.GetDiskInfo()
+		return err
+	})
+	if reader, ok := d.(scsismart.SMARTReader); ok {
+		r.timed("smart", func() error {
+			_, err := reader.GetSMARTAttributes()
+			return err
+		})
+	}
+	if reader, ok := d.(scsismart.LogReader); ok {
+		r.timed("log", func() error {
+			_, err := reader.ReadLogPage(scsismart.SupportedLogPagesPage, 0)
+			return err
+		})
+	}
+}
+
+// watch samples the resources of the process every second and keeps their peak
+func (r *soakRun) watch(ctx context.Context) {
+	ticker := time.NewTicker(time.Second)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		sample := sampleResources()
+		r.mu.Lock()
+		if sample.fds > r.peak.fds {
+			r.peak.fds = sample.fds
+		}
+		if sample.heap > r.peak.heap {
+			r.peak.heap = sample.heap
+		}
+		if sample.goroutines > r.peak.goroutines {
+			r.peak.goroutines = sample.goroutines
+		}
+		r.mu.Unlock()
+	}
+}
+
+// soak hammers a device, or the fake backend, with identification, SMART and log reads for a
+// duration and reports the errors, latencies and resource usage. It is a diagnostic command
+// for validating the library before deploying the daemon. The exit status is 1 if commands
+// failed or file descriptors leaked.
+func soak(args []string) int {
+	fs := flag.NewFlagSet("soak", flag.ExitOnError)
+	duration := fs.Duration("duration", time.Minute, "time to run the test for")
+	workers := fs.Int("workers", 1, "number of concurrent workers querying the device")
+	interval := fs.Duration("interval", 0, "pause of each worker between two iterations")
+	fake := fs.Bool("fake", false, "query an emulated SATA disk instead of a device")
+	fakeErrors := fs.Float64("fake-errors", 0, "probability of a command of the emulated disk failing")
+	fakeLatency := fs.Duration("fake-latency", 0, "time each command of the emulated disk takes")
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "usage: smart soak [flags] <device>\n       smart soak -fake [flags]")
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+
+	if *workers <= 0 || *duration <= 0 || (fs.NArg() != 1 && !*fake) || (fs.NArg() != 0 && *fake) {
+		fs.Usage()
+		return exitUsage
+	}
+
+	run := &soakRun{interval: *interval, ops: make(map[string]*opStats)}
+	if *fake {
+		executor := scsismart.NewFakeExecutor()
+		executor.ErrorRate = *fakeErrors
+		executor.Latency = *fakeLatency
+		run.device = fakeDevice
+		run.opts.Executor = executor
+	} else {
+		devPath, err := utilities.ResolveDevice(fs.Arg(0))
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			return exitError
+		}
+		run.device = devPath
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	ctx, cancel := context.WithTimeout(ctx, *duration)
+	defer cancel()
+
+	runtime.GC()
+	before := sampleResources()
+	run.peak = before
+	start := time.Now()
+
+	watchCtx, stopWatch := context.WithCancel(context.Background())
+	watchDone := make(chan struct{})
+	go func() {
+		defer close(watchDone)
+		run.watch(watchCtx)
+	}()
+
+	var wg sync.WaitGroup
+	for i := 0; i < *workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for ctx.Err() == nil {
+				run.iterate()
+				if run.interval > 0 {
+					select {
+					case <-ctx.Done():
+					case <-time.After(run.interval):
+					}
+				}
+			}
+		}()
+	}
+	wg.Wait()
+	// the peak is read below, once the watcher stopped updating it
+	stopWatch()
+	<-watchDone
+	elapsed := time.Since(start)
+
+	runtime.GC()
+	after := sampleResources()
+
+	fmt.Printf("%s: %d workers for %s\n\n", run.device, *workers, elapsed.Round(time.Millisecond))
+	tw := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "OPERATION\tCOUNT\tERRORS\tRATE\tAVG\tP99\tMAX")
+	var failed uint64
+	for _, name := range run.order {
+		s := run.ops[name]
+		failed += s.errors
+		fmt.Fprintf(tw, "%s\t%d\t%d\t%.1f/s\t%s\t<%s\t%s\n", name, s.count, s.errors,
+			float64(s.count)/elapsed.Seconds(), (s.total / time.Duration(s.count)).Round(time.Microsecond),
+			s.percentile(99), s.max.Round(time.Microsecond))
+	}
+	tw.Flush()
+
+	fmt.Printf("\nfile descriptors: %d before, %d peak, %d after\n", before.fds, run.peak.fds, after.fds)
+	fmt.Printf("heap: %d KiB before, %d KiB peak, %d KiB after\n", before.heap/1024, run.peak.heap/1024, after.heap/1024)
+	fmt.Printf("goroutines: %d before, %d peak, %d after\n", before.goroutines, run.peak.goroutines, after.goroutines)
+
+	status := 0
+	if failed > 0 {
+		fmt.Printf("\n%d operations failed\n", failed)
+		status = exitError
+	}
+	if after.fds > before.fds {
+		fmt.Printf("\n%d file descriptors leaked\n", after.fds-before.fds)
+		status = exitError
+	}
+
+	return status
+}
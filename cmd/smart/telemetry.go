@@ -0,0 +1,76 @@
+/*
+Copyright 2018 The OpenEBS Authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// The telemetry subcommand, saving the telemetry log of an NVMe device for its vendor.
+
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/openebs/smart/internal/utilities"
+	"github.com/openebs/smart/nvme"
+)
+
+// telemetry captures the host-initiated telemetry data of an NVMe device and writes it to a file
+func telemetry(args []string) int {
+	fs := flag.NewFlagSet("telemetry", flag.ExitOnError)
+	output := fs.String("o", "", "file to write the telemetry log to")
+	dataArea := fs.Int("data-area", 3, "last data area to read, 1 to 3, larger areas hold more details")
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "usage: smart telemetry -o file [-data-area 1|2|3] <device>")
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+
+	if fs.NArg() != 1 || *output == "" || *dataArea < 1 || *dataArea > 3 {
+		fs.Usage()
+		return exitUsage
+	}
+
+	devPath, err := utilities.ResolveDevice(fs.Arg(0))
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return exitError
+	}
+
+	d := nvme.NVMeDevice{Name: devPath}
+	if err := d.Open(); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return exitError
+	}
+	defer d.Close()
+
+	f, err := os.OpenFile(*output, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0600)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return exitError
+	}
+
+	header, err := d.TelemetryHostLog(f, *dataArea)
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "%s: %v\n", devPath, err)
+		return exitError
+	}
+
+	lastBlock, _ := header.LastBlock(*dataArea)
+	fmt.Printf("%s: wrote %d bytes of telemetry data (generation %d) to %s\n",
+		devPath, (int(lastBlock)+1)*512, header.HostGeneration, *output)
+
+	return 0
+}
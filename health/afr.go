@@ -0,0 +1,94 @@
+/*
+Copyright 2018 The OpenEBS Authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Estimation of the annualized failure rate of a drive.
+
+package health
+
+// FailureModel estimates the probability that a drive fails within a year
+type FailureModel interface {
+	AnnualizedFailureRate(in Input) float64 // between 0 and 1
+}
+
+// FailureModelFunc adapts a function to the FailureModel interface
+type FailureModelFunc func(in Input) float64
+
+// AnnualizedFailureRate calls f(in)
+func (f FailureModelFunc) AnnualizedFailureRate(in Input) float64 {
+	return f(in)
+}
+
+// BaseAFR is the annualized failure rate of a healthy drive assumed by the default model
+const BaseAFR = 0.015
+
+// DefaultFailureModel is a heuristic multiplying BaseAFR by factors for the warning signs found
+// most predictive in published field studies: reallocated, pending and uncorrectable sectors,
+// command timeouts, and the infant mortality and wear-out ages, plus the NVMe critical warnings.
+// It is not calibrated against a fleet and is meant to rank drives rather than predict exact rates.
+var DefaultFailureModel FailureModel = FailureModelFunc(defaultAFR)
+
+// defaultAFR implements DefaultFailureModel
+func defaultAFR(in Input) float64 {
+	afr := BaseAFR
+
+	raw := func(id uint8) uint64 {
+		a, _ := in.Attrs.Get(id)
+		return a.Raw
+	}
+
+	switch reallocated := raw(5); {
+	case reallocated > 100:
+		afr *= 6
+	case reallocated > 0:
+		afr *= 3
+	}
+	if raw(197) > 0 || raw(198) > 0 {
+		afr *= 5
+	}
+	if raw(187) > 0 {
+		afr *= 4
+	}
+	if raw(188) > 0 {
+		afr *= 2
+	}
+
+	if a, ok := in.Attrs.Get(powerOnHoursAttr); ok {
+		switch years := float64(a.Raw) / (365 * 24); {
+		case years < 0.25:
+			afr *= 1.5
+		case years > 5:
+			afr *= 2
+		}
+	}
+
+	if in.SelfTest != nil && in.SelfTest.Failed() {
+		afr *= 5
+	}
+
+	switch w := in.CriticalWarning; {
+	case w.ReliabilityDegraded() || w.MediaReadOnly():
+		afr *= 10
+	case w.SpareBelowThreshold():
+		afr *= 4
+	case w.VolatileBackupFailed() || w.PMRUnreliable():
+		afr *= 3
+	case w.TemperatureExceeded():
+		afr *= 1.5
+	}
+
+	if afr > 0.99 {
+		return 0.99
+	}
+
+	return afr
+}
@@ -0,0 +1,139 @@
+/*
+Copyright 2018 The OpenEBS Authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Composite health score of a drive.
+
+package health
+
+import (
+	"github.com/openebs/smart/atasmart"
+	"github.com/openebs/smart/nvme"
+	"github.com/openebs/smart/scsismart"
+)
+
+// Input is the data a health score is computed from
+type Input struct {
+	Attrs    atasmart.AttrCollection
+	Deltas   map[uint8]int64           // change of the counting attributes since the previous reading
+	SelfTest *scsismart.SelfTestStatus // last self-test, nil if unknown
+
+	// CriticalWarning holds the critical warnings of NVMe drives, 0 for the other drives
+	CriticalWarning nvme.CriticalWarning
+}
+
+// Penalty is the number of points an attribute costs per unit of its raw value, up to a maximum
+type Penalty struct {
+	PerUnit float64
+	Max     float64
+}
+
+// Weights tunes how much each factor lowers the health score
+type Weights struct {
+	Attrs          map[uint8]Penalty // penalties on the raw value of key attributes
+	Trend          map[uint8]Penalty // penalties on the recent change of counting attributes
+	FailingNow     float64           // per attribute at or below its threshold
+	FailedInPast   float64           // per attribute whose worst value reached its threshold
+	SelfTestFailed float64
+	PerYear        float64 // per year of power-on time
+	MaxAge         float64
+
+	// CriticalWarnings are the penalties of the NVMe critical warnings, per warning bit
+	CriticalWarnings map[nvme.CriticalWarning]float64
+}
+
+// DefaultWeights weigh pending and uncorrectable sectors the most, as they predict failures best,
+// and age the least
+var DefaultWeights = Weights{
+	Attrs: map[uint8]Penalty{
+		5:   {PerUnit: 0.5, Max: 25}, // reallocated sectors
+		187: {PerUnit: 2, Max: 20},   // reported uncorrectable errors
+		188: {PerUnit: 0.5, Max: 10}, // command timeouts
+		197: {PerUnit: 2, Max: 30},   // pending sectors
+		198: {PerUnit: 2, Max: 30},   // offline uncorrectable sectors
+		199: {PerUnit: 0.1, Max: 5},  // interface CRC errors
+	},
+	Trend: map[uint8]Penalty{
+		5:   {PerUnit: 2, Max: 20},
+		187: {PerUnit: 4, Max: 20},
+		197: {PerUnit: 4, Max: 20},
+		198: {PerUnit: 4, Max: 20},
+	},
+	FailingNow:     50,
+	FailedInPast:   10,
+	SelfTestFailed: 30,
+	PerYear:        2,
+	MaxAge:         10,
+	CriticalWarnings: map[nvme.CriticalWarning]float64{
+		nvme.CriticalWarningSpare:          30,
+		nvme.CriticalWarningTemperature:    10,
+		nvme.CriticalWarningReliability:    60,
+		nvme.CriticalWarningReadOnly:       100,
+		nvme.CriticalWarningVolatileBackup: 40,
+		nvme.CriticalWarningPMR:            30,
+	},
+}
+
+// powerOnHoursAttr is the SMART attribute counting power-on hours
+const powerOnHoursAttr = 9
+
+// Score returns the health score of a drive from 100, healthy, down to 0, about to fail
+func Score(in Input, w Weights) int {
+	var penalty float64
+
+	for _, a := range in.Attrs {
+		if p, ok := w.Attrs[a.ID]; ok {
+			penalty += p.apply(float64(a.Raw))
+		}
+		if a.FailingNow {
+			penalty += w.FailingNow
+		} else if a.FailedInPast {
+			penalty += w.FailedInPast
+		}
+	}
+
+	for id, delta := range in.Deltas {
+		if p, ok := w.Trend[id]; ok && delta > 0 {
+			penalty += p.apply(float64(delta))
+		}
+	}
+
+	if in.SelfTest != nil && in.SelfTest.Failed() {
+		penalty += w.SelfTestFailed
+	}
+
+	for bit, p := range w.CriticalWarnings {
+		if in.CriticalWarning&bit != 0 {
+			penalty += p
+		}
+	}
+
+	if a, ok := in.Attrs.Get(powerOnHoursAttr); ok {
+		penalty += Penalty{PerUnit: w.PerYear, Max: w.MaxAge}.apply(float64(a.Raw) / (365 * 24))
+	}
+
+	score := 100 - penalty
+	if score < 0 {
+		return 0
+	}
+
+	return int(score + 0.5)
+}
+
+// apply returns the penalty for a value
+func (p Penalty) apply(value float64) float64 {
+	if penalty := value * p.PerUnit; penalty < p.Max {
+		return penalty
+	}
+
+	return p.Max
+}
@@ -0,0 +1,180 @@
+/*
+Copyright 2018 The OpenEBS Authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// TLS, client certificate and token authentication of the helper on the network.
+
+package helper
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/subtle"
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net"
+	"strings"
+	"time"
+)
+
+// authTimeout bounds the time a client has to authenticate
+const authTimeout = 10 * time.Second
+
+// maxTokenLen is the longest token line the helper reads
+const maxTokenLen = 4096
+
+// Replies of the helper to the token of a client
+const (
+	authOK     = "OK\n"
+	authDenied = "DENIED\n"
+)
+
+// ServerTLSConfig returns the TLS configuration of a helper listening on the network. Clients
+// must present a certificate signed by the CAs of clientCAFile, unless it is empty.
+func ServerTLSConfig(certFile, keyFile, clientCAFile string) (*tls.Config, error) {
+	cert, err := tls.LoadX509KeyPair(certFile, keyFile)
+	if err != nil {
+		return nil, fmt.Errorf("load helper certificate: %v", err)
+	}
+	config := &tls.Config{Certificates: []tls.Certificate{cert}, MinVersion: tls.VersionTLS12}
+
+	if clientCAFile != "" {
+		pool, err := loadCertPool(clientCAFile)
+		if err != nil {
+			return nil, err
+		}
+		config.ClientCAs = pool
+		config.ClientAuth = tls.RequireAndVerifyClientCert
+	}
+
+	return config, nil
+}
+
+// ClientTLSConfig returns the TLS configuration of a client of a helper on the network,
+// verifying the helper with the CAs of caFile, or the system CAs if it is empty. The client
+// presents the certificate of certFile and keyFile if they are set.
+func ClientTLSConfig(caFile, certFile, keyFile string) (*tls.Config, error) {
+	config := &tls.Config{MinVersion: tls.VersionTLS12}
+
+	if caFile != "" {
+		pool, err := loadCertPool(caFile)
+		if err != nil {
+			return nil, err
+		}
+		config.RootCAs = pool
+	}
+	if certFile != "" || keyFile != "" {
+		cert, err := tls.LoadX509KeyPair(certFile, keyFile)
+		if err != nil {
+			return nil, fmt.Errorf("load client certificate: %v", err)
+		}
+		config.Certificates = []tls.Certificate{cert}
+	}
+
+	return config, nil
+}
+
+// loadCertPool reads the PEM certificates of a file
+func loadCertPool(file string) (*x509.CertPool, error) {
+	pem, err := ioutil.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM(pem) {
+		return nil, fmt.Errorf("%s holds no PEM certificate", file)
+	}
+
+	return pool, nil
+}
+
+// ListenTLS listens on a TCP address, e.g. :7001, with TLS. The helper is never served on the
+// network in clear text, as it exposes the devices of the host.
+func ListenTLS(addr string, config *tls.Config) (net.Listener, error) {
+	if config == nil || len(config.Certificates) == 0 {
+		return nil, fmt.Errorf("helper on %s needs a TLS certificate", addr)
+	}
+
+	return tls.Listen("tcp", addr, config)
+}
+
+// NewTLSListener serves the connections of a listener, e.g. a socket passed by systemd, with TLS
+func NewTLSListener(l net.Listener, config *tls.Config) (net.Listener, error) {
+	if config == nil || len(config.Certificates) == 0 {
+		return nil, fmt.Errorf("helper on %s needs a TLS certificate", l.Addr())
+	}
+
+	return tls.NewListener(l, config), nil
+}
+
+// ReadToken reads a token from a file, without the surrounding whitespace
+func ReadToken(file string) (string, error) {
+	data, err := ioutil.ReadFile(file)
+	if err != nil {
+		return "", err
+	}
+
+	token := strings.TrimSpace(string(data))
+	if token == "" {
+		return "", fmt.Errorf("%s holds no token", file)
+	}
+	return token, nil
+}
+
+// authenticate checks the token a client sends as its first line
+func authenticate(conn net.Conn, token string) error {
+	conn.SetDeadline(time.Now().Add(authTimeout))
+	defer conn.SetDeadline(time.Time{})
+
+	// ReadSlice fails with bufio.ErrBufferFull instead of growing past maxTokenLen
+	line, err := bufio.NewReaderSize(conn, maxTokenLen).ReadSlice('\n')
+	if err == bufio.ErrBufferFull {
+		conn.Write([]byte(authDenied))
+		return fmt.Errorf("token from %s longer than %d bytes", conn.RemoteAddr(), maxTokenLen)
+	}
+	if err != nil {
+		return fmt.Errorf("read token: %v", err)
+	}
+
+	if subtle.ConstantTimeCompare(bytes.TrimSuffix(line, []byte("\n")), []byte(token)) != 1 {
+		conn.Write([]byte(authDenied))
+		return fmt.Errorf("invalid token from %s", conn.RemoteAddr())
+	}
+
+	_, err = conn.Write([]byte(authOK))
+	return err
+}
+
+// sendToken authenticates a client to the helper with a token
+func sendToken(conn net.Conn, token string) error {
+	conn.SetDeadline(time.Now().Add(authTimeout))
+	defer conn.SetDeadline(time.Time{})
+
+	if _, err := conn.Write([]byte(token + "\n")); err != nil {
+		return fmt.Errorf("send token: %v", err)
+	}
+
+	// a TLS handshake refused by the helper only fails here, on the first read
+	reply := make([]byte, len(authOK))
+	if _, err := io.ReadFull(conn, reply); err != nil {
+		return fmt.Errorf("read token reply: %v", err)
+	}
+	if string(reply) != authOK {
+		return fmt.Errorf("helper refused the token")
+	}
+
+	return nil
+}
@@ -0,0 +1,200 @@
+/*
+Copyright 2018 The OpenEBS Authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Privileged helper executing SG_IO requests for unprivileged processes over a unix socket.
+
+package helper
+
+import (
+	"crypto/tls"
+	"fmt"
+	"net"
+	"net/rpc"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/openebs/smart/scsismart"
+)
+
+// DefaultSocket is the unix socket the helper listens on by default
+const DefaultSocket = "/run/smart/helper.sock"
+
+// serviceName is the name of the RPC service of the helper
+const serviceName = "SG"
+
+// Options controls what the helper accepts to do
+type Options struct {
+	// AllowDestructive allows the commands other than the read-only queries of the library,
+	// which may write to or erase the media
+	AllowDestructive bool
+
+	// Token is the secret clients must send before any request, none if empty
+	Token string
+}
+
+// Service is the RPC service of the helper
+type Service struct {
+	opts Options
+	exec scsismart.Executor
+}
+
+// checkDevice refuses requests for anything but the block devices and SCSI generic nodes of
+// /dev, so that the helper cannot be used to open arbitrary files. It returns the path of the
+// device with the symbolic links resolved, which is the path to open.
+func checkDevice(device string) (string, error) {
+	path, err := filepath.EvalSymlinks(device)
+	if err != nil {
+		return "", err
+	}
+
+	info, err := os.Stat(path)
+	if err != nil {
+		return "", err
+	}
+	mode := info.Mode()
+	if !strings.HasPrefix(path, "/dev/") || mode&os.ModeDevice == 0 ||
+		(mode&os.ModeCharDevice != 0 && !strings.HasPrefix(filepath.Base(path), "sg")) {
+		return "", fmt.Errorf("%s is not a disk", device)
+	}
+
+	return path, nil
+}
+
+// Execute runs an SG_IO request
+func (s *Service) Execute(req scsismart.SGRequest, resp *scsismart.SGResponse) error {
+	if len(req.CDB) == 0 {
+		return fmt.Errorf("empty CDB")
+	}
+	if !s.opts.AllowDestructive && !scsismart.ReadOnly(req) {
+		return fmt.Errorf("%s refused by the helper", scsismart.CDBName(req.CDB))
+	}
+	path, err := checkDevice(req.Device)
+	if err != nil {
+		return err
+	}
+	req.Device = path
+
+	r, err := s.exec.ExecuteSG(req)
+	if err != nil {
+		return err
+	}
+	*resp = r
+
+	return nil
+}
+
+// Serve runs the helper on a listener until it is closed. Access to the helper is controlled
+// by the permissions of its socket, or by TLS client certificates on the network, and by
+// opts.Token if set.
+func Serve(l net.Listener, opts Options) error {
+	server := rpc.NewServer()
+	if err := server.RegisterName(serviceName, &Service{opts: opts, exec: scsismart.LocalExecutor{}}); err != nil {
+		return err
+	}
+
+	for {
+		conn, err := l.Accept()
+		if err != nil {
+			return err
+		}
+		go func(conn net.Conn) {
+			if opts.Token != "" {
+				if err := authenticate(conn, opts.Token); err != nil {
+					conn.Close()
+					return
+				}
+			}
+			server.ServeConn(conn)
+		}(conn)
+	}
+}
+
+// Listen creates the unix socket of the helper, replacing a stale one, and restricts it to the
+// given file mode, e.g. 0660 to grant access to the members of the group of the socket
+func Listen(socket string, mode os.FileMode) (net.Listener, error) {
+	if err := os.MkdirAll(filepath.Dir(socket), 0755); err != nil {
+		return nil, err
+	}
+	os.Remove(socket)
+
+	l, err := net.Listen("unix", socket)
+	if err != nil {
+		return nil, err
+	}
+	if err := os.Chmod(socket, mode); err != nil {
+		l.Close()
+		return nil, err
+	}
+
+	return l, nil
+}
+
+// Client is an executor sending the SG_IO requests of a process to a helper
+type Client struct {
+	rpc *rpc.Client
+}
+
+// Dial connects to the helper listening on a unix socket
+func Dial(socket string) (*Client, error) {
+	return DialWithOptions(socket, DialOptions{})
+}
+
+// DialOptions controls how a client connects to the helper
+type DialOptions struct {
+	TLS   *tls.Config // TLS configuration of the helpers on the network, see ClientTLSConfig
+	Token string      // token the helper requires, if any
+}
+
+// DialWithOptions connects to the helper listening on a unix socket, or on the network with
+// TLS if address is a tcp://host:port URL
+func DialWithOptions(address string, opts DialOptions) (*Client, error) {
+	var (
+		conn net.Conn
+		err  error
+	)
+	if host := strings.TrimPrefix(address, "tcp://"); host != address {
+		config := opts.TLS
+		if config == nil {
+			config = &tls.Config{MinVersion: tls.VersionTLS12}
+		}
+		conn, err = tls.Dial("tcp", host, config)
+	} else {
+		conn, err = net.Dial("unix", address)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("connect to helper %s: %v", address, err)
+	}
+
+	if opts.Token != "" {
+		if err := sendToken(conn, opts.Token); err != nil {
+			conn.Close()
+			return nil, fmt.Errorf("connect to helper %s: %v", address, err)
+		}
+	}
+
+	return &Client{rpc: rpc.NewClient(conn)}, nil
+}
+
+// ExecuteSG sends an SG_IO request to the helper
+func (c *Client) ExecuteSG(req scsismart.SGRequest) (scsismart.SGResponse, error) {
+	var resp scsismart.SGResponse
+	err := c.rpc.Call(serviceName+".Execute", req, &resp)
+
+	return resp, err
+}
+
+// Close closes the connection to the helper
+func (c *Client) Close() error {
+	return c.rpc.Close()
+}
@@ -28,9 +28,10 @@ const (
 	capSysAdmin             = 1 << 21
 )
 
+// userCapHeader is struct __user_cap_header_struct, pid is a 32-bit int on every architecture
 type userCapHeader struct {
 	version uint32
-	pid     int
+	pid     int32
 }
 
 type userCapData struct {
@@ -44,19 +45,21 @@ type userCapsV3 struct {
 	data [2]userCapData
 }
 
-// CapabilitiesCheck invokes the CAPGET syscall which checks for necessary capabilities.
+// CapabilitiesCheck invokes the CAPGET syscall which checks for necessary capabilities, and
+// returns an error if device access will fail for lack of them.
 // Note : If the binary is executed as root, it automatically has all capabilities set.
-func CapabilitiesCheck() {
+func CapabilitiesCheck() error {
 	userCaps := new(userCapsV3)
 	userCaps.hdr.version = linuxCapabilityVersion3
 
 	_, _, err := unix.RawSyscall(unix.SYS_CAPGET, uintptr(unsafe.Pointer(&userCaps.hdr)), uintptr(unsafe.Pointer(&userCaps.data)), 0)
 	if err != 0 {
-		fmt.Println("SYS_CAPGET() has failed:", err.Error())
-		return
+		return fmt.Errorf("SYS_CAPGET() has failed: %v", err)
 	}
 
 	if (userCaps.data[0].effective&capSysRawIO == 0) && (userCaps.data[0].effective&capSysAdmin == 0) {
-		fmt.Println("capSysRawIO and capSysAdmin are not in effect, device access will fail. Atleast one of them should be in effect for accessing a device.")
+		return fmt.Errorf("capSysRawIO and capSysAdmin are not in effect, device access will fail. Atleast one of them should be in effect for accessing a device.")
 	}
+
+	return nil
 }
@@ -28,3 +28,13 @@ func Ioctl(fd, cmd, ptr uintptr) error {
 	}
 	return nil
 }
+
+// IoctlValue executes an ioctl command on the specified file descriptor and returns the
+// non-negative value returned by the ioctl, which some commands use to report a status
+func IoctlValue(fd, cmd, ptr uintptr) (uintptr, error) {
+	r1, _, errno := unix.Syscall(unix.SYS_IOCTL, fd, cmd, ptr)
+	if errno != 0 {
+		return 0, errno
+	}
+	return r1, nil
+}
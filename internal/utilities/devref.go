@@ -0,0 +1,107 @@
+/*
+Copyright 2018 The OpenEBS Authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Resolution of stable device references to kernel device nodes.
+
+package utilities
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// Directories of the udev symlinks to block devices
+const (
+	DiskByIDPath   = "/dev/disk/by-id"
+	DiskByPathPath = "/dev/disk/by-path"
+	DiskByUUIDPath = "/dev/disk/by-uuid"
+)
+
+// wwnRef matches a WWN given as hex digits, optionally prefixed by 0x, naa. or wwn-0x
+var wwnRef = regexp.MustCompile(`^(?i)(0x|naa\.|wwn-0x)?([0-9a-f]{16}|[0-9a-f]{32})$`)
+
+// ResolveDevice returns the kernel device node of a whole disk referenced by any of:
+//
+//	a device node or a symlink to one, e.g. /dev/sda or /dev/disk/by-path/pci-0000:00:1f.2-ata-1
+//	a kernel name, e.g. sda
+//	a WWN, e.g. 0x5000c500a1b2c3d4 or naa.5000c500a1b2c3d4
+//	a filesystem UUID as UUID=<uuid>
+//
+// References to a partition resolve to the disk holding it.
+func ResolveDevice(ref string) (string, error) {
+	path := ref
+	switch {
+	case strings.HasPrefix(ref, "/"):
+	case strings.HasPrefix(ref, "UUID="):
+		path = filepath.Join(DiskByUUIDPath, strings.TrimPrefix(ref, "UUID="))
+	case wwnRef.MatchString(ref):
+		wwn := strings.ToLower(wwnRef.FindStringSubmatch(ref)[2])
+		node, err := resolveWWN(wwn)
+		if err != nil {
+			return "", fmt.Errorf("%s: %v", ref, err)
+		}
+		path = node
+	default:
+		path = filepath.Join("/dev", ref)
+	}
+
+	node, err := filepath.EvalSymlinks(path)
+	if err != nil {
+		return "", fmt.Errorf("cannot resolve device %s: %v", ref, err)
+	}
+
+	return wholeDisk(node), nil
+}
+
+// resolveWWN returns the device node of the disk with a WWN, from its by-id symlink or else the
+// wwid sysfs attribute of the disks
+func resolveWWN(wwn string) (string, error) {
+	link := filepath.Join(DiskByIDPath, "wwn-0x"+wwn)
+	if _, err := os.Stat(link); err == nil {
+		return link, nil
+	}
+
+	wwids, _ := filepath.Glob(filepath.Join(SysBlockPath, "*", "device", "wwid"))
+	for _, path := range wwids {
+		wwid, err := ReadSysfs(path)
+		if err != nil {
+			continue
+		}
+		// e.g. naa.5000c500a1b2c3d4 for SCSI disks
+		if strings.TrimPrefix(strings.ToLower(wwid), "naa.") == wwn {
+			return filepath.Join("/dev", filepath.Base(filepath.Dir(filepath.Dir(path)))), nil
+		}
+	}
+
+	return "", fmt.Errorf("no disk with WWN %s", wwn)
+}
+
+// wholeDisk returns the device node of the disk holding a partition, or the node itself if it
+// is not a partition
+func wholeDisk(node string) string {
+	sysPath := filepath.Join("/sys/class/block", filepath.Base(node))
+	if _, err := os.Stat(filepath.Join(sysPath, "partition")); err != nil {
+		return node
+	}
+
+	// the sysfs directory of a partition is a subdirectory of the disk's
+	target, err := filepath.EvalSymlinks(sysPath)
+	if err != nil {
+		return node
+	}
+
+	return filepath.Join("/dev", filepath.Base(filepath.Dir(target)))
+}
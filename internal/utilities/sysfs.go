@@ -0,0 +1,61 @@
+/*
+Copyright 2018 The OpenEBS Authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Helper functions for reading block device attributes from sysfs.
+
+package utilities
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// SysBlockPath is the sysfs directory holding one entry per block device
+const SysBlockPath = "/sys/block"
+
+// SysfsBlockDir returns the sysfs directory of a block device given its /dev path, e.g. /dev/sda
+func SysfsBlockDir(devPath string) string {
+	return filepath.Join(SysBlockPath, filepath.Base(devPath))
+}
+
+// ReadSysfs returns the contents of a sysfs attribute file with surrounding whitespace trimmed
+func ReadSysfs(path string) (string, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return "", err
+	}
+
+	return strings.TrimSpace(string(data)), nil
+}
+
+// ReadSysfsUint returns the contents of a sysfs attribute file parsed as an unsigned integer
+func ReadSysfsUint(path string) (uint64, error) {
+	s, err := ReadSysfs(path)
+	if err != nil {
+		return 0, err
+	}
+
+	return strconv.ParseUint(s, 10, 64)
+}
+
+// SysfsDriver returns the name of the kernel driver bound to a block device, e.g. sd or virtio_blk
+func SysfsDriver(devPath string) string {
+	link, err := filepath.EvalSymlinks(filepath.Join(SysfsBlockDir(devPath), "device", "driver"))
+	if err != nil {
+		return ""
+	}
+
+	return filepath.Base(link)
+}
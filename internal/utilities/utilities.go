@@ -23,6 +23,8 @@ import (
 )
 
 var (
+	// NativeEndian is the byte order of the host. Data returned by devices must be decoded with
+	// the byte order of its protocol instead (little-endian for ATA/NVMe, big-endian for SCSI).
 	NativeEndian binary.ByteOrder
 )
 
@@ -68,3 +70,19 @@ func ConvertBytes(v uint64) string {
 		return fmt.Sprintf("%.3g %s", float64(v)/float64(d), suffixes[i])
 	}
 }
+
+// DecodeRotationRate interprets a nominal media rotation rate as reported by ATA IDENTIFY word 217
+// and the SCSI Block Device Characteristics VPD page. It returns the rate in RPM (0 if the device
+// does not rotate or does not report it) along with a human-readable form.
+func DecodeRotationRate(v uint16) (uint16, string) {
+	switch {
+	case v == 0x0000:
+		return 0, "Rate not reported"
+	case v == 0x0001:
+		return 0, "Solid State Device"
+	case v <= 0x0400 || v == 0xffff:
+		return 0, fmt.Sprintf("Reserved (%#04x)", v)
+	}
+
+	return v, fmt.Sprintf("%d rpm", v)
+}
@@ -0,0 +1,49 @@
+/*
+Copyright 2018 The OpenEBS Authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// MMC and SD command definitions.
+
+package mmc
+
+const (
+	// MMC and SD commands
+	CmdSendExtCSD = 8  // SEND_EXT_CSD, eMMC only
+	CmdGenCmd     = 56 // GEN_CMD, vendor specific data block
+
+	// Response and command type flags, see <linux/mmc/core.h>
+	rspPresent = 1 << 0
+	rspCRC     = 1 << 2
+	rspOpcode  = 1 << 4
+	cmdADTC    = 1 << 5 // addressed data transfer command
+	rspSPIS1   = 1 << 7
+
+	flagsR1ADTC = rspSPIS1 | rspPresent | rspCRC | rspOpcode | cmdADTC
+
+	// MMC_IOC_CMD, _IOWR(MMC_BLOCK_MAJOR, 0, struct mmc_ioc_cmd)
+	MMCIocCmd = 0xc048b300
+
+	// BlockSize is the size of the data blocks of the commands
+	BlockSize = 512
+
+	// GenCmdRead is the argument bit of GEN_CMD selecting a read of the data block
+	GenCmdRead = 0x00000001
+)
+
+// Offsets of the health fields of the eMMC EXT_CSD register
+const (
+	extCSDPreEOLInfo   = 267
+	extCSDLifeTimeEstA = 268
+	extCSDLifeTimeEstB = 269
+	extCSDLen          = 512
+	extCSDLifeExceeded = 0x0b // life time estimate once the device exceeded its estimated life
+)
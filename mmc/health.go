@@ -0,0 +1,168 @@
+/*
+Copyright 2018 The OpenEBS Authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Health reporting of eMMC devices and of the SD cards with a known vendor health block.
+
+package mmc
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// PreEOL is the pre end of life state of an eMMC device, from the consumption of its reserved
+// blocks
+type PreEOL uint8
+
+// Pre end of life states
+const (
+	PreEOLUndefined PreEOL = iota
+	PreEOLNormal
+	PreEOLWarning // 80% of the reserved blocks consumed
+	PreEOLUrgent  // 90% of the reserved blocks consumed
+)
+
+func (p PreEOL) String() string {
+	switch p {
+	case PreEOLNormal:
+		return "normal"
+	case PreEOLWarning:
+		return "warning"
+	case PreEOLUrgent:
+		return "urgent"
+	}
+
+	return "undefined"
+}
+
+// Health is the wear of an SD card or eMMC device
+type Health struct {
+	Source    string // where the health was read from, e.g. "EXT_CSD" or "SanDisk GEN_CMD"
+	LifeUsed  int    // percent of the estimated life used, -1 if not reported
+	LifeUsedB int    // percent used of the second memory type of eMMC devices, -1 if not reported
+	PreEOL    PreEOL
+}
+
+// lifeTimeEstimate converts an eMMC life time estimate, which is reported in steps of 10%, to
+// the upper bound of the percent of life used
+func lifeTimeEstimate(v uint8) int {
+	switch {
+	case v == 0 || v > extCSDLifeExceeded:
+		return -1
+	case v == extCSDLifeExceeded:
+		return 100
+	}
+
+	return int(v) * 10
+}
+
+// ParseExtCSDHealth decodes the device life time estimates and the pre end of life information
+// of the EXT_CSD register of an eMMC device
+func ParseExtCSDHealth(extCSD []byte) (Health, error) {
+	if len(extCSD) < extCSDLen {
+		return Health{}, fmt.Errorf("EXT_CSD is %d bytes, want %d", len(extCSD), extCSDLen)
+	}
+
+	return Health{
+		Source:    "EXT_CSD",
+		LifeUsed:  lifeTimeEstimate(extCSD[extCSDLifeTimeEstA]),
+		LifeUsedB: lifeTimeEstimate(extCSD[extCSDLifeTimeEstB]),
+		PreEOL:    PreEOL(extCSD[extCSDPreEOLInfo]),
+	}, nil
+}
+
+// genCmdHealth is the decoder of the vendor health block of a family of SD cards
+type genCmdHealth struct {
+	vendor    string
+	arg       uint32
+	signature string // signature at the start of the block
+	lifeUsed  int    // offset of the percent of life used
+}
+
+// genCmdHealths lists the known vendor health blocks. SanDisk industrial cards return their
+// health status, starting with "DS", for a read GEN_CMD with no other argument bit set.
+var genCmdHealths = []genCmdHealth{
+	{vendor: "SanDisk", arg: 0, signature: "DS", lifeUsed: 8},
+}
+
+// ParseGenCmdHealth decodes a vendor health block read with GEN_CMD, returning false if the
+// block is not one of a known vendor
+func ParseGenCmdHealth(block []byte) (Health, bool) {
+	for _, v := range genCmdHealths {
+		if len(block) > v.lifeUsed && strings.HasPrefix(string(block), v.signature) {
+			return Health{Source: v.vendor + " GEN_CMD", LifeUsed: int(block[v.lifeUsed]), LifeUsedB: -1}, true
+		}
+	}
+
+	return Health{}, false
+}
+
+// extCSDHealthSysfs returns the health of an eMMC device from the EXT_CSD fields exposed in
+// sysfs, which do not require raw access to the device
+func (d *Device) extCSDHealthSysfs() (Health, error) {
+	lifeTime, err := d.sysfsAttr("life_time")
+	if err != nil {
+		return Health{}, err
+	}
+	preEOL, err := d.sysfsAttr("pre_eol_info")
+	if err != nil {
+		return Health{}, err
+	}
+
+	// life_time holds the estimates of both memory types, e.g. "0x01 0x02"
+	var est [2]uint64
+	for i, field := range strings.Fields(lifeTime) {
+		if i < len(est) {
+			est[i], _ = strconv.ParseUint(field, 0, 8)
+		}
+	}
+	eol, _ := strconv.ParseUint(preEOL, 0, 8)
+
+	return Health{
+		Source:    "EXT_CSD",
+		LifeUsed:  lifeTimeEstimate(uint8(est[0])),
+		LifeUsedB: lifeTimeEstimate(uint8(est[1])),
+		PreEOL:    PreEOL(eol),
+	}, nil
+}
+
+// Health returns the wear of the device. eMMC devices report it in their EXT_CSD register,
+// which is read from sysfs if the device cannot be sent commands. SD cards only report it in
+// vendor health blocks, and only the cards of the vendors in genCmdHealths are supported.
+func (d *Device) Health() (Health, error) {
+	switch d.Type() {
+	case TypeMMC:
+		extCSD, err := d.ExtCSD()
+		if err != nil {
+			if h, sysErr := d.extCSDHealthSysfs(); sysErr == nil {
+				return h, nil
+			}
+			return Health{}, err
+		}
+		return ParseExtCSDHealth(extCSD)
+	case TypeSD:
+		for _, v := range genCmdHealths {
+			block, err := d.GenCmd(v.arg)
+			if err != nil {
+				return Health{}, err
+			}
+			if h, ok := ParseGenCmdHealth(block); ok {
+				return h, nil
+			}
+		}
+		return Health{}, fmt.Errorf("%s does not report a known vendor health block", d.Name)
+	}
+
+	return Health{}, fmt.Errorf("%s is not an SD card or eMMC device", d.Name)
+}
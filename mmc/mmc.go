@@ -0,0 +1,129 @@
+/*
+Copyright 2018 The OpenEBS Authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// MMC ioctl passthrough for SD cards and eMMC devices.
+
+package mmc
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+	"unsafe"
+
+	"golang.org/x/sys/unix"
+
+	"github.com/openebs/smart/internal/ioctl"
+	"github.com/openebs/smart/internal/utilities"
+)
+
+// mmc_ioc_cmd structure, see <uapi/linux/mmc/ioctl.h>
+type mmcIocCmd struct {
+	writeFlag      int32
+	isACmd         int32
+	opcode         uint32
+	arg            uint32
+	response       [4]uint32
+	flags          uint32
+	blksz          uint32
+	blocks         uint32
+	postsleepMinUs uint32
+	postsleepMaxUs uint32
+	dataTimeoutNs  uint32
+	cmdTimeoutMs   uint32
+	_              uint32
+	dataPtr        uint64
+} // 72 bytes
+
+// Compile time check that mmcIocCmd has the same 72-byte layout on every architecture
+var _ [72 - unsafe.Sizeof(mmcIocCmd{})]byte
+var _ [unsafe.Sizeof(mmcIocCmd{}) - 72]byte
+
+// Card types reported by the type attribute of the card in sysfs
+const (
+	TypeSD   = "SD"
+	TypeMMC  = "MMC" // eMMC
+	TypeSDIO = "SDIO"
+)
+
+// Device is an SD card or eMMC device (/dev/mmcblk*)
+type Device struct {
+	Name string
+	fd   int
+}
+
+// IsMMC checks whether a device path refers to a MMC block device
+func IsMMC(name string) bool {
+	if driver := utilities.SysfsDriver(name); driver != "" {
+		return driver == "mmcblk"
+	}
+
+	return strings.HasPrefix(filepath.Base(name), "mmcblk")
+}
+
+// Open returns error if a MMC device returns error when opened
+func (d *Device) Open() (err error) {
+	d.fd, err = unix.Open(d.Name, unix.O_RDWR, 0600)
+	return err
+}
+
+// Close returns error if a MMC device is not closed
+func (d *Device) Close() error {
+	return unix.Close(d.fd)
+}
+
+// sysfsAttr returns an attribute of the card of the device in sysfs
+func (d *Device) sysfsAttr(attr string) (string, error) {
+	return utilities.ReadSysfs(filepath.Join(utilities.SysfsBlockDir(d.Name), "device", attr))
+}
+
+// Type returns the type of the card, e.g. TypeSD or TypeMMC
+func (d *Device) Type() string {
+	t, _ := d.sysfsAttr("type")
+	return t
+}
+
+// readBlock sends a command reading a single data block
+func (d *Device) readBlock(opcode, arg uint32) ([]byte, error) {
+	buf := make([]byte, BlockSize)
+
+	cmd := mmcIocCmd{
+		opcode:  opcode,
+		arg:     arg,
+		flags:   flagsR1ADTC,
+		blksz:   BlockSize,
+		blocks:  1,
+		dataPtr: uint64(uintptr(unsafe.Pointer(&buf[0]))),
+	}
+
+	if err := ioctl.Ioctl(uintptr(d.fd), MMCIocCmd, uintptr(unsafe.Pointer(&cmd))); err != nil {
+		return nil, fmt.Errorf("MMC CMD%d: %w", opcode, err)
+	}
+
+	return buf, nil
+}
+
+// GenCmd sends GEN_CMD (CMD56) to read the vendor specific data block selected by arg, with
+// GenCmdRead set. The contents of the block are only defined by the vendor of the card.
+func (d *Device) GenCmd(arg uint32) ([]byte, error) {
+	return d.readBlock(CmdGenCmd, arg|GenCmdRead)
+}
+
+// ExtCSD returns the 512-byte EXT_CSD register of an eMMC device
+func (d *Device) ExtCSD() ([]byte, error) {
+	if t := d.Type(); t != TypeMMC {
+		return nil, fmt.Errorf("%s is not an eMMC device", d.Name)
+	}
+
+	return d.readBlock(CmdSendExtCSD, 0)
+}
@@ -0,0 +1,108 @@
+/*
+Copyright 2018 The OpenEBS Authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Deltas of the counting attributes of the monitored devices.
+
+package monitor
+
+import "github.com/openebs/smart/atasmart"
+
+// DefaultCountingAttrs are the attributes whose raw value counts errors or remapped sectors:
+// reallocated sectors, end-to-end errors, reported uncorrectable errors, command timeouts,
+// reallocation events, pending and offline uncorrectable sectors and interface CRC errors
+var DefaultCountingAttrs = []uint8{5, 184, 187, 188, 196, 197, 198, 199}
+
+// AttrDelta is the change of the raw value of a counting attribute
+type AttrDelta struct {
+	ID            uint8
+	Name          string
+	Raw           uint64 // raw value at the last poll
+	SincePoll     int64  // change since the previous poll
+	SinceBaseline int64  // change since the baseline of the drive
+}
+
+// computeDeltas returns the deltas of the counting attributes of a device. The values of the
+// first poll of a drive become its baseline unless one was set with SetBaseline.
+func (m *Monitor) computeDeltas(state *deviceState, attrs atasmart.AttrCollection) []AttrDelta {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	baseline, ok := m.baselines[state.fingerprint]
+	if !ok {
+		baseline = make(map[uint8]uint64)
+		m.baselines[state.fingerprint] = baseline
+	}
+
+	var deltas []AttrDelta
+	for _, id := range m.opts.CountingAttrs {
+		a, ok := attrs.Get(id)
+		if !ok {
+			continue
+		}
+
+		if _, ok := baseline[id]; !ok {
+			baseline[id] = a.Raw
+		}
+
+		delta := AttrDelta{ID: id, Name: a.Name, Raw: a.Raw, SinceBaseline: int64(a.Raw - baseline[id])}
+		if prev, ok := state.attrs[id]; ok {
+			delta.SincePoll = int64(a.Raw - prev.Raw)
+		}
+		deltas = append(deltas, delta)
+	}
+
+	return deltas
+}
+
+// setDeltas records the deltas computed at the last poll of a device
+func (m *Monitor) setDeltas(device string, deltas []AttrDelta) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if deltas == nil {
+		delete(m.deltas, device)
+		return
+	}
+	m.deltas[device] = deltas
+}
+
+// Deltas returns the deltas of the counting attributes of a device computed at the last poll.
+// It may be called while the monitor runs.
+func (m *Monitor) Deltas(device string) []AttrDelta {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	return append([]AttrDelta(nil), m.deltas[device]...)
+}
+
+// SetBaseline sets the raw values deltas are computed against for a drive, identified by its
+// fingerprint (see smartinfo.Fingerprint). Attributes missing from raw take the value of the
+// next poll.
+func (m *Monitor) SetBaseline(fingerprint string, raw map[uint8]uint64) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	baseline := make(map[uint8]uint64, len(raw))
+	for id, value := range raw {
+		baseline[id] = value
+	}
+	m.baselines[fingerprint] = baseline
+}
+
+// ResetBaseline makes the values of the next poll of a drive its new baseline
+func (m *Monitor) ResetBaseline(fingerprint string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	delete(m.baselines, fingerprint)
+}
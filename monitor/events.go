@@ -0,0 +1,84 @@
+/*
+Copyright 2018 The OpenEBS Authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Health events reported by the monitor.
+
+package monitor
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/openebs/smart/atasmart"
+	"github.com/openebs/smart/nvme"
+)
+
+// EventType is the kind of change a health event reports
+type EventType int
+
+// Event types
+const (
+	DeviceAdded EventType = iota
+	DeviceRemoved
+	AttributeChanged
+	HealthDegraded
+	SelfTestCompleted
+	TemperatureAlert
+	CriticalWarningChanged
+)
+
+var eventTypeNames = map[EventType]string{
+	DeviceAdded:            "DeviceAdded",
+	DeviceRemoved:          "DeviceRemoved",
+	AttributeChanged:       "AttributeChanged",
+	HealthDegraded:         "HealthDegraded",
+	SelfTestCompleted:      "SelfTestCompleted",
+	TemperatureAlert:       "TemperatureAlert",
+	CriticalWarningChanged: "CriticalWarningChanged",
+}
+
+// String returns the name of the event type
+func (t EventType) String() string {
+	if name, ok := eventTypeNames[t]; ok {
+		return name
+	}
+
+	return fmt.Sprintf("EventType(%d)", int(t))
+}
+
+// Event is a change in the set or health of the monitored devices
+type Event struct {
+	Type        EventType
+	Time        time.Time
+	Device      string // device path, e.g. /dev/sda
+	Fingerprint string // stable identifier of the drive, see smartinfo.Fingerprint
+
+	// Attr is the attribute which changed or failed for AttributeChanged and HealthDegraded
+	// events, and Previous its value at the previous poll.
+	Attr     atasmart.Attr
+	Previous atasmart.Attr
+	Delta    int64 // change of the raw value since the previous poll
+
+	// SelfTestStatus is the result of the self-test for SelfTestCompleted events, 0 if it passed
+	SelfTestStatus uint8
+
+	// Temperature is the temperature in degrees Celsius for TemperatureAlert events, and
+	// TemperatureLevel its new alert level, TemperatureNormal when the alert clears.
+	Temperature      int
+	TemperatureLevel TemperatureLevel
+
+	// CriticalWarning holds the critical warnings of an NVMe device for CriticalWarningChanged
+	// events, 0 when they all cleared, and PreviousCriticalWarning the ones of the previous poll
+	CriticalWarning         nvme.CriticalWarning
+	PreviousCriticalWarning nvme.CriticalWarning
+}
@@ -0,0 +1,113 @@
+/*
+Copyright 2018 The OpenEBS Authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Handling of the devices which disappear while they are polled, e.g. pulled drives.
+
+package monitor
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"golang.org/x/sys/unix"
+)
+
+// goneTTL is how long the state of a drive which disappeared is kept for it to reappear
+const goneTTL = 24 * time.Hour
+
+// goneDrive is the state of a drive which disappeared, kept until it reappears or expires
+type goneDrive struct {
+	state *deviceState
+	since time.Time
+}
+
+// queryFailed counts a failed query of a device and, if the device is gone, stops polling it
+// and emits a DeviceRemoved event. The path is only polled again once its device node is back.
+func (m *Monitor) queryFailed(ctx context.Context, name string, err error) error {
+	m.countError(name)
+	if !deviceGone(name, err) {
+		return nil
+	}
+
+	m.gonePaths[name] = true
+	state := m.devices[name]
+	if state == nil {
+		return nil
+	}
+
+	return m.removeDevice(ctx, name, state)
+}
+
+// removeDevice forgets a device which was removed and emits a DeviceRemoved event. The state of
+// the drive is kept so that polling resumes where it stopped if the drive reappears, at the
+// same path or another one.
+func (m *Monitor) removeDevice(ctx context.Context, name string, state *deviceState) error {
+	m.keepGone(state)
+	m.identity.Invalidate(name)
+	m.setDeltas(name, nil)
+	m.setHealth(name, nil)
+	m.forgetTemperatures(name)
+	m.forgetErrors(name)
+	delete(m.devices, name)
+
+	return m.emit(ctx, Event{Type: DeviceRemoved, Device: name, Fingerprint: state.fingerprint})
+}
+
+// keepGone keeps the state of a drive which disappeared, keyed on its fingerprint
+func (m *Monitor) keepGone(state *deviceState) {
+	m.goneDrives[state.fingerprint] = goneDrive{state: state, since: time.Now()}
+}
+
+// resume returns the state of a gone drive reappearing with the given fingerprint, under any
+// name, nil if the drive was not gone
+func (m *Monitor) resume(fingerprint string) *deviceState {
+	gone, ok := m.goneDrives[fingerprint]
+	if !ok {
+		return nil
+	}
+	delete(m.goneDrives, fingerprint)
+
+	return gone.state
+}
+
+// expireGone forgets the gone drives which did not reappear within goneTTL
+func (m *Monitor) expireGone(now time.Time) {
+	for fingerprint, gone := range m.goneDrives {
+		if now.Sub(gone.since) > goneTTL {
+			delete(m.goneDrives, fingerprint)
+		}
+	}
+}
+
+// deviceGone reports whether a query of a device failed because the device is gone. The errno
+// is usually lost in the errors of the queries, so the device is opened again to find out.
+func deviceGone(name string, err error) bool {
+	if errors.Is(err, unix.ENODEV) || errors.Is(err, unix.ENXIO) {
+		return true
+	}
+
+	return !devicePresent(name)
+}
+
+// devicePresent reports whether a device node exists and is backed by a device. Errors other
+// than those of a missing device, e.g. EACCES without a privileged helper, count as present.
+func devicePresent(name string) bool {
+	fd, err := unix.Open(name, unix.O_RDONLY|unix.O_NONBLOCK|unix.O_CLOEXEC, 0)
+	if err != nil {
+		return err != unix.ENODEV && err != unix.ENXIO && err != unix.ENOENT
+	}
+	unix.Close(fd)
+
+	return true
+}
@@ -0,0 +1,176 @@
+/*
+Copyright 2018 The OpenEBS Authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Maintenance windows and low I/O detection gating the heavy operations on devices.
+
+package monitor
+
+import (
+	"bufio"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// procDiskstats is the kernel I/O statistics file of the block devices
+const procDiskstats = "/proc/diskstats"
+
+// Window is a recurring time window, in local time
+type Window struct {
+	Weekdays []time.Weekday // days the window starts on, every day if empty
+	Start    time.Duration  // offset of the start of the window from midnight
+	End      time.Duration  // offset of the end from midnight, windows with End < Start span midnight
+}
+
+// Contains reports whether a time falls in the window
+func (w Window) Contains(t time.Time) bool {
+	t = t.Local()
+	midnight := time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, t.Location())
+	offset := t.Sub(midnight)
+	day := t.Weekday()
+
+	if w.End < w.Start && offset < w.End {
+		// in the part of a window spanning midnight which started the day before
+		day = (day + 6) % 7
+		offset += 24 * time.Hour
+	}
+	if offset < w.Start || offset >= w.Start+w.span() {
+		return false
+	}
+	if len(w.Weekdays) == 0 {
+		return true
+	}
+	for _, d := range w.Weekdays {
+		if d == day {
+			return true
+		}
+	}
+
+	return false
+}
+
+// span returns the duration of the window
+func (w Window) span() time.Duration {
+	if w.End < w.Start {
+		return w.End + 24*time.Hour - w.Start
+	}
+	return w.End - w.Start
+}
+
+// MaintenancePolicy restricts heavy operations, such as extended self-tests, surface scans and
+// full log dumps, to maintenance windows or to the periods a device is nearly idle. The zero
+// value allows them at any time.
+type MaintenancePolicy struct {
+	Windows []Window
+
+	// MaxIOPS is the I/O rate below which a device is considered idle enough for heavy
+	// operations outside of the windows, 0 to only allow them in the windows
+	MaxIOPS float64
+}
+
+// ioSample is a reading of the completed I/Os of a device
+type ioSample struct {
+	ios  uint64
+	time time.Time
+}
+
+// ioRates computes the I/O rates of devices from successive readings of /proc/diskstats
+type ioRates struct {
+	mu      sync.Mutex
+	samples map[string]ioSample // keyed on kernel name
+	rates   map[string]float64
+}
+
+// readDiskstats returns the number of reads and writes completed by each block device
+func readDiskstats() (map[string]uint64, error) {
+	f, err := os.Open(procDiskstats)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	ios := make(map[string]uint64)
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		// major minor name reads merged sectors ms writes ...
+		fields := strings.Fields(scanner.Text())
+		if len(fields) < 8 {
+			continue
+		}
+		reads, _ := strconv.ParseUint(fields[3], 10, 64)
+		writes, _ := strconv.ParseUint(fields[7], 10, 64)
+		ios[fields[2]] = reads + writes
+	}
+
+	return ios, scanner.Err()
+}
+
+// sample reads /proc/diskstats and updates the I/O rate of a device since its previous sample
+func (r *ioRates) sample(device string) {
+	stats, err := readDiskstats()
+	if err != nil {
+		return
+	}
+	name := filepath.Base(device)
+	ios, ok := stats[name]
+	if !ok {
+		return
+	}
+	now := time.Now()
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if prev, ok := r.samples[name]; ok && now.After(prev.time) && ios >= prev.ios {
+		r.rates[name] = float64(ios-prev.ios) / now.Sub(prev.time).Seconds()
+	}
+	r.samples[name] = ioSample{ios: ios, time: now}
+}
+
+// rate returns the I/O rate of a device between its last two samples
+func (r *ioRates) rate(device string) (float64, bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	rate, ok := r.rates[filepath.Base(device)]
+	return rate, ok
+}
+
+// HeavyAllowed reports whether heavy operations may run on a device now according to the
+// maintenance policy: in a maintenance window, or while the I/O rate of the device measured
+// over the last poll is below MaxIOPS. Callers running surface scans or log dumps should check
+// it first. It may be called while the monitor runs.
+func (m *Monitor) HeavyAllowed(device string) bool {
+	policy := m.opts.Maintenance
+	if len(policy.Windows) == 0 && policy.MaxIOPS <= 0 {
+		return true
+	}
+
+	now := time.Now()
+	for _, w := range policy.Windows {
+		if w.Contains(now) {
+			return true
+		}
+	}
+
+	if policy.MaxIOPS > 0 {
+		if rate, ok := m.io.rate(device); ok && rate < policy.MaxIOPS {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,497 @@
+/*
+Copyright 2018 The OpenEBS Authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Periodic health monitoring of the disks found during a scan.
+
+package monitor
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/openebs/smart/atasmart"
+	"github.com/openebs/smart/health"
+	"github.com/openebs/smart/nvme"
+	"github.com/openebs/smart/scsismart"
+	"github.com/openebs/smart/smartinfo"
+	"github.com/openebs/smart/thermal"
+)
+
+// DefaultInterval is the time between two polls of the devices by default
+const DefaultInterval = 30 * time.Minute
+
+// Options controls what a Monitor watches and how often
+type Options struct {
+	Interval    time.Duration         // time between two polls, DefaultInterval if zero
+	Scan        smartinfo.ScanOptions // devices to watch
+	Devices     []string              // devices to watch instead of scanning for them, if not empty
+	IdentityTTL time.Duration         // time identification data is cached for, see smartinfo.NewIdentityCache
+	EventBuffer int                   // number of events buffered in the event channel
+
+	// CountingAttrs are the IDs of the attributes whose raw value counts events, for which
+	// deltas are computed. DefaultCountingAttrs is used if nil.
+	CountingAttrs []uint8
+
+	// Weights tunes the health scores of the devices, health.DefaultWeights is used if nil
+	Weights *health.Weights
+
+	// FailureModel estimates the failure rates of the devices, health.DefaultFailureModel is
+	// used if nil
+	FailureModel health.FailureModel
+
+	// Temperature holds the temperature alert thresholds of each device class, the
+	// DefaultTemperatureThresholds are used for the classes missing from it
+	Temperature map[DeviceClass]TemperatureThresholds
+
+	// TemperatureSamples is the number of temperature samples kept for each device,
+	// DefaultTemperatureSamples if zero
+	TemperatureSamples int
+
+	// Maintenance restricts when heavy operations may run, see HeavyAllowed
+	Maintenance MaintenancePolicy
+
+	// LongSelfTestInterval is the time between two extended self-tests started by the monitor,
+	// which waits for the maintenance policy to allow them. 0 disables them.
+	LongSelfTestInterval time.Duration
+
+	// Overrides tune the timeouts, pass-through type, probes and attributes of some devices
+	Overrides smartinfo.Overrides
+
+	// PollDone, if set, is called after each completed poll, e.g. to ping a watchdog so that a
+	// monitor stuck in a poll is restarted
+	PollDone func()
+}
+
+// deviceState is what a Monitor remembers about a device between polls
+type deviceState struct {
+	fingerprint     string
+	class           DeviceClass
+	attrs           map[uint8]atasmart.Attr
+	selfTestRunning bool
+	lastLongTest    time.Time // start of the last extended self-test started by the monitor
+	temp            temperatureState
+	criticalWarning nvme.CriticalWarning // NVMe critical warnings at the last poll
+}
+
+// Monitor polls the disks found during a scan and reports the changes in their set and health
+// as events, so that programs can react to them in-process instead of polling.
+type Monitor struct {
+	opts       Options
+	identity   *smartinfo.IdentityCache
+	events     chan Event
+	devices    map[string]*deviceState
+	gonePaths  map[string]bool      // paths whose device disappeared while polled, see queryFailed
+	goneDrives map[string]goneDrive // keyed on fingerprint, see removeDevice
+
+	mu        sync.Mutex
+	deltas    map[string][]AttrDelta      // keyed on device path
+	health    map[string]Health           // keyed on device path
+	baselines map[string]map[uint8]uint64 // keyed on fingerprint
+	temps     map[string]thermal.Series   // keyed on device path
+	stats     Stats
+
+	io ioRates
+}
+
+// New returns a Monitor watching the devices selected by opts
+func New(opts Options) *Monitor {
+	if opts.Interval <= 0 {
+		opts.Interval = DefaultInterval
+	}
+	if opts.CountingAttrs == nil {
+		opts.CountingAttrs = DefaultCountingAttrs
+	}
+	if opts.Weights == nil {
+		opts.Weights = &health.DefaultWeights
+	}
+	if opts.FailureModel == nil {
+		opts.FailureModel = health.DefaultFailureModel
+	}
+	if opts.TemperatureSamples <= 0 {
+		opts.TemperatureSamples = DefaultTemperatureSamples
+	}
+
+	return &Monitor{
+		opts:       opts,
+		identity:   smartinfo.NewIdentityCache(opts.IdentityTTL),
+		events:     make(chan Event, opts.EventBuffer),
+		devices:    make(map[string]*deviceState),
+		gonePaths:  make(map[string]bool),
+		goneDrives: make(map[string]goneDrive),
+		deltas:     make(map[string][]AttrDelta),
+		health:     make(map[string]Health),
+		baselines:  make(map[string]map[uint8]uint64),
+		temps:      make(map[string]thermal.Series),
+		stats:      Stats{Errors: make(map[string]uint64)},
+		io: ioRates{
+			samples: make(map[string]ioSample),
+			rates:   make(map[string]float64),
+		},
+	}
+}
+
+// Events returns the channel events are delivered on. It is closed when Run returns. Polling
+// blocks until the events of the previous poll are received, so the channel must be drained.
+func (m *Monitor) Events() <-chan Event {
+	return m.events
+}
+
+// Run polls the devices every interval until ctx is done
+func (m *Monitor) Run(ctx context.Context) error {
+	defer close(m.events)
+
+	ticker := time.NewTicker(m.opts.Interval)
+	defer ticker.Stop()
+
+	for {
+		if err := m.Poll(ctx); err != nil {
+			return err
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
+
+// Poll scans and queries the devices once, emitting the events for the changes since the
+// previous poll. It must not be called concurrently with Run.
+func (m *Monitor) Poll(ctx context.Context) error {
+	start := time.Now()
+	names, err := m.scan()
+	if err != nil {
+		return err
+	}
+
+	seen := make(map[string]bool)
+	for i, name := range names {
+		seen[name] = true
+
+		m.setPending(len(names) - i)
+		if err := m.pollDevice(ctx, name); err != nil {
+			return err
+		}
+	}
+	m.setPending(0)
+
+	for name, state := range m.devices {
+		if !seen[name] {
+			if err := m.removeDevice(ctx, name, state); err != nil {
+				return err
+			}
+		}
+	}
+	for name := range m.gonePaths {
+		if !seen[name] {
+			delete(m.gonePaths, name)
+		}
+	}
+	m.expireGone(start)
+
+	m.pollDone(start)
+	if m.opts.PollDone != nil {
+		m.opts.PollDone()
+	}
+
+	return nil
+}
+
+// scan returns the devices to poll, the ones given in the options or the usable disks and the
+// NVMe namespaces found during a scan
+func (m *Monitor) scan() ([]string, error) {
+	if len(m.opts.Devices) > 0 {
+		var names []string
+		for _, name := range m.opts.Devices {
+			if !m.opts.Scan.Ignored(name) {
+				names = append(names, name)
+			}
+		}
+		return names, nil
+	}
+
+	devices, err := smartinfo.ScanDevicesE(m.opts.Scan)
+	if err != nil {
+		return nil, err
+	}
+
+	var names []string
+	for _, device := range devices {
+		if device.Status == smartinfo.DeviceStatusOK {
+			names = append(names, device.Name)
+		}
+	}
+
+	namespaces, err := smartinfo.ScanNVMeNamespaces(m.opts.Scan)
+	if err != nil {
+		return nil, err
+	}
+
+	return append(names, namespaces...), nil
+}
+
+// pollDevice queries a device and emits the events for the changes since the previous poll.
+// Errors querying the device are not reported, the device is polled again next time unless it
+// is gone, in which case it is only polled again once its device node is back.
+func (m *Monitor) pollDevice(ctx context.Context, name string) error {
+	if m.gonePaths[name] && !devicePresent(name) {
+		return nil
+	}
+
+	override := m.opts.Overrides.For(name)
+	attr, err := m.identity.DiskDetailWithOptions(name, override.DetectOptions(scsismart.DetectOptions{}))
+	if err != nil && !scsismart.IsPartial(err) {
+		return m.queryFailed(ctx, name, err)
+	}
+	if err != nil {
+		// the details which could be queried identify the drive, the rest is polled again
+		m.countError(name)
+	}
+	fingerprint := smartinfo.Fingerprint(attr)
+	m.io.sample(name)
+	delete(m.gonePaths, name)
+
+	state, ok := m.devices[name]
+	if ok && state.fingerprint != fingerprint {
+		// Another drive was plugged in at the same path
+		m.keepGone(state)
+		m.forgetTemperatures(name)
+		if err := m.emit(ctx, Event{Type: DeviceRemoved, Device: name, Fingerprint: state.fingerprint}); err != nil {
+			return err
+		}
+		ok = false
+	}
+	if !ok {
+		// A drive which reappears resumes where it stopped, the first extended self-test of
+		// a new one is due one interval after it is first seen
+		state = m.resume(fingerprint)
+		if state == nil {
+			state = &deviceState{
+				fingerprint:  fingerprint,
+				class:        deviceClass(attr),
+				attrs:        make(map[uint8]atasmart.Attr),
+				lastLongTest: time.Now(),
+			}
+		}
+		m.devices[name] = state
+		if err := m.emit(ctx, Event{Type: DeviceAdded, Device: name, Fingerprint: fingerprint}); err != nil {
+			return err
+		}
+	}
+
+	// Only the temperature and critical warnings of NVMe devices are monitored
+	if state.class == ClassNVMe {
+		return m.pollNVMe(ctx, name, state, override)
+	}
+
+	// The device is opened for each poll rather than held by a scsismart.DeviceManager: the
+	// overrides give each device its own detect options, which a manager shares, and a handle
+	// kept open between polls, which are far apart, would pin the node of a removed drive
+	d, err := scsismart.DetectSCSITypeWithOptions(name, override.DetectOptions(scsismart.DetectOptions{Context: ctx}))
+	if err != nil {
+		return m.queryFailed(ctx, name, err)
+	}
+	defer d.Close()
+
+	var attrs atasmart.AttrCollection
+	reader, readsSMART := d.(scsismart.SMARTReader)
+	readsSMART = readsSMART && !override.ProbeDisabled(smartinfo.ProbeSMART)
+	if readsSMART {
+		attrs, err = reader.GetSMARTAttributes()
+	}
+
+	if !override.ProbeDisabled(smartinfo.ProbeTemperature) {
+		// the temperature of ATA devices is one of the attributes read above
+		temp, ok := scsismart.AttrTemperature(attrs)
+		if tempReader, isReader := d.(scsismart.TemperatureReader); isReader && !readsSMART {
+			var tempErr error
+			temp, tempErr = tempReader.Temperature()
+			ok = tempErr == nil
+		}
+		if ok {
+			if err := m.checkTemperature(ctx, name, state, temp); err != nil {
+				return err
+			}
+		}
+	}
+
+	if !readsSMART {
+		return nil
+	}
+	if err != nil && !scsismart.IsPartial(err) {
+		return m.queryFailed(ctx, name, err)
+	}
+	if err != nil {
+		// attributes without thresholds are still tracked, the thresholds are read again next poll
+		m.countError(name)
+	}
+	attrs = override.FilterAttrs(attrs)
+
+	deltas := m.computeDeltas(state, attrs)
+	m.setDeltas(name, deltas)
+
+	for _, a := range attrs {
+		prev, known := state.attrs[a.ID]
+		state.attrs[a.ID] = a
+
+		event := Event{Device: name, Fingerprint: fingerprint, Attr: a, Previous: prev}
+		if known {
+			event.Delta = int64(a.Raw - prev.Raw)
+		}
+		switch {
+		case a.FailingNow && (!known || !prev.FailingNow):
+			event.Type = HealthDegraded
+		case known && (a.Value != prev.Value || a.Raw != prev.Raw):
+			event.Type = AttributeChanged
+		default:
+			continue
+		}
+		if err := m.emit(ctx, event); err != nil {
+			return err
+		}
+	}
+
+	// The trend weighs the change since the previous poll, the change since the baseline grows
+	// for the life of the drive and is already weighed by the raw values
+	input := health.Input{Attrs: attrs, Deltas: make(map[uint8]int64)}
+	for _, delta := range deltas {
+		input.Deltas[delta.ID] = delta.SincePoll
+	}
+	defer func() {
+		// The device may have gone while its self-test status was read
+		if _, ok := m.devices[name]; !ok {
+			return
+		}
+		m.setHealth(name, &Health{
+			Score: health.Score(input, *m.opts.Weights),
+			AFR:   m.opts.FailureModel.AnnualizedFailureRate(input),
+		})
+	}()
+
+	if tester, ok := d.(scsismart.SelfTester); ok && !override.ProbeDisabled(smartinfo.ProbeSelfTest) {
+		status, err := tester.SelfTestStatus()
+		if err != nil {
+			return m.queryFailed(ctx, name, err)
+		}
+		input.SelfTest = &status
+
+		if state.selfTestRunning && !status.InProgress {
+			event := Event{Type: SelfTestCompleted, Device: name, Fingerprint: fingerprint, SelfTestStatus: status.Result}
+			if err := m.emit(ctx, event); err != nil {
+				return err
+			}
+		}
+		state.selfTestRunning = status.InProgress
+
+		if m.longSelfTestDue(state) && !status.InProgress && m.HeavyAllowed(name) {
+			if err := tester.StartSelfTest(scsismart.SelfTestExtended); err == nil {
+				state.lastLongTest = time.Now()
+				state.selfTestRunning = true
+			}
+		}
+	}
+
+	return nil
+}
+
+// pollNVMe checks the composite temperature and the critical warnings of an NVMe device, which
+// its health is assessed from
+func (m *Monitor) pollNVMe(ctx context.Context, name string, state *deviceState, override smartinfo.DeviceOverride) error {
+	d := nvme.NVMeDevice{Name: name}
+	if err := d.Open(); err != nil {
+		return m.queryFailed(ctx, name, err)
+	}
+	defer d.Close()
+
+	// the temperature and the critical warnings are read from the same health log
+	info, err := d.HealthInfo()
+	if err != nil {
+		return m.queryFailed(ctx, name, err)
+	}
+	warning := info.CriticalWarning
+
+	// controllers which do not report their temperature report 0 Kelvin
+	if !override.ProbeDisabled(smartinfo.ProbeTemperature) && info.Temperature > -273 {
+		if err := m.checkTemperature(ctx, name, state, info.Temperature); err != nil {
+			return err
+		}
+	}
+
+	input := health.Input{CriticalWarning: warning}
+	m.setHealth(name, &Health{
+		Score: health.Score(input, *m.opts.Weights),
+		AFR:   m.opts.FailureModel.AnnualizedFailureRate(input),
+	})
+
+	if warning == state.criticalWarning {
+		return nil
+	}
+	event := Event{
+		Type:                    CriticalWarningChanged,
+		Device:                  name,
+		Fingerprint:             state.fingerprint,
+		CriticalWarning:         warning,
+		PreviousCriticalWarning: state.criticalWarning,
+	}
+	state.criticalWarning = warning
+
+	return m.emit(ctx, event)
+}
+
+// longSelfTestDue reports whether the monitor should start an extended self-test on a device
+func (m *Monitor) longSelfTestDue(state *deviceState) bool {
+	interval := m.opts.LongSelfTestInterval
+	return interval > 0 && time.Since(state.lastLongTest) >= interval
+}
+
+// Health is the health of a device assessed at its last poll
+type Health struct {
+	Score int     // see health.Score
+	AFR   float64 // estimated annualized failure rate, see health.FailureModel
+}
+
+// setHealth records the health assessed at the last poll of a device, or forgets it if nil
+func (m *Monitor) setHealth(device string, h *Health) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if h == nil {
+		delete(m.health, device)
+		return
+	}
+	m.health[device] = *h
+}
+
+// Health returns the health of a device assessed at the last poll. It may be called while the
+// monitor runs.
+func (m *Monitor) Health(device string) (Health, bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	h, ok := m.health[device]
+	return h, ok
+}
+
+// emit delivers an event, giving up when ctx is done
+func (m *Monitor) emit(ctx context.Context, event Event) error {
+	event.Time = time.Now()
+
+	select {
+	case m.events <- event:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
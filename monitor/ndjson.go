@@ -0,0 +1,91 @@
+/*
+Copyright 2018 The OpenEBS Authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Newline-delimited JSON encoding of events.
+
+package monitor
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"time"
+
+	"github.com/openebs/smart/atasmart"
+)
+
+// eventJSON is the JSON form of an Event, holding only the fields relevant to its type
+type eventJSON struct {
+	Type             string         `json:"type"`
+	Time             time.Time      `json:"time"`
+	Device           string         `json:"device"`
+	Fingerprint      string         `json:"fingerprint,omitempty"`
+	Attr             *atasmart.Attr `json:"attr,omitempty"`
+	Previous         *atasmart.Attr `json:"previous,omitempty"`
+	Delta            int64          `json:"delta,omitempty"`
+	SelfTestStatus   *uint8         `json:"self_test_status,omitempty"`
+	Temperature      *int           `json:"temperature,omitempty"`
+	TemperatureLevel string         `json:"temperature_level,omitempty"`
+	CriticalWarning  *uint8         `json:"critical_warning,omitempty"`
+	CriticalWarnings []string       `json:"critical_warnings,omitempty"`
+}
+
+// MarshalJSON encodes an event as a JSON object with the fields of its type, the type being
+// given by name, e.g. {"type":"DeviceAdded","time":...,"device":"/dev/sda",...}
+func (e Event) MarshalJSON() ([]byte, error) {
+	j := eventJSON{
+		Type:        e.Type.String(),
+		Time:        e.Time,
+		Device:      e.Device,
+		Fingerprint: e.Fingerprint,
+	}
+
+	switch e.Type {
+	case AttributeChanged, HealthDegraded:
+		j.Attr, j.Previous, j.Delta = &e.Attr, &e.Previous, e.Delta
+	case SelfTestCompleted:
+		j.SelfTestStatus = &e.SelfTestStatus
+	case TemperatureAlert:
+		j.Temperature = &e.Temperature
+		j.TemperatureLevel = e.TemperatureLevel.String()
+	case CriticalWarningChanged:
+		warning := uint8(e.CriticalWarning)
+		j.CriticalWarning = &warning
+		j.CriticalWarnings = e.CriticalWarning.Names()
+	}
+
+	return json.Marshal(j)
+}
+
+// WriteNDJSON writes the events received from a channel to w as newline-delimited JSON, one
+// object per line, until the channel is closed or ctx is done. Each line is written with a
+// single Write, so that readers of a pipe never see partial objects.
+func WriteNDJSON(ctx context.Context, w io.Writer, events <-chan Event) error {
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case event, ok := <-events:
+			if !ok {
+				return nil
+			}
+			line, err := json.Marshal(event)
+			if err != nil {
+				return err
+			}
+			if _, err := w.Write(append(line, '\n')); err != nil {
+				return err
+			}
+		}
+	}
+}
@@ -0,0 +1,78 @@
+/*
+Copyright 2018 The OpenEBS Authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Runtime statistics of a Monitor, for diagnosing the monitor itself.
+
+package monitor
+
+import "time"
+
+// Stats are the runtime statistics of a Monitor
+type Stats struct {
+	Devices          int               `json:"devices"`            // devices known at the last poll
+	PendingPolls     int               `json:"pending_polls"`      // devices left to poll in the current poll
+	QueuedEvents     int               `json:"queued_events"`      // events waiting to be received
+	LastPoll         time.Time         `json:"last_poll"`          // start of the last complete poll
+	LastPollDuration time.Duration     `json:"last_poll_duration"` // nanoseconds
+	Errors           map[string]uint64 `json:"errors"`             // failed queries, keyed on device path
+}
+
+// Stats returns the runtime statistics of the monitor. It may be called while the monitor
+// runs.
+func (m *Monitor) Stats() Stats {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	stats := m.stats
+	stats.QueuedEvents = len(m.events)
+	stats.Errors = make(map[string]uint64, len(m.stats.Errors))
+	for device, n := range m.stats.Errors {
+		stats.Errors[device] = n
+	}
+
+	return stats
+}
+
+// setPending records the number of devices left to poll in the current poll
+func (m *Monitor) setPending(n int) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.stats.PendingPolls = n
+}
+
+// pollDone records the completion of a poll started at start
+func (m *Monitor) pollDone(start time.Time) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.stats.Devices = len(m.devices)
+	m.stats.LastPoll = start
+	m.stats.LastPollDuration = time.Since(start)
+}
+
+// countError counts a failed query of a device
+func (m *Monitor) countError(device string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.stats.Errors[device]++
+}
+
+// forgetErrors forgets the failed queries of a removed device
+func (m *Monitor) forgetErrors(device string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	delete(m.stats.Errors, device)
+}
@@ -0,0 +1,226 @@
+/*
+Copyright 2018 The OpenEBS Authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Temperature alerts with hysteresis and debouncing.
+
+package monitor
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/openebs/smart/scsismart"
+	"github.com/openebs/smart/thermal"
+)
+
+// DefaultTemperatureSamples is the number of temperature samples kept for each device by default
+const DefaultTemperatureSamples = 1440
+
+// DeviceClass is the kind of a device, which selects its default temperature thresholds
+type DeviceClass int
+
+// Device classes
+const (
+	ClassHDD DeviceClass = iota
+	ClassSSD
+	ClassNVMe
+	ClassUnknown // devices which do not report whether their medium rotates
+)
+
+var deviceClassNames = map[DeviceClass]string{
+	ClassHDD:     "HDD",
+	ClassSSD:     "SSD",
+	ClassNVMe:    "NVMe",
+	ClassUnknown: "unknown",
+}
+
+// String returns the name of the device class
+func (c DeviceClass) String() string {
+	if name, ok := deviceClassNames[c]; ok {
+		return name
+	}
+
+	return fmt.Sprintf("DeviceClass(%d)", int(c))
+}
+
+// deviceClass returns the class of a device from its identification data
+func deviceClass(attr scsismart.DiskAttr) DeviceClass {
+	switch {
+	case strings.HasPrefix(attr.KernelName, "nvme"):
+		return ClassNVMe
+	case attr.RotationRate > 0:
+		return ClassHDD
+	case attr.RotationRateStr == "Solid State Device":
+		return ClassSSD
+	}
+
+	// a rotation rate of 0 is not reported or reserved, which tells nothing about the medium
+	return ClassUnknown
+}
+
+// TemperatureLevel is the alert level of a device temperature
+type TemperatureLevel int
+
+// Temperature levels
+const (
+	TemperatureNormal TemperatureLevel = iota
+	TemperatureWarning
+	TemperatureCritical
+)
+
+var temperatureLevelNames = map[TemperatureLevel]string{
+	TemperatureNormal:   "Normal",
+	TemperatureWarning:  "Warning",
+	TemperatureCritical: "Critical",
+}
+
+// String returns the name of the temperature level
+func (l TemperatureLevel) String() string {
+	if name, ok := temperatureLevelNames[l]; ok {
+		return name
+	}
+
+	return fmt.Sprintf("TemperatureLevel(%d)", int(l))
+}
+
+// TemperatureThresholds configures the temperature alerts of a device class. Temperatures are
+// in degrees Celsius.
+type TemperatureThresholds struct {
+	Warning  int
+	Critical int
+
+	// Hysteresis is how far below a threshold the temperature must fall for its alert to clear
+	Hysteresis int
+
+	// Debounce is the number of consecutive polls a new level must be seen at before it is
+	// alerted on, so that brief excursions do not raise alerts. 0 and 1 alert immediately.
+	Debounce int
+}
+
+// DefaultTemperatureThresholds are the thresholds of the device classes missing from
+// Options.Temperature
+var DefaultTemperatureThresholds = map[DeviceClass]TemperatureThresholds{
+	ClassHDD:  {Warning: 50, Critical: 60, Hysteresis: 3, Debounce: 2},
+	ClassSSD:  {Warning: 65, Critical: 75, Hysteresis: 3, Debounce: 2},
+	ClassNVMe: {Warning: 70, Critical: 80, Hysteresis: 3, Debounce: 2},
+	// the thresholds of the most heat sensitive class, as the device may be a hard disk
+	ClassUnknown: {Warning: 50, Critical: 60, Hysteresis: 3, Debounce: 2},
+}
+
+// threshold returns the temperature at which a level is entered
+func (t TemperatureThresholds) threshold(level TemperatureLevel) int {
+	if level == TemperatureCritical {
+		return t.Critical
+	}
+	return t.Warning
+}
+
+// level returns the level of a temperature given the current level, which is only left for
+// a lower one once the temperature is Hysteresis degrees below its threshold
+func (t TemperatureThresholds) level(temp int, current TemperatureLevel) TemperatureLevel {
+	level := TemperatureNormal
+	switch {
+	case temp >= t.Critical:
+		level = TemperatureCritical
+	case temp >= t.Warning:
+		level = TemperatureWarning
+	}
+
+	for l := current; l > level; l-- {
+		if temp > t.threshold(l)-t.Hysteresis {
+			return l
+		}
+	}
+
+	return level
+}
+
+// temperatureState is the alert state of the temperature of a device between polls
+type temperatureState struct {
+	level   TemperatureLevel // level last alerted on
+	pending TemperatureLevel // level seen at the last polls, not alerted on yet
+	seen    int              // number of consecutive polls pending was seen at
+}
+
+// thresholds returns the temperature thresholds of a device class
+func (m *Monitor) thresholds(class DeviceClass) TemperatureThresholds {
+	if t, ok := m.opts.Temperature[class]; ok {
+		return t
+	}
+	return DefaultTemperatureThresholds[class]
+}
+
+// checkTemperature updates the temperature state of a device and emits a TemperatureAlert
+// event when its level changes for long enough
+func (m *Monitor) checkTemperature(ctx context.Context, name string, state *deviceState, temp int) error {
+	m.recordTemperature(name, temp)
+
+	t := m.thresholds(state.class)
+	level := t.level(temp, state.temp.level)
+
+	if level == state.temp.level {
+		state.temp.pending, state.temp.seen = level, 0
+		return nil
+	}
+
+	if level != state.temp.pending {
+		state.temp.pending, state.temp.seen = level, 0
+	}
+	state.temp.seen++
+	if state.temp.seen < t.Debounce {
+		return nil
+	}
+
+	state.temp = temperatureState{level: level, pending: level}
+
+	event := Event{
+		Type:             TemperatureAlert,
+		Device:           name,
+		Fingerprint:      state.fingerprint,
+		Temperature:      temp,
+		TemperatureLevel: level,
+	}
+	return m.emit(ctx, event)
+}
+
+// recordTemperature adds a sample to the temperature history of a device, dropping the oldest
+// samples beyond Options.TemperatureSamples
+func (m *Monitor) recordTemperature(device string, temp int) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	series := append(m.temps[device], thermal.Sample{Time: time.Now(), Celsius: temp, Source: thermal.SourceMonitor})
+	if len(series) > m.opts.TemperatureSamples {
+		series = series[len(series)-m.opts.TemperatureSamples:]
+	}
+	m.temps[device] = series
+}
+
+// forgetTemperatures drops the temperature history of a device
+func (m *Monitor) forgetTemperatures(device string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	delete(m.temps, device)
+}
+
+// TemperatureHistory returns the temperatures of a device sampled at each poll, oldest first.
+// It may be called while the monitor runs.
+func (m *Monitor) TemperatureHistory(device string) thermal.Series {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	return append(thermal.Series(nil), m.temps[device]...)
+}
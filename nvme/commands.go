@@ -0,0 +1,44 @@
+/*
+Copyright 2018 The OpenEBS Authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// NVMe admin command definitions.
+
+package nvme
+
+const (
+	// NVMe admin commands
+	NVMeAdminGetLogPage = 0x02
+	NVMeAdminIdentify   = 0x06
+
+	// Log page identifiers
+	LogErrorInformation = 0x01
+	LogSMARTHealth      = 0x02
+	LogTelemetryHost    = 0x07
+	LogEnduranceGroup   = 0x09
+	LogSanitizeStatus   = 0x81
+
+	// Identify CNS values
+	IdentifyNamespace  = 0x00
+	IdentifyController = 0x01
+
+	// NVME_IOCTL_ADMIN_CMD, _IOWR('N', 0x41, struct nvme_admin_cmd)
+	NVMeIoctlAdminCmd = 0xc0484e41
+
+	// DefaultTimeout in millisecs
+	DefaultTimeout = 20000
+)
+
+// PCI vendor IDs of NVMe controller vendors with known vendor specific log pages
+const (
+	PCIVendorIntel = 0x8086
+)
@@ -0,0 +1,155 @@
+/*
+Copyright 2018 The OpenEBS Authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// NVMe Endurance Group Information log and life remaining reporting.
+
+package nvme
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math"
+)
+
+// ctrattEnduranceGroups is the CTRATT bit of controllers which support endurance groups
+const ctrattEnduranceGroups = 1 << 4
+
+// EnduranceGroupLog is the Endurance Group Information log page (0x09). The 128-bit
+// counters are saturated at the maximum uint64 value.
+type EnduranceGroupLog struct {
+	ID                  uint16
+	CriticalWarning     uint8
+	AvailableSpare      uint8 // percent
+	SpareThreshold      uint8 // percent
+	PercentUsed         uint8 // may exceed 100 once the rated endurance is used up
+	EnduranceEstimate   uint64
+	DataUnitsRead       uint64
+	DataUnitsWritten    uint64
+	MediaUnitsWritten   uint64
+	HostReadCommands    uint64
+	HostWriteCommands   uint64
+	MediaErrors         uint64
+	ErrorLogEntries     uint64
+	TotalCapacity       uint64
+	UnallocatedCapacity uint64
+}
+
+// uint128 returns the little-endian 128-bit value at the start of b, saturated to 64 bits
+func uint128(b []byte) uint64 {
+	if binary.LittleEndian.Uint64(b[8:]) != 0 {
+		return math.MaxUint64
+	}
+	return binary.LittleEndian.Uint64(b)
+}
+
+// ParseEnduranceGroupLog parses an Endurance Group Information log page
+func ParseEnduranceGroupLog(id uint16, b []byte) (EnduranceGroupLog, error) {
+	if len(b) < 192 {
+		return EnduranceGroupLog{}, fmt.Errorf("endurance group log too short: %d bytes", len(b))
+	}
+
+	return EnduranceGroupLog{
+		ID:                  id,
+		CriticalWarning:     b[0],
+		AvailableSpare:      b[3],
+		SpareThreshold:      b[4],
+		PercentUsed:         b[5],
+		EnduranceEstimate:   uint128(b[32:]),
+		DataUnitsRead:       uint128(b[48:]),
+		DataUnitsWritten:    uint128(b[64:]),
+		MediaUnitsWritten:   uint128(b[80:]),
+		HostReadCommands:    uint128(b[96:]),
+		HostWriteCommands:   uint128(b[112:]),
+		MediaErrors:         uint128(b[128:]),
+		ErrorLogEntries:     uint128(b[144:]),
+		TotalCapacity:       uint128(b[160:]),
+		UnallocatedCapacity: uint128(b[176:]),
+	}, nil
+}
+
+// EnduranceGroupLog reads the Endurance Group Information log of an endurance group
+func (d *NVMeDevice) EnduranceGroupLog(id uint16) (EnduranceGroupLog, error) {
+	respBuf := make([]byte, 512)
+
+	if err := d.getLogPage(LogEnduranceGroup, 0, id, respBuf); err != nil {
+		return EnduranceGroupLog{}, err
+	}
+
+	return ParseEnduranceGroupLog(id, respBuf)
+}
+
+// EnduranceGroupLogs reads the Endurance Group Information logs of all the endurance
+// groups of the controller. It returns no logs if the controller does not support
+// endurance groups.
+func (d *NVMeDevice) EnduranceGroupLogs() ([]EnduranceGroupLog, error) {
+	ctrl, err := d.IdentifyController()
+	if err != nil {
+		return nil, err
+	}
+	if ctrl.CTRATT&ctrattEnduranceGroups == 0 {
+		return nil, nil
+	}
+
+	var logs []EnduranceGroupLog
+	for id := uint16(1); id != 0 && id <= ctrl.ENDGIDMAX; id++ {
+		log, err := d.EnduranceGroupLog(id)
+		if err != nil {
+			// endurance group identifiers need not be contiguous
+			var se StatusError
+			if errors.As(err, &se) {
+				continue
+			}
+			return nil, err
+		}
+		logs = append(logs, log)
+	}
+
+	return logs, nil
+}
+
+// lifeRemaining converts a percentage used estimate to the percentage of life remaining
+func lifeRemaining(percentUsed uint8) int {
+	if percentUsed >= 100 {
+		return 0
+	}
+	return 100 - int(percentUsed)
+}
+
+// LifeRemaining returns the estimated percentage of the rated endurance remaining. Wear
+// reported per endurance group takes precedence over the controller wide SMART / Health
+// Information log, and the most worn endurance group determines the life remaining.
+func (d *NVMeDevice) LifeRemaining() (int, error) {
+	logs, err := d.EnduranceGroupLogs()
+	if err != nil {
+		return 0, err
+	}
+
+	if len(logs) > 0 {
+		remaining := 100
+		for _, log := range logs {
+			if l := lifeRemaining(log.PercentUsed); l < remaining {
+				remaining = l
+			}
+		}
+		return remaining, nil
+	}
+
+	healthLog, err := d.healthLog()
+	if err != nil {
+		return 0, err
+	}
+
+	// percentage used is byte 5 of the SMART / Health Information log
+	return lifeRemaining(healthLog[5]), nil
+}
@@ -0,0 +1,91 @@
+/*
+Copyright 2018 The OpenEBS Authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Error Information log page (0x01) of NVMe controllers.
+
+package nvme
+
+import (
+	"encoding/binary"
+	"fmt"
+	"sort"
+)
+
+// errorLogEntryLen is the length of an entry of the Error Information log
+const errorLogEntryLen = 64
+
+// ErrorLogEntry is an entry of the Error Information log, describing a command which the
+// controller completed with an error
+type ErrorLogEntry struct {
+	ErrorCount     uint64 // unique identifier of the error, incremented for each error
+	SubmissionQID  uint16 // submission queue of the command, 0 for the admin queue
+	CommandID      uint16
+	Status         StatusError // status the command completed with
+	ParameterError uint16      // byte and bit of the command parameter in error, 0xffff if none
+	LBA            uint64      // first LBA which experienced the error, if applicable
+	Namespace      uint32      // namespace of the error, 0 or 0xffffffff if not applicable
+	CommandInfo    uint64      // command specific information
+	TransportType  uint8
+}
+
+// ParseErrorLog parses an Error Information log page, returning its valid entries with the most
+// recent error first
+func ParseErrorLog(b []byte) ([]ErrorLogEntry, error) {
+	if len(b) < errorLogEntryLen {
+		return nil, fmt.Errorf("error information log too short: %d bytes", len(b))
+	}
+
+	var entries []ErrorLogEntry
+	for off := 0; off+errorLogEntryLen <= len(b); off += errorLogEntryLen {
+		e := b[off : off+errorLogEntryLen]
+
+		// Entries with a zero error count are unused
+		count := binary.LittleEndian.Uint64(e)
+		if count == 0 {
+			continue
+		}
+
+		entries = append(entries, ErrorLogEntry{
+			ErrorCount:     count,
+			SubmissionQID:  binary.LittleEndian.Uint16(e[8:]),
+			CommandID:      binary.LittleEndian.Uint16(e[10:]),
+			Status:         StatusError{Status: binary.LittleEndian.Uint16(e[12:]) >> 1}, // without the phase tag
+			ParameterError: binary.LittleEndian.Uint16(e[14:]),
+			LBA:            binary.LittleEndian.Uint64(e[16:]),
+			Namespace:      binary.LittleEndian.Uint32(e[24:]),
+			TransportType:  e[29],
+			CommandInfo:    binary.LittleEndian.Uint64(e[32:]),
+		})
+	}
+
+	sort.Slice(entries, func(i, j int) bool { return entries[i].ErrorCount > entries[j].ErrorCount })
+
+	return entries, nil
+}
+
+// ErrorLog reads the Error Information log of the controller, returning the errors it still
+// holds with the most recent first. The controller keeps a limited number of them, given by
+// the ELPE field of the Identify Controller data structure.
+func (d *NVMeDevice) ErrorLog() ([]ErrorLogEntry, error) {
+	ctrl, err := d.IdentifyController()
+	if err != nil {
+		return nil, err
+	}
+
+	respBuf := make([]byte, (int(ctrl.ELPE)+1)*errorLogEntryLen)
+	if err := d.GetLogPage(LogErrorInformation, 0xffffffff, respBuf); err != nil {
+		return nil, err
+	}
+
+	return ParseErrorLog(respBuf)
+}
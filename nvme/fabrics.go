@@ -0,0 +1,121 @@
+/*
+Copyright 2018 The OpenEBS Authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// NVMe controller discovery through sysfs, including NVMe over Fabrics controllers.
+
+package nvme
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/openebs/smart/internal/utilities"
+)
+
+// sysfs directories of NVMe controllers and subsystems
+const (
+	SysClassNVMe          = "/sys/class/nvme"
+	SysClassNVMeSubsystem = "/sys/class/nvme-subsystem"
+)
+
+// NVMe transports as reported by sysfs
+const (
+	TransportPCIe = "pcie"
+	TransportTCP  = "tcp"
+	TransportRDMA = "rdma"
+	TransportFC   = "fc"
+	TransportLoop = "loop"
+)
+
+var (
+	controllerName = regexp.MustCompile(`^nvme\d+$`)
+	namespaceName  = regexp.MustCompile(`^nvme\d+n\d+$`)
+)
+
+// ControllerInfo is the sysfs metadata of an NVMe controller
+type ControllerInfo struct {
+	Name      string // e.g. nvme0
+	Transport string // one of the Transport values
+	Address   string // transport address, e.g. traddr=10.0.0.1,trsvcid=4420 or a PCI address
+	SubsysNQN string // NVMe qualified name of the subsystem
+	State     string // e.g. live, connecting
+}
+
+// Fabrics reports whether the controller is connected over NVMe over Fabrics
+func (c ControllerInfo) Fabrics() bool {
+	return c.Transport != "" && c.Transport != TransportPCIe
+}
+
+// ReadControllerInfo returns the sysfs metadata of an NVMe controller, given its name
+func ReadControllerInfo(name string) ControllerInfo {
+	dir := filepath.Join(SysClassNVMe, name)
+	read := func(attr string) string {
+		value, _ := utilities.ReadSysfs(filepath.Join(dir, attr))
+		return value
+	}
+
+	return ControllerInfo{
+		Name:      name,
+		Transport: read("transport"),
+		Address:   read("address"),
+		SubsysNQN: read("subsysnqn"),
+		State:     read("state"),
+	}
+}
+
+// ControllerName returns the name of the controller an NVMe device node leads to: the node
+// itself for a controller such as /dev/nvme0, or the controller of a namespace such as
+// /dev/nvme0n1. Namespaces shared by several controllers through native multipathing are
+// attached to their subsystem, in which case a live controller of the subsystem is returned.
+func ControllerName(devPath string) string {
+	name := filepath.Base(devPath)
+	if controllerName.MatchString(name) {
+		return name
+	}
+	if !namespaceName.MatchString(name) {
+		return ""
+	}
+
+	link, err := os.Readlink(filepath.Join(utilities.SysBlockPath, name, "device"))
+	if err != nil {
+		return ""
+	}
+
+	parent := filepath.Base(link)
+	if !strings.HasPrefix(parent, "nvme-subsys") {
+		return parent
+	}
+
+	entries, err := ioutil.ReadDir(filepath.Join(SysClassNVMeSubsystem, parent))
+	if err != nil {
+		return ""
+	}
+
+	var fallback string
+	for _, entry := range entries {
+		if !controllerName.MatchString(entry.Name()) {
+			continue
+		}
+		if ReadControllerInfo(entry.Name()).State == "live" {
+			return entry.Name()
+		}
+		if fallback == "" {
+			fallback = entry.Name()
+		}
+	}
+
+	return fallback
+}
@@ -0,0 +1,173 @@
+/*
+Copyright 2018 The OpenEBS Authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// NVMe namespace identification and LBA format reporting.
+
+package nvme
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"strconv"
+
+	"github.com/openebs/smart/internal/utilities"
+)
+
+// LBAFormat is an LBA format descriptor of the Identify Namespace data structure
+type LBAFormat struct {
+	MetadataSize        uint16
+	LBADataSize         uint8 // log2 of the LBA data size in bytes
+	RelativePerformance uint8 // bits 1:0, 0 is best and 3 is degraded
+}
+
+// IdentifyNamespaceData is the NVMe Identify Namespace data structure (CNS 00h)
+type IdentifyNamespaceData struct {
+	Size        uint64 // NSZE, namespace size in logical blocks
+	Capacity    uint64 // NCAP
+	Utilization uint64 // NUSE
+	Features    uint8  // NSFEAT
+	NumLBAF     uint8  // NLBAF, 0's based number of LBA formats
+	FormatLBAS  uint8  // FLBAS, formatted LBA size
+	MetadataCap uint8  // MC
+	DPC         uint8  // end-to-end data protection capabilities
+	DPS         uint8  // end-to-end data protection type settings
+	_           [34]byte
+	NPWG        uint16 // namespace preferred write granularity, 0's based in logical blocks
+	NPWA        uint16
+	NPDG        uint16
+	NPDA        uint16
+	NOWS        uint16
+	_           [28]byte
+	EnduranceID uint16   // ENDGID, endurance group of the namespace
+	NGUID       [16]byte // namespace globally unique identifier, zero if not reported
+	EUI64       [8]byte  // IEEE extended unique identifier, zero if not reported
+	LBAF        [16]LBAFormat
+	_           [3904]byte
+} // 4096 bytes
+
+// NamespaceFormat is the formatting of a namespace as reported by Identify Namespace
+type NamespaceFormat struct {
+	LBASize          uint32 // logical block size in bytes
+	MetadataSize     uint16 // metadata bytes per logical block
+	ExtendedMetadata bool   // metadata is transferred at the end of the data LBA
+	PIType           uint8  // end-to-end protection information type, 0 if disabled
+	PIFirst          bool   // protection information is in the first bytes of the metadata
+	PreferredWrite   uint32 // preferred write granularity in bytes, 0 if not reported
+	Kind             string // "4Kn", "512e" or "512n"
+}
+
+var namespaceID = regexp.MustCompile(`^nvme\d+n(\d+)$`)
+
+// NamespaceID returns the namespace ID of an NVMe namespace device node, e.g. 1 for /dev/nvme0n1
+func NamespaceID(devPath string) (uint32, error) {
+	name := filepath.Base(devPath)
+
+	if nsid, err := utilities.ReadSysfsUint(filepath.Join(utilities.SysBlockPath, name, "nsid")); err == nil {
+		return uint32(nsid), nil
+	}
+
+	m := namespaceID.FindStringSubmatch(name)
+	if m == nil {
+		return 0, fmt.Errorf("%s is not an NVMe namespace", devPath)
+	}
+	nsid, err := strconv.ParseUint(m[1], 10, 32)
+
+	return uint32(nsid), err
+}
+
+// IdentifyNamespace sends an NVMe Identify command for the data structure of a namespace
+func (d *NVMeDevice) IdentifyNamespace(nsid uint32) (IdentifyNamespaceData, error) {
+	var identifyBuf IdentifyNamespaceData
+
+	responseBuf := make([]byte, 4096)
+
+	cmd := nvmePassthruCmd{
+		opcode: NVMeAdminIdentify,
+		nsid:   nsid,
+		cdw10:  IdentifyNamespace,
+	}
+
+	if err := d.adminCmd(&cmd, responseBuf); err != nil {
+		return identifyBuf, fmt.Errorf("NVMe IDENTIFY NAMESPACE %d: %w", nsid, err)
+	}
+
+	// NVMe data structures are little-endian regardless of the host
+	binary.Read(bytes.NewBuffer(responseBuf), binary.LittleEndian, &identifyBuf)
+
+	return identifyBuf, nil
+}
+
+// EUI returns the globally unique identifier of the namespace in the format of the Linux wwid,
+// e.g. eui.0025385b71b07e2f, preferring the NGUID over the EUI64. It is empty if the namespace
+// reports neither.
+func (ns *IdentifyNamespaceData) EUI() string {
+	if ns.NGUID != [16]byte{} {
+		return fmt.Sprintf("eui.%x", ns.NGUID)
+	}
+	if ns.EUI64 != [8]byte{} {
+		return fmt.Sprintf("eui.%x", ns.EUI64)
+	}
+
+	return ""
+}
+
+// Format returns the formatting of the namespace: its LBA format in use, metadata and
+// protection information settings
+func (ns *IdentifyNamespaceData) Format() NamespaceFormat {
+	// FLBAS bits 3:0 hold the format index, bits 6:5 its upper bits when there are more than 16
+	index := int(ns.FormatLBAS&0x0f | (ns.FormatLBAS>>5&0x03)<<4)
+
+	var format NamespaceFormat
+	if index < len(ns.LBAF) && index <= int(ns.NumLBAF) {
+		lbaf := ns.LBAF[index]
+		format.LBASize = 1 << lbaf.LBADataSize
+		format.MetadataSize = lbaf.MetadataSize
+	}
+	format.ExtendedMetadata = ns.FormatLBAS&0x10 != 0
+	format.PIType = ns.DPS & 0x07
+	format.PIFirst = ns.DPS&0x08 != 0
+
+	// NPWG and the related fields are valid if bit 4 of NSFEAT is set
+	if ns.Features&0x10 != 0 {
+		format.PreferredWrite = (uint32(ns.NPWG) + 1) * format.LBASize
+	}
+
+	switch {
+	case format.LBASize >= 4096:
+		format.Kind = "4Kn"
+	case format.PreferredWrite >= 4096:
+		format.Kind = "512e"
+	default:
+		format.Kind = "512n"
+	}
+
+	return format
+}
+
+// Format returns the formatting of the namespace the device was opened on
+func (d *NVMeDevice) Format() (NamespaceFormat, error) {
+	nsid, err := NamespaceID(d.Name)
+	if err != nil {
+		return NamespaceFormat{}, err
+	}
+
+	ns, err := d.IdentifyNamespace(nsid)
+	if err != nil {
+		return NamespaceFormat{}, err
+	}
+
+	return ns.Format(), nil
+}
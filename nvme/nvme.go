@@ -0,0 +1,246 @@
+/*
+Copyright 2018 The OpenEBS Authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// NVMe admin passthrough functions.
+
+package nvme
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"path/filepath"
+	"unsafe"
+
+	"golang.org/x/sys/unix"
+
+	"github.com/openebs/smart/internal/ioctl"
+)
+
+// nvme_passthru_cmd structure See <uapi/linux/nvme_ioctl.h>
+type nvmePassthruCmd struct {
+	opcode      uint8
+	flags       uint8
+	rsvd1       uint16
+	nsid        uint32
+	cdw2        uint32
+	cdw3        uint32
+	metadata    uint64
+	addr        uint64
+	metadataLen uint32
+	dataLen     uint32
+	cdw10       uint32
+	cdw11       uint32
+	cdw12       uint32
+	cdw13       uint32
+	cdw14       uint32
+	cdw15       uint32
+	timeoutMs   uint32
+	result      uint32
+} // 72 bytes
+
+// Compile time check that nvmePassthruCmd has the same 72-byte layout on every architecture
+var _ [72 - unsafe.Sizeof(nvmePassthruCmd{})]byte
+var _ [unsafe.Sizeof(nvmePassthruCmd{}) - 72]byte
+
+// IdentifyControllerData is the NVMe Identify Controller data structure (CNS 01h)
+type IdentifyControllerData struct {
+	VendorID          uint16   // PCI vendor ID
+	SubsystemVendorID uint16   // PCI subsystem vendor ID
+	SerialNumber      [20]byte // serial number, padded with spaces
+	ModelNumber       [40]byte // model number, padded with spaces
+	FirmwareRev       [8]byte  // firmware revision, padded with spaces
+	_                 [5]byte
+	MDTS              uint8 // maximum data transfer size, a power of two of the minimum page size
+	_                 [18]byte
+	CTRATT            uint32 // controller attributes
+	_                 [161]byte
+	LPA               uint8 // log page attributes
+	ELPE              uint8 // error log page entries, 0's based
+	_                 [65]byte
+	SANICAP           uint32 // sanitize capabilities
+	_                 [8]byte
+	ENDGIDMAX         uint16 // maximum endurance group identifier
+	_                 [3754]byte
+} // 4096 bytes
+
+// NVMeDevice structure
+type NVMeDevice struct {
+	Name       string
+	Controller ControllerInfo // sysfs metadata of the controller, set by Open
+	fd         int
+}
+
+// Open returns error if a NVMe device returns error when opened. Admin commands to the
+// namespaces of NVMe over Fabrics controllers are sent to a live controller of the subsystem,
+// since the namespace node may be backed by a path which is down.
+func (d *NVMeDevice) Open() (err error) {
+	path := d.Name
+
+	if name := ControllerName(d.Name); name != "" {
+		d.Controller = ReadControllerInfo(name)
+		if d.Controller.Fabrics() {
+			path = filepath.Join("/dev", name)
+		}
+	}
+
+	d.fd, err = unix.Open(path, unix.O_RDWR, 0600)
+	return err
+}
+
+// Close returns error if a NVMe device is not closed
+func (d *NVMeDevice) Close() error {
+	return unix.Close(d.fd)
+}
+
+// StatusError is returned when the controller completes a command with a non-zero status
+type StatusError struct {
+	Status uint16 // status field of the completion queue entry, without the phase tag
+}
+
+func (e StatusError) Error() string {
+	return fmt.Sprintf("NVMe status type %#x code %#02x", e.Type(), e.Code())
+}
+
+// Type returns the status code type, e.g. 0 for generic command status
+func (e StatusError) Type() uint8 {
+	return uint8(e.Status>>8) & 0x07
+}
+
+// Code returns the status code
+func (e StatusError) Code() uint8 {
+	return uint8(e.Status)
+}
+
+// adminCmd issues an NVMe admin command, transferring the supplied buffer in the direction
+// given by the opcode
+func (d *NVMeDevice) adminCmd(cmd *nvmePassthruCmd, respBuf []byte) error {
+	if len(respBuf) > 0 {
+		cmd.addr = uint64(uintptr(unsafe.Pointer(&respBuf[0])))
+		cmd.dataLen = uint32(len(respBuf))
+	}
+	if cmd.timeoutMs == 0 {
+		cmd.timeoutMs = DefaultTimeout
+	}
+
+	status, err := ioctl.IoctlValue(uintptr(d.fd), NVMeIoctlAdminCmd, uintptr(unsafe.Pointer(cmd)))
+	if err != nil {
+		return err
+	}
+	if status != 0 {
+		return StatusError{Status: uint16(status)}
+	}
+
+	return nil
+}
+
+// AdminCommand is a raw NVMe admin command, see AdminPassthru
+type AdminCommand struct {
+	Opcode    uint8
+	Flags     uint8
+	NSID      uint32
+	CDW2      uint32
+	CDW3      uint32
+	CDW10     uint32
+	CDW11     uint32
+	CDW12     uint32
+	CDW13     uint32
+	CDW14     uint32
+	CDW15     uint32
+	Data      []byte // data transferred in the direction given by the opcode, may be nil
+	TimeoutMs uint32 // DefaultTimeout if zero
+}
+
+// AdminPassthru issues a raw NVMe admin command, like nvme-cli's admin-passthru, and returns
+// dword 0 of its completion queue entry. It lets callers issue commands the library does not
+// wrap yet; nothing is checked, so commands which modify the device must be used with care.
+func (d *NVMeDevice) AdminPassthru(c AdminCommand) (uint32, error) {
+	cmd := nvmePassthruCmd{
+		opcode:    c.Opcode,
+		flags:     c.Flags,
+		nsid:      c.NSID,
+		cdw2:      c.CDW2,
+		cdw3:      c.CDW3,
+		cdw10:     c.CDW10,
+		cdw11:     c.CDW11,
+		cdw12:     c.CDW12,
+		cdw13:     c.CDW13,
+		cdw14:     c.CDW14,
+		cdw15:     c.CDW15,
+		timeoutMs: c.TimeoutMs,
+	}
+
+	if err := d.adminCmd(&cmd, c.Data); err != nil {
+		return 0, fmt.Errorf("NVMe admin command %#02x: %w", c.Opcode, err)
+	}
+
+	return cmd.result, nil
+}
+
+// IdentifyController sends an NVMe Identify command for the controller data structure
+func (d *NVMeDevice) IdentifyController() (IdentifyControllerData, error) {
+	var identifyBuf IdentifyControllerData
+
+	responseBuf := make([]byte, 4096)
+
+	cmd := nvmePassthruCmd{
+		opcode: NVMeAdminIdentify,
+		cdw10:  IdentifyController,
+	}
+
+	if err := d.adminCmd(&cmd, responseBuf); err != nil {
+		return identifyBuf, fmt.Errorf("NVMe IDENTIFY CONTROLLER: %w", err)
+	}
+
+	// NVMe data structures are little-endian regardless of the host
+	binary.Read(bytes.NewBuffer(responseBuf), binary.LittleEndian, &identifyBuf)
+
+	return identifyBuf, nil
+}
+
+// GetLogPage reads the log page with the given identifier into the supplied buffer.
+// The buffer length must be a multiple of 4 bytes.
+func (d *NVMeDevice) GetLogPage(logID uint8, nsid uint32, respBuf []byte) error {
+	return d.getLogPage(logID, nsid, 0, respBuf)
+}
+
+// getLogPage reads a log page with a log specific identifier, such as the endurance group
+// of the Endurance Group Information log.
+func (d *NVMeDevice) getLogPage(logID uint8, nsid uint32, lsi uint16, respBuf []byte) error {
+	return d.getLogPageAt(logID, nsid, 0, lsi, 0, respBuf)
+}
+
+// getLogPageAt reads a log page from a byte offset, with a log specific field such as the
+// create bit of the telemetry logs and a log specific identifier
+func (d *NVMeDevice) getLogPageAt(logID uint8, nsid uint32, lsp uint8, lsi uint16, offset uint64, respBuf []byte) error {
+	if len(respBuf) == 0 || len(respBuf)%4 != 0 {
+		return fmt.Errorf("invalid log page buffer length %d", len(respBuf))
+	}
+
+	numd := uint32(len(respBuf)/4 - 1) // 0's based number of dwords
+
+	cmd := nvmePassthruCmd{
+		opcode: NVMeAdminGetLogPage,
+		nsid:   nsid,
+		cdw10:  (numd&0xffff)<<16 | uint32(lsp&0x0f)<<8 | uint32(logID),
+		cdw11:  uint32(lsi)<<16 | numd>>16,
+		cdw12:  uint32(offset),
+		cdw13:  uint32(offset >> 32),
+	}
+
+	if err := d.adminCmd(&cmd, respBuf); err != nil {
+		return fmt.Errorf("NVMe GET LOG PAGE %#02x: %w", logID, err)
+	}
+
+	return nil
+}
@@ -0,0 +1,129 @@
+/*
+Copyright 2018 The OpenEBS Authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Sanitize Status log page (0x81), the progress and outcome of the last sanitize operation.
+
+package nvme
+
+import (
+	"encoding/binary"
+	"fmt"
+	"time"
+)
+
+// sanicapMethods are the bits of SANICAP set for the supported sanitize methods: crypto erase,
+// block erase and overwrite
+const sanicapMethods = 0x07
+
+// SanitizeState is the status of the most recent sanitize operation
+type SanitizeState uint8
+
+// Sanitize states
+const (
+	SanitizeNever                 SanitizeState = 0 // the device was never sanitized
+	SanitizeCompleted             SanitizeState = 1
+	SanitizeInProgress            SanitizeState = 2
+	SanitizeFailed                SanitizeState = 3
+	SanitizeCompletedNoDeallocate SanitizeState = 4 // completed, but the media was not deallocated
+)
+
+var sanitizeStateNames = map[SanitizeState]string{
+	SanitizeNever:                 "never sanitized",
+	SanitizeCompleted:             "completed",
+	SanitizeInProgress:            "in progress",
+	SanitizeFailed:                "failed",
+	SanitizeCompletedNoDeallocate: "completed without deallocation",
+}
+
+// String returns the description of the sanitize state
+func (s SanitizeState) String() string {
+	if name, ok := sanitizeStateNames[s]; ok {
+		return name
+	}
+
+	return fmt.Sprintf("SanitizeState(%d)", uint8(s))
+}
+
+// Sanitize actions of the SANACT field of the Sanitize command
+const (
+	SanitizeActionExitFailure = 1
+	SanitizeActionBlockErase  = 2
+	SanitizeActionOverwrite   = 3
+	SanitizeActionCryptoErase = 4
+)
+
+// SanitizeStatus is the Sanitize Status log page. The estimated times are zero when the
+// controller gives no estimate.
+type SanitizeStatus struct {
+	State            SanitizeState
+	Progress         float64 // percent done of the sanitize in progress
+	OverwritePasses  uint8   // overwrite passes completed by the last overwrite sanitize
+	GlobalDataErased bool    // no user data was written since the last sanitize or format
+	Action           uint8   // SANACT of the last sanitize command, see SanitizeActionOverwrite
+	OverwriteTime    time.Duration
+	BlockEraseTime   time.Duration
+	CryptoEraseTime  time.Duration
+}
+
+// estimatedTime converts an estimated time of the Sanitize Status log, in seconds
+func estimatedTime(seconds uint32) time.Duration {
+	if seconds == 0xffffffff {
+		return 0
+	}
+
+	return time.Duration(seconds) * time.Second
+}
+
+// ParseSanitizeStatus parses a Sanitize Status log page
+func ParseSanitizeStatus(b []byte) (SanitizeStatus, error) {
+	if len(b) < 20 {
+		return SanitizeStatus{}, fmt.Errorf("sanitize status log too short: %d bytes", len(b))
+	}
+
+	sstat := binary.LittleEndian.Uint16(b[2:])
+	status := SanitizeStatus{
+		State:            SanitizeState(sstat & 0x07),
+		OverwritePasses:  uint8(sstat>>3) & 0x1f,
+		GlobalDataErased: sstat&0x0100 != 0,
+		Action:           uint8(binary.LittleEndian.Uint32(b[4:]) & 0x07),
+		OverwriteTime:    estimatedTime(binary.LittleEndian.Uint32(b[8:])),
+		BlockEraseTime:   estimatedTime(binary.LittleEndian.Uint32(b[12:])),
+		CryptoEraseTime:  estimatedTime(binary.LittleEndian.Uint32(b[16:])),
+	}
+
+	// SPROG is the numerator of a fraction of 65536, 65535 once the sanitize is done
+	if status.State == SanitizeInProgress {
+		status.Progress = float64(binary.LittleEndian.Uint16(b)) * 100 / 65536
+	}
+
+	return status, nil
+}
+
+// SanitizeStatus reads the Sanitize Status log of the controller, which tells whether a
+// sanitize operation is in progress or how the last one ended
+func (d *NVMeDevice) SanitizeStatus() (SanitizeStatus, error) {
+	ctrl, err := d.IdentifyController()
+	if err != nil {
+		return SanitizeStatus{}, err
+	}
+	if ctrl.SANICAP&sanicapMethods == 0 {
+		return SanitizeStatus{}, fmt.Errorf("%s does not support sanitize", d.Name)
+	}
+
+	respBuf := make([]byte, 512)
+	if err := d.GetLogPage(LogSanitizeStatus, 0xffffffff, respBuf); err != nil {
+		return SanitizeStatus{}, err
+	}
+
+	return ParseSanitizeStatus(respBuf)
+}
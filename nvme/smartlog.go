@@ -0,0 +1,225 @@
+/*
+Copyright 2018 The OpenEBS Authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// NVMe SMART / Health Information log.
+
+package nvme
+
+import (
+	"encoding/binary"
+	"fmt"
+	"strings"
+)
+
+// healthLog reads the controller wide SMART / Health Information log
+func (d *NVMeDevice) healthLog() ([]byte, error) {
+	respBuf := make([]byte, 512)
+
+	if err := d.GetLogPage(LogSMARTHealth, 0xffffffff, respBuf); err != nil {
+		return nil, err
+	}
+
+	return respBuf, nil
+}
+
+// ThermalData is the thermal information of the SMART / Health Information log. Temperatures
+// are in degrees Celsius.
+type ThermalData struct {
+	Composite        int
+	Sensors          map[int]int // implemented temperature sensors, keyed on their number 1 to 8
+	WarningTime      uint32      // minutes spent above the warning composite temperature threshold
+	CriticalTime     uint32      // minutes spent above the critical composite temperature threshold
+	TransitionCounts [2]uint32   // thermal management temperature 1 and 2 transitions
+	TransitionTime   [2]uint32   // seconds spent in thermal management temperature 1 and 2
+}
+
+// kelvinToCelsius converts a temperature of the SMART / Health Information log
+func kelvinToCelsius(kelvin uint16) int {
+	return int(kelvin) - 273
+}
+
+// ThermalData returns the thermal information of the controller
+func (d *NVMeDevice) ThermalData() (ThermalData, error) {
+	healthLog, err := d.healthLog()
+	if err != nil {
+		return ThermalData{}, err
+	}
+
+	// the composite temperature is in Kelvin at bytes 2:1
+	kelvin := binary.LittleEndian.Uint16(healthLog[1:])
+	if kelvin == 0 {
+		return ThermalData{}, fmt.Errorf("controller does not report its temperature")
+	}
+
+	thermal := ThermalData{
+		Composite:    kelvinToCelsius(kelvin),
+		WarningTime:  binary.LittleEndian.Uint32(healthLog[192:]),
+		CriticalTime: binary.LittleEndian.Uint32(healthLog[196:]),
+		Sensors:      make(map[int]int),
+	}
+
+	// sensors which are not implemented report 0
+	for i := 0; i < 8; i++ {
+		if sensor := binary.LittleEndian.Uint16(healthLog[200+2*i:]); sensor != 0 {
+			thermal.Sensors[i+1] = kelvinToCelsius(sensor)
+		}
+	}
+
+	for i := 0; i < 2; i++ {
+		thermal.TransitionCounts[i] = binary.LittleEndian.Uint32(healthLog[216+4*i:])
+		thermal.TransitionTime[i] = binary.LittleEndian.Uint32(healthLog[224+4*i:])
+	}
+
+	return thermal, nil
+}
+
+// Temperature returns the composite temperature of the controller in degrees Celsius
+func (d *NVMeDevice) Temperature() (int, error) {
+	thermal, err := d.ThermalData()
+	if err != nil {
+		return 0, err
+	}
+
+	return thermal.Composite, nil
+}
+
+// CriticalWarning is the critical warning byte of the SMART / Health Information log, each bit
+// flagging a condition the controller considers critical
+type CriticalWarning uint8
+
+// Critical warning bits
+const (
+	CriticalWarningSpare          CriticalWarning = 1 << 0 // available spare below threshold
+	CriticalWarningTemperature    CriticalWarning = 1 << 1 // temperature over or under a threshold
+	CriticalWarningReliability    CriticalWarning = 1 << 2 // NVM subsystem reliability degraded
+	CriticalWarningReadOnly       CriticalWarning = 1 << 3 // media placed in read only mode
+	CriticalWarningVolatileBackup CriticalWarning = 1 << 4 // volatile memory backup device failed
+	CriticalWarningPMR            CriticalWarning = 1 << 5 // persistent memory region unreliable
+)
+
+// criticalWarningNames names the critical warning bits, in bit order
+var criticalWarningNames = []struct {
+	bit  CriticalWarning
+	name string
+}{
+	{CriticalWarningSpare, "spare below threshold"},
+	{CriticalWarningTemperature, "temperature exceeded"},
+	{CriticalWarningReliability, "reliability degraded"},
+	{CriticalWarningReadOnly, "media read-only"},
+	{CriticalWarningVolatileBackup, "volatile backup failed"},
+	{CriticalWarningPMR, "PMR unreliable"},
+}
+
+// SpareBelowThreshold reports whether the available spare fell below its threshold
+func (w CriticalWarning) SpareBelowThreshold() bool { return w&CriticalWarningSpare != 0 }
+
+// TemperatureExceeded reports whether a temperature is over or under a threshold
+func (w CriticalWarning) TemperatureExceeded() bool { return w&CriticalWarningTemperature != 0 }
+
+// ReliabilityDegraded reports whether media or internal errors degraded the reliability
+func (w CriticalWarning) ReliabilityDegraded() bool { return w&CriticalWarningReliability != 0 }
+
+// MediaReadOnly reports whether the media was placed in read only mode
+func (w CriticalWarning) MediaReadOnly() bool { return w&CriticalWarningReadOnly != 0 }
+
+// VolatileBackupFailed reports whether the volatile memory backup device failed
+func (w CriticalWarning) VolatileBackupFailed() bool { return w&CriticalWarningVolatileBackup != 0 }
+
+// PMRUnreliable reports whether the persistent memory region became read only or unreliable
+func (w CriticalWarning) PMRUnreliable() bool { return w&CriticalWarningPMR != 0 }
+
+// Names returns the names of the warnings set, e.g. "spare below threshold". Reserved bits are
+// named by their number.
+func (w CriticalWarning) Names() []string {
+	var names []string
+	known := CriticalWarning(0)
+	for _, n := range criticalWarningNames {
+		known |= n.bit
+		if w&n.bit != 0 {
+			names = append(names, n.name)
+		}
+	}
+	for bit := uint(0); bit < 8; bit++ {
+		if b := CriticalWarning(1 << bit); w&b != 0 && known&b == 0 {
+			names = append(names, fmt.Sprintf("reserved bit %d", bit))
+		}
+	}
+
+	return names
+}
+
+func (w CriticalWarning) String() string {
+	if w == 0 {
+		return "none"
+	}
+	return strings.Join(w.Names(), ", ")
+}
+
+// CriticalWarning returns the critical warnings of the controller
+func (d *NVMeDevice) CriticalWarning() (CriticalWarning, error) {
+	healthLog, err := d.healthLog()
+	if err != nil {
+		return 0, err
+	}
+
+	return CriticalWarning(healthLog[0]), nil
+}
+
+// HealthInfo is the wear and error information of the SMART / Health Information log. The
+// 128-bit counters are saturated at the maximum uint64 value.
+type HealthInfo struct {
+	CriticalWarning  CriticalWarning
+	Temperature      int   // composite temperature in degrees Celsius
+	AvailableSpare   uint8 // percent
+	SpareThreshold   uint8 // percent
+	PercentUsed      uint8 // may exceed 100 once the rated endurance is used up
+	DataUnitsRead    uint64
+	DataUnitsWritten uint64
+	PowerCycles      uint64
+	PowerOnHours     uint64
+	UnsafeShutdowns  uint64
+	MediaErrors      uint64 // unrecovered data integrity errors
+	ErrorLogEntries  uint64
+}
+
+// ParseHealthInfo parses a SMART / Health Information log page
+func ParseHealthInfo(b []byte) (HealthInfo, error) {
+	if len(b) < 192 {
+		return HealthInfo{}, fmt.Errorf("SMART / Health Information log too short: %d bytes", len(b))
+	}
+
+	return HealthInfo{
+		CriticalWarning:  CriticalWarning(b[0]),
+		Temperature:      kelvinToCelsius(binary.LittleEndian.Uint16(b[1:])),
+		AvailableSpare:   b[3],
+		SpareThreshold:   b[4],
+		PercentUsed:      b[5],
+		DataUnitsRead:    uint128(b[32:]),
+		DataUnitsWritten: uint128(b[48:]),
+		PowerCycles:      uint128(b[112:]),
+		PowerOnHours:     uint128(b[128:]),
+		UnsafeShutdowns:  uint128(b[144:]),
+		MediaErrors:      uint128(b[160:]),
+		ErrorLogEntries:  uint128(b[176:]),
+	}, nil
+}
+
+// HealthInfo returns the wear and error information of the controller
+func (d *NVMeDevice) HealthInfo() (HealthInfo, error) {
+	healthLog, err := d.healthLog()
+	if err != nil {
+		return HealthInfo{}, err
+	}
+
+	return ParseHealthInfo(healthLog)
+}
@@ -0,0 +1,82 @@
+/*
+Copyright 2018 The OpenEBS Authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Tests and benchmarks of the SMART / Health Information log parser.
+
+package nvme
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// readFixture reads a log page captured in testdata
+func readFixture(tb testing.TB, name string) []byte {
+	tb.Helper()
+
+	buf, err := os.ReadFile(filepath.Join("testdata", name))
+	if err != nil {
+		tb.Fatal(err)
+	}
+
+	return buf
+}
+
+// TestParseHealthInfoFixture checks the little-endian fields of the log, which must decode the
+// same on big-endian hosts
+func TestParseHealthInfoFixture(t *testing.T) {
+	info, err := ParseHealthInfo(readFixture(t, "smart_log.bin"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	fields := []struct {
+		name      string
+		got, want uint64
+	}{
+		{"temperature", uint64(info.Temperature), 37},
+		{"percent used", uint64(info.PercentUsed), 3},
+		{"data units read", info.DataUnitsRead, 1234567},
+		{"data units written", info.DataUnitsWritten, 2345678},
+		{"power cycles", info.PowerCycles, 57},
+		{"power on hours", info.PowerOnHours, 4321},
+		{"unsafe shutdowns", info.UnsafeShutdowns, 12},
+		{"error log entries", info.ErrorLogEntries, 3},
+	}
+	for _, f := range fields {
+		if f.got != f.want {
+			t.Errorf("%s = %d, want %d", f.name, f.got, f.want)
+		}
+	}
+}
+
+func TestParseHealthInfoAllocs(t *testing.T) {
+	buf := readFixture(t, "smart_log.bin")
+
+	allocs := testing.AllocsPerRun(100, func() { _, _ = ParseHealthInfo(buf) })
+	if allocs > 0 {
+		t.Errorf("ParseHealthInfo: %v allocations per call, want none", allocs)
+	}
+}
+
+func BenchmarkParseHealthInfo(b *testing.B) {
+	buf := readFixture(b, "smart_log.bin")
+	b.ReportAllocs()
+
+	for i := 0; i < b.N; i++ {
+		if _, err := ParseHealthInfo(buf); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
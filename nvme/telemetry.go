@@ -0,0 +1,156 @@
+/*
+Copyright 2018 The OpenEBS Authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Telemetry Host-Initiated log page (0x07), the vendor specific internal state of a controller
+// captured on request for failure analysis.
+
+package nvme
+
+import (
+	"encoding/binary"
+	"fmt"
+	"io"
+)
+
+const (
+	// telemetryBlockLen is the size of the blocks of the telemetry logs, the header included
+	telemetryBlockLen = 512
+
+	// maxTelemetryChunk bounds the size of the reads of the telemetry logs, which are further
+	// limited by the maximum data transfer size of the controller
+	maxTelemetryChunk = 128 * 1024
+
+	// lpaTelemetry is the bit of the log page attributes set if the telemetry logs are supported
+	lpaTelemetry = 1 << 3
+
+	// telemetryCreate is the log specific field creating a new snapshot of the host-initiated
+	// telemetry data
+	telemetryCreate = 0x01
+)
+
+// TelemetryHeader is the header of the Telemetry Host-Initiated log. The data areas are
+// cumulative: data area 2 ends after data area 1, which ends after the header (block 0).
+type TelemetryHeader struct {
+	IEEEOUI              [3]byte
+	DataArea1LastBlock   uint16
+	DataArea2LastBlock   uint16
+	DataArea3LastBlock   uint16
+	HostGeneration       uint8 // incremented each time host-initiated data is captured
+	ControllerDataAvail  bool  // controller-initiated telemetry data is available
+	ControllerGeneration uint8
+	ReasonIdentifier     [128]byte // vendor specific
+}
+
+// ParseTelemetryHeader parses the header of a Telemetry Host-Initiated log page
+func ParseTelemetryHeader(b []byte) (TelemetryHeader, error) {
+	var h TelemetryHeader
+
+	if len(b) < telemetryBlockLen {
+		return h, fmt.Errorf("telemetry log header too short: %d bytes", len(b))
+	}
+	if b[0] != LogTelemetryHost {
+		return h, fmt.Errorf("telemetry log header has log identifier %#02x", b[0])
+	}
+
+	copy(h.IEEEOUI[:], b[5:8])
+	h.DataArea1LastBlock = binary.LittleEndian.Uint16(b[8:])
+	h.DataArea2LastBlock = binary.LittleEndian.Uint16(b[10:])
+	h.DataArea3LastBlock = binary.LittleEndian.Uint16(b[12:])
+	h.HostGeneration = b[381]
+	h.ControllerDataAvail = b[382] != 0
+	h.ControllerGeneration = b[383]
+	copy(h.ReasonIdentifier[:], b[384:512])
+
+	return h, nil
+}
+
+// LastBlock returns the last block of a data area, from 1 to 3
+func (h TelemetryHeader) LastBlock(dataArea int) (uint16, error) {
+	switch dataArea {
+	case 1:
+		return h.DataArea1LastBlock, nil
+	case 2:
+		return h.DataArea2LastBlock, nil
+	case 3:
+		return h.DataArea3LastBlock, nil
+	}
+
+	return 0, fmt.Errorf("invalid telemetry data area %d", dataArea)
+}
+
+// TelemetryHostLog captures a new snapshot of the host-initiated telemetry data and writes the
+// log, from its header to the end of dataArea (1 to 3), to w. The blob is only meaningful to
+// the vendor of the controller. It is read in chunks no larger than the maximum data transfer
+// size of the controller, and an error is returned if another snapshot was captured meanwhile.
+func (d *NVMeDevice) TelemetryHostLog(w io.Writer, dataArea int) (TelemetryHeader, error) {
+	ctrl, err := d.IdentifyController()
+	if err != nil {
+		return TelemetryHeader{}, err
+	}
+	if ctrl.LPA&lpaTelemetry == 0 {
+		return TelemetryHeader{}, fmt.Errorf("%s does not support telemetry logs", d.Name)
+	}
+
+	// MDTS is in units of the minimum memory page size, assumed to be 4 KiB, 0 if unlimited
+	chunk := maxTelemetryChunk
+	if ctrl.MDTS != 0 && ctrl.MDTS < 6 && 4096<<ctrl.MDTS < chunk {
+		chunk = 4096 << ctrl.MDTS
+	}
+
+	headerBuf := make([]byte, telemetryBlockLen)
+	if err := d.getLogPageAt(LogTelemetryHost, 0, telemetryCreate, 0, 0, headerBuf); err != nil {
+		return TelemetryHeader{}, err
+	}
+	header, err := ParseTelemetryHeader(headerBuf)
+	if err != nil {
+		return header, err
+	}
+	lastBlock, err := header.LastBlock(dataArea)
+	if err != nil {
+		return header, err
+	}
+
+	if _, err := w.Write(headerBuf); err != nil {
+		return header, err
+	}
+
+	end := (uint64(lastBlock) + 1) * telemetryBlockLen
+	buf := make([]byte, chunk)
+	for offset := uint64(telemetryBlockLen); offset < end; {
+		n := uint64(len(buf))
+		if end-offset < n {
+			n = end - offset
+		}
+		if err := d.getLogPageAt(LogTelemetryHost, 0, 0, 0, offset, buf[:n]); err != nil {
+			return header, err
+		}
+		if _, err := w.Write(buf[:n]); err != nil {
+			return header, err
+		}
+		offset += n
+	}
+
+	// The data read is consistent if no snapshot was captured while reading it
+	if err := d.getLogPageAt(LogTelemetryHost, 0, 0, 0, 0, headerBuf); err != nil {
+		return header, err
+	}
+	after, err := ParseTelemetryHeader(headerBuf)
+	if err != nil {
+		return header, err
+	}
+	if after.HostGeneration != header.HostGeneration {
+		return header, fmt.Errorf("telemetry data of %s changed while it was read", d.Name)
+	}
+
+	return header, nil
+}
@@ -0,0 +1,120 @@
+/*
+Copyright 2018 The OpenEBS Authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Vendor specific NVMe log pages.
+
+package nvme
+
+import (
+	"encoding/binary"
+	"fmt"
+)
+
+// VendorAttr is a health metric decoded from a vendor specific log page
+type VendorAttr struct {
+	Name       string
+	Normalized uint8
+	Raw        uint64
+}
+
+// VendorPlugin reads and decodes the vendor specific log pages of a controller
+type VendorPlugin func(d *NVMeDevice) ([]VendorAttr, error)
+
+// vendorPlugins holds the registered vendor plugins keyed on PCI vendor ID
+var vendorPlugins = map[uint16]VendorPlugin{
+	PCIVendorIntel: intelAdditionalSmartLog,
+}
+
+// RegisterVendorPlugin registers a plugin for controllers with the given PCI vendor ID,
+// replacing any plugin already registered for it.
+func RegisterVendorPlugin(vendorID uint16, plugin VendorPlugin) {
+	vendorPlugins[vendorID] = plugin
+}
+
+// VendorAttributes returns the vendor specific health metrics of a controller, using the
+// plugin registered for its PCI vendor ID.
+func (d *NVMeDevice) VendorAttributes() ([]VendorAttr, error) {
+	identifyBuf, err := d.IdentifyController()
+	if err != nil {
+		return nil, err
+	}
+
+	plugin, ok := vendorPlugins[identifyBuf.VendorID]
+	if !ok {
+		return nil, fmt.Errorf("no vendor log plugin for PCI vendor %#04x", identifyBuf.VendorID)
+	}
+
+	return plugin(d)
+}
+
+// Intel additional SMART log page (0xca) attribute keys
+var intelSmartKeys = map[uint8]string{
+	0xab: "program_fail_count",
+	0xac: "erase_fail_count",
+	0xad: "wear_leveling",
+	0xb8: "end_to_end_error_detection_count",
+	0xc7: "crc_error_count",
+	0xe2: "timed_workload_media_wear",
+	0xe3: "timed_workload_host_reads",
+	0xe4: "timed_workload_timer",
+	0xea: "thermal_throttle_status",
+	0xf0: "retry_buffer_overflow_count",
+	0xf3: "pll_lock_loss_count",
+	0xf4: "nand_bytes_written",
+	0xf5: "host_bytes_written",
+}
+
+// intelAdditionalSmartLog reads the Intel additional SMART attributes log page (0xca).
+// Each entry is 12 bytes: key, 2 reserved, normalized value, reserved, 6 byte raw value, reserved.
+func intelAdditionalSmartLog(d *NVMeDevice) ([]VendorAttr, error) {
+	respBuf := make([]byte, 512)
+
+	if err := d.GetLogPage(0xca, 0xffffffff, respBuf); err != nil {
+		return nil, err
+	}
+
+	var attrs []VendorAttr
+
+	for offset := 0; offset+12 <= len(respBuf); offset += 12 {
+		entry := respBuf[offset : offset+12]
+
+		name, ok := intelSmartKeys[entry[0]]
+		if !ok {
+			continue
+		}
+
+		raw := entry[5:11]
+
+		switch entry[0] {
+		case 0xad:
+			// Wear leveling raw value holds min/max/avg erase counts
+			attrs = append(attrs,
+				VendorAttr{"wear_leveling_min", entry[3], uint64(binary.LittleEndian.Uint16(raw[0:]))},
+				VendorAttr{"wear_leveling_max", entry[3], uint64(binary.LittleEndian.Uint16(raw[2:]))},
+				VendorAttr{"wear_leveling_avg", entry[3], uint64(binary.LittleEndian.Uint16(raw[4:]))})
+		case 0xea:
+			// Thermal throttle raw value holds the throttle percentage and event count
+			attrs = append(attrs,
+				VendorAttr{"thermal_throttle_percent", entry[3], uint64(raw[0])},
+				VendorAttr{"thermal_throttle_count", entry[3], uint64(binary.LittleEndian.Uint32(raw[1:]))})
+		default:
+			var value uint64
+			for i := len(raw) - 1; i >= 0; i-- {
+				value = value<<8 | uint64(raw[i])
+			}
+			attrs = append(attrs, VendorAttr{name, entry[3], value})
+		}
+	}
+
+	return attrs, nil
+}
@@ -0,0 +1,68 @@
+/*
+Copyright 2018 The OpenEBS Authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// HTML rendering of disk reports.
+
+package render
+
+import (
+	"html/template"
+	"io"
+)
+
+var htmlReport = template.Must(template.New("report").Funcs(template.FuncMap{
+	"info":     infoFields,
+	"selfTest": selfTestString,
+}).Parse(`<!DOCTYPE html>
+<html>
+<head><meta charset="utf-8"><title>{{.Device}}</title></head>
+<body>
+<h1>{{.Device}}</h1>
+<h2>Information</h2>
+<table>
+{{- range info .}}
+<tr><th>{{.Label}}</th><td>{{.Value}}</td></tr>
+{{- end}}
+</table>
+{{- range .Attr.FirmwareWarnings}}
+<p><strong>WARNING:</strong> {{.}}</p>
+{{- end}}
+{{- if .Attrs}}
+<h2>SMART attributes</h2>
+<table>
+<tr><th>ID</th><th>Name</th><th>Flags</th><th>Value</th><th>Worst</th><th>Threshold</th><th>Type</th><th>Updated</th><th>When failed</th><th>Raw value</th></tr>
+{{- range .Attrs}}
+<tr><td>{{.ID}}</td><td>{{.Name}}</td><td>{{printf "0x%04x" .Flags}}</td><td>{{.Value}}</td><td>{{.Worst}}</td><td>{{.Threshold}}</td><td>{{.Type}}</td><td>{{.Updated}}</td><td>{{.WhenFailed}}</td><td>{{.RawString}}</td></tr>
+{{- end}}
+</table>
+{{- end}}
+{{- if .SelfTest}}
+<h2>Self-test</h2>
+<p>{{selfTest .}}</p>
+{{- end}}
+{{- if .Warnings}}
+<h2>Warnings</h2>
+<ul>
+{{- range .Warnings}}
+<li>{{.}}</li>
+{{- end}}
+</ul>
+{{- end}}
+</body>
+</html>
+`))
+
+// WriteHTML writes a report to w as a standalone HTML page
+func WriteHTML(w io.Writer, r Report) error {
+	return htmlReport.Execute(w, r)
+}
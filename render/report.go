@@ -0,0 +1,78 @@
+/*
+Copyright 2018 The OpenEBS Authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Collection of the data rendered in a disk report.
+
+package render
+
+import (
+	"github.com/openebs/smart/atasmart"
+	"github.com/openebs/smart/scsismart"
+)
+
+// Report holds everything known about a disk, as rendered by WriteText and WriteHTML
+type Report struct {
+	Device       string
+	Attr         scsismart.DiskAttr
+	Attrs        atasmart.AttrCollection   // nil if the device reports no SMART attributes
+	SelfTest     *scsismart.SelfTestStatus // nil if the device cannot run self-tests
+	SED          *scsismart.SEDInfo        // nil if the device does not report TCG features
+	Provisioning *scsismart.Provisioning   // nil if the device does not report logical block provisioning
+	Warnings     []string                  // non fatal errors met while collecting the data
+}
+
+// Collect queries a device for the data of its report, using the capabilities it supports.
+// Only a failure to identify the device is returned as an error.
+func Collect(device string, d scsismart.Dev) (Report, error) {
+	r := Report{Device: device}
+
+	attr, err := d.GetDiskInfo()
+	if err != nil && !scsismart.IsPartial(err) {
+		return r, err
+	}
+	r.Attr = attr
+	if err != nil {
+		r.Warnings = append(r.Warnings, "disk info: "+err.Error())
+	}
+
+	if reader, ok := d.(scsismart.SMARTReader); ok {
+		if r.Attrs, err = reader.GetSMARTAttributes(); err != nil {
+			r.Warnings = append(r.Warnings, "SMART attributes: "+err.Error())
+		}
+	}
+
+	if tester, ok := d.(scsismart.SelfTester); ok {
+		if status, err := tester.SelfTestStatus(); err == nil {
+			r.SelfTest = &status
+		} else {
+			r.Warnings = append(r.Warnings, "self-test status: "+err.Error())
+		}
+	}
+
+	if reporter, ok := d.(scsismart.SEDReporter); ok {
+		if info, err := reporter.SEDInfo(); err == nil {
+			r.SED = &info
+		} else {
+			r.Warnings = append(r.Warnings, "self-encryption: "+err.Error())
+		}
+	}
+
+	// Logical block provisioning is optional, devices which do not report it are not warned about
+	if reporter, ok := d.(scsismart.ProvisioningReporter); ok {
+		if p, err := reporter.Provisioning(); err == nil {
+			r.Provisioning = &p
+		}
+	}
+
+	return r, nil
+}
@@ -0,0 +1,135 @@
+/*
+Copyright 2018 The OpenEBS Authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Plain text rendering of disk reports.
+
+package render
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/openebs/smart/internal/utilities"
+	"github.com/openebs/smart/scsismart"
+)
+
+// field is a labelled value of the information section, skipped when empty
+type field struct {
+	Label string
+	Value string
+}
+
+// infoFields returns the fields of the information section of a report
+func infoFields(r Report) []field {
+	a := r.Attr
+
+	fields := []field{
+		{"Device", r.Device},
+		{"Model Number", a.ModelNumber},
+		{"Serial Number", a.SerialNumber},
+		{"LU WWN Device Id", a.LuWWNDeviceID},
+		{"Firmware Revision", a.FirmwareRevision},
+		{"User Capacity", fmt.Sprintf("%d bytes (%s)", a.UserCapacity, utilities.ConvertBytes(a.UserCapacity))},
+		{"Sector Size", fmt.Sprintf("%d bytes logical, %d bytes physical", a.LBSize, a.PBSize)},
+		{"Rotation Rate", a.RotationRateStr},
+		{"ATA Version", a.ATAMajorVersion},
+		{"Transport", a.Transport},
+		{"Hypervisor", a.Hypervisor},
+		{"iSCSI Target", a.ISCSITargetIQN},
+		{"FC Remote WWPN", a.FCRemoteWWPN},
+		{"SMART support", fmt.Sprint(a.SMARTSupported)},
+	}
+	if r.SED != nil {
+		fields = append(fields, field{"Self-encryption", sedString(*r.SED)})
+	}
+	if r.Provisioning != nil {
+		fields = append(fields, field{"Provisioning", r.Provisioning.String()})
+	}
+
+	var nonEmpty []field
+	for _, f := range fields {
+		if f.Value != "" {
+			nonEmpty = append(nonEmpty, f)
+		}
+	}
+
+	return nonEmpty
+}
+
+// sedString describes the self-encrypting drive features of a device
+func sedString(sed scsismart.SEDInfo) string {
+	if len(sed.SSCs) == 0 {
+		return "not supported"
+	}
+
+	s := strings.Join(sed.SSCs, ", ")
+	switch {
+	case sed.Locked:
+		s += ", locked"
+	case sed.LockingEnabled:
+		s += ", locking enabled"
+	default:
+		s += ", locking disabled"
+	}
+
+	return s
+}
+
+// selfTestString describes the self-test status of a report
+func selfTestString(r Report) string {
+	switch {
+	case r.SelfTest.InProgress:
+		return fmt.Sprintf("in progress, %d%% remaining", r.SelfTest.Remaining)
+	case r.SelfTest.Result == 0:
+		return "last self-test completed without error"
+	}
+
+	return fmt.Sprintf("last self-test failed or was interrupted (status %d)", r.SelfTest.Result)
+}
+
+// WriteText writes a report to w as a multi-section text report in the style of smartctl -a
+func WriteText(w io.Writer, r Report) error {
+	bw := bufio.NewWriter(w)
+
+	fmt.Fprintln(bw, "=== START OF INFORMATION SECTION ===")
+	for _, f := range infoFields(r) {
+		fmt.Fprintf(bw, "%-19s %s\n", f.Label+":", f.Value)
+	}
+
+	for _, warning := range r.Attr.FirmwareWarnings {
+		fmt.Fprintf(bw, "WARNING: %s\n", warning)
+	}
+
+	if r.Attrs != nil {
+		fmt.Fprintln(bw, "\n=== START OF SMART DATA SECTION ===")
+		if err := r.Attrs.WriteTable(bw); err != nil {
+			return err
+		}
+	}
+
+	if r.SelfTest != nil {
+		fmt.Fprintln(bw, "\n=== START OF SELF-TEST SECTION ===")
+		fmt.Fprintf(bw, "Self-test status: %s\n", selfTestString(r))
+	}
+
+	if len(r.Warnings) > 0 {
+		fmt.Fprintln(bw, "\n=== WARNINGS ===")
+		for _, warning := range r.Warnings {
+			fmt.Fprintln(bw, warning)
+		}
+	}
+
+	return bw.Flush()
+}
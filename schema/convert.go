@@ -0,0 +1,135 @@
+/*
+Copyright 2018 The OpenEBS Authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Conversion of the disk data to and from the schema.
+
+package schema
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/openebs/smart/atasmart"
+	"github.com/openebs/smart/render"
+)
+
+// FromReport returns the schema of a disk report
+func FromReport(r render.Report) Disk {
+	attr := r.Attr
+	d := Disk{
+		SchemaVersion:          Version,
+		Device:                 r.Device,
+		KernelName:             attr.KernelName,
+		Bus:                    attr.Bus,
+		Driver:                 attr.Driver,
+		Vendor:                 string(bytes.TrimRight(attr.SCSIInquiry.VendorID[:], " \x00")),
+		Model:                  strings.TrimSpace(attr.ModelNumber),
+		Serial:                 strings.TrimSpace(attr.SerialNumber),
+		Firmware:               strings.TrimSpace(attr.FirmwareRevision),
+		WWN:                    attr.LuWWNDeviceID,
+		CapacityBytes:          attr.UserCapacity,
+		LogicalBlocks:          attr.TotalLogicalBlocks,
+		LogicalBlockSizeBytes:  uint32(attr.LBSize),
+		PhysicalBlockSizeBytes: uint32(attr.PBSize),
+		RotationRateRPM:        uint32(attr.RotationRate),
+		Transport:              attr.Transport,
+		ATAMajorVersion:        attr.ATAMajorVersion,
+		ATAVersion:             attr.ATAMinorVersion,
+		SMARTSupported:         attr.SMARTSupported,
+		Virtual:                attr.Virtual,
+		Hypervisor:             attr.Hypervisor,
+		Paths:                  attr.Paths,
+		ByIDLinks:              attr.ByIDLinks,
+		ByPathLinks:            attr.ByPathLinks,
+		FirmwareWarnings:       attr.FirmwareWarnings,
+		Warnings:               r.Warnings,
+	}
+	if d.WWN == "" && attr.WWN != 0 {
+		d.WWN = fmt.Sprintf("naa.%016x", attr.WWN)
+	}
+
+	for _, a := range r.Attrs {
+		d.Attributes = append(d.Attributes, fromAttr(a))
+	}
+
+	if s := r.SelfTest; s != nil {
+		d.SelfTest = &SelfTest{
+			InProgress:       s.InProgress,
+			RemainingPercent: s.Remaining,
+			Status:           s.Result,
+			Failed:           s.Failed(),
+		}
+	}
+
+	return d
+}
+
+// fromAttr returns the schema of a SMART attribute
+func fromAttr(a atasmart.Attr) Attribute {
+	return Attribute{
+		ID:           a.ID,
+		Name:         a.Name,
+		Flags:        a.Flags,
+		Value:        a.Value,
+		Worst:        a.Worst,
+		Threshold:    a.Threshold,
+		Raw:          a.Raw,
+		RawBytes:     fmt.Sprintf("%#012x", rawBytes(a.RawBytes)),
+		RawString:    a.RawString,
+		Prefailure:   a.Flags&atasmart.AttrFlagPrefailure != 0,
+		FailingNow:   a.FailingNow,
+		FailedInPast: a.FailedInPast,
+	}
+}
+
+// rawBytes returns the little-endian 48-bit raw value of an attribute
+func rawBytes(raw [6]byte) uint64 {
+	var v uint64
+	for i := len(raw) - 1; i >= 0; i-- {
+		v = v<<8 | uint64(raw[i])
+	}
+	return v
+}
+
+// Decode decodes a JSON document of any schema version into the current schema. Documents
+// without schema_version are taken to be the JSON encoding of a render.Report, which is what
+// consumers stored before the schema existed.
+func Decode(data []byte) (Disk, error) {
+	var header struct {
+		SchemaVersion *int `json:"schema_version"`
+	}
+	if err := json.Unmarshal(data, &header); err != nil {
+		return Disk{}, fmt.Errorf("failed to decode disk: %v", err)
+	}
+
+	if header.SchemaVersion == nil {
+		var r render.Report
+		if err := json.Unmarshal(data, &r); err != nil {
+			return Disk{}, fmt.Errorf("failed to decode unversioned disk: %v", err)
+		}
+		return FromReport(r), nil
+	}
+
+	switch v := *header.SchemaVersion; v {
+	case Version:
+		var d Disk
+		if err := json.Unmarshal(data, &d); err != nil {
+			return Disk{}, fmt.Errorf("failed to decode disk: %v", err)
+		}
+		return d, nil
+	default:
+		return Disk{}, fmt.Errorf("unsupported schema version %d, this version supports up to %d", v, Version)
+	}
+}
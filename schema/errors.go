@@ -0,0 +1,118 @@
+/*
+Copyright 2018 The OpenEBS Authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Classification of the errors of devices which could not be queried.
+
+package schema
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"syscall"
+
+	"github.com/openebs/smart/nvme"
+	"github.com/openebs/smart/scsismart"
+	"golang.org/x/sys/unix"
+)
+
+// errnoCategories are the categories of the system errors met opening and querying devices
+var errnoCategories = map[syscall.Errno]string{
+	syscall.EACCES:    CategoryPermission,
+	syscall.EPERM:     CategoryPermission,
+	syscall.ENOENT:    CategoryNotFound,
+	syscall.ENXIO:     CategoryNotFound,
+	syscall.ENODEV:    CategoryNotFound,
+	syscall.EBUSY:     CategoryBusy,
+	syscall.ENOTTY:    CategoryUnsupported,
+	syscall.EINVAL:    CategoryUnsupported,
+	syscall.EIO:       CategoryDevice,
+	syscall.ETIMEDOUT: CategoryTimeout,
+}
+
+// FromError returns the schema of the error of a device. The code is the name of the system
+// error, the SCSI sense key or host status, or the NVMe status of the failure, whichever is
+// found first in err and the errors it wraps.
+func FromError(device string, err error) DeviceError {
+	e := DeviceError{Device: device, Error: err.Error(), Code: "UNKNOWN", Category: CategoryUnknown}
+
+	var (
+		errno   syscall.Errno
+		notDisk scsismart.NotDiskError
+		nvmeErr nvme.StatusError
+	)
+	if status, ok := scsismart.Status(err); ok {
+		e.SCSI = &SCSIStatus{
+			Command:      status.Command,
+			Status:       status.SCSIStatus,
+			HostStatus:   status.HostStatus,
+			DriverStatus: status.DriverStatus,
+			SenseKey:     status.SenseKey,
+			ASC:          status.ASC,
+			ASCQ:         status.ASCQ,
+		}
+	}
+
+	switch {
+	case errors.As(err, &notDisk):
+		e.Code, e.Category = "NOT_A_DISK", CategoryNotDisk
+	case errors.Is(err, context.Canceled):
+		e.Code, e.Category = "CANCELED", CategoryCanceled
+	case errors.Is(err, context.DeadlineExceeded):
+		e.Code, e.Category = "DEADLINE_EXCEEDED", CategoryCanceled
+	case errors.As(err, &errno):
+		e.Errno = int(errno)
+		if e.Code = unix.ErrnoName(errno); e.Code == "" {
+			e.Code = fmt.Sprintf("ERRNO_%d", int(errno))
+		}
+		if category, ok := errnoCategories[errno]; ok {
+			e.Category = category
+		}
+	case errors.As(err, &nvmeErr):
+		e.NVMe = &NVMeStatus{StatusCodeType: nvmeErr.Type(), StatusCode: nvmeErr.Code()}
+		e.Code = fmt.Sprintf("NVME_SCT%d_SC%#02x", nvmeErr.Type(), nvmeErr.Code())
+		e.Category = CategoryDevice
+		// invalid opcode or field in the generic command status
+		if nvmeErr.Type() == 0 && (nvmeErr.Code() == 0x01 || nvmeErr.Code() == 0x02) {
+			e.Category = CategoryUnsupported
+		}
+	case e.SCSI != nil:
+		e.Code, e.Category = classifySCSI(err, *e.SCSI)
+	}
+
+	return e
+}
+
+// classifySCSI returns the code and category of a SCSI command failure
+func classifySCSI(err error, status SCSIStatus) (string, string) {
+	switch {
+	case scsismart.IsTimeout(err):
+		if status.HostStatus != scsismart.DIDOk {
+			return scsismart.HostStatusName(status.HostStatus), CategoryTimeout
+		}
+		return scsismart.DriverStatusName(status.DriverStatus), CategoryTimeout
+	case scsismart.IsTransportError(err):
+		return scsismart.HostStatusName(status.HostStatus), CategoryTransport
+	case status.Status == scsismart.SCSIStatusCheckCondition:
+		code := scsismart.SenseKeyName(status.SenseKey)
+		switch status.SenseKey {
+		case scsismart.SenseKeyMediumError:
+			return code, CategoryMedium
+		case scsismart.SenseKeyIllegalRequest:
+			return code, CategoryUnsupported
+		}
+		return code, CategoryDevice
+	}
+
+	return fmt.Sprintf("SCSI_STATUS_%#02x", status.Status), CategoryDevice
+}
@@ -0,0 +1,143 @@
+/*
+Copyright 2018 The OpenEBS Authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package schema defines the stable JSON form of the disk data, for the consumers storing or
+// exchanging it. Field names and units are part of the schema: within a schema version they
+// are never renamed, removed or given another meaning. Changing them bumps Version and adds a
+// conversion of the previous version to Decode, so that stored documents remain readable.
+package schema
+
+// Version is the version of the schema written by this package
+const Version = 1
+
+// Disk is the schema of a disk
+type Disk struct {
+	SchemaVersion int `json:"schema_version"`
+
+	Device     string `json:"device"`      // device path, e.g. /dev/sda
+	KernelName string `json:"kernel_name"` // e.g. sda
+	Bus        string `json:"bus"`         // e.g. ata, scsi, usb, virtio
+	Driver     string `json:"driver"`      // e.g. sd, virtio_blk
+
+	// Identification strings, without the padding of the device
+	Vendor   string `json:"vendor"` // SCSI INQUIRY vendor, ATA for ATA disks
+	Model    string `json:"model"`
+	Serial   string `json:"serial"`
+	Firmware string `json:"firmware"`
+	WWN      string `json:"wwn,omitempty"` // NAA format, e.g. naa.5000c500a1b2c3d4
+
+	CapacityBytes          uint64 `json:"capacity_bytes"`
+	LogicalBlocks          uint64 `json:"logical_blocks"`
+	LogicalBlockSizeBytes  uint32 `json:"logical_block_size_bytes"`
+	PhysicalBlockSizeBytes uint32 `json:"physical_block_size_bytes"`
+	RotationRateRPM        uint32 `json:"rotation_rate_rpm"` // 0 for solid state or unreported
+
+	Transport       string `json:"transport,omitempty"`         // e.g. Serial ATA SATA 3.1
+	ATAMajorVersion string `json:"ata_major_version,omitempty"` // e.g. ACS-3
+	ATAVersion      string `json:"ata_version,omitempty"`       // e.g. ACS-3 T13/2161-D revision 4
+
+	SMARTSupported   bool     `json:"smart_supported"`
+	Virtual          bool     `json:"virtual"`
+	Hypervisor       string   `json:"hypervisor,omitempty"`
+	Paths            []string `json:"paths,omitempty"`         // device paths of a multipathed disk
+	ByIDLinks        []string `json:"by_id_links,omitempty"`   // /dev/disk/by-id aliases
+	ByPathLinks      []string `json:"by_path_links,omitempty"` // /dev/disk/by-path aliases
+	FirmwareWarnings []string `json:"firmware_warnings,omitempty"`
+
+	Attributes []Attribute `json:"attributes,omitempty"` // ATA SMART attributes by ID
+	SelfTest   *SelfTest   `json:"self_test,omitempty"`  // absent if the disk cannot run self-tests
+
+	// Warnings are the non fatal errors met while collecting the data
+	Warnings []string `json:"warnings,omitempty"`
+}
+
+// Attribute is the schema of an ATA SMART attribute
+type Attribute struct {
+	ID        uint8  `json:"id"`
+	Name      string `json:"name"`  // e.g. Reallocated_Sector_Ct
+	Flags     uint16 `json:"flags"` // attribute flags as reported by the disk
+	Value     uint8  `json:"value"` // normalized current value
+	Worst     uint8  `json:"worst"` // worst normalized value
+	Threshold uint8  `json:"threshold"`
+
+	// Raw is the raw value interpreted according to the raw format of the attribute, e.g. the
+	// current temperature in degrees Celsius without the minimum and maximum
+	Raw       uint64 `json:"raw"`
+	RawBytes  string `json:"raw_bytes"`  // 48-bit raw value as reported, hexadecimal
+	RawString string `json:"raw_string"` // raw value as displayed by smartctl
+
+	Prefailure   bool `json:"prefailure"`     // failing predicts an imminent failure
+	FailingNow   bool `json:"failing_now"`    // value at or below the threshold
+	FailedInPast bool `json:"failed_in_past"` // worst value at or below the threshold
+}
+
+// SelfTest is the schema of the state of the last or running self-test
+type SelfTest struct {
+	InProgress       bool  `json:"in_progress"`
+	RemainingPercent int   `json:"remaining_percent"` // of the running self-test
+	Status           uint8 `json:"status"`            // self-test execution status, 0 when it passed
+	Failed           bool  `json:"failed"`            // the last self-test completed with a failure
+}
+
+// Batch is the schema of the disks queried together, e.g. from a list of devices
+type Batch struct {
+	SchemaVersion int `json:"schema_version"`
+
+	Disks  []Disk        `json:"disks"`
+	Errors []DeviceError `json:"errors,omitempty"` // devices which could not be queried
+}
+
+// DeviceError is the schema of a device which could not be queried, classified so that
+// automation does not need to parse the message
+type DeviceError struct {
+	Device   string `json:"device"` // device identifier as given, e.g. a path or a WWN
+	Error    string `json:"error"`  // human readable message
+	Code     string `json:"code"`   // e.g. EACCES, ILLEGAL_REQUEST, DID_TIMEOUT, see FromError
+	Category string `json:"category"`
+
+	Errno int         `json:"errno,omitempty"` // system error number, if the error is one
+	SCSI  *SCSIStatus `json:"scsi,omitempty"`  // status of the failed SCSI command, if the error is one
+	NVMe  *NVMeStatus `json:"nvme,omitempty"`  // status of the failed NVMe command, if the error is one
+}
+
+// Error categories
+const (
+	CategoryPermission  = "permission"  // the device cannot be opened or queried without privileges
+	CategoryNotFound    = "not_found"   // the device does not exist
+	CategoryBusy        = "busy"        // the device is in use
+	CategoryNotDisk     = "not_disk"    // the device is not a disk, e.g. an enclosure
+	CategoryUnsupported = "unsupported" // the device rejected a command it does not implement
+	CategoryTimeout     = "timeout"     // a command timed out
+	CategoryTransport   = "transport"   // the device could not be reached
+	CategoryMedium      = "medium"      // the medium could not be read
+	CategoryDevice      = "device"      // the device reported another error
+	CategoryCanceled    = "canceled"    // the query was canceled or ran out of time
+	CategoryUnknown     = "unknown"
+)
+
+// SCSIStatus is the schema of the status of a failed SCSI command
+type SCSIStatus struct {
+	Command      string `json:"command"` // e.g. ATA PASS-THROUGH(16) IDENTIFY DEVICE
+	Status       uint8  `json:"status"`
+	HostStatus   uint16 `json:"host_status"`
+	DriverStatus uint16 `json:"driver_status"`
+	SenseKey     uint8  `json:"sense_key"`
+	ASC          uint8  `json:"asc"`
+	ASCQ         uint8  `json:"ascq"`
+}
+
+// NVMeStatus is the schema of the status of a failed NVMe command
+type NVMeStatus struct {
+	StatusCodeType uint8 `json:"status_code_type"`
+	StatusCode     uint8 `json:"status_code"`
+}
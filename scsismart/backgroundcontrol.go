@@ -0,0 +1,116 @@
+/*
+Copyright 2018 The OpenEBS Authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Background Control mode subpage (0x1c/0x01) for background media scans.
+// See SBC-3 section 6.5.4 (Background Control mode page).
+
+package scsismart
+
+import (
+	"encoding/binary"
+	"fmt"
+)
+
+// backgroundControlPageLen is the length of the Background Control subpage including its header
+const backgroundControlPageLen = 16
+
+// BackgroundControl holds the tunables of the Background Control mode subpage
+type BackgroundControl struct {
+	SuspendOnLogFull bool   // S_L_FULL, suspend scans when the results log is full
+	LowIR            bool   // LOWIR, log only informational exceptions of low impact
+	EnableBMS        bool   // EN_BMS, background medium scan enabled
+	EnablePreScan    bool   // EN_PS, background pre-scan enabled
+	BMSInterval      uint16 // Background medium scan interval time (hours)
+	PreScanTimeLimit uint16 // Background pre-scan time limit (hours)
+	MinIdleTime      uint16 // Minimum idle time before background scan (millisecs)
+	MaxSuspendTime   uint16 // Maximum time to suspend background scan (millisecs)
+}
+
+// backgroundControlPage reads the Background Control subpage with the given page control value.
+func (d *SCSIDevice) backgroundControlPage(pageCtrl uint8) ([]byte, error) {
+	response, err := d.modeSense(BackgroundControlPage, BackgroundControlSubPage, pageCtrl)
+	if err != nil {
+		return nil, fmt.Errorf("SgExecute MODE SENSE background control: %w", err)
+	}
+
+	page, err := modePage(response)
+	if err != nil {
+		return nil, err
+	}
+
+	if page[0]&0x3f != BackgroundControlPage || page[1] != BackgroundControlSubPage ||
+		len(page) < backgroundControlPageLen {
+		return nil, fmt.Errorf("device does not support the background control mode page")
+	}
+
+	return page, nil
+}
+
+// BackgroundControl returns the current background media scan settings of a SCSI device
+func (d *SCSIDevice) BackgroundControl() (BackgroundControl, error) {
+	var bc BackgroundControl
+
+	page, err := d.backgroundControlPage(ModePageControlCurrent)
+	if err != nil {
+		return bc, err
+	}
+
+	bc.SuspendOnLogFull = page[4]&0x04 != 0
+	bc.LowIR = page[4]&0x02 != 0
+	bc.EnableBMS = page[4]&0x01 != 0
+	bc.EnablePreScan = page[5]&0x01 != 0
+	bc.BMSInterval = binary.BigEndian.Uint16(page[6:])
+	bc.PreScanTimeLimit = binary.BigEndian.Uint16(page[8:])
+	bc.MinIdleTime = binary.BigEndian.Uint16(page[10:])
+	bc.MaxSuspendTime = binary.BigEndian.Uint16(page[12:])
+
+	return bc, nil
+}
+
+// SetBackgroundControl updates the background media scan settings of a SCSI device.
+// If save is set, the settings are also stored in the saved page and persist across power cycles.
+func (d *SCSIDevice) SetBackgroundControl(bc BackgroundControl, save bool) error {
+	// Read-modify-write the current page so reserved fields are passed back unchanged
+	current, err := d.backgroundControlPage(ModePageControlCurrent)
+	if err != nil {
+		return err
+	}
+
+	page := make([]byte, backgroundControlPageLen)
+	copy(page, current)
+
+	page[4] &^= 0x07
+	if bc.SuspendOnLogFull {
+		page[4] |= 0x04
+	}
+	if bc.LowIR {
+		page[4] |= 0x02
+	}
+	if bc.EnableBMS {
+		page[4] |= 0x01
+	}
+	page[5] &^= 0x01
+	if bc.EnablePreScan {
+		page[5] |= 0x01
+	}
+	binary.BigEndian.PutUint16(page[6:], bc.BMSInterval)
+	binary.BigEndian.PutUint16(page[8:], bc.PreScanTimeLimit)
+	binary.BigEndian.PutUint16(page[10:], bc.MinIdleTime)
+	binary.BigEndian.PutUint16(page[12:], bc.MaxSuspendTime)
+
+	if err := d.modeSelect(page, save); err != nil {
+		return fmt.Errorf("SgExecute MODE SELECT background control: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,119 @@
+/*
+Copyright 2018 The OpenEBS Authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Caching mode page (0x08) for the cache settings of SCSI disks.
+// See SBC-3 section 6.5.5 (Caching mode page).
+
+package scsismart
+
+import (
+	"encoding/binary"
+	"fmt"
+)
+
+// cachingPageLen is the length of the Caching mode page including its header
+const cachingPageLen = 20
+
+// Caching holds the settings of the Caching mode page
+type Caching struct {
+	WriteCache         bool   // WCE, volatile write cache enabled
+	ReadCacheDisabled  bool   // RCD, reads are always served from the medium
+	ReadAheadDisabled  bool   // DRA, read-ahead disabled
+	PrefetchDisableLen uint16 // reads longer than this many blocks do not prefetch, 0xffff if never
+	MinPrefetch        uint16 // minimum number of blocks prefetched
+	MaxPrefetch        uint16 // maximum number of blocks prefetched
+	MaxPrefetchCeiling uint16 // maximum number of blocks prefetched by a read
+	CacheSegments      uint8  // number of cache segments
+
+	// WriteCacheChangeable is set if WCE can be changed with SetWriteCache
+	WriteCacheChangeable bool
+}
+
+// cachingPage reads the Caching mode page with the given page control value
+func (d *SCSIDevice) cachingPage(pageCtrl uint8) ([]byte, error) {
+	response, err := d.modeSense(CachingPage, 0, pageCtrl)
+	if err != nil {
+		return nil, fmt.Errorf("SgExecute MODE SENSE caching: %w", err)
+	}
+
+	page, err := modePage(response)
+	if err != nil {
+		return nil, err
+	}
+
+	if page[0]&0x3f != CachingPage || len(page) < cachingPageLen {
+		return nil, fmt.Errorf("device does not support the caching mode page")
+	}
+
+	return page, nil
+}
+
+// Caching returns the current cache settings of a SCSI disk, the counterpart of the write cache
+// and read look-ahead feature sets of ATA devices
+func (d *SCSIDevice) Caching() (Caching, error) {
+	var c Caching
+
+	page, err := d.cachingPage(ModePageControlCurrent)
+	if err != nil {
+		return c, err
+	}
+
+	c.WriteCache = page[2]&0x04 != 0
+	c.ReadCacheDisabled = page[2]&0x01 != 0
+	c.PrefetchDisableLen = binary.BigEndian.Uint16(page[4:])
+	c.MinPrefetch = binary.BigEndian.Uint16(page[6:])
+	c.MaxPrefetch = binary.BigEndian.Uint16(page[8:])
+	c.MaxPrefetchCeiling = binary.BigEndian.Uint16(page[10:])
+	c.ReadAheadDisabled = page[12]&0x20 != 0
+	c.CacheSegments = page[13]
+
+	if changeable, err := d.cachingPage(ModePageControlChangeable); err == nil {
+		c.WriteCacheChangeable = changeable[2]&0x04 != 0
+	}
+
+	return c, nil
+}
+
+// SetWriteCache enables or disables the volatile write cache of a SCSI disk. Disabling it
+// slows writes down, enabling it risks losing the cached writes on power loss, so it is never
+// called by the library itself. If save is set, the setting is also stored in the saved page
+// and persists across power cycles.
+func (d *SCSIDevice) SetWriteCache(enable, save bool) error {
+	changeable, err := d.cachingPage(ModePageControlChangeable)
+	if err != nil {
+		return err
+	}
+	if changeable[2]&0x04 == 0 {
+		return fmt.Errorf("write cache setting of %s cannot be changed", d.Name)
+	}
+
+	// Read-modify-write the current page so the other settings are passed back unchanged
+	current, err := d.cachingPage(ModePageControlCurrent)
+	if err != nil {
+		return err
+	}
+
+	page := make([]byte, len(current))
+	copy(page, current)
+
+	page[2] &^= 0x04
+	if enable {
+		page[2] |= 0x04
+	}
+
+	if err := d.modeSelect(page, save); err != nil {
+		return fmt.Errorf("SgExecute MODE SELECT caching: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,140 @@
+/*
+Copyright 2018 The OpenEBS Authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Capability interfaces implemented by the device types which support them.
+
+package scsismart
+
+import (
+	"github.com/openebs/smart/atasmart"
+	"github.com/openebs/smart/mmc"
+)
+
+// Identifier is implemented by devices which report their identity and geometry
+type Identifier interface {
+	GetDiskInfo() (DiskAttr, error)
+}
+
+// CapacityReader is implemented by devices which report their capacity and block sizes
+type CapacityReader interface {
+	ReadCapacity() (Capacity, error)
+}
+
+// SMARTReader is implemented by devices which report ATA SMART attributes. The attributes are
+// returned along with a MultiError if some of them could only be read partially.
+type SMARTReader interface {
+	GetSMARTAttributes() (atasmart.AttrCollection, error)
+}
+
+// SelfTester is implemented by devices which can run self-tests
+type SelfTester interface {
+	StartSelfTest(test SelfTestType) error
+	SelfTestStatus() (SelfTestStatus, error)
+}
+
+// LogReader is implemented by devices which return log pages
+type LogReader interface {
+	ReadLogPage(pageNo, subPageNo uint8) ([]byte, error)
+}
+
+// Spinner is implemented by devices whose media can be spun down and up
+type Spinner interface {
+	SpinDown() error
+	SpinUp() error
+}
+
+// Verifier is implemented by devices which can verify that blocks of their media are readable
+type Verifier interface {
+	VerifyBlocks(lba uint64, count uint32) error
+	MaxVerifyBlocks() uint32
+}
+
+// CapabilityReporter is implemented by devices which report the optional features they support
+type CapabilityReporter interface {
+	Capabilities() (Capabilities, error)
+}
+
+// SEDReporter is implemented by devices which report their self-encrypting drive features
+type SEDReporter interface {
+	SEDInfo() (SEDInfo, error)
+}
+
+// ProvisioningReporter is implemented by devices which report their logical block provisioning
+type ProvisioningReporter interface {
+	Provisioning() (Provisioning, error)
+}
+
+// TemperatureReader is implemented by devices which report their current temperature
+type TemperatureReader interface {
+	Temperature() (int, error)
+}
+
+// TemperatureHistoryReader is implemented by devices which keep a history of their temperature
+type TemperatureHistoryReader interface {
+	SCTTemperatureHistory() (atasmart.SCTTempHistory, error)
+}
+
+// InformationalExceptionsReader is implemented by devices which predict their failure through
+// informational exceptions
+type InformationalExceptionsReader interface {
+	InformationalExceptions() (InformationalExceptions, error)
+}
+
+// CachingReporter is implemented by disks which report their cache settings
+type CachingReporter interface {
+	Caching() (Caching, error)
+}
+
+// TapeAlertReader is implemented by devices which report TapeAlert flags
+type TapeAlertReader interface {
+	TapeAlerts() ([]TapeAlert, error)
+}
+
+// MMCHealthReader is implemented by SD cards and eMMC devices which report their wear
+type MMCHealthReader interface {
+	Health() (mmc.Health, error)
+}
+
+// Capability interfaces implemented by each device type
+var (
+	_ Dev                           = (*SCSIDevice)(nil)
+	_ CapacityReader                = (*SCSIDevice)(nil)
+	_ LogReader                     = (*SCSIDevice)(nil)
+	_ CapabilityReporter            = (*SCSIDevice)(nil)
+	_ Spinner                       = (*SCSIDevice)(nil)
+	_ Verifier                      = (*SCSIDevice)(nil)
+	_ SEDReporter                   = (*SCSIDevice)(nil)
+	_ ProvisioningReporter          = (*SCSIDevice)(nil)
+	_ TemperatureReader             = (*SCSIDevice)(nil)
+	_ TapeAlertReader               = (*SCSIDevice)(nil)
+	_ InformationalExceptionsReader = (*SCSIDevice)(nil)
+	_ CachingReporter               = (*SCSIDevice)(nil)
+
+	_ Dev                      = (*SATA)(nil)
+	_ SMARTReader              = (*SATA)(nil)
+	_ SelfTester               = (*SATA)(nil)
+	_ CapabilityReporter       = (*SATA)(nil)
+	_ Spinner                  = (*SATA)(nil)
+	_ Verifier                 = (*SATA)(nil)
+	_ SEDReporter              = (*SATA)(nil)
+	_ TemperatureReader        = (*SATA)(nil)
+	_ TemperatureHistoryReader = (*SATA)(nil)
+
+	_ Dev = (*VirtioBlk)(nil)
+
+	_ Dev             = (*MMCBlk)(nil)
+	_ MMCHealthReader = (*MMCBlk)(nil)
+
+	_ Dev               = (*NVMeBlk)(nil)
+	_ TemperatureReader = (*NVMeBlk)(nil)
+)
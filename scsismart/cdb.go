@@ -0,0 +1,158 @@
+/*
+Copyright 2018 The OpenEBS Authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Helpers for building SCSI Command Descriptor Blocks.
+// See SPC-4 section 4.2 (The CDB) and SAT-3 section 12.2 (ATA PASS-THROUGH commands).
+
+package scsismart
+
+import "encoding/binary"
+
+// ATA pass-through protocols
+const (
+	ATAProtocolHardReset  = 0
+	ATAProtocolSRST       = 1
+	ATAProtocolNonData    = 3
+	ATAProtocolPIODataIn  = 4
+	ATAProtocolPIODataOut = 5
+	ATAProtocolDMA        = 6
+	ATAProtocolUDMADataIn = 10
+)
+
+// ATA pass-through T_LENGTH values, the field holding the transfer length
+const (
+	ATATLengthNone        = 0
+	ATATLengthFeatures    = 1
+	ATATLengthSectorCount = 2
+)
+
+// SetFlags sets the command specific flags in byte 1 of a 6-byte CDB, e.g. EVPD for INQUIRY
+func (c *CDB6) SetFlags(flags uint8) {
+	c[1] = flags
+}
+
+// SetVPDPage sets the EVPD bit and page code of an INQUIRY CDB for a vital product data page
+func (c *CDB6) SetVPDPage(pageNo uint8) {
+	c[1] |= 0x01 // EVPD = 1
+	c[2] = pageNo
+}
+
+// SetPage sets the page control, page code and subpage code of a MODE SENSE(6) CDB
+func (c *CDB6) SetPage(pageCtrl, pageNo, subPageNo uint8) {
+	c[2] = (pageCtrl << 6) | (pageNo & 0x3f)
+	c[3] = subPageNo
+}
+
+// SetAllocationLength sets the allocation (or parameter list) length of a 6-byte CDB
+func (c *CDB6) SetAllocationLength(n uint8) {
+	c[4] = n
+}
+
+// SetControl sets the control byte of a 6-byte CDB
+func (c *CDB6) SetControl(control uint8) {
+	c[5] = control
+}
+
+// SetLBA sets the logical block address of a 10-byte CDB
+func (c *CDB10) SetLBA(lba uint32) {
+	binary.BigEndian.PutUint32(c[2:], lba)
+}
+
+// SetPage sets the page control, page code and subpage code of a LOG SENSE CDB
+func (c *CDB10) SetPage(pageCtrl, pageNo, subPageNo uint8) {
+	c[2] = (pageCtrl << 6) | (pageNo & 0x3f)
+	c[3] = subPageNo
+}
+
+// SetAllocationLength sets the allocation (or transfer) length of a 10-byte CDB
+func (c *CDB10) SetAllocationLength(n uint16) {
+	binary.BigEndian.PutUint16(c[7:], n)
+}
+
+// SetControl sets the control byte of a 10-byte CDB
+func (c *CDB10) SetControl(control uint8) {
+	c[9] = control
+}
+
+// SetLBA sets the logical block address of a 12-byte CDB
+func (c *CDB12) SetLBA(lba uint32) {
+	binary.BigEndian.PutUint32(c[2:], lba)
+}
+
+// SetAllocationLength sets the allocation (or transfer) length of a 12-byte CDB
+func (c *CDB12) SetAllocationLength(n uint32) {
+	binary.BigEndian.PutUint32(c[6:], n)
+}
+
+// SetControl sets the control byte of a 12-byte CDB
+func (c *CDB12) SetControl(control uint8) {
+	c[11] = control
+}
+
+// SetServiceAction sets the service action of a 16-byte CDB, e.g. for SERVICE ACTION IN(16)
+func (c *CDB16) SetServiceAction(action uint8) {
+	c[1] = (c[1] &^ 0x1f) | (action & 0x1f)
+}
+
+// SetLBA sets the logical block address of a 16-byte CDB
+func (c *CDB16) SetLBA(lba uint64) {
+	binary.BigEndian.PutUint64(c[2:], lba)
+}
+
+// SetAllocationLength sets the allocation (or transfer) length of a 16-byte CDB
+func (c *CDB16) SetAllocationLength(n uint32) {
+	binary.BigEndian.PutUint32(c[10:], n)
+}
+
+// SetControl sets the control byte of a 16-byte CDB
+func (c *CDB16) SetControl(control uint8) {
+	c[15] = control
+}
+
+// SetATAProtocol sets the PROTOCOL and EXTEND fields of an ATA PASS-THROUGH(16) CDB
+func (c *CDB16) SetATAProtocol(protocol uint8, extend bool) {
+	c[1] = protocol << 1
+	if extend {
+		c[1] |= 0x01
+	}
+}
+
+// SetATATransfer sets the T_DIR, BYT_BLOK and T_LENGTH fields of an ATA PASS-THROUGH(16) CDB.
+// fromDevice is set for data-in commands, blocks when the transfer length is counted in blocks.
+func (c *CDB16) SetATATransfer(fromDevice, blocks bool, tLength uint8) {
+	c[2] = tLength & 0x03
+	if blocks {
+		c[2] |= 0x04
+	}
+	if fromDevice {
+		c[2] |= 0x08
+	}
+}
+
+// SetATACheckCondition sets the CK_COND bit of an ATA PASS-THROUGH(16) CDB, so that the ATA
+// registers are returned in the sense data even if the command succeeds. It must be called
+// after SetATATransfer.
+func (c *CDB16) SetATACheckCondition() {
+	c[2] |= 0x20
+}
+
+// SetATARegisters sets the ATA command registers of an ATA PASS-THROUGH(16) CDB
+func (c *CDB16) SetATARegisters(command uint8, features, count uint16, lba uint64, device uint8) {
+	c[3], c[4] = uint8(features>>8), uint8(features)
+	c[5], c[6] = uint8(count>>8), uint8(count)
+	c[7], c[8] = uint8(lba>>24), uint8(lba)
+	c[9], c[10] = uint8(lba>>32), uint8(lba>>8)
+	c[11], c[12] = uint8(lba>>40), uint8(lba>>16)
+	c[13] = device
+	c[14] = command
+}
@@ -19,35 +19,89 @@ import "fmt"
 
 // SCSI commands being used
 const (
+	SCSITestUnitReady  = 0x00
+	SCSIFormatUnit     = 0x04
 	SCSIInquiry        = 0x12
+	SCSIModeSelect6    = 0x15
 	SCSIModeSense6     = 0x1a
+	SCSIStartStopUnit  = 0x1b
+	SCSISendDiagnostic = 0x1d
 	SCSIReadCapacity10 = 0x25
+	SCSIVerify10       = 0x2f
+	SCSISanitize       = 0x48
+	SCSILogSense       = 0x4d
 	SCSIATAPassThru16  = 0x85
+	SCSIVerify16       = 0x8f
+	SCSIServiceAction  = 0x9e // SERVICE ACTION IN(16)
+	SCSIReportLUNs     = 0xa0
+	SCSIATAPassThru12  = 0xa1
+	SCSISecurityIn     = 0xa2 // SECURITY PROTOCOL IN
+	SCSIVerify12       = 0xaf
+
+	// SERVICE ACTION IN(16) service actions
+	ReadCapacity16ServiceAction = 0x10
 
 	// Minimum length of standard INQUIRY response
 	INQRespLen = 36
+	// Length of the standard INQUIRY response up to the version descriptors
+	INQRespExtendedLen = 96
+
+	// SCSI vital product data pages
+	VPDDeviceIdentification       = 0x83
+	VPDBlockLimits                = 0xb0
+	VPDBlockDeviceCharacteristics = 0xb1
+	VPDLogicalBlockProvisioning   = 0xb2
 
 	// SCSI-3 mode pages
 	RigidDiskDriveGeometryPage = 0x04
+	CachingPage                = 0x08
+	BackgroundControlPage      = 0x1c
+
+	// SCSI log pages
+	SupportedLogPagesPage          = 0x00
+	TemperatureLogPage             = 0x0d
+	SelfTestResultsLogPage         = 0x10
+	ProtocolSpecificPortLogPage    = 0x18
+	TapeAlertLogPage               = 0x2e
+	InformationalExceptionsLogPage = 0x2f
+
+	// Log page control field
+	LogPageControlCumulative = 1
+
+	// SCSI-3 mode subpages
+	BackgroundControlSubPage = 0x01
 
 	// Mode page control field
-	ModePageControlDefault = 2
+	ModePageControlCurrent    = 0
+	ModePageControlChangeable = 1
+	ModePageControlDefault    = 2
+	ModePageControlSaved      = 3
 )
 
 // SCSI CDB types
 type CDB6 [6]byte
 type CDB10 [10]byte
+type CDB12 [12]byte
 type CDB16 [16]byte
 
-// InquiryResponse is the struct for SCSI INQUIRY response
+// InquiryResponse is the struct for SCSI INQUIRY response. Devices returning only the first 36
+// bytes leave the fields past ProductRev zeroed.
 type InquiryResponse struct {
-	Peripheral byte
-	_          byte
-	Version    byte
-	_          [5]byte
-	VendorID   [8]byte
-	ProductID  [16]byte
-	ProductRev [4]byte
+	Peripheral         byte // peripheral qualifier and device type
+	Flags1             byte // RMB
+	Version            byte
+	Flags3             byte // NORMACA, HISUP and response data format
+	AdditionalLength   byte
+	Flags5             byte // SCCS, ACC, TPGS, 3PC and PROTECT
+	Flags6             byte // ENCSERV and MULTIP
+	Flags7             byte // CMDQUE
+	VendorID           [8]byte
+	ProductID          [16]byte
+	ProductRev         [4]byte
+	VendorSpecific     [20]byte
+	_                  [2]byte
+	VersionDescriptors [8]uint16
+	_                  [22]byte
 }
 
 func (inquiry InquiryResponse) String() string {
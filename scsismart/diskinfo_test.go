@@ -0,0 +1,76 @@
+/*
+Copyright 2018 The OpenEBS Authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Benchmarks and allocation ceiling of the full disk info flow against the emulated SATA disk.
+
+package scsismart
+
+import "testing"
+
+// detectFake detects the emulated SATA disk of a FakeExecutor
+func detectFake(tb testing.TB) Dev {
+	tb.Helper()
+
+	dev, err := Detect("/dev/fake", DetectOptions{Executor: NewFakeExecutor()})
+	if err != nil {
+		tb.Fatal(err)
+	}
+
+	return dev
+}
+
+// maxDiskInfoAllocs is the number of allocations a GetDiskInfo call on the emulated disk may make,
+// from the SG_IO requests to the decoded attributes. It leaves a little room for the sysfs lookups,
+// which depend on the host.
+const maxDiskInfoAllocs = 110
+
+func TestGetDiskInfoAllocs(t *testing.T) {
+	dev := detectFake(t)
+	defer dev.Close()
+
+	allocs := testing.AllocsPerRun(20, func() { _, _ = dev.GetDiskInfo() })
+	if allocs > maxDiskInfoAllocs {
+		t.Errorf("GetDiskInfo: %v allocations per call, want at most %v", allocs, maxDiskInfoAllocs)
+	}
+}
+
+func BenchmarkGetDiskInfo(b *testing.B) {
+	dev := detectFake(b)
+	defer dev.Close()
+	b.ReportAllocs()
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		if _, err := dev.GetDiskInfo(); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkGetSMARTAttributes(b *testing.B) {
+	dev := detectFake(b)
+	defer dev.Close()
+
+	sata, ok := dev.(*SATA)
+	if !ok {
+		b.Fatalf("fake disk detected as %T, want *SATA", dev)
+	}
+	b.ReportAllocs()
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		if _, err := sata.GetSMARTAttributes(); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
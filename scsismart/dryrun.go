@@ -0,0 +1,142 @@
+/*
+Copyright 2018 The OpenEBS Authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Dry run of the commands changing the state of devices.
+
+package scsismart
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/openebs/smart/atasmart"
+)
+
+// scsiEffects are the effects of the SCSI commands changing the state of a device
+var scsiEffects = map[uint8]string{
+	SCSIFormatUnit:     "formats the medium, erasing all its data",
+	SCSIModeSelect6:    "changes mode page settings",
+	0x55:               "changes mode page settings", // MODE SELECT(10)
+	SCSIStartStopUnit:  "changes the power condition, e.g. spins the medium down or up",
+	SCSISendDiagnostic: "starts a self-test",
+	SCSISanitize:       "sanitizes the medium, erasing all its data",
+	0x4c:               "resets or changes log parameters",            // LOG SELECT
+	0x0a:               "writes to the medium",                        // WRITE(6)
+	0x2a:               "writes to the medium",                        // WRITE(10)
+	0x2e:               "writes to the medium",                        // WRITE AND VERIFY(10)
+	0x3b:               "writes the buffer, e.g. downloads microcode", // WRITE BUFFER
+	0x41:               "writes to the medium",                        // WRITE SAME(10)
+	0x42:               "unmaps blocks, discarding their data",        // UNMAP
+	0x8a:               "writes to the medium",                        // WRITE(16)
+	0x93:               "writes to the medium",                        // WRITE SAME(16)
+	0xaa:               "writes to the medium",                        // WRITE(12)
+	0xb5:               "changes security settings",                   // SECURITY PROTOCOL OUT
+}
+
+// ataEffects are the effects of the ATA commands changing the state of a device
+var ataEffects = map[uint8]string{
+	0x06:                          "trims blocks, discarding their data", // DATA SET MANAGEMENT
+	0x30:                          "writes to the medium",                // WRITE SECTORS
+	0x34:                          "writes to the medium",                // WRITE SECTORS EXT
+	0x35:                          "writes to the medium",                // WRITE DMA EXT
+	0x5e:                          "changes security settings",           // TRUSTED SEND
+	0x92:                          "downloads microcode",                 // DOWNLOAD MICROCODE
+	0xca:                          "writes to the medium",                // WRITE DMA
+	atasmart.AtaSanitizeDevice:    "sanitizes the medium, erasing all its data",
+	atasmart.AtaStandbyImmediate:  "spins the medium down",
+	atasmart.AtaIdleImmediate:     "spins the medium up",
+	atasmart.AtaStandby:           "spins the medium down and sets the standby timer",
+	atasmart.AtaIdle:              "sets the standby timer",
+	atasmart.AtaSetFeatures:       "changes a device feature",
+	0xf1:                          "sets a security password", // SECURITY SET PASSWORD
+	atasmart.AtaSecurityEraseUnit: "erases all the data of the device",
+}
+
+// smartEffects are the effects of the SMART subcommands changing the state of a device
+var smartEffects = map[uint8]string{
+	0xd8:                   "enables SMART",  // SMART ENABLE OPERATIONS
+	0xd9:                   "disables SMART", // SMART DISABLE OPERATIONS
+	atasmart.SmartWriteLog: "writes a SMART log",
+}
+
+// selfTestEffects are the effects of the SMART EXECUTE OFF-LINE IMMEDIATE subcommands
+var selfTestEffects = map[uint8]string{
+	atasmart.SelfTestShort:      "starts a short self-test",
+	atasmart.SelfTestExtended:   "starts an extended self-test",
+	atasmart.SelfTestConveyance: "starts a conveyance self-test",
+	atasmart.SelfTestAbort:      "aborts the running self-test",
+}
+
+// CommandEffect returns the effect of a command on the state of a device, or false if it is one
+// of the commands which only read from the device, see ReadOnly.
+func CommandEffect(req SGRequest) (string, bool) {
+	if ReadOnly(req) {
+		return "", false
+	}
+
+	cdb := req.CDB
+	command, features, lbaLow, ok := ataPassThruRegisters(cdb)
+	switch {
+	case ok && cdb[1]>>1&0x0f <= ATAProtocolSRST:
+		return "resets the device", true
+	case !ok:
+		if effect, ok := scsiEffects[cdb[0]]; ok {
+			return effect, true
+		}
+	case command != atasmart.AtaSmart:
+		if effect, ok := ataEffects[command]; ok {
+			return effect, true
+		}
+	case features == atasmart.SmartExecuteOfflineImmed:
+		if effect, ok := selfTestEffects[lbaLow]; ok {
+			return effect, true
+		}
+		return "starts off-line data collection or a self-test", true
+	default:
+		if effect, ok := smartEffects[features]; ok {
+			return effect, true
+		}
+	}
+
+	return "may change the state of the device", true
+}
+
+// DryRunExecutor sends the commands which only read from the devices through another executor
+// and reports any other command instead of sending it, so that the operations of a program can
+// be reviewed before they are run for real.
+type DryRunExecutor struct {
+	Executor Executor  // executor of the read-only commands, LocalExecutor if nil
+	Output   io.Writer // where the commands not sent are reported
+}
+
+// ExecuteSG executes the request if it only reads from the device, and reports it otherwise.
+// The requests not sent complete successfully without data. Which requests are sent is
+// decided by ReadOnly, from the opcode, ATA protocol and data direction of the request, so a
+// dry run is only as safe as that gate.
+func (e *DryRunExecutor) ExecuteSG(req SGRequest) (SGResponse, error) {
+	if len(req.CDB) == 0 {
+		return SGResponse{}, fmt.Errorf("empty CDB")
+	}
+
+	effect, ok := CommandEffect(req)
+	if !ok {
+		executor := e.Executor
+		if executor == nil {
+			executor = LocalExecutor{}
+		}
+		return executor.ExecuteSG(req)
+	}
+
+	fmt.Fprintf(e.Output, "dry run: %s: %s [% x]: %s, not sent\n", req.Device, CDBName(req.CDB), req.CDB, effect)
+	return SGResponse{Info: SGInfoOk, Data: make([]byte, req.DataLen)}, nil
+}
@@ -0,0 +1,42 @@
+/*
+Copyright 2018 The OpenEBS Authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Extended Power Conditions (EPC) reporting of SATA devices.
+
+package scsismart
+
+import (
+	"fmt"
+
+	"github.com/openebs/smart/atasmart"
+)
+
+// PowerConditions returns the EPC power conditions of a SATA device with their timer settings,
+// read from the Power Conditions log
+func (d *SATA) PowerConditions() ([]atasmart.PowerCondition, error) {
+	identifyBuf, err := d.AtaIdentify()
+	if err != nil {
+		return nil, err
+	}
+
+	if !identifyBuf.EPCSupported() {
+		return nil, fmt.Errorf("Extended Power Conditions not supported by %s", d.Name)
+	}
+
+	logBuf, err := d.ReadLogExt(atasmart.PowerConditionsLog, 0, 2)
+	if err != nil {
+		return nil, err
+	}
+
+	return atasmart.ParsePowerConditions(logBuf)
+}
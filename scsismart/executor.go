@@ -0,0 +1,153 @@
+/*
+Copyright 2018 The OpenEBS Authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Execution of SG_IO requests by another process, such as a privileged helper.
+
+package scsismart
+
+import (
+	"fmt"
+	"sync"
+)
+
+// SGRequest is an SG_IO request for a device, as forwarded to an Executor
+type SGRequest struct {
+	Device    string // device path, e.g. /dev/sda
+	CDB       []byte
+	Direction int32  // one of the SGDxfer values
+	Data      []byte // data sent to the device for SGDxferToDev, nil otherwise
+	DataLen   int    // length of the data transfer
+	Timeout   uint32 // millisecs
+}
+
+// MaxDataLen is the largest data transfer a LocalExecutor accepts
+const MaxDataLen = 1 << 20
+
+// SGResponse is the outcome of an SG_IO request
+type SGResponse struct {
+	Data         []byte // data received from the device for SGDxferFromDev
+	Info         uint32 // SG_IO info, SGInfoOk if the command succeeded
+	Status       uint8  // SCSI status
+	HostStatus   uint16
+	DriverStatus uint16
+	Sense        []byte
+}
+
+// Executor executes SG_IO requests on behalf of a process, typically in a privileged helper
+// process so that the process itself needs no CAP_SYS_RAWIO
+type Executor interface {
+	ExecuteSG(req SGRequest) (SGResponse, error)
+}
+
+var (
+	executorMu      sync.RWMutex
+	defaultExecutor Executor
+)
+
+// SetExecutor sets the executor of the SG_IO requests of the devices opened without an
+// explicit DetectOptions.Executor. A nil executor restores direct device access.
+func SetExecutor(e Executor) {
+	executorMu.Lock()
+	defer executorMu.Unlock()
+
+	defaultExecutor = e
+}
+
+// CurrentExecutor returns the executor set by SetExecutor, nil for direct device access
+func CurrentExecutor() Executor {
+	return currentExecutor()
+}
+
+// currentExecutor returns the executor set by SetExecutor, nil for direct device access
+func currentExecutor() Executor {
+	executorMu.RLock()
+	defer executorMu.RUnlock()
+
+	return defaultExecutor
+}
+
+// execRemote sends a CDB through the executor of the device
+func (d *SCSIDevice) execRemote(cdb []byte, direction int32, dataBuf *[]byte, timeout uint32) error {
+	req := SGRequest{
+		Device:    d.Name,
+		CDB:       cdb,
+		Direction: direction,
+		DataLen:   len(*dataBuf),
+		Timeout:   timeout,
+	}
+	if direction == SGDxferToDev {
+		req.Data = *dataBuf
+	}
+
+	resp, err := d.executor.ExecuteSG(req)
+	if err != nil {
+		return fmt.Errorf("%s: %w", CDBName(cdb), err)
+	}
+	if direction == SGDxferFromDev {
+		copy(*dataBuf, resp.Data)
+	}
+
+	if resp.Info&SGInfoOkMask != SGInfoOk {
+		e := sgIOErr{
+			command:      CDBName(cdb),
+			scsiStatus:   resp.Status,
+			hostStatus:   resp.HostStatus,
+			driverStatus: resp.DriverStatus,
+		}
+		copy(e.senseBuf[:], resp.Sense)
+		return e
+	}
+
+	return nil
+}
+
+// LocalExecutor executes SG_IO requests by opening the devices itself. It is what a privileged
+// helper runs the requests it receives with.
+type LocalExecutor struct{}
+
+// ExecuteSG opens the device of a request, sends its CDB and closes the device
+func (LocalExecutor) ExecuteSG(req SGRequest) (SGResponse, error) {
+	var resp SGResponse
+
+	if len(req.CDB) == 0 || len(req.CDB) > 16 {
+		return resp, fmt.Errorf("invalid CDB length %d", len(req.CDB))
+	}
+
+	if req.DataLen < 0 || req.DataLen > MaxDataLen {
+		return resp, fmt.Errorf("invalid data length %d", req.DataLen)
+	}
+
+	d := SCSIDevice{Name: req.Device, executor: LocalExecutor{}}
+	if err := d.Open(); err != nil {
+		return resp, err
+	}
+	defer d.Close()
+
+	data := make([]byte, req.DataLen)
+	copy(data, req.Data)
+
+	err := d.sendSG(req.CDB, req.Direction, &data, req.Timeout)
+	if e, ok := err.(sgIOErr); ok {
+		resp.Info = SGInfoOkMask
+		resp.Status, resp.HostStatus, resp.DriverStatus = e.scsiStatus, e.hostStatus, e.driverStatus
+		resp.Sense = e.senseBuf[:]
+	} else if err != nil {
+		return resp, err
+	}
+
+	if req.Direction == SGDxferFromDev {
+		resp.Data = data
+	}
+
+	return resp, nil
+}
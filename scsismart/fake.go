@@ -0,0 +1,291 @@
+/*
+Copyright 2018 The OpenEBS Authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Emulated SATA disk for exercising the library without hardware.
+
+package scsismart
+
+import (
+	"encoding/binary"
+	"fmt"
+	"math/rand"
+	"sync"
+	"time"
+
+	"github.com/openebs/smart/atasmart"
+)
+
+// FakeExecutor is an Executor emulating a SATA disk behind a SCSI/ATA translation layer. It
+// answers INQUIRY, READ CAPACITY, LOG SENSE of the supported pages and the ATA IDENTIFY,
+// SMART READ DATA, SMART READ THRESHOLDS and READ LOG EXT pass-through commands, and rejects
+// the other commands as a device would. It serves every device name it is asked for.
+type FakeExecutor struct {
+	Model    string
+	Serial   string
+	Firmware string
+	Blocks   uint64 // number of 512-byte logical blocks
+
+	// ErrorRate is the probability of a command failing with a transport error, to exercise
+	// the error paths of the callers
+	ErrorRate float64
+
+	// Latency is the time each command takes
+	Latency time.Duration
+
+	once  sync.Once
+	start time.Time
+	mu    sync.Mutex
+	rand  *rand.Rand
+}
+
+// fakeAttr is a SMART attribute of the emulated disk
+type fakeAttr struct {
+	id, value, threshold uint8
+	raw                  uint64
+}
+
+// fakeAttrs are the SMART attributes of the emulated disk, the raw value of the power on hours
+// is replaced by the time the executor has been in use
+var fakeAttrs = []fakeAttr{
+	{id: 1, value: 100, threshold: 6},
+	{id: 5, value: 100, threshold: 10},
+	{id: 9, value: 99},
+	{id: 12, value: 100, threshold: 20, raw: 42},
+	{id: 194, value: 65, raw: 35},
+	{id: 197, value: 100},
+	{id: 198, value: 100},
+	{id: 199, value: 200},
+}
+
+// NewFakeExecutor returns a FakeExecutor emulating a 1 TB disk
+func NewFakeExecutor() *FakeExecutor {
+	return &FakeExecutor{
+		Model:    "FAKE SATA DISK",
+		Serial:   "FAKE0000000001",
+		Firmware: "FAKE01",
+		Blocks:   1953525168,
+	}
+}
+
+// ExecuteSG answers a request as the emulated disk would
+func (f *FakeExecutor) ExecuteSG(req SGRequest) (SGResponse, error) {
+	f.once.Do(func() {
+		f.start = time.Now()
+		f.rand = rand.New(rand.NewSource(f.start.UnixNano()))
+	})
+
+	var resp SGResponse
+	if len(req.CDB) == 0 {
+		return resp, fmt.Errorf("invalid CDB length 0")
+	}
+
+	if f.Latency > 0 {
+		time.Sleep(f.Latency)
+	}
+
+	f.mu.Lock()
+	fail := f.ErrorRate > 0 && f.rand.Float64() < f.ErrorRate
+	f.mu.Unlock()
+	if fail {
+		resp.Info = SGInfoOkMask
+		resp.HostStatus = DIDError
+		return resp, nil
+	}
+
+	if len(req.CDB) == 16 && req.CDB[0] == SCSIATAPassThru16 &&
+		req.CDB[14] == atasmart.AtaSmart && req.CDB[4] == atasmart.SmartReturnStatus {
+		// the ATA registers come back in the sense data, see smartStatusSense
+		resp.Info = SGInfoOkMask
+		resp.Status = SCSIStatusCheckCondition
+		resp.Sense = smartStatusSense()
+		return resp, nil
+	}
+
+	data, ok := f.answer(req.CDB)
+	if !ok {
+		// CHECK CONDITION, ILLEGAL REQUEST, INVALID COMMAND OPERATION CODE
+		resp.Info = SGInfoOkMask
+		resp.Status = SCSIStatusCheckCondition
+		resp.Sense = []byte{0x70, 0, 0x05, 0, 0, 0, 0, 10, 0, 0, 0, 0, 0x20, 0x00}
+		return resp, nil
+	}
+
+	if req.Direction == SGDxferFromDev {
+		resp.Data = make([]byte, req.DataLen)
+		copy(resp.Data, data)
+	}
+
+	return resp, nil
+}
+
+// answer returns the data the emulated disk returns for a CDB, false if it rejects the CDB
+func (f *FakeExecutor) answer(cdb []byte) ([]byte, bool) {
+	switch {
+	case cdb[0] == SCSIInquiry && len(cdb) == 6 && cdb[1]&0x01 == 0:
+		return f.inquiry(), true
+	case cdb[0] == SCSIReadCapacity10 && len(cdb) == 10:
+		data := make([]byte, 8)
+		last := f.Blocks - 1
+		if last > 0xffffffff {
+			last = 0xffffffff
+		}
+		binary.BigEndian.PutUint32(data[0:], uint32(last))
+		binary.BigEndian.PutUint32(data[4:], 512)
+		return data, true
+	case cdb[0] == SCSIServiceAction && len(cdb) == 16 && cdb[1]&0x1f == ReadCapacity16ServiceAction:
+		data := make([]byte, 32)
+		binary.BigEndian.PutUint64(data[0:], f.Blocks-1)
+		binary.BigEndian.PutUint32(data[8:], 512)
+		return data, true
+	case cdb[0] == SCSILogSense && len(cdb) == 10:
+		return f.logPage(cdb[2] & 0x3f)
+	case cdb[0] == SCSIATAPassThru16 && len(cdb) == 16:
+		return f.ata(cdb[14], cdb[4], cdb[8])
+	}
+
+	return nil, false
+}
+
+// inquiry returns the standard INQUIRY data of the emulated disk, of an ATA device behind a
+// SCSI/ATA translation layer
+func (f *FakeExecutor) inquiry() []byte {
+	data := make([]byte, INQRespExtendedLen)
+	data[2] = 0x06 // SPC-4
+	data[3] = 0x02 // response data format
+	data[4] = INQRespExtendedLen - 5
+	copy(data[8:], fmt.Sprintf("%-8s%-16.16s%-4.4s", "ATA", f.Model, f.Firmware))
+	return data
+}
+
+// logPage returns a LOG SENSE page of the emulated disk, which supports the temperature page
+func (f *FakeExecutor) logPage(pageNo uint8) ([]byte, bool) {
+	switch pageNo {
+	case SupportedLogPagesPage:
+		return []byte{SupportedLogPagesPage, 0, 0, 2, SupportedLogPagesPage, TemperatureLogPage}, true
+	case TemperatureLogPage:
+		return []byte{TemperatureLogPage, 0, 0, 12, 0, 0, 0x03, 2, 0, 35, 0, 1, 0x03, 2, 0, 60}, true
+	}
+
+	return nil, false
+}
+
+// ata returns the data returned by an ATA command of the emulated disk
+func (f *FakeExecutor) ata(command, features, lbaLow uint8) ([]byte, bool) {
+	switch {
+	case command == atasmart.AtaIdentifyDevice:
+		return f.identify(), true
+	case command == atasmart.AtaSmart && features == atasmart.SmartReadData:
+		return f.smartData(), true
+	case command == atasmart.AtaSmart && features == atasmart.SmartReadThresholds:
+		return f.smartThresholds(), true
+	case command == atasmart.AtaReadLogExt && lbaLow == 0:
+		// General purpose log directory, version 1 and no logs
+		data := make([]byte, atasmart.SectorSize)
+		data[0] = 0x01
+		return data, true
+	}
+
+	return nil, false
+}
+
+// smartStatusSense returns the sense data of a SMART RETURN STATUS of the emulated disk, whose
+// thresholds are not exceeded, with the ATA registers in an ATA Status Return descriptor
+func smartStatusSense() []byte {
+	sense := make([]byte, 22)
+	sense[0], sense[1], sense[3] = 0x72, 0x01, 0x1d // RECOVERED ERROR, ATA PASS-THROUGH INFORMATION AVAILABLE
+	sense[7] = 14
+
+	desc := sense[8:]
+	desc[0], desc[1] = 0x09, 0x0c
+	desc[9], desc[11] = atasmart.SmartLBAMid, atasmart.SmartLBAHigh
+	desc[13] = 0x50 // DRDY, DSC
+
+	return sense
+}
+
+// ataString encodes an ATA IDENTIFY string, padded with spaces and byte swapped
+func ataString(dst []byte, s string) {
+	padded := []byte(fmt.Sprintf("%-*.*s", len(dst), len(dst), s))
+	for i := 0; i+1 < len(padded); i += 2 {
+		dst[i], dst[i+1] = padded[i+1], padded[i]
+	}
+}
+
+// identify returns the ATA IDENTIFY DEVICE data of the emulated disk
+func (f *FakeExecutor) identify() []byte {
+	data := make([]byte, atasmart.SectorSize)
+	word := func(n int, v uint16) { binary.LittleEndian.PutUint16(data[2*n:], v) }
+
+	ataString(data[20:40], f.Serial)
+	ataString(data[46:54], f.Firmware)
+	ataString(data[54:94], f.Model)
+	word(80, 0x07f0)                                    // ATA8-ACS to ACS-3
+	word(82, 0x0001)                                    // SMART feature set supported
+	word(83, 0x4400)                                    // 48-bit addressing
+	word(84, 0x4000)                                    // valid
+	word(85, 0x0001)                                    // SMART enabled
+	word(86, 0x0400)                                    // 48-bit addressing enabled
+	word(87, 0x4000)                                    // valid
+	word(217, 7200)                                     // nominal rotation rate
+	word(222, 0x1000|0x0040)                            // Serial ATA, SATA 3.1
+	binary.LittleEndian.PutUint64(data[200:], f.Blocks) // words 100..103
+
+	return data
+}
+
+// checksum sets the last byte of a SMART data structure so that its bytes add up to 0
+func checksum(data []byte) {
+	var sum uint8
+	for _, b := range data[:atasmart.SectorSize-1] {
+		sum += b
+	}
+	data[atasmart.SectorSize-1] = -sum
+}
+
+// smartData returns the SMART READ DATA page of the emulated disk
+func (f *FakeExecutor) smartData() []byte {
+	data := make([]byte, atasmart.SectorSize)
+	binary.LittleEndian.PutUint16(data[0:], 0x0010)
+
+	for i, a := range fakeAttrs {
+		entry := data[2+12*i:]
+		entry[0] = a.id
+		binary.LittleEndian.PutUint16(entry[1:], 0x0033)
+		entry[3], entry[4] = a.value, a.value
+
+		raw := a.raw
+		if a.id == 9 {
+			raw = uint64(time.Since(f.start) / time.Hour)
+		}
+		for j := 0; j < 6; j++ {
+			entry[5+j] = uint8(raw >> (8 * j))
+		}
+	}
+	checksum(data)
+
+	return data
+}
+
+// smartThresholds returns the SMART READ THRESHOLDS page of the emulated disk
+func (f *FakeExecutor) smartThresholds() []byte {
+	data := make([]byte, atasmart.SectorSize)
+	binary.LittleEndian.PutUint16(data[0:], 0x0010)
+
+	for i, a := range fakeAttrs {
+		data[2+12*i] = a.id
+		data[3+12*i] = a.threshold
+	}
+	checksum(data)
+
+	return data
+}
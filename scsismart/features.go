@@ -0,0 +1,105 @@
+/*
+Copyright 2018 The OpenEBS Authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Discovery of the optional features supported by a device.
+
+package scsismart
+
+// Capabilities reports the optional features supported by a device, so that callers can skip
+// the commands that would fail on it
+type Capabilities struct {
+	SMART        bool // SMART feature set (informational exceptions for SCSI devices)
+	SMARTEnabled bool
+	GPL          bool // General Purpose Logging feature set
+	SCT          bool // SCT Command Transport
+	TRIM         bool // DATA SET MANAGEMENT TRIM, or UNMAP for SCSI devices
+	SelfTest     bool
+	Security     bool // Security feature set
+	SecurityOn   bool // Security feature set enabled (user password set)
+	Sanitize     bool
+	Zoned        bool // host-aware, host-managed or device-managed zoned device
+	NCQ          bool // Native Command Queuing
+	EPC          bool // Extended Power Conditions
+	Trusted      bool // Trusted Computing feature set, or SECURITY PROTOCOL IN for SCSI devices
+}
+
+// Capabilities returns the optional features supported by a SATA device, as reported by ATA
+// IDENTIFY DEVICE
+func (d *SATA) Capabilities() (Capabilities, error) {
+	identifyBuf, err := d.AtaIdentify()
+	if err != nil {
+		return Capabilities{}, err
+	}
+
+	// Word 48 is only valid when its bits 15:14 are 01b, word 76 when it is neither 0 nor ffffh
+	word76Valid := identifyBuf.Word76 != 0 && identifyBuf.Word76 != 0xffff
+	word48Valid := identifyBuf.Word48&0xc000 == 0x4000
+	features := identifyBuf.Features()
+
+	return Capabilities{
+		SMART:        features.SMART.Supported,
+		SMARTEnabled: features.SMART.Enabled,
+		GPL:          features.GPL.Supported,
+		SCT:          identifyBuf.Word206&0x0001 != 0,
+		TRIM:         identifyBuf.Word169&0x0001 != 0,
+		SelfTest:     features.SMARTSelfTest.Supported,
+		Security:     features.Security.Supported,
+		SecurityOn:   identifyBuf.Word128&0x0002 != 0,
+		Sanitize:     identifyBuf.Word59&0x1000 != 0,
+		Zoned:        identifyBuf.Word69&0x0003 != 0,
+		NCQ:          word76Valid && identifyBuf.Word76&0x0100 != 0,
+		EPC:          features.EPC.Supported,
+		Trusted:      word48Valid && identifyBuf.Word48&0x0001 != 0,
+	}, nil
+}
+
+// Capabilities returns the optional features supported by a SCSI device, as reported by its
+// supported log pages and its logical block provisioning and block device characteristics
+// VPD pages. Features which are only defined for ATA devices are never reported.
+func (d *SCSIDevice) Capabilities() (Capabilities, error) {
+	var caps Capabilities
+
+	inqResp, err := d.SCSIInquiry()
+	if err != nil {
+		return caps, err
+	}
+
+	if pages, err := d.logSense(SupportedLogPagesPage, 0); err == nil {
+		for _, page := range pages[4:] {
+			switch page & 0x3f {
+			case InformationalExceptionsLogPage:
+				caps.SMART = true
+				caps.SMARTEnabled = true
+			case SelfTestResultsLogPage:
+				caps.SelfTest = true
+			}
+		}
+	}
+
+	// LBPU bit of the Logical Block Provisioning VPD page
+	if page, err := d.inquiryVPD(VPDLogicalBlockProvisioning); err == nil && len(page) > 5 {
+		caps.TRIM = page[5]&0x80 != 0
+	}
+
+	// SECURITY PROTOCOL IN for the security protocol information fails if it is not supported
+	caps.Trusted = d.securityProtocolIn(SecurityProtocolInfo, 0, make([]byte, 512)) == nil
+
+	// Host-managed zoned block devices have their own peripheral device type, host-aware and
+	// device-managed ones report the ZONED field of the Block Device Characteristics VPD page
+	caps.Zoned = inqResp.PeripheralDeviceType() == PeripheralZoned
+	if page, err := d.inquiryVPD(VPDBlockDeviceCharacteristics); err == nil && len(page) > 8 {
+		caps.Zoned = caps.Zoned || page[8]&0x30 != 0
+	}
+
+	return caps, nil
+}
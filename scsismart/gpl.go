@@ -0,0 +1,42 @@
+/*
+Copyright 2018 The OpenEBS Authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// General Purpose Logging (GPL) log reads of SATA devices.
+
+package scsismart
+
+import (
+	"fmt"
+
+	"github.com/openebs/smart/atasmart"
+)
+
+// ReadLogExt sends an ATA READ LOG EXT command via SCSI_ATA_PASSTHRU_16 and returns count
+// 512-byte pages of a General Purpose Logging log, starting at the given page
+func (d *SATA) ReadLogExt(logAddr uint8, page, count uint16) ([]byte, error) {
+	responseBuf := make([]byte, int(count)*atasmart.SectorSize)
+
+	// The log address goes in LBA 7:0 and the page number in LBA 15:8 and 47:40
+	lba := uint64(logAddr) | uint64(page&0xff)<<8 | uint64(page>>8)<<40
+
+	cdb16 := CDB16{SCSIATAPassThru16}
+	cdb16.SetATAProtocol(ATAProtocolPIODataIn, true)
+	cdb16.SetATATransfer(true, true, ATATLengthSectorCount)
+	cdb16.SetATARegisters(atasmart.AtaReadLogExt, 0, count, lba, 0)
+
+	if err := d.sendCDB(cdb16[:], &responseBuf); err != nil {
+		return nil, fmt.Errorf("sendCDB READ LOG EXT %#02x: %w", logAddr, err)
+	}
+
+	return responseBuf, nil
+}
@@ -0,0 +1,317 @@
+/*
+Copyright 2018 The OpenEBS Authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Overall health of the devices, from the health information of their protocol.
+
+package scsismart
+
+import (
+	"fmt"
+
+	"github.com/openebs/smart/atasmart"
+	"github.com/openebs/smart/mmc"
+	"github.com/openebs/smart/nvme"
+)
+
+// HealthState is the overall health of a device
+type HealthState int
+
+// Health states, from the best to the worst
+const (
+	HealthUnknown HealthState = iota // the device reports no health information
+	HealthOK
+	HealthWarning
+	HealthFailing
+)
+
+var healthStateNames = map[HealthState]string{
+	HealthUnknown: "Unknown",
+	HealthOK:      "OK",
+	HealthWarning: "Warning",
+	HealthFailing: "Failing",
+}
+
+// String returns the name of the health state
+func (s HealthState) String() string {
+	if name, ok := healthStateNames[s]; ok {
+		return name
+	}
+
+	return fmt.Sprintf("HealthState(%d)", int(s))
+}
+
+// HealthStatus is the overall health of a device with the reasons it is not OK
+type HealthStatus struct {
+	State   HealthState
+	Reasons []string
+}
+
+// degrade lowers the health state, never raising it, and records the reason
+func (h *HealthStatus) degrade(state HealthState, format string, args ...interface{}) {
+	if state > h.State {
+		h.State = state
+	}
+	h.Reasons = append(h.Reasons, fmt.Sprintf(format, args...))
+}
+
+// LifeUsedWarning is the percent of the rated endurance used from which a device is worn out
+const LifeUsedWarning = 90
+
+// SCSIHealthStatus returns the health of a SCSI device from its informational exceptions
+func SCSIHealthStatus(ie InformationalExceptions) HealthStatus {
+	status := HealthStatus{State: HealthOK}
+	if ie.FailurePredicted() {
+		status.degrade(HealthFailing, "failure predicted, ASC %#02x ASCQ %#02x", ie.ASC, ie.ASCQ)
+	} else if ie.ASC != 0 {
+		status.degrade(HealthWarning, "informational exception, ASC %#02x ASCQ %#02x", ie.ASC, ie.ASCQ)
+	}
+
+	return status
+}
+
+// HealthStatus returns the health of a SCSI device from its informational exceptions
+func (d *SCSIDevice) HealthStatus() (HealthStatus, error) {
+	ie, err := d.InformationalExceptions()
+	if err != nil {
+		return HealthStatus{}, err
+	}
+
+	return SCSIHealthStatus(ie), nil
+}
+
+// ataReturnRegisters returns the LBA mid and high registers of the ATA Status Return descriptor
+// of descriptor format sense data, or of the fixed format sense data of SAT reporting ATA
+// PASS-THROUGH INFORMATION AVAILABLE
+func ataReturnRegisters(sense []byte) (uint8, uint8, bool) {
+	switch sense[0] & 0x7f {
+	case 0x72, 0x73: // descriptor format
+		end := 8 + int(sense[7])
+		if end > len(sense) {
+			end = len(sense)
+		}
+		for off := 8; off+2 <= end; off += 2 + int(sense[off+1]) {
+			if sense[off] == 0x09 && off+14 <= end { // ATA Status Return
+				return sense[off+9], sense[off+11], true
+			}
+		}
+	case 0x70, 0x71: // fixed format
+		if sense[12] == 0x00 && sense[13] == 0x1d {
+			return sense[10], sense[11], true
+		}
+	}
+
+	return 0, 0, false
+}
+
+// smartReturnStatus sends an ATA SMART RETURN STATUS command and reports whether the device
+// exceeded the threshold of an attribute, which it tells in the LBA mid and high registers
+func (d *SATA) smartReturnStatus() (bool, error) {
+	cdb16 := CDB16{SCSIATAPassThru16}
+	cdb16.SetATAProtocol(ATAProtocolNonData, false)
+	cdb16.SetATATransfer(false, false, ATATLengthNone)
+	cdb16.SetATACheckCondition()
+	cdb16.SetATARegisters(atasmart.AtaSmart, atasmart.SmartReturnStatus, 0, smartLBA, 0)
+
+	// The registers come back in the sense data of a CHECK CONDITION, RECOVERED ERROR
+	var noData []byte
+	err := d.sendCDBDirection(cdb16[:], SGDxferNone, &noData)
+	e, ok := err.(sgIOErr)
+	if !ok {
+		if err == nil {
+			err = fmt.Errorf("SMART RETURN STATUS returned no ATA registers")
+		}
+		return false, err
+	}
+	mid, high, ok := ataReturnRegisters(e.senseBuf[:])
+	if !ok {
+		return false, err
+	}
+
+	switch {
+	case mid == atasmart.SmartLBAMid && high == atasmart.SmartLBAHigh:
+		return false, nil
+	case mid == atasmart.SmartLBAMidExceeded && high == atasmart.SmartLBAHighExceeded:
+		return true, nil
+	}
+
+	return false, fmt.Errorf("SMART RETURN STATUS returned unknown LBA mid/high %#02x/%#02x", mid, high)
+}
+
+// ATAHealth is what the health of an ATA device is assessed from, along with the identity of
+// the device reported by the same ATA IDENTIFY
+type ATAHealth struct {
+	Model, Serial, Firmware string
+
+	ThresholdExceeded bool                    // SMART RETURN STATUS reports a threshold exceeded
+	Attrs             atasmart.AttrCollection // nil if they could not be read
+	SelfTest          *SelfTestStatus         // last self-test, nil if unknown
+}
+
+// MediaErrors returns the number of pending and uncorrectable sectors of an ATA device
+func (h ATAHealth) MediaErrors() uint64 {
+	pending, _ := h.Attrs.Get(197)
+	uncorrectable, _ := h.Attrs.Get(198)
+
+	return pending.Raw + uncorrectable.Raw
+}
+
+// ReadHealth reads the SMART status, SMART attributes and last self-test of an ATA device, the
+// attributes and self-test from the same SMART READ DATA, and its identity. If some of the commands fail, what
+// the others read is returned along with a MultiError.
+func (d *SATA) ReadHealth() (ATAHealth, error) {
+	var (
+		h    ATAHealth
+		errs MultiError
+	)
+
+	exceeded, statusErr := d.smartReturnStatus()
+	errs.add("SMART RETURN STATUS", statusErr)
+	h.ThresholdExceeded = exceeded
+
+	identifyBuf, err := d.AtaIdentify()
+	if err == nil {
+		id := DiskAttr{
+			ModelNumber:      string(identifyBuf.GetModelNumber()),
+			SerialNumber:     string(identifyBuf.GetSerialNumber()),
+			FirmwareRevision: string(identifyBuf.GetFirmwareRevision()),
+		}
+		applyQuirks(&identifyBuf, &id)
+		h.Model, h.Serial, h.Firmware = id.ModelNumber, id.SerialNumber, id.FirmwareRevision
+
+		var smartBuf atasmart.SmartPage
+		if smartBuf, err = d.ReadSMARTData(); err == nil {
+			var attrsErr error
+			h.Attrs, attrsErr = d.smartAttributes(&identifyBuf, &smartBuf)
+			errs.merge(attrsErr)
+			selfTest := selfTestStatus(&smartBuf)
+			h.SelfTest = &selfTest
+		}
+	}
+	if err != nil {
+		if statusErr != nil {
+			return h, err
+		}
+		errs.add("SMART READ DATA", err)
+	}
+
+	return h, errs.err()
+}
+
+// ATAHealthStatus returns the health of an ATA device from its SMART status, its attributes, its
+// reallocated, pending and uncorrectable sectors and its last self-test
+func ATAHealthStatus(h ATAHealth) HealthStatus {
+	status := HealthStatus{State: HealthOK}
+	if h.ThresholdExceeded {
+		status.degrade(HealthFailing, "SMART status reports a threshold exceeded")
+	}
+	for _, a := range h.Attrs {
+		switch {
+		case a.FailingNow:
+			status.degrade(HealthFailing, "attribute %d %s failing now", a.ID, a.Name)
+		case a.FailedInPast:
+			status.degrade(HealthWarning, "attribute %d %s failed in the past", a.ID, a.Name)
+		}
+	}
+	if reallocated, _ := h.Attrs.Get(5); reallocated.Raw > 0 {
+		status.degrade(HealthWarning, "%d reallocated sectors", reallocated.Raw)
+	}
+	if n := h.MediaErrors(); n > 0 {
+		status.degrade(HealthWarning, "%d pending or uncorrectable sectors", n)
+	}
+	if h.SelfTest != nil && h.SelfTest.Failed() {
+		status.degrade(HealthFailing, "last self-test failed with status %d", h.SelfTest.Result)
+	}
+
+	return status
+}
+
+// HealthStatus returns the health of an ATA device, see ATAHealthStatus. If some of the commands
+// fail, the health assessed from the others is returned along with a MultiError.
+func (d *SATA) HealthStatus() (HealthStatus, error) {
+	h, err := d.ReadHealth()
+	if err != nil && !IsPartial(err) {
+		return HealthStatus{}, err
+	}
+
+	return ATAHealthStatus(h), err
+}
+
+// NVMeHealthStatus returns the health of an NVMe device from its SMART / Health Information log
+func NVMeHealthStatus(info nvme.HealthInfo) HealthStatus {
+	status := HealthStatus{State: HealthOK}
+	switch w := info.CriticalWarning; {
+	case w.ReliabilityDegraded() || w.MediaReadOnly() || w.SpareBelowThreshold():
+		status.degrade(HealthFailing, "critical warnings %s", w)
+	case w != 0:
+		status.degrade(HealthWarning, "critical warnings %s", w)
+	}
+	if info.MediaErrors > 0 {
+		status.degrade(HealthWarning, "%d media errors", info.MediaErrors)
+	}
+	if info.PercentUsed >= LifeUsedWarning {
+		status.degrade(HealthWarning, "%d%% of the rated endurance used", info.PercentUsed)
+	}
+
+	return status
+}
+
+// HealthStatus returns the health of an NVMe device from its SMART / Health Information log
+func (d *NVMeBlk) HealthStatus() (HealthStatus, error) {
+	info, err := d.HealthInfo()
+	if err != nil {
+		return HealthStatus{}, err
+	}
+
+	return NVMeHealthStatus(info), nil
+}
+
+// MMCLifeUsed returns the percent of the rated endurance of an SD card or eMMC device used, the
+// highest of its memory types, -1 if not reported
+func MMCLifeUsed(h mmc.Health) int {
+	if h.LifeUsedB > h.LifeUsed {
+		return h.LifeUsedB
+	}
+	return h.LifeUsed
+}
+
+// MMCHealthStatus returns the health of an SD card or eMMC device from its pre end of life and
+// life time estimates
+func MMCHealthStatus(h mmc.Health) HealthStatus {
+	status := HealthStatus{State: HealthOK}
+	switch h.PreEOL {
+	case mmc.PreEOLUrgent:
+		status.degrade(HealthFailing, "pre end of life %s", h.PreEOL)
+	case mmc.PreEOLWarning:
+		status.degrade(HealthWarning, "pre end of life %s", h.PreEOL)
+	}
+	if used := MMCLifeUsed(h); used >= LifeUsedWarning {
+		status.degrade(HealthWarning, "%d%% of the rated endurance used", used)
+	}
+
+	return status
+}
+
+// HealthStatus returns the health of an SD card or eMMC device from its wear estimates
+func (d *MMCBlk) HealthStatus() (HealthStatus, error) {
+	h, err := d.Health()
+	if err != nil {
+		return HealthStatus{}, err
+	}
+
+	return MMCHealthStatus(h), nil
+}
+
+// HealthStatus returns an unknown health, virtio disks report no health information
+func (d *VirtioBlk) HealthStatus() (HealthStatus, error) {
+	return HealthStatus{State: HealthUnknown}, nil
+}
@@ -0,0 +1,78 @@
+/*
+Copyright 2018 The OpenEBS Authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Kernel identity of a block device (name, devpath, driver, aliases) collected from sysfs.
+
+package scsismart
+
+import (
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/openebs/smart/internal/utilities"
+)
+
+// busType returns the bus a block device is attached through, derived from its sysfs devpath
+func busType(devPath string, sysDevice string) string {
+	switch {
+	case strings.Contains(devPath, "/usb"):
+		return "usb"
+	case strings.Contains(devPath, "/ata"):
+		return "ata"
+	}
+
+	subsystem, err := filepath.EvalSymlinks(filepath.Join(sysDevice, "subsystem"))
+	if err != nil {
+		return ""
+	}
+
+	return filepath.Base(subsystem)
+}
+
+// diskLinks returns the symlinks of a udev directory, such as /dev/disk/by-id, pointing at a
+// device node
+func diskLinks(dir, devNode string) []string {
+	var links []string
+
+	matches, _ := filepath.Glob(filepath.Join(dir, "*"))
+	for _, link := range matches {
+		if target, err := filepath.EvalSymlinks(link); err == nil && target == devNode {
+			links = append(links, link)
+		}
+	}
+	sort.Strings(links)
+
+	return links
+}
+
+// setIdentityAttr fills the kernel identity fields of a DiskAttr from sysfs
+func setIdentityAttr(name string, attr *DiskAttr) {
+	// The device may have been referenced through a symlink such as /dev/disk/by-id/...
+	devNode, err := filepath.EvalSymlinks(name)
+	if err != nil {
+		devNode = name
+	}
+
+	attr.KernelName = filepath.Base(devNode)
+	sysDir := utilities.SysfsBlockDir(devNode)
+
+	if sysPath, err := filepath.EvalSymlinks(sysDir); err == nil {
+		attr.DevPath = strings.TrimPrefix(sysPath, "/sys")
+	}
+	attr.MajorMinor, _ = utilities.ReadSysfs(filepath.Join(sysDir, "dev"))
+	attr.Bus = busType(attr.DevPath, filepath.Join(sysDir, "device"))
+	attr.Driver = utilities.SysfsDriver(devNode)
+	attr.ByIDLinks = diskLinks(utilities.DiskByIDPath, devNode)
+	attr.ByPathLinks = diskLinks(utilities.DiskByPathPath, devNode)
+}
@@ -0,0 +1,65 @@
+/*
+Copyright 2018 The OpenEBS Authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Informational Exceptions log page of SCSI devices, their failure prediction.
+
+package scsismart
+
+import (
+	"encoding/binary"
+	"fmt"
+)
+
+// ascFailurePrediction is the additional sense code of a failure prediction threshold exceeded
+const ascFailurePrediction = 0x5d
+
+// InformationalExceptions is the general parameter of the Informational Exceptions log page
+type InformationalExceptions struct {
+	ASC         uint8 // additional sense code of the last exception, 0 if none
+	ASCQ        uint8
+	Temperature int // most recent temperature in degrees Celsius, -1 if not reported
+}
+
+// FailurePredicted reports whether the device predicts its own failure
+func (ie InformationalExceptions) FailurePredicted() bool {
+	return ie.ASC == ascFailurePrediction
+}
+
+// InformationalExceptions returns the general parameter of the Informational Exceptions log
+// page, the SCSI equivalent of the SMART health status
+func (d *SCSIDevice) InformationalExceptions() (InformationalExceptions, error) {
+	page, err := d.logSense(InformationalExceptionsLogPage, 0)
+	if err != nil {
+		return InformationalExceptions{}, fmt.Errorf("SgExecute LOG SENSE informational exceptions: %w", err)
+	}
+
+	for offset := 4; offset+4 <= len(page); {
+		paramLen := int(page[offset+3])
+		if offset+4+paramLen > len(page) {
+			break
+		}
+
+		// parameter 0000h holds the ASC, ASCQ and most recent temperature
+		if binary.BigEndian.Uint16(page[offset:]) == 0x0000 && paramLen >= 3 {
+			ie := InformationalExceptions{ASC: page[offset+4], ASCQ: page[offset+5], Temperature: -1}
+			if temp := page[offset+6]; temp != 0xff {
+				ie.Temperature = int(temp)
+			}
+			return ie, nil
+		}
+
+		offset += 4 + paramLen
+	}
+
+	return InformationalExceptions{}, fmt.Errorf("device does not report informational exceptions")
+}
@@ -0,0 +1,192 @@
+/*
+Copyright 2018 The OpenEBS Authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Decoding of the standard INQUIRY data.
+
+package scsismart
+
+import "fmt"
+
+// Peripheral qualifiers of the standard INQUIRY data
+const (
+	PeripheralConnected    = 0 // a device of the peripheral device type is connected
+	PeripheralNotConnected = 1 // a device of the type is supported but not connected
+	PeripheralNotSupported = 3 // no device is supported at the logical unit
+)
+
+// Peripheral device types of the standard INQUIRY data
+const (
+	PeripheralDirectAccess = 0x00 // block device, e.g. a disk
+	PeripheralSequential   = 0x01 // tape
+	PeripheralCDDVD        = 0x05
+	PeripheralEnclosure    = 0x0d // SCSI enclosure services
+	PeripheralRBC          = 0x0e // simplified direct access device (Reduced Block Commands)
+	PeripheralZoned        = 0x14 // host managed zoned block device
+)
+
+// peripheralDeviceTypes names the common peripheral device types
+var peripheralDeviceTypes = map[uint8]string{
+	PeripheralDirectAccess: "direct access block device",
+	PeripheralSequential:   "sequential access device",
+	PeripheralCDDVD:        "CD/DVD device",
+	0x07:                   "optical memory device",
+	0x08:                   "media changer",
+	0x0c:                   "storage array controller",
+	PeripheralEnclosure:    "enclosure services device",
+	PeripheralRBC:          "simplified direct access device",
+	0x11:                   "object based storage device",
+	PeripheralZoned:        "host managed zoned block device",
+	0x1e:                   "well known logical unit",
+	0x1f:                   "unknown or no device type",
+}
+
+// versionDescriptors names the standards of the version descriptors, keyed on the first code of
+// the range of each standard. The low 5 bits of a descriptor select the revision.
+var versionDescriptors = map[uint16]string{
+	0x0040: "SAM-2",
+	0x0060: "SAM-3",
+	0x0080: "SAM-4",
+	0x00a0: "SAM-5",
+	0x00c0: "SAM-6",
+	0x0120: "SPC",
+	0x0180: "SBC",
+	0x0260: "SPC-2",
+	0x0300: "SPC-3",
+	0x0320: "SBC-2",
+	0x0460: "SPC-4",
+	0x04c0: "SBC-3",
+	0x05c0: "SPC-5",
+	0x0600: "SBC-4",
+	0x0620: "ZBC",
+	0x0960: "iSCSI",
+	0x0be0: "SAS",
+	0x0c00: "SAS-1.1",
+	0x0c20: "SAS-2",
+	0x0c40: "SAS-2.1",
+	0x0c60: "SAS-3",
+	0x0c80: "SAS-4",
+	0x1ea0: "SAT",
+	0x1ec0: "SAT-2",
+	0x1ee0: "SAT-3",
+	0x1f00: "SAT-4",
+}
+
+// PeripheralQualifier returns the peripheral qualifier, e.g. PeripheralConnected
+func (inquiry InquiryResponse) PeripheralQualifier() uint8 {
+	return inquiry.Peripheral >> 5
+}
+
+// PeripheralDeviceType returns the peripheral device type, e.g. 0 for block devices
+func (inquiry InquiryResponse) PeripheralDeviceType() uint8 {
+	return inquiry.Peripheral & 0x1f
+}
+
+// DeviceTypeString returns the name of the peripheral device type
+func (inquiry InquiryResponse) DeviceTypeString() string {
+	if s, ok := peripheralDeviceTypes[inquiry.PeripheralDeviceType()]; ok {
+		return s
+	}
+
+	return fmt.Sprintf("device type %#02x", inquiry.PeripheralDeviceType())
+}
+
+// IsDisk returns whether a device is connected at the logical unit and is a disk, i.e. a
+// direct access, simplified direct access or host managed zoned block device. The disk
+// commands, e.g. READ CAPACITY or the SMART commands, are only meaningful for disks.
+func (inquiry InquiryResponse) IsDisk() bool {
+	if inquiry.PeripheralQualifier() != PeripheralConnected {
+		return false
+	}
+
+	switch inquiry.PeripheralDeviceType() {
+	case PeripheralDirectAccess, PeripheralRBC, PeripheralZoned:
+		return true
+	}
+
+	return false
+}
+
+// NotDiskError is returned when detecting a device which is not a disk, e.g. a CD/DVD drive,
+// a tape or an enclosure
+type NotDiskError struct {
+	Name    string
+	Inquiry InquiryResponse
+}
+
+func (e NotDiskError) Error() string {
+	if e.Inquiry.PeripheralQualifier() != PeripheralConnected {
+		return fmt.Sprintf("%s: no device connected", e.Name)
+	}
+
+	return fmt.Sprintf("%s is a %s, not a disk", e.Name, e.Inquiry.DeviceTypeString())
+}
+
+// Removable returns whether the medium is removable (RMB)
+func (inquiry InquiryResponse) Removable() bool {
+	return inquiry.Flags1&0x80 != 0
+}
+
+// ResponseDataFormat returns the response data format, 2 for all the current standards
+func (inquiry InquiryResponse) ResponseDataFormat() uint8 {
+	return inquiry.Flags3 & 0x0f
+}
+
+// Protect returns whether the device supports protection information (PROTECT)
+func (inquiry InquiryResponse) Protect() bool {
+	return inquiry.Flags5&0x01 != 0
+}
+
+// ThirdPartyCopy returns whether the device supports third-party copy commands (3PC)
+func (inquiry InquiryResponse) ThirdPartyCopy() bool {
+	return inquiry.Flags5&0x08 != 0
+}
+
+// TPGS returns the target port group support, non-zero if asymmetric logical unit access is
+// supported
+func (inquiry InquiryResponse) TPGS() uint8 {
+	return (inquiry.Flags5 >> 4) & 0x03
+}
+
+// EnclosureServices returns whether the device has an embedded enclosure services component
+// (ENCSERV)
+func (inquiry InquiryResponse) EnclosureServices() bool {
+	return inquiry.Flags6&0x40 != 0
+}
+
+// MultiPort returns whether the device has more than one port (MULTIP)
+func (inquiry InquiryResponse) MultiPort() bool {
+	return inquiry.Flags6&0x10 != 0
+}
+
+// CommandQueuing returns whether the device supports command queuing (CMDQUE)
+func (inquiry InquiryResponse) CommandQueuing() bool {
+	return inquiry.Flags7&0x02 != 0
+}
+
+// Standards returns the names of the standards the device claims conformance to in its version
+// descriptors
+func (inquiry InquiryResponse) Standards() []string {
+	var standards []string
+	for _, desc := range inquiry.VersionDescriptors {
+		if desc == 0 {
+			continue
+		}
+		if s, ok := versionDescriptors[desc&^0x1f]; ok {
+			standards = append(standards, s)
+		} else {
+			standards = append(standards, fmt.Sprintf("%#04x", desc))
+		}
+	}
+
+	return standards
+}
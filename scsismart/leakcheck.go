@@ -0,0 +1,30 @@
+//go:build !smartdebug
+// +build !smartdebug
+
+/*
+Copyright 2018 The OpenEBS Authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Leak tracking of device descriptors, disabled unless built with the smartdebug tag.
+
+package scsismart
+
+// leakTracker tracks whether an opened device is closed, it holds nothing in release builds
+type leakTracker struct{}
+
+// newLeakTracker returns the tracker of a device opened by the caller
+func newLeakTracker(name string) *leakTracker {
+	return nil
+}
+
+// closed records that the tracked device was closed
+func (t *leakTracker) closed() {}
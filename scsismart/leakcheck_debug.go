@@ -0,0 +1,54 @@
+//go:build smartdebug
+// +build smartdebug
+
+/*
+Copyright 2018 The OpenEBS Authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Leak tracking of device descriptors in builds with the smartdebug tag.
+
+package scsismart
+
+import (
+	"log"
+	"runtime"
+	"sync/atomic"
+)
+
+// leakTracker reports the devices which are garbage collected without having been closed,
+// along with the stack which opened them
+type leakTracker struct {
+	name     string
+	stack    string
+	isClosed int32
+}
+
+// newLeakTracker returns the tracker of a device opened by the caller
+func newLeakTracker(name string) *leakTracker {
+	buf := make([]byte, 4096)
+	t := &leakTracker{name: name, stack: string(buf[:runtime.Stack(buf, false)])}
+
+	runtime.SetFinalizer(t, func(t *leakTracker) {
+		if atomic.LoadInt32(&t.isClosed) == 0 {
+			log.Printf("scsismart: %s was never closed, opened at:\n%s", t.name, t.stack)
+		}
+	})
+
+	return t
+}
+
+// closed records that the tracked device was closed
+func (t *leakTracker) closed() {
+	if t != nil {
+		atomic.StoreInt32(&t.isClosed, 1)
+	}
+}
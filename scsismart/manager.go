@@ -0,0 +1,116 @@
+/*
+Copyright 2018 The OpenEBS Authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Device manager owning the open device handles of long-running processes.
+
+package scsismart
+
+import (
+	"fmt"
+	"sync"
+)
+
+// DeviceManager owns the devices opened by a long-running process, so that each device is
+// opened once however many times it is used, and closed when it goes away. The devices it
+// returns are shared and must not be closed by the callers. It is safe for concurrent use.
+type DeviceManager struct {
+	opts DetectOptions
+
+	mu      sync.Mutex
+	devices map[string]Dev // keyed on device path
+	closed  bool
+}
+
+// NewDeviceManager returns a DeviceManager detecting the devices with the given options
+func NewDeviceManager(opts DetectOptions) *DeviceManager {
+	return &DeviceManager{opts: opts, devices: make(map[string]Dev)}
+}
+
+// Get returns the device at a path, detecting and opening it on first use. The detection runs
+// without the lock, so that a slow device does not hold up the others; if two callers detect
+// the same device at once, the first one stored wins and the other handle is closed.
+func (m *DeviceManager) Get(name string) (Dev, error) {
+	m.mu.Lock()
+	closed := m.closed
+	d, ok := m.devices[name]
+	m.mu.Unlock()
+
+	if closed {
+		return nil, fmt.Errorf("device manager is closed")
+	}
+	if ok {
+		return d, nil
+	}
+
+	d, err := Detect(name, m.opts)
+	if err != nil {
+		return nil, err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.closed {
+		d.Close()
+		return nil, fmt.Errorf("device manager is closed")
+	}
+	if stored, ok := m.devices[name]; ok {
+		d.Close()
+		return stored, nil
+	}
+	m.devices[name] = d
+
+	return d, nil
+}
+
+// Forget closes the device at a path, e.g. once it was removed or failed, so that the next
+// Get opens it again
+func (m *DeviceManager) Forget(name string) error {
+	m.mu.Lock()
+	d, ok := m.devices[name]
+	delete(m.devices, name)
+	m.mu.Unlock()
+
+	if !ok {
+		return nil
+	}
+
+	return d.Close()
+}
+
+// Len returns the number of devices held open
+func (m *DeviceManager) Len() int {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	return len(m.devices)
+}
+
+// Close closes all the devices, returning the first error met. The manager cannot be used
+// afterwards.
+func (m *DeviceManager) Close() error {
+	m.mu.Lock()
+	devices := m.devices
+	m.devices = make(map[string]Dev)
+	m.closed = true
+	m.mu.Unlock()
+
+	var firstErr error
+	for name, d := range devices {
+		if err := d.Close(); err != nil && firstErr == nil {
+			firstErr = fmt.Errorf("close %s: %w", name, err)
+		}
+	}
+
+	return firstErr
+}
@@ -0,0 +1,283 @@
+/*
+Copyright 2018 The OpenEBS Authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Pass-through to the physical drives behind MegaRAID controllers.
+
+package scsismart
+
+import (
+	"encoding/binary"
+	"fmt"
+	"strconv"
+	"strings"
+	"unsafe"
+
+	"golang.org/x/sys/unix"
+
+	"github.com/openebs/smart/internal/ioctl"
+)
+
+// MegaRAIDIoctlNode is the management node of the megaraid_sas driver
+const MegaRAIDIoctlNode = "/dev/megaraid_sas_ioctl_node"
+
+// MegaRAID firmware interface (MFI) definitions, see drivers/scsi/megaraid/megaraid_sas.h
+const (
+	mfiCmdPDSCSIIO = 0x04 // SCSI command to a physical drive
+	mfiCmdDCMD     = 0x05 // direct controller command
+
+	mfiFrameDirWrite = 0x0008
+	mfiFrameDirRead  = 0x0010
+
+	mfiStatOK                = 0x00
+	mfiStatSCSIDoneWithError = 0x2d
+
+	mrDCMDPDGetList = 0x02010000
+
+	megasasMaxIoctlSGE = 16
+	megasasMaxPDs      = 240
+	megasasPDAddrLen   = 24 // length of an address in the physical drive list
+	megasasFrameLen    = 128
+	megasasSenseLen    = 32
+
+	// Offsets of the fields shared by the MFI frames
+	mfiFrameCmd         = 0
+	mfiFrameCmdStatus   = 2
+	mfiFrameSGECount    = 7
+	mfiFrameFlags       = 16
+	mfiFrameDataXferLen = 20
+
+	// Offsets of the fields of the pass-through frame
+	mfiPthruSenseLen = 1
+	mfiPthruTargetID = 4
+	mfiPthruCDBLen   = 6
+	mfiPthruSenseBuf = 24
+	mfiPthruCDB      = 32
+	mfiPthruSGL      = 48
+
+	// Offsets of the fields of the DCMD frame
+	mfiDCMDOpcode = 24
+	mfiDCMDSGL    = 40
+)
+
+// megasasIocPacket is struct megasas_iocpacket. The C struct is packed, so the iovecs of its
+// scatter-gather list are kept as bytes and written in the native layout of struct iovec.
+type megasasIocPacket struct {
+	hostNo   uint16
+	_        uint16
+	sglOff   uint32
+	sgeCount uint32
+	senseOff uint32
+	senseLen uint32
+	frame    [megasasFrameLen]byte
+	sgl      [megasasMaxIoctlSGE * 2 * unsafe.Sizeof(uintptr(0))]byte
+}
+
+// Compile time check that megasasIocPacket is packed like the C struct, 404 bytes on 64-bit
+// architectures and 276 bytes on 32-bit ones
+var _ [148 + len(megasasIocPacket{}.sgl) - int(unsafe.Sizeof(megasasIocPacket{}))]byte
+var _ [int(unsafe.Sizeof(megasasIocPacket{})) - 148 - len(megasasIocPacket{}.sgl)]byte
+
+// MEGASAS_IOC_FIRMWARE, _IOWR('M', 1, struct megasas_iocpacket)
+const megasasIocFirmware = 0xc0004d01 | uint32(unsafe.Sizeof(megasasIocPacket{}))<<16
+
+// putPointer writes a user space pointer in the native layout expected by the driver
+func putPointer(b []byte, p uintptr) {
+	copy(b, (*[unsafe.Sizeof(uintptr(0))]byte)(unsafe.Pointer(&p))[:])
+}
+
+// megaraidFirmware sends a MFI frame to a controller, transferring buf in the direction given by
+// the frame flags, and returns the command status of the frame
+func megaraidFirmware(host int, frame []byte, sglOff int, buf []byte, sense []byte, senseOff int) (uint8, error) {
+	fd, err := unix.Open(MegaRAIDIoctlNode, unix.O_RDWR, 0600)
+	if err != nil {
+		return 0, err
+	}
+	defer unix.Close(fd)
+
+	pkt := megasasIocPacket{hostNo: uint16(host), sglOff: uint32(sglOff)}
+	copy(pkt.frame[:], frame)
+
+	if len(buf) > 0 {
+		pkt.sgeCount = 1
+		putPointer(pkt.sgl[:], uintptr(unsafe.Pointer(&buf[0])))
+		putPointer(pkt.sgl[unsafe.Sizeof(uintptr(0)):], uintptr(len(buf)))
+	}
+	if len(sense) > 0 {
+		// the driver copies the sense data to the pointer stored in the frame at senseOff
+		pkt.senseOff = uint32(senseOff)
+		pkt.senseLen = uint32(len(sense))
+		putPointer(pkt.frame[senseOff:], uintptr(unsafe.Pointer(&sense[0])))
+	}
+
+	if err := ioctl.Ioctl(uintptr(fd), uintptr(megasasIocFirmware), uintptr(unsafe.Pointer(&pkt))); err != nil {
+		return 0, err
+	}
+
+	return pkt.frame[mfiFrameCmdStatus], nil
+}
+
+// MegaRAIDExecutor executes the SG_IO requests of a physical drive behind a MegaRAID controller
+// as MFI pass-through frames. ATA drives are reached through the SCSI/ATA translation of the
+// controller.
+type MegaRAIDExecutor struct {
+	Host     int    // SCSI host number of the controller
+	DeviceID uint16 // device ID of the drive, as listed by MegaRAIDDrives
+}
+
+// ExecuteSG sends the CDB of a request to the drive. The device path of the request is ignored.
+func (e MegaRAIDExecutor) ExecuteSG(req SGRequest) (SGResponse, error) {
+	var resp SGResponse
+
+	if len(req.CDB) == 0 || len(req.CDB) > 16 {
+		return resp, fmt.Errorf("invalid CDB length %d", len(req.CDB))
+	}
+	if req.DataLen < 0 || req.DataLen > MaxDataLen {
+		return resp, fmt.Errorf("invalid data length %d", req.DataLen)
+	}
+	// the target ID of a pass-through frame is a single byte, followed by the LUN
+	if e.DeviceID > 0xff {
+		return resp, fmt.Errorf("MegaRAID host %d: invalid device ID %d", e.Host, e.DeviceID)
+	}
+
+	data := make([]byte, req.DataLen)
+	copy(data, req.Data)
+	sense := make([]byte, megasasSenseLen)
+
+	frame := make([]byte, megasasFrameLen)
+	frame[mfiFrameCmd] = mfiCmdPDSCSIIO
+	frame[mfiPthruSenseLen] = megasasSenseLen
+	frame[mfiPthruCDBLen] = uint8(len(req.CDB))
+	frame[mfiPthruTargetID] = uint8(e.DeviceID)
+	copy(frame[mfiPthruCDB:], req.CDB)
+	binary.LittleEndian.PutUint32(frame[mfiFrameDataXferLen:], uint32(len(data)))
+	if len(data) > 0 {
+		frame[mfiFrameSGECount] = 1
+		switch req.Direction {
+		case SGDxferToDev:
+			binary.LittleEndian.PutUint16(frame[mfiFrameFlags:], mfiFrameDirWrite)
+		case SGDxferFromDev:
+			binary.LittleEndian.PutUint16(frame[mfiFrameFlags:], mfiFrameDirRead)
+		}
+	}
+
+	status, err := megaraidFirmware(e.Host, frame, mfiPthruSGL, data, sense, mfiPthruSenseBuf)
+	if err != nil {
+		return resp, err
+	}
+
+	switch status {
+	case mfiStatOK:
+		resp.Info = SGInfoOk
+	case mfiStatSCSIDoneWithError:
+		// the SCSI status is not returned by the driver, the command completed with sense data
+		resp.Info = SGInfoOkMask
+		resp.Status = 0x02
+		resp.Sense = sense
+	default:
+		return resp, fmt.Errorf("MegaRAID host %d device %d: MFI status %#02x", e.Host, e.DeviceID, status)
+	}
+
+	if req.Direction == SGDxferFromDev {
+		resp.Data = data
+	}
+
+	return resp, nil
+}
+
+// MegaRAIDDrives returns the device IDs of the physical disks attached to a MegaRAID controller,
+// including those which are members of logical volumes
+func MegaRAIDDrives(host int) ([]uint16, error) {
+	buf := make([]byte, 8+megasasMaxPDs*megasasPDAddrLen)
+
+	frame := make([]byte, megasasFrameLen)
+	frame[mfiFrameCmd] = mfiCmdDCMD
+	frame[mfiFrameSGECount] = 1
+	binary.LittleEndian.PutUint16(frame[mfiFrameFlags:], mfiFrameDirRead)
+	binary.LittleEndian.PutUint32(frame[mfiFrameDataXferLen:], uint32(len(buf)))
+	binary.LittleEndian.PutUint32(frame[mfiDCMDOpcode:], mrDCMDPDGetList)
+
+	status, err := megaraidFirmware(host, frame, mfiDCMDSGL, buf, nil, 0)
+	if err != nil {
+		return nil, fmt.Errorf("MegaRAID host %d physical drive list: %w", host, err)
+	}
+	if status != mfiStatOK {
+		return nil, fmt.Errorf("MegaRAID host %d physical drive list: MFI status %#02x", host, status)
+	}
+
+	count := int(binary.LittleEndian.Uint32(buf[4:]))
+	if count > megasasMaxPDs {
+		count = megasasMaxPDs
+	}
+
+	var ids []uint16
+	for i := 0; i < count; i++ {
+		addr := buf[8+i*megasasPDAddrLen:]
+		// only disks, enclosures are also listed
+		if addr[6] == PeripheralDirectAccess {
+			ids = append(ids, binary.LittleEndian.Uint16(addr))
+		}
+	}
+
+	return ids, nil
+}
+
+// megaraidPrefix starts the names of the drives behind MegaRAID controllers
+const megaraidPrefix = "megaraid:"
+
+// MegaRAIDName returns the name a drive behind a MegaRAID controller is detected by, e.g.
+// megaraid:0:8 for device 8 of host 0
+func MegaRAIDName(host int, deviceID uint16) string {
+	return fmt.Sprintf("%s%d:%d", megaraidPrefix, host, deviceID)
+}
+
+// ParseMegaRAIDName returns the host and device ID of a name returned by MegaRAIDName
+func ParseMegaRAIDName(name string) (host int, deviceID uint16, ok bool) {
+	if !strings.HasPrefix(name, megaraidPrefix) {
+		return 0, 0, false
+	}
+
+	fields := strings.Split(strings.TrimPrefix(name, megaraidPrefix), ":")
+	if len(fields) != 2 {
+		return 0, 0, false
+	}
+	h, err1 := strconv.Atoi(fields[0])
+	id, err2 := strconv.ParseUint(fields[1], 10, 16)
+	if err1 != nil || err2 != nil {
+		return 0, 0, false
+	}
+
+	return h, uint16(id), true
+}
+
+// detectMegaRAID returns the device for a drive behind a MegaRAID controller, named as returned
+// by MegaRAIDName, whose commands are sent through a MegaRAIDExecutor
+func detectMegaRAID(name string, opts DetectOptions) (Dev, error) {
+	host, id, ok := ParseMegaRAIDName(name)
+	if !ok {
+		return nil, nil
+	}
+
+	var executor Executor = MegaRAIDExecutor{Host: host, DeviceID: id}
+
+	// the drive is only reachable through the controller, a dry run still filters its commands
+	current := opts.Executor
+	if current == nil {
+		current = currentExecutor()
+	}
+	if dryRun, ok := current.(*DryRunExecutor); ok {
+		executor = &DryRunExecutor{Executor: executor, Output: dryRun.Output}
+	}
+	opts.Executor = executor
+
+	return detectSCSI(name, opts)
+}
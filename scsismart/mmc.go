@@ -0,0 +1,123 @@
+/*
+Copyright 2018 The OpenEBS Authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Functions for SD cards and eMMC devices, which are reached through the MMC ioctl.
+
+package scsismart
+
+import (
+	"fmt"
+	"io"
+	"path/filepath"
+
+	"github.com/openebs/smart/internal/utilities"
+	"github.com/openebs/smart/mmc"
+)
+
+// MMCBlk is an SD card or eMMC device (/dev/mmcblk*). Its identity is read from the registers
+// the kernel exposes in sysfs, and its health through the MMC ioctl.
+type MMCBlk struct {
+	mmc.Device
+}
+
+// detectMMC returns a MMCBlk device for SD cards and eMMC devices
+func detectMMC(name string, opts DetectOptions) (Dev, error) {
+	if !mmc.IsMMC(name) {
+		return nil, nil
+	}
+
+	dev := MMCBlk{mmc.Device{Name: name}}
+	if err := dev.Open(); err != nil {
+		return nil, err
+	}
+
+	return &dev, nil
+}
+
+// GetDiskInfo returns the identity and capacity of an SD card or eMMC device. If some of them
+// cannot be read, the others are returned along with a MultiError.
+func (d *MMCBlk) GetDiskInfo() (DiskAttr, error) {
+	var errs MultiError
+	sysDir := utilities.SysfsBlockDir(d.Name)
+
+	MMCAttr := DiskAttr{}
+	MMCAttr.Transport = "eMMC"
+	if d.Type() == mmc.TypeSD {
+		MMCAttr.Transport = "SD"
+	}
+
+	cid, err := d.CID()
+	errs.add("CID", err)
+	if err == nil {
+		MMCAttr.SerialNumber = fmt.Sprintf("%#08x", cid.SerialNumber)
+		MMCAttr.ModelNumber = cid.ProductName
+		MMCAttr.FirmwareRevision = cid.Revision
+	}
+
+	// sysfs reports the size in 512-byte sectors regardless of the logical block size
+	sectors, err := utilities.ReadSysfsUint(filepath.Join(sysDir, "size"))
+	errs.add("sysfs size", err)
+
+	lbSize, _ := utilities.ReadSysfsUint(filepath.Join(sysDir, "queue", "logical_block_size"))
+	pbSize, _ := utilities.ReadSysfsUint(filepath.Join(sysDir, "queue", "physical_block_size"))
+
+	MMCAttr.UserCapacity = sectors * 512
+	if lbSize != 0 {
+		MMCAttr.TotalLogicalBlocks = MMCAttr.UserCapacity / lbSize
+	}
+	MMCAttr.LBSize = uint16(lbSize)
+	MMCAttr.PBSize = uint16(pbSize)
+	MMCAttr.SMARTSupported = false
+	setIdentityAttr(d.Name, &MMCAttr)
+
+	return MMCAttr, errs.err()
+}
+
+// WriteDiskInfo writes the available information for an SD card or eMMC device to w
+func (d *MMCBlk) WriteDiskInfo(w io.Writer) error {
+	// The attributes which could be read are written even if some could not
+	diskAttr, err := d.GetDiskInfo()
+
+	fmt.Fprintf(w, "Product Name: %s\n", diskAttr.ModelNumber)
+	fmt.Fprintf(w, "Serial Number: %s\n", diskAttr.SerialNumber)
+	fmt.Fprintf(w, "Product Revision: %s\n", diskAttr.FirmwareRevision)
+	if cid, err := d.CID(); err == nil {
+		fmt.Fprintf(w, "Manufacturer ID: %#02x, OEM ID: %s\n", cid.ManufacturerID, cid.OEMID)
+		fmt.Fprintf(w, "Manufacturing Date: %d/%02d\n", cid.Year, cid.Month)
+	}
+	fmt.Fprintf(w, "User Capacity:%v bytes (%v)\n", diskAttr.UserCapacity, utilities.ConvertBytes(diskAttr.UserCapacity))
+	fmt.Fprintln(w, "Transport:", diskAttr.Transport)
+
+	if health, err := d.Health(); err == nil {
+		fmt.Fprintf(w, "Life Used: %s (from %s)\n", lifeUsedString(health.LifeUsed), health.Source)
+		if health.LifeUsedB >= 0 {
+			fmt.Fprintf(w, "Life Used (type B): %s\n", lifeUsedString(health.LifeUsedB))
+		}
+		if health.PreEOL != mmc.PreEOLUndefined {
+			fmt.Fprintln(w, "Pre EOL:", health.PreEOL)
+		}
+	} else {
+		fmt.Fprintf(w, "Health: not available (%v)\n", err)
+	}
+
+	return err
+}
+
+// lifeUsedString formats a percent of life used, which is negative when it is not reported
+func lifeUsedString(percent int) string {
+	if percent < 0 {
+		return "not reported"
+	}
+
+	return fmt.Sprintf("%d%%", percent)
+}
@@ -0,0 +1,102 @@
+/*
+Copyright 2018 The OpenEBS Authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Aggregation of the errors of the probes gathering the attributes of a device.
+
+package scsismart
+
+import (
+	"errors"
+	"strings"
+)
+
+// ProbeError is the failure of one of the probes, e.g. a command, used to gather the
+// attributes of a device
+type ProbeError struct {
+	Probe string // name of the probe, e.g. "INQUIRY"
+	Err   error
+}
+
+func (e ProbeError) Error() string {
+	return e.Probe + ": " + e.Err.Error()
+}
+
+// Unwrap returns the error of the probe
+func (e ProbeError) Unwrap() error {
+	return e.Err
+}
+
+// MultiError is returned alongside the attributes of a device when some of the probes
+// gathering them failed. The attributes of the probes which succeeded are still set.
+type MultiError []ProbeError
+
+func (m MultiError) Error() string {
+	msgs := make([]string, len(m))
+	for i, e := range m {
+		msgs[i] = e.Error()
+	}
+
+	return strings.Join(msgs, "; ")
+}
+
+// Unwrap returns the errors of the probes, so that errors.Is and errors.As look into them
+func (m MultiError) Unwrap() []error {
+	errs := make([]error, len(m))
+	for i, e := range m {
+		errs[i] = e
+	}
+
+	return errs
+}
+
+// Failed returns whether a probe failed
+func (m MultiError) Failed(probe string) bool {
+	for _, e := range m {
+		if e.Probe == probe {
+			return true
+		}
+	}
+
+	return false
+}
+
+// add records the error of a probe, if any
+func (m *MultiError) add(probe string, err error) {
+	if err != nil {
+		*m = append(*m, ProbeError{Probe: probe, Err: err})
+	}
+}
+
+// merge records the probes which failed in a MultiError returned along with partial results
+func (m *MultiError) merge(err error) {
+	var partial MultiError
+	if errors.As(err, &partial) {
+		*m = append(*m, partial...)
+	}
+}
+
+// err returns the MultiError, or nil if no probe failed
+func (m MultiError) err() error {
+	if len(m) == 0 {
+		return nil
+	}
+
+	return m
+}
+
+// IsPartial returns whether err only reports the probes which failed, in which case the
+// attributes returned with it are usable
+func IsPartial(err error) bool {
+	var m MultiError
+	return errors.As(err, &m)
+}
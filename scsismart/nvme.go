@@ -0,0 +1,176 @@
+/*
+Copyright 2018 The OpenEBS Authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// NVMe namespaces and controllers, identified through the NVMe admin commands.
+
+package scsismart
+
+import (
+	"encoding/binary"
+	"fmt"
+	"io"
+	"path/filepath"
+	"strings"
+
+	"github.com/openebs/smart/internal/utilities"
+	"github.com/openebs/smart/nvme"
+)
+
+// NVMeBlk is an NVMe namespace (/dev/nvme*n*) or controller (/dev/nvme*). Its identity is read
+// with IDENTIFY CONTROLLER, and its health from the SMART / Health Information log.
+type NVMeBlk struct {
+	nvme.NVMeDevice
+}
+
+// detectNVMe returns a NVMeBlk device for NVMe namespaces and controllers
+func detectNVMe(name string, opts DetectOptions) (Dev, error) {
+	devNode, err := filepath.EvalSymlinks(name)
+	if err != nil {
+		devNode = name
+	}
+	if nvme.ControllerName(devNode) == "" {
+		return nil, nil
+	}
+
+	dev := NVMeBlk{nvme.NVMeDevice{Name: name}}
+	if err := dev.Open(); err != nil {
+		return nil, err
+	}
+
+	return &dev, nil
+}
+
+// GetDiskInfo returns the identity, capacity and health of an NVMe device. If some of them
+// cannot be read, the others are returned along with a MultiError.
+func (d *NVMeBlk) GetDiskInfo() (DiskAttr, error) {
+	var errs MultiError
+	sysDir := utilities.SysfsBlockDir(d.Name)
+
+	NVMeAttr := DiskAttr{}
+	NVMeAttr.Transport = "NVMe"
+	if d.Controller.Fabrics() {
+		NVMeAttr.Transport = "NVMe over " + d.Controller.Transport
+	}
+
+	id, err := d.IdentifyController()
+	errs.add("IDENTIFY CONTROLLER", err)
+	if err == nil {
+		NVMeAttr.VendorID = id.VendorID
+		NVMeAttr.SerialNumber = strings.TrimSpace(string(id.SerialNumber[:]))
+		NVMeAttr.ModelNumber = strings.TrimSpace(string(id.ModelNumber[:]))
+		NVMeAttr.FirmwareRevision = strings.TrimSpace(string(id.FirmwareRev[:]))
+	}
+
+	// Controllers have no capacity nor identifier of their own, only their namespaces do
+	if nsid, err := nvme.NamespaceID(d.Name); err == nil {
+		ns, err := d.IdentifyNamespace(nsid)
+		errs.add("IDENTIFY NAMESPACE", err)
+		if err == nil {
+			setNamespaceAttr(nsid, ns, &NVMeAttr)
+		}
+	}
+
+	// sysfs reports the size in 512-byte sectors regardless of the logical block size. It is
+	// only used if the namespace could not be identified.
+	if NVMeAttr.UserCapacity == 0 {
+		if sectors, err := utilities.ReadSysfsUint(filepath.Join(sysDir, "size")); err == nil {
+			lbSize, _ := utilities.ReadSysfsUint(filepath.Join(sysDir, "queue", "logical_block_size"))
+
+			NVMeAttr.UserCapacity = sectors * 512
+			if lbSize != 0 {
+				NVMeAttr.TotalLogicalBlocks = NVMeAttr.UserCapacity / lbSize
+			}
+			NVMeAttr.LBSize = uint16(lbSize)
+		}
+	}
+	pbSize, _ := utilities.ReadSysfsUint(filepath.Join(sysDir, "queue", "physical_block_size"))
+	NVMeAttr.PBSize = uint16(pbSize)
+
+	health, err := d.HealthInfo()
+	errs.add("SMART / Health Information log", err)
+	if err == nil {
+		NVMeAttr.NVMeHealth = &health
+		NVMeAttr.SMARTSupported = true
+	}
+	setIdentityAttr(d.Name, &NVMeAttr)
+
+	return NVMeAttr, errs.err()
+}
+
+// setNamespaceAttr fills the capacity and identifier of a namespace from its Identify Namespace
+// data structure
+func setNamespaceAttr(nsid uint32, ns nvme.IdentifyNamespaceData, attr *DiskAttr) {
+	format := ns.Format()
+	lbSize := uint64(format.LBASize)
+
+	attr.NVMeNamespaceID = nsid
+	attr.TotalLogicalBlocks = ns.Size
+	attr.UserCapacity = ns.Size * lbSize
+	attr.NVMeCapacity = ns.Capacity * lbSize
+	attr.NVMeUtilization = ns.Utilization * lbSize
+	attr.LBSize = uint16(lbSize)
+
+	attr.LuWWNDeviceID = ns.EUI()
+	if ns.EUI64 != [8]byte{} {
+		attr.WWN = binary.BigEndian.Uint64(ns.EUI64[:])
+	}
+}
+
+// WriteDiskInfo writes the available information for an NVMe device to w
+func (d *NVMeBlk) WriteDiskInfo(w io.Writer) error {
+	// The attributes which could be read are written even if some could not
+	diskAttr, err := d.GetDiskInfo()
+
+	fmt.Fprintf(w, "Model Number: %s\n", diskAttr.ModelNumber)
+	fmt.Fprintf(w, "Serial Number: %s\n", diskAttr.SerialNumber)
+	fmt.Fprintf(w, "Firmware Revision: %s\n", diskAttr.FirmwareRevision)
+	fmt.Fprintf(w, "PCI Vendor ID: %#04x\n", diskAttr.VendorID)
+	if diskAttr.NVMeNamespaceID != 0 {
+		fmt.Fprintln(w, "Namespace ID:", diskAttr.NVMeNamespaceID)
+		if diskAttr.LuWWNDeviceID != "" {
+			fmt.Fprintln(w, "Namespace EUI:", diskAttr.LuWWNDeviceID)
+		}
+		fmt.Fprintf(w, "Namespace Utilization: %v bytes (%v) of %v bytes\n", diskAttr.NVMeUtilization,
+			utilities.ConvertBytes(diskAttr.NVMeUtilization), diskAttr.NVMeCapacity)
+	}
+	if diskAttr.UserCapacity != 0 {
+		fmt.Fprintf(w, "User Capacity:%v bytes (%v)\n", diskAttr.UserCapacity, utilities.ConvertBytes(diskAttr.UserCapacity))
+		fmt.Fprintf(w, "Sector Size: %d bytes logical, %d bytes physical\n", diskAttr.LBSize, diskAttr.PBSize)
+	}
+	fmt.Fprintln(w, "Transport:", diskAttr.Transport)
+
+	if health := diskAttr.NVMeHealth; health != nil {
+		fmt.Fprintln(w, "\nSMART / Health Information :")
+		fmt.Fprintln(w, "Critical Warning:", health.CriticalWarning)
+		fmt.Fprintf(w, "Temperature: %d Celsius\n", health.Temperature)
+		fmt.Fprintf(w, "Available Spare: %d%% (threshold %d%%)\n", health.AvailableSpare, health.SpareThreshold)
+		fmt.Fprintf(w, "Percentage Used: %d%%\n", health.PercentUsed)
+		fmt.Fprintf(w, "Data Units Read: %d, Written: %d\n", health.DataUnitsRead, health.DataUnitsWritten)
+		fmt.Fprintln(w, "Power Cycles:", health.PowerCycles)
+		fmt.Fprintln(w, "Power On Hours:", health.PowerOnHours)
+		fmt.Fprintln(w, "Unsafe Shutdowns:", health.UnsafeShutdowns)
+		fmt.Fprintln(w, "Media and Data Integrity Errors:", health.MediaErrors)
+		fmt.Fprintln(w, "Error Information Log Entries:", health.ErrorLogEntries)
+	}
+
+	// Sanitize is optional, devices which do not support it have no sanitize status
+	if sanitize, err := d.SanitizeStatus(); err == nil {
+		if sanitize.State == nvme.SanitizeInProgress {
+			fmt.Fprintf(w, "Sanitize: %s (%.1f%%)\n", sanitize.State, sanitize.Progress)
+		} else {
+			fmt.Fprintln(w, "Sanitize:", sanitize.State)
+		}
+	}
+
+	return err
+}
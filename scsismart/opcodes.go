@@ -0,0 +1,106 @@
+/*
+Copyright 2018 The OpenEBS Authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// SCSI operation code names, used in error messages and debug traces.
+
+package scsismart
+
+import (
+	"fmt"
+
+	"github.com/openebs/smart/atasmart"
+)
+
+// scsiOpcodeNames maps SCSI operation codes to their names (SPC-4, SBC-3)
+var scsiOpcodeNames = map[uint8]string{
+	0x00: "TEST UNIT READY",
+	0x03: "REQUEST SENSE",
+	0x04: "FORMAT UNIT",
+	0x08: "READ(6)",
+	0x0a: "WRITE(6)",
+	0x12: "INQUIRY",
+	0x15: "MODE SELECT(6)",
+	0x1a: "MODE SENSE(6)",
+	0x1b: "START STOP UNIT",
+	0x1c: "RECEIVE DIAGNOSTIC RESULTS",
+	0x1d: "SEND DIAGNOSTIC",
+	0x25: "READ CAPACITY(10)",
+	0x28: "READ(10)",
+	0x2a: "WRITE(10)",
+	0x2f: "VERIFY(10)",
+	0x35: "SYNCHRONIZE CACHE(10)",
+	0x37: "READ DEFECT DATA(10)",
+	0x3b: "WRITE BUFFER",
+	0x3c: "READ BUFFER",
+	0x41: "WRITE SAME(10)",
+	0x42: "UNMAP",
+	0x48: "SANITIZE",
+	0x4c: "LOG SELECT",
+	0x4d: "LOG SENSE",
+	0x55: "MODE SELECT(10)",
+	0x5a: "MODE SENSE(10)",
+	0x85: "ATA PASS-THROUGH(16)",
+	0x88: "READ(16)",
+	0x8a: "WRITE(16)",
+	0x8f: "VERIFY(16)",
+	0x91: "SYNCHRONIZE CACHE(16)",
+	0x93: "WRITE SAME(16)",
+	0x9e: "SERVICE ACTION IN(16)",
+	0xa0: "REPORT LUNS",
+	0xa1: "ATA PASS-THROUGH(12)",
+	0xa2: "SECURITY PROTOCOL IN",
+	0xa3: "MAINTENANCE IN",
+	0xa8: "READ(12)",
+	0xaa: "WRITE(12)",
+	0xaf: "VERIFY(12)",
+	0xb5: "SECURITY PROTOCOL OUT",
+	0xb7: "READ DEFECT DATA(12)",
+}
+
+// serviceActionInNames maps SERVICE ACTION IN(16) service actions to their names
+var serviceActionInNames = map[uint8]string{
+	0x10: "READ CAPACITY(16)",
+	0x12: "GET LBA STATUS",
+	0x13: "REPORT REFERRALS",
+}
+
+// OpcodeName returns the name of a SCSI operation code, e.g. "INQUIRY"
+func OpcodeName(opcode uint8) string {
+	if name, ok := scsiOpcodeNames[opcode]; ok {
+		return name
+	}
+
+	return fmt.Sprintf("SCSI opcode %#02x", opcode)
+}
+
+// CDBName returns a descriptive name for a CDB, resolving service actions and the ATA command
+// carried by ATA pass-through, e.g. "ATA PASS-THROUGH(16) SMART READ DATA".
+func CDBName(cdb []byte) string {
+	if len(cdb) == 0 {
+		return "empty CDB"
+	}
+
+	switch {
+	case cdb[0] == SCSIServiceAction && len(cdb) >= 2:
+		if name, ok := serviceActionInNames[cdb[1]&0x1f]; ok {
+			return name
+		}
+	case cdb[0] == SCSIATAPassThru16 && len(cdb) == 16:
+		features := uint16(cdb[3])<<8 | uint16(cdb[4])
+		return OpcodeName(cdb[0]) + " " + atasmart.CommandName(cdb[14], features)
+	case cdb[0] == SCSIATAPassThru12 && len(cdb) == 12:
+		return OpcodeName(cdb[0]) + " " + atasmart.CommandName(cdb[9], uint16(cdb[3]))
+	}
+
+	return OpcodeName(cdb[0])
+}
@@ -0,0 +1,65 @@
+/*
+Copyright 2018 The OpenEBS Authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Choice of the SCSI/ATA translation pass-through command of SATA devices.
+
+package scsismart
+
+import "fmt"
+
+// PassThrough selects how ATA commands are sent to a device behind a SCSI/ATA translation layer
+type PassThrough string
+
+// Pass-through types
+const (
+	PassThroughAuto  PassThrough = ""      // ATA PASS-THROUGH(16) to the disks reporting ATA as vendor
+	PassThroughSAT16 PassThrough = "sat16" // ATA PASS-THROUGH(16), even to iSCSI disks
+	PassThroughSAT12 PassThrough = "sat12" // ATA PASS-THROUGH(12), for bridges rejecting the 16-byte CDB
+	PassThroughNone  PassThrough = "none"  // no ATA pass-through, the disk is queried as plain SCSI
+)
+
+// ParsePassThrough parses the name of a pass-through type, an empty name or auto selecting
+// PassThroughAuto
+func ParsePassThrough(name string) (PassThrough, error) {
+	switch p := PassThrough(name); p {
+	case "auto":
+		return PassThroughAuto, nil
+	case PassThroughAuto, PassThroughSAT16, PassThroughSAT12, PassThroughNone:
+		return p, nil
+	}
+
+	return PassThroughAuto, fmt.Errorf("unknown pass-through type %q", name)
+}
+
+// ataPassThru12 converts an ATA PASS-THROUGH(16) CDB to the equivalent ATA PASS-THROUGH(12) CDB.
+// Commands using the 48-bit registers have no 12-byte equivalent.
+func ataPassThru12(cdb16 []byte) ([]byte, error) {
+	if cdb16[1]&0x01 != 0 {
+		return nil, fmt.Errorf("%s needs the 48-bit registers of ATA PASS-THROUGH(16)", CDBName(cdb16))
+	}
+
+	return []byte{
+		SCSIATAPassThru12,
+		cdb16[1],  // protocol
+		cdb16[2],  // transfer flags
+		cdb16[4],  // features
+		cdb16[6],  // count
+		cdb16[8],  // LBA low
+		cdb16[10], // LBA mid
+		cdb16[12], // LBA high
+		cdb16[13], // device
+		cdb16[14], // command
+		0,
+		cdb16[15], // control
+	}, nil
+}
@@ -0,0 +1,164 @@
+/*
+Copyright 2018 The OpenEBS Authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Configuration of the standby and EPC timers of SATA devices.
+
+package scsismart
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/openebs/smart/atasmart"
+)
+
+// ErrPowerManagementNotAllowed is returned when changing power management settings of a device
+// which was not opened with DetectOptions.AllowPowerManagement
+var ErrPowerManagementNotAllowed = errors.New("power management changes not allowed, see DetectOptions.AllowPowerManagement")
+
+// EPC power condition identifiers
+const (
+	PowerConditionStandbyZ = 0x00
+	PowerConditionStandbyY = 0x01
+	PowerConditionIdleA    = 0x81
+	PowerConditionIdleB    = 0x82
+	PowerConditionIdleC    = 0x83
+	PowerConditionAll      = 0xff
+)
+
+// AllowPowerManagement allows or forbids changing the power management settings of the device
+func (d *SCSIDevice) AllowPowerManagement(allow bool) {
+	d.allowPowerManagement = allow
+}
+
+// StandbyTimerValue returns the ATA standby timer count for a timeout, rounded up to the nearest
+// value the timer supports: multiples of 5 secs up to 20 mins, then multiples of 30 mins up to
+// 5.5 hours. A zero timeout disables the standby timer.
+func StandbyTimerValue(timeout time.Duration) (uint8, error) {
+	switch {
+	case timeout <= 0:
+		return 0, nil
+	case timeout <= 20*time.Minute:
+		return uint8((timeout + 5*time.Second - 1) / (5 * time.Second)), nil
+	case timeout <= 330*time.Minute:
+		return uint8(240 + (timeout+30*time.Minute-1)/(30*time.Minute)), nil
+	}
+
+	return 0, fmt.Errorf("standby timeout %v exceeds 5.5 hours", timeout)
+}
+
+// SetStandbyTimer sends an ATA IDLE command via SCSI_ATA_PASSTHRU_16 to set the time the device
+// stays idle before it spins down to standby. A zero timeout disables the standby timer.
+func (d *SATA) SetStandbyTimer(timeout time.Duration) error {
+	if !d.allowPowerManagement {
+		return ErrPowerManagementNotAllowed
+	}
+
+	count, err := StandbyTimerValue(timeout)
+	if err != nil {
+		return err
+	}
+
+	if err := d.ataNonData(atasmart.AtaIdle, 0, uint16(count), 0); err != nil {
+		return fmt.Errorf("sendCDB IDLE: %w", err)
+	}
+
+	return nil
+}
+
+// SetPowerConditionTimer sends an ATA SET FEATURES EPC Set Power Condition Timer command via
+// SCSI_ATA_PASSTHRU_16 to set the timer of an EPC power condition and enable or disable it. The
+// setting survives power cycles if save is set.
+func (d *SATA) SetPowerConditionTimer(condition uint8, timer time.Duration, enable, save bool) error {
+	if !d.allowPowerManagement {
+		return ErrPowerManagementNotAllowed
+	}
+
+	// The timer is in units of 100 millisecs, or of minutes if it does not fit in 16 bits
+	lba := uint64(atasmart.EPCSetPowerConditionTimer)
+	if units := (timer + 100*time.Millisecond - 1) / (100 * time.Millisecond); units <= 0xffff {
+		lba |= uint64(units) << 8
+	} else if units = (timer + time.Minute - 1) / time.Minute; units <= 0xffff {
+		lba |= 0x80 | uint64(units)<<8
+	} else {
+		return fmt.Errorf("power condition timer %v too long", timer)
+	}
+	if save {
+		lba |= 0x10
+	}
+	if enable {
+		lba |= 0x20
+	}
+
+	if err := d.ataNonData(atasmart.AtaSetFeatures, atasmart.SetFeaturesEPC, uint16(condition), lba); err != nil {
+		return fmt.Errorf("sendCDB SET FEATURES EPC: %w", err)
+	}
+
+	return nil
+}
+
+// startStopUnit sends a SCSI START STOP UNIT command to a device, starting or stopping its media
+func (d *SCSIDevice) startStopUnit(start bool) error {
+	if !d.allowPowerManagement {
+		return ErrPowerManagementNotAllowed
+	}
+
+	cdb := CDB6{SCSIStartStopUnit}
+	if start {
+		cdb[4] = 0x01
+	}
+
+	var noData []byte
+	if err := d.sendCDBDirection(cdb[:], SGDxferNone, &noData); err != nil {
+		return fmt.Errorf("SgExecute START STOP UNIT: %w", err)
+	}
+
+	return nil
+}
+
+// SpinDown sends a SCSI START STOP UNIT command to stop the media of a device
+func (d *SCSIDevice) SpinDown() error {
+	return d.startStopUnit(false)
+}
+
+// SpinUp sends a SCSI START STOP UNIT command to start the media of a device
+func (d *SCSIDevice) SpinUp() error {
+	return d.startStopUnit(true)
+}
+
+// SpinDown sends an ATA STANDBY IMMEDIATE command via SCSI_ATA_PASSTHRU_16 to spin down a SATA device
+func (d *SATA) SpinDown() error {
+	if !d.allowPowerManagement {
+		return ErrPowerManagementNotAllowed
+	}
+
+	if err := d.ataNonData(atasmart.AtaStandbyImmediate, 0, 0, 0); err != nil {
+		return fmt.Errorf("sendCDB STANDBY IMMEDIATE: %w", err)
+	}
+
+	return nil
+}
+
+// SpinUp sends an ATA IDLE IMMEDIATE command via SCSI_ATA_PASSTHRU_16 to spin up a SATA device
+func (d *SATA) SpinUp() error {
+	if !d.allowPowerManagement {
+		return ErrPowerManagementNotAllowed
+	}
+
+	if err := d.ataNonData(atasmart.AtaIdleImmediate, 0, 0, 0); err != nil {
+		return fmt.Errorf("sendCDB IDLE IMMEDIATE: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,135 @@
+/*
+Copyright 2018 The OpenEBS Authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+// Allowlist of the commands which only read from a device.
+
+package scsismart
+
+import (
+	"encoding/binary"
+
+	"github.com/openebs/smart/atasmart"
+)
+
+// readOnlyOpcodes are the SCSI commands sent by the library which neither write to the medium
+// nor change the settings of a device
+var readOnlyOpcodes = map[uint8]bool{
+	SCSITestUnitReady:  true,
+	SCSIInquiry:        true,
+	SCSIModeSense6:     true,
+	SCSIReadCapacity10: true,
+	SCSIReportLUNs:     true,
+	SCSISecurityIn:     true,
+	SCSIVerify10:       true,
+	SCSIVerify12:       true,
+	SCSIVerify16:       true,
+}
+
+// readOnlyATACommands are the ATA commands sent by the library which only read from a device
+var readOnlyATACommands = map[uint8]bool{
+	atasmart.AtaIdentifyDevice:       true,
+	atasmart.AtaReadLogExt:           true,
+	atasmart.AtaReadVerifySectors:    true,
+	atasmart.AtaReadVerifySectorsExt: true,
+	atasmart.AtaTrustedReceive:       true,
+}
+
+// readOnlySMARTCommands are the SMART subcommands sent by the library which only read from a
+// device
+var readOnlySMARTCommands = map[uint8]bool{
+	atasmart.SmartReadData:       true,
+	atasmart.SmartReadThresholds: true,
+	atasmart.SmartReadLog:        true,
+	atasmart.SmartReturnStatus:   true,
+}
+
+// ataPassThruRegisters returns the command, features and LBA low registers of an ATA
+// pass-through CDB, or false if the CDB does not carry an ATA command
+func ataPassThruRegisters(cdb []byte) (command, features, lbaLow uint8, ok bool) {
+	switch {
+	case cdb[0] == SCSIATAPassThru16 && len(cdb) == 16:
+		return cdb[14], cdb[4], cdb[8], true
+	case cdb[0] == SCSIATAPassThru12 && len(cdb) == 12:
+		return cdb[9], cdb[3], cdb[5], true
+	}
+
+	return 0, 0, 0, false
+}
+
+// sctReadTable reports whether an SCT command key only selects the data table returned by the
+// next read of the SCT data transfer log
+func sctReadTable(key []byte) bool {
+	return len(key) >= 4 &&
+		binary.LittleEndian.Uint16(key[0:]) == atasmart.SCTActionDataTable &&
+		binary.LittleEndian.Uint16(key[2:]) == atasmart.SCTFunctionReadTable
+}
+
+// ataTransferReadOnly reports whether the protocol and transfer direction of an ATA pass-through
+// CDB can only read from the device, direction being the SG_IO transfer direction of the request.
+// The resets and the other protocols act on the device whatever the command register holds.
+// PIO data-out is only allowed for a SMART WRITE LOG, whose log and data are checked by ReadOnly.
+func ataTransferReadOnly(cdb []byte, direction int32, command, features uint8) bool {
+	fromDevice := cdb[2]&0x08 != 0
+	noData := cdb[2]&0x03 == ATATLengthNone
+
+	switch cdb[1] >> 1 & 0x0f {
+	case ATAProtocolNonData:
+		return noData && direction == SGDxferNone
+	case ATAProtocolPIODataIn, ATAProtocolDMA, ATAProtocolUDMADataIn:
+		// DMA transfers in either direction, T_DIR tells which
+		return !noData && fromDevice && direction == SGDxferFromDev
+	case ATAProtocolPIODataOut:
+		return !noData && !fromDevice && direction == SGDxferToDev &&
+			command == atasmart.AtaSmart && features == atasmart.SmartWriteLog
+	}
+
+	return false
+}
+
+// ReadOnly reports whether a request is one of the commands sent by the library which only read
+// from a device, with the transfer direction of that command. Anything else, including the
+// commands the library does not know, may change the state of the device.
+func ReadOnly(req SGRequest) bool {
+	cdb := req.CDB
+	if len(cdb) == 0 {
+		return false
+	}
+
+	command, features, lbaLow, ok := ataPassThruRegisters(cdb)
+	if !ok {
+		// none of the read-only SCSI commands sends data to the device
+		if req.Direction == SGDxferToDev {
+			return false
+		}
+
+		switch cdb[0] {
+		case SCSILogSense:
+			// SP saves the log parameters to non-volatile storage
+			return len(cdb) == 10 && cdb[1]&0x01 == 0
+		case SCSIServiceAction:
+			return len(cdb) == 16 && cdb[1]&0x1f == ReadCapacity16ServiceAction
+		}
+		return readOnlyOpcodes[cdb[0]]
+	}
+
+	if !ataTransferReadOnly(cdb, req.Direction, command, features) {
+		return false
+	}
+	if command != atasmart.AtaSmart {
+		return readOnlyATACommands[command]
+	}
+	if features == atasmart.SmartWriteLog {
+		return lbaLow == atasmart.SCTCommandStatusLog && sctReadTable(req.Data)
+	}
+
+	return readOnlySMARTCommands[features]
+}
@@ -0,0 +1,122 @@
+/*
+Copyright 2018 The OpenEBS Authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Tests of the read-only gate used by the helper and the dry-run executor.
+
+package scsismart
+
+import (
+	"encoding/binary"
+	"testing"
+
+	"github.com/openebs/smart/atasmart"
+)
+
+// passThru16 builds an ATA PASS-THROUGH(16) CDB as the library does
+func passThru16(protocol uint8, fromDevice bool, tLength, command, features uint8, lbaLow uint8) []byte {
+	cdb16 := CDB16{SCSIATAPassThru16}
+	cdb16.SetATAProtocol(protocol, false)
+	cdb16.SetATATransfer(fromDevice, true, tLength)
+	cdb16.SetATARegisters(command, uint16(features), 1, smartLBA|uint64(lbaLow), 0)
+
+	return cdb16[:]
+}
+
+// passThru12 converts an ATA PASS-THROUGH(16) CDB without 48-bit registers to its 12-byte form
+func passThru12(cdb16 []byte) []byte {
+	return []byte{SCSIATAPassThru12, cdb16[1], cdb16[2], cdb16[4], cdb16[6], cdb16[8],
+		cdb16[10], cdb16[12], cdb16[13], cdb16[14], 0, cdb16[15]}
+}
+
+// sctKey returns an SCT command key sector for an action and function code
+func sctKey(action, function uint16) []byte {
+	key := make([]byte, atasmart.SectorSize)
+	binary.LittleEndian.PutUint16(key[0:], action)
+	binary.LittleEndian.PutUint16(key[2:], function)
+
+	return key
+}
+
+func TestReadOnly(t *testing.T) {
+	identify := passThru16(ATAProtocolPIODataIn, true, ATATLengthSectorCount, atasmart.AtaIdentifyDevice, 0, 0)
+	returnStatus := passThru16(ATAProtocolNonData, false, ATATLengthNone, atasmart.AtaSmart, atasmart.SmartReturnStatus, 0)
+	writeLog := passThru16(ATAProtocolPIODataOut, false, ATATLengthSectorCount, atasmart.AtaSmart,
+		atasmart.SmartWriteLog, atasmart.SCTCommandStatusLog)
+	readTable := sctKey(atasmart.SCTActionDataTable, atasmart.SCTFunctionReadTable)
+
+	tests := []struct {
+		name string
+		req  SGRequest
+		want bool
+	}{
+		{"IDENTIFY PIO data-in",
+			SGRequest{CDB: identify, Direction: SGDxferFromDev}, true},
+		{"IDENTIFY DMA",
+			SGRequest{CDB: passThru16(ATAProtocolDMA, true, ATATLengthSectorCount, atasmart.AtaIdentifyDevice, 0, 0),
+				Direction: SGDxferFromDev}, true},
+		{"IDENTIFY UDMA data-in",
+			SGRequest{CDB: passThru16(ATAProtocolUDMADataIn, true, ATATLengthSectorCount, atasmart.AtaIdentifyDevice, 0, 0),
+				Direction: SGDxferFromDev}, true},
+		{"IDENTIFY hard reset",
+			SGRequest{CDB: passThru16(ATAProtocolHardReset, true, ATATLengthSectorCount, atasmart.AtaIdentifyDevice, 0, 0),
+				Direction: SGDxferFromDev}, false},
+		{"IDENTIFY SRST",
+			SGRequest{CDB: passThru16(ATAProtocolSRST, false, ATATLengthNone, atasmart.AtaIdentifyDevice, 0, 0),
+				Direction: SGDxferNone}, false},
+		{"IDENTIFY SRST via ATA PASS-THROUGH(12)",
+			SGRequest{CDB: passThru12(passThru16(ATAProtocolSRST, false, ATATLengthNone, atasmart.AtaIdentifyDevice, 0, 0)),
+				Direction: SGDxferNone}, false},
+		{"IDENTIFY data-out direction",
+			SGRequest{CDB: identify, Direction: SGDxferToDev}, false},
+		{"IDENTIFY no data direction",
+			SGRequest{CDB: identify, Direction: SGDxferNone}, false},
+		{"IDENTIFY DMA to the device",
+			SGRequest{CDB: passThru16(ATAProtocolDMA, false, ATATLengthSectorCount, atasmart.AtaIdentifyDevice, 0, 0),
+				Direction: SGDxferFromDev}, false},
+		{"IDENTIFY PIO data-out",
+			SGRequest{CDB: passThru16(ATAProtocolPIODataOut, false, ATATLengthSectorCount, atasmart.AtaIdentifyDevice, 0, 0),
+				Direction: SGDxferToDev}, false},
+		{"IDENTIFY via ATA PASS-THROUGH(12)",
+			SGRequest{CDB: passThru12(identify), Direction: SGDxferFromDev}, true},
+		{"SMART RETURN STATUS",
+			SGRequest{CDB: returnStatus, Direction: SGDxferNone}, true},
+		{"SMART RETURN STATUS with data",
+			SGRequest{CDB: returnStatus, Direction: SGDxferFromDev}, false},
+		{"SCT read table key",
+			SGRequest{CDB: writeLog, Direction: SGDxferToDev, Data: readTable}, true},
+		{"SCT read table key read from the device",
+			SGRequest{CDB: writeLog, Direction: SGDxferFromDev, Data: readTable}, false},
+		{"SCT write table key",
+			SGRequest{CDB: writeLog, Direction: SGDxferToDev, Data: sctKey(atasmart.SCTActionDataTable, 2)}, false},
+		{"SMART WRITE LOG to a host log",
+			SGRequest{CDB: passThru16(ATAProtocolPIODataOut, false, ATATLengthSectorCount, atasmart.AtaSmart,
+				atasmart.SmartWriteLog, 0x80), Direction: SGDxferToDev, Data: readTable}, false},
+		{"INQUIRY",
+			SGRequest{CDB: []byte{SCSIInquiry, 0, 0, 0, 36, 0}, Direction: SGDxferFromDev}, true},
+		{"INQUIRY data-out direction",
+			SGRequest{CDB: []byte{SCSIInquiry, 0, 0, 0, 36, 0}, Direction: SGDxferToDev}, false},
+	}
+
+	if ReadOnly(SGRequest{Direction: SGDxferNone}) {
+		t.Error("empty CDB: ReadOnly = true, want false")
+	}
+
+	for _, tt := range tests {
+		if got := ReadOnly(tt.req); got != tt.want {
+			t.Errorf("%s: ReadOnly = %v, want %v", tt.name, got, tt.want)
+		}
+		if _, changes := CommandEffect(tt.req); changes == tt.want {
+			t.Errorf("%s: CommandEffect reports a change = %v, want %v", tt.name, changes, !tt.want)
+		}
+	}
+}
@@ -0,0 +1,91 @@
+/*
+Copyright 2018 The OpenEBS Authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Registry of the device backends used to detect the type of a device.
+
+package scsismart
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+)
+
+// DetectFunc returns the device for name if the backend handles it. It returns a nil Dev and a
+// nil error if the device is not one of its own, so that the next backend is tried.
+type DetectFunc func(name string, opts DetectOptions) (Dev, error)
+
+// Backend is a device backend registered for detection
+type Backend struct {
+	Name     string
+	Priority int // backends with a higher priority are tried first
+	Detect   DetectFunc
+}
+
+// Priorities of the built-in backends
+const (
+	PriorityVirtio = 100
+	PriorityMMC    = 100
+	PriorityNVMe   = 100
+	PriorityRAID   = 100
+	PrioritySCSI   = 0 // fallback for any device supporting SG_IO
+)
+
+var (
+	backendsMu sync.RWMutex
+	backends   = []Backend{
+		{Name: "virtio", Priority: PriorityVirtio, Detect: detectVirtioBlk},
+		{Name: "mmc", Priority: PriorityMMC, Detect: detectMMC},
+		{Name: "nvme", Priority: PriorityNVMe, Detect: detectNVMe},
+		{Name: "megaraid", Priority: PriorityRAID, Detect: detectMegaRAID},
+		{Name: "scsi", Priority: PrioritySCSI, Detect: detectSCSI},
+	}
+)
+
+// RegisterBackend registers a device backend, replacing any backend already registered under
+// the same name. Backends are typically registered from the init function of their package.
+func RegisterBackend(b Backend) {
+	backendsMu.Lock()
+	defer backendsMu.Unlock()
+
+	replaced := false
+	for i := range backends {
+		if backends[i].Name == b.Name {
+			backends[i] = b
+			replaced = true
+			break
+		}
+	}
+	if !replaced {
+		backends = append(backends, b)
+	}
+
+	// a replacement may change the priority of the backend
+	sort.SliceStable(backends, func(i, j int) bool { return backends[i].Priority > backends[j].Priority })
+}
+
+// Detect returns the device for name from the first registered backend handling it, trying the
+// backends in order of decreasing priority.
+func Detect(name string, opts DetectOptions) (Dev, error) {
+	backendsMu.RLock()
+	list := append([]Backend(nil), backends...)
+	backendsMu.RUnlock()
+
+	for _, b := range list {
+		if dev, err := b.Detect(name, opts); dev != nil || err != nil {
+			return dev, err
+		}
+	}
+
+	return nil, fmt.Errorf("no backend handles device %s", name)
+}
@@ -0,0 +1,125 @@
+/*
+Copyright 2018 The OpenEBS Authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Protocol-Specific Port log page (0x18) for SAS phy error counters.
+// See SPL-3 section 9.2.8.1 (Protocol-Specific Port log page for SAS).
+
+package scsismart
+
+import (
+	"encoding/binary"
+	"fmt"
+)
+
+// sasProtocolID is the protocol identifier of SAS in protocol specific log parameters
+const sasProtocolID = 0x6
+
+// SASPhyCounters holds the error counters of a single SAS phy
+type SASPhyCounters struct {
+	PortID             uint16 // relative target port identifier
+	PhyID              uint8
+	SASAddress         uint64
+	AttachedSASAddress uint64
+	AttachedPhyID      uint8
+	NegotiatedLinkRate string
+	InvalidDwordCount  uint32
+	DisparityErrors    uint32 // running disparity error count
+	LossOfDwordSync    uint32
+	PhyResetProblems   uint32
+}
+
+// sasLinkRate returns the negotiated logical link rate of a phy as a string
+func sasLinkRate(rate uint8) string {
+	switch rate {
+	case 0x0:
+		return "unknown"
+	case 0x1:
+		return "phy disabled"
+	case 0x2:
+		return "speed negotiation failed"
+	case 0x3:
+		return "SATA spinup hold"
+	case 0x8:
+		return "1.5 Gbps"
+	case 0x9:
+		return "3 Gbps"
+	case 0xa:
+		return "6 Gbps"
+	case 0xb:
+		return "12 Gbps"
+	case 0xc:
+		return "22.5 Gbps"
+	}
+
+	return fmt.Sprintf("reserved (%#x)", rate)
+}
+
+// SASPhyCounters returns the error counters of every phy of a SAS device from the
+// Protocol-Specific Port log page
+func (d *SCSIDevice) SASPhyCounters() ([]SASPhyCounters, error) {
+	page, err := d.logSense(ProtocolSpecificPortLogPage, 0)
+	if err != nil {
+		return nil, fmt.Errorf("SgExecute LOG SENSE protocol specific port: %w", err)
+	}
+
+	var phys []SASPhyCounters
+
+	for offset := 4; offset+8 <= len(page); {
+		param := page[offset:]
+		paramLen := int(param[3]) + 4
+		if offset+paramLen > len(page) {
+			paramLen = len(page) - offset
+		}
+		if paramLen < 8 {
+			break
+		}
+
+		if param[4]&0x0f != sasProtocolID {
+			return nil, fmt.Errorf("protocol specific port log page is not for SAS (protocol %#x)", param[4]&0x0f)
+		}
+
+		portID := binary.BigEndian.Uint16(param[0:])
+		numPhys := int(param[7])
+
+		// SAS phy log descriptors follow the 8 byte port parameter header
+		desc := param[8:paramLen]
+		for i := 0; i < numPhys && len(desc) >= 48; i++ {
+			descLen := int(desc[3]) + 4
+			if descLen < 48 {
+				descLen = 48
+			}
+
+			phys = append(phys, SASPhyCounters{
+				PortID:             portID,
+				PhyID:              desc[1],
+				SASAddress:         binary.BigEndian.Uint64(desc[8:]),
+				AttachedSASAddress: binary.BigEndian.Uint64(desc[16:]),
+				AttachedPhyID:      desc[24],
+				NegotiatedLinkRate: sasLinkRate(desc[5] & 0x0f),
+				InvalidDwordCount:  binary.BigEndian.Uint32(desc[32:]),
+				DisparityErrors:    binary.BigEndian.Uint32(desc[36:]),
+				LossOfDwordSync:    binary.BigEndian.Uint32(desc[40:]),
+				PhyResetProblems:   binary.BigEndian.Uint32(desc[44:]),
+			})
+
+			if descLen > len(desc) {
+				break
+			}
+			desc = desc[descLen:]
+		}
+
+		offset += paramLen
+	}
+
+	return phys, nil
+}
@@ -16,12 +16,11 @@ limitations under the License.
 package scsismart
 
 import (
-	"bytes"
-	"encoding/binary"
 	"fmt"
+	"io"
 
 	"github.com/openebs/smart/atasmart"
-	"github.com/openebs/smart/utilities"
+	"github.com/openebs/smart/internal/utilities"
 )
 
 // SATA is a simple wrapper around an embedded SCSIDevice type, which handles sending ATA
@@ -37,94 +36,256 @@ func (d *SATA) AtaIdentify() (atasmart.IdentDevData, error) {
 	responseBuf := make([]byte, 512)
 
 	cdb16 := CDB16{SCSIATAPassThru16}
-	cdb16[1] = 0x08 // ATA protocol (4 << 1, PIO data-in)
-	cdb16[2] = 0x0e // BYT_BLOK = 1, T_LENGTH = 2, T_DIR = 1
-	cdb16[14] = atasmart.AtaIdentifyDevice
+	cdb16.SetATAProtocol(ATAProtocolPIODataIn, false)
+	cdb16.SetATATransfer(true, true, ATATLengthSectorCount)
+	cdb16.SetATARegisters(atasmart.AtaIdentifyDevice, 0, 1, 0, 0)
 
 	if err := d.sendCDB(cdb16[:], &responseBuf); err != nil {
-		return identifyBuf, fmt.Errorf("sendCDB ATA IDENTIFY: %v", err)
+		return identifyBuf, fmt.Errorf("sendCDB ATA IDENTIFY: %w", err)
 	}
 
-	binary.Read(bytes.NewBuffer(responseBuf), utilities.NativeEndian, &identifyBuf)
+	return atasmart.ParseIdentDevData(responseBuf)
+}
 
-	return identifyBuf, nil
+// ataNonData sends an ATA command which transfers no data via SCSI_ATA_PASSTHRU_16
+func (d *SATA) ataNonData(command uint8, features, count uint16, lba uint64) error {
+	return d.ataNonDataExt(command, features, count, lba, false)
 }
 
-// GetDiskInfo returns all the disk attributes and smart info for a particular SATA device
-func (d *SATA) GetDiskInfo() (DiskAttr, error) {
-	// Standard SCSI INQUIRY command
-	inqResp, err := d.SCSIInquiry()
+// ataNonDataExt sends an ATA command which transfers no data via SCSI_ATA_PASSTHRU_16, using
+// 48-bit registers if extend is set
+func (d *SATA) ataNonDataExt(command uint8, features, count uint16, lba uint64, extend bool) error {
+	cdb16 := CDB16{SCSIATAPassThru16}
+	cdb16.SetATAProtocol(ATAProtocolNonData, extend)
+	cdb16.SetATATransfer(false, false, ATATLengthNone)
+	cdb16.SetATARegisters(command, features, count, lba, 0)
+
+	var noData []byte
+	return d.sendCDBDirection(cdb16[:], SGDxferNone, &noData)
+}
+
+// smartLBA holds the SMART signature in the LBA mid/high registers
+const smartLBA = atasmart.SmartLBAHigh<<16 | atasmart.SmartLBAMid<<8
+
+// smartReadCommand sends an ATA SMART command with the given feature via SCSI_ATA_PASSTHRU_16 and
+// returns the 512-byte data sector read in response
+func (d *SATA) smartReadCommand(feature uint8) ([]byte, error) {
+	responseBuf := make([]byte, 512)
+
+	cdb16 := CDB16{SCSIATAPassThru16}
+	cdb16.SetATAProtocol(ATAProtocolPIODataIn, false)
+	cdb16.SetATATransfer(true, true, ATATLengthSectorCount)
+	cdb16.SetATARegisters(atasmart.AtaSmart, uint16(feature), 1, smartLBA, 0)
+
+	if err := d.sendCDB(cdb16[:], &responseBuf); err != nil {
+		return nil, err
+	}
+
+	return responseBuf, nil
+}
+
+// ReadSMARTData sends an ATA SMART READ DATA command via SCSI_ATA_PASSTHRU_16 and returns the SMART data page
+func (d *SATA) ReadSMARTData() (atasmart.SmartPage, error) {
+	responseBuf, err := d.smartReadCommand(atasmart.SmartReadData)
 	if err != nil {
-		return DiskAttr{}, fmt.Errorf("SgExecute INQUIRY: %v", err)
+		return atasmart.SmartPage{}, fmt.Errorf("sendCDB SMART READ DATA: %w", err)
 	}
 
-	// inqCapacity is the total capacity of a disk in bytes
-	inqCapacity, err := d.readCapacity()
+	return atasmart.ParseSmartPage(responseBuf)
+}
+
+// ReadSMARTThresholds sends an ATA SMART READ THRESHOLDS command via SCSI_ATA_PASSTHRU_16 and returns
+// the SMART threshold page
+func (d *SATA) ReadSMARTThresholds() (atasmart.SmartThresholdPage, error) {
+	responseBuf, err := d.smartReadCommand(atasmart.SmartReadThresholds)
 	if err != nil {
-		return DiskAttr{}, fmt.Errorf("SgExecute readCapacity: %v", err)
+		return atasmart.SmartThresholdPage{}, fmt.Errorf("sendCDB SMART READ THRESHOLDS: %w", err)
 	}
 
+	return atasmart.ParseSmartThresholdPage(responseBuf)
+}
+
+// GetSMARTAttributes returns the SMART attributes of a SATA device, decoded using the drive database.
+// If the thresholds cannot be read, the attributes are returned without them along with a MultiError.
+func (d *SATA) GetSMARTAttributes() (atasmart.AttrCollection, error) {
 	identifyBuf, err := d.AtaIdentify()
 	if err != nil {
-		return DiskAttr{}, err
+		return nil, err
 	}
 
-	LogicalSec, PhysicalSec := identifyBuf.GetSectorSize()
+	smartBuf, err := d.ReadSMARTData()
+	if err != nil {
+		return nil, err
+	}
+
+	return d.smartAttributes(&identifyBuf, &smartBuf)
+}
+
+// smartAttributes decodes the attributes of a SMART data page using the drive database, with the
+// thresholds of the device if it reports them. A failure to read the thresholds is returned as a
+// MultiError along with the attributes.
+func (d *SATA) smartAttributes(identifyBuf *atasmart.IdentDevData, smartBuf *atasmart.SmartPage) (atasmart.AttrCollection, error) {
+	var errs MultiError
+
+	// Thresholds are optional (obsolete since ACS-3), attributes are still returned without them.
+	// A page with a wrong checksum is corrupted and is not used either.
+	var thresholds *atasmart.SmartThresholdPage
+	thresholdBuf, err := d.ReadSMARTThresholds()
+	if err == nil {
+		thresholds = &thresholdBuf
+	}
+	errs.add("SMART READ THRESHOLDS", err)
+
+	model := string(identifyBuf.GetModelNumber())
+	firmware := string(identifyBuf.GetFirmwareRevision())
+
+	attrs := atasmart.NewAttrCollection(smartBuf, thresholds, model, firmware)
+
+	return atasmart.LookupQuirks(model, firmware).Apply(attrs), errs.err()
+}
+
+// applyQuirks overrides the identity of a SATA device reported by ATA IDENTIFY according to the
+// quirks of its model and firmware revision
+func applyQuirks(identifyBuf *atasmart.IdentDevData, attr *DiskAttr) {
+	quirks := atasmart.LookupQuirks(attr.ModelNumber, attr.FirmwareRevision)
+
+	if quirks.Has(atasmart.QuirkSwappedSerial) {
+		attr.SerialNumber = string(identifyBuf.SerialNumber[:])
+	}
+	if quirks.Has(atasmart.QuirkSwappedModel) {
+		attr.ModelNumber = string(identifyBuf.ModelNumber[:])
+	}
+	if quirks.Has(atasmart.QuirkBogusWWN) {
+		attr.LuWWNDeviceID = ""
+		attr.WWN = 0
+	}
+}
+
+// GetDiskInfo returns all the disk attributes and smart info for a particular SATA device. If some
+// of the commands fail, the attributes gathered by the others are returned along with a MultiError.
+func (d *SATA) GetDiskInfo() (DiskAttr, error) {
+	var errs MultiError
+
+	// Standard SCSI INQUIRY command
+	inqResp, err := d.SCSIInquiry()
+	errs.add("INQUIRY", err)
+
+	// inqCapacity holds the total capacity of a disk
+	inqCapacity, err := d.ReadCapacity()
+	errs.add("READ CAPACITY", err)
 
 	SATASmartAttr := DiskAttr{}
 	SATASmartAttr.SCSIInquiry = inqResp
-	SATASmartAttr.UserCapacity = inqCapacity
-	SATASmartAttr.LBSize = LogicalSec
-	SATASmartAttr.PBSize = PhysicalSec
+	SATASmartAttr.UserCapacity = inqCapacity.Bytes()
+	SATASmartAttr.TotalLogicalBlocks = inqCapacity.LogicalBlocks
+	SATASmartAttr.LBSize = uint16(inqCapacity.LogicalBlockSize)
+	SATASmartAttr.PBSize = uint16(inqCapacity.PhysicalBlockSize)
+
+	identifyBuf, err := d.AtaIdentify()
+	if err != nil {
+		errs.add("ATA IDENTIFY", err)
+		SATASmartAttr.Hypervisor = inqResp.Hypervisor()
+		SATASmartAttr.Virtual = SATASmartAttr.Hypervisor != ""
+		setIdentityAttr(d.Name, &SATASmartAttr)
+		return SATASmartAttr, errs.err()
+	}
+
+	SATASmartAttr.LBSize, SATASmartAttr.PBSize = identifyBuf.GetSectorSize()
 	SATASmartAttr.SerialNumber = string(identifyBuf.GetSerialNumber())
 	SATASmartAttr.LuWWNDeviceID = identifyBuf.GetWWN()
+	if wwn, ok := identifyBuf.GetWWNValue(); ok {
+		SATASmartAttr.WWN = wwn
+	}
 	SATASmartAttr.FirmwareRevision = string(identifyBuf.GetFirmwareRevision())
 	SATASmartAttr.ModelNumber = string(identifyBuf.GetModelNumber())
-	SATASmartAttr.RotationRate = identifyBuf.RotationRate
+	SATASmartAttr.FirmwareWarnings = atasmart.LookupFirmwareWarnings(SATASmartAttr.ModelNumber, SATASmartAttr.FirmwareRevision)
+	SATASmartAttr.RotationRate, SATASmartAttr.RotationRateStr = identifyBuf.GetRotationRate()
 	SATASmartAttr.ATAMajorVersion = identifyBuf.GetATAMajorVersion()
 	SATASmartAttr.ATAMinorVersion = identifyBuf.GetATAMinorVersion()
+	SATASmartAttr.TransportMinor = identifyBuf.GetTransportMinorVersion()
 	SATASmartAttr.Transport = identifyBuf.Transport()
+	SATASmartAttr.SMARTSupported = identifyBuf.Features().SMART.Supported
+	SATASmartAttr.Hypervisor = virtualPlatform(string(inqResp.VendorID[:]), SATASmartAttr.ModelNumber)
+	SATASmartAttr.Virtual = SATASmartAttr.Hypervisor != ""
+	applyQuirks(&identifyBuf, &SATASmartAttr)
+	setIdentityAttr(d.Name, &SATASmartAttr)
 
-	return SATASmartAttr, nil
+	return SATASmartAttr, errs.err()
 }
 
-// PrintDiskInfo prints all the available information for a SATA disk (both basic attr and smart attr)
-func (d *SATA) PrintDiskInfo() error {
+// WriteDiskInfo writes all the available information for a SATA disk (both basic attr and smart attr) to w.
+// The information which could not be gathered is reported by a MultiError once the rest is written.
+func (d *SATA) WriteDiskInfo(w io.Writer) error {
 	// Standard SCSI INQUIRY command
 	inqResp, err := d.SCSIInquiry()
 	if err != nil {
-		return fmt.Errorf("SgExecute INQUIRY: %v", err)
+		return fmt.Errorf("SgExecute INQUIRY: %w", err)
 	}
 
-	fmt.Println("SCSI INQUIRY:", inqResp)
+	fmt.Fprintln(w, "SCSI INQUIRY:", inqResp)
+
+	var errs MultiError
 
 	// inqCapacity is the total capacity of a disk in bytes
-	inqCapacity, err := d.readCapacity()
-	if err != nil {
-		return fmt.Errorf("SgExecute readCapacity: %v", err)
+	if inqCapacity, err := d.readCapacity(); err == nil {
+		fmt.Fprintf(w, "User Capacity:%v bytes (%v)\n", inqCapacity, utilities.ConvertBytes(inqCapacity))
+	} else {
+		errs.add("READ CAPACITY", err)
 	}
 
-	fmt.Printf("User Capacity:%v bytes (%v)\n", inqCapacity, utilities.ConvertBytes(inqCapacity))
-
 	identifyBuf, err := d.AtaIdentify()
 	if err != nil {
-		return err
+		errs.add("ATA IDENTIFY", err)
+		return errs.err()
 	}
 
 	LogicalSec, PhysicalSec := identifyBuf.GetSectorSize()
 
-	fmt.Println("\nATA IDENTIFY data :")
-	fmt.Printf("Serial Number: %s\n", identifyBuf.GetSerialNumber())
-	fmt.Printf("Model Number: %s\n", identifyBuf.GetModelNumber())
-	fmt.Println("LU WWN Device Id:", identifyBuf.GetWWN())
-	fmt.Printf("Firmware Revision: %s\n", identifyBuf.GetFirmwareRevision())
-	fmt.Println("ATA Major Version:", identifyBuf.GetATAMajorVersion())
-	fmt.Println("ATA Minor Version:", identifyBuf.GetATAMinorVersion())
-	fmt.Printf("Sector Size: %d bytes logical, %d bytes physical\n", LogicalSec, PhysicalSec)
-	fmt.Printf("Rotation Rate: %d\n", identifyBuf.RotationRate)
-	fmt.Printf("SMART support available: %v\n", identifyBuf.Word87>>14 == 1)
-	fmt.Printf("SMART support enabled: %v\n", identifyBuf.Word85&0x1 != 0)
-	fmt.Println("Transport:", identifyBuf.Transport())
-
-	return nil
+	fmt.Fprintln(w, "\nATA IDENTIFY data :")
+	fmt.Fprintf(w, "Serial Number: %s\n", identifyBuf.GetSerialNumber())
+	fmt.Fprintf(w, "Model Number: %s\n", identifyBuf.GetModelNumber())
+	fmt.Fprintln(w, "LU WWN Device Id:", identifyBuf.GetWWN())
+	fmt.Fprintf(w, "Firmware Revision: %s\n", identifyBuf.GetFirmwareRevision())
+	for _, warning := range atasmart.LookupFirmwareWarnings(string(identifyBuf.GetModelNumber()), string(identifyBuf.GetFirmwareRevision())) {
+		fmt.Fprintf(w, "WARNING: %s\n", warning)
+	}
+	fmt.Fprintln(w, "ATA Major Version:", identifyBuf.GetATAMajorVersion())
+	fmt.Fprintln(w, "ATA Minor Version:", identifyBuf.GetATAMinorVersion())
+	fmt.Fprintln(w, "Transport Minor Version:", identifyBuf.GetTransportMinorVersion())
+	fmt.Fprintf(w, "Sector Size: %d bytes logical, %d bytes physical\n", LogicalSec, PhysicalSec)
+	_, rotationRate := identifyBuf.GetRotationRate()
+	fmt.Fprintf(w, "Rotation Rate: %s\n", rotationRate)
+	features := identifyBuf.Features()
+	fmt.Fprintf(w, "SMART support available: %v\n", features.SMART.Supported)
+	fmt.Fprintf(w, "SMART support enabled: %v\n", features.SMART.Enabled)
+	fmt.Fprintln(w, "Transport:", identifyBuf.Transport())
+
+	if hypervisor := virtualPlatform(string(inqResp.VendorID[:]), string(identifyBuf.GetModelNumber())); hypervisor != "" {
+		fmt.Fprintf(w, "Virtual disk: %s\n", hypervisor)
+	}
+
+	fmt.Fprintln(w, "\nSupported feature sets :")
+	for _, f := range features.List() {
+		if f.Supported {
+			fmt.Fprintf(w, "%s (enabled: %v)\n", f.Name, f.Enabled)
+		}
+	}
+
+	if !features.SMART.Enabled {
+		return errs.err()
+	}
+
+	attrs, err := d.GetSMARTAttributes()
+	if err != nil && !IsPartial(err) {
+		errs.add("SMART READ DATA", err)
+		return errs.err()
+	}
+	errs.merge(err)
+
+	fmt.Fprintln(w, "\nSMART attributes :")
+	if err := attrs.WriteTable(w); err != nil {
+		return err
+	}
+
+	return errs.err()
 }
@@ -17,14 +17,20 @@ package scsismart
 
 import (
 	"bytes"
+	"context"
 	"encoding/binary"
 	"fmt"
+	"io"
+	"strings"
+	"sync"
+	"time"
 	"unsafe"
 
 	"golang.org/x/sys/unix"
 
-	"github.com/openebs/smart/ioctl"
-	"github.com/openebs/smart/utilities"
+	"github.com/openebs/smart/internal/ioctl"
+	"github.com/openebs/smart/internal/utilities"
+	"github.com/openebs/smart/nvme"
 )
 
 // SCSI generic (sg)
@@ -40,7 +46,7 @@ const (
 
 	SGIO = 0x2285
 
-	// DefaultTimeout in millisecs
+	// DefaultTimeout in millisecs, used for commands which are neither quick nor long
 	DefaultTimeout = 20000
 )
 
@@ -70,7 +76,13 @@ type sgIOHeader struct {
 	info           uint32  // auxiliary information
 }
 
+// Compile time check that sgIOHeader matches the layout of the kernel's struct sg_io_hdr on the
+// target architecture, a mismatch corrupts the SG_IO ioctl argument
+var _ [sgIOHeaderSize - unsafe.Sizeof(sgIOHeader{})]byte
+var _ [unsafe.Sizeof(sgIOHeader{}) - sgIOHeaderSize]byte
+
 type sgIOErr struct {
+	command      string // name of the failed command, see CDBName
 	scsiStatus   uint8
 	hostStatus   uint16
 	driverStatus uint16
@@ -79,43 +91,131 @@ type sgIOErr struct {
 
 // DiskAttr is the structure for returning disk details
 type DiskAttr struct {
-	SCSIInquiry      InquiryResponse
-	VendorID         uint16
-	UserCapacity     uint64
-	LBSize           uint16
-	PBSize           uint16
-	SerialNumber     string
-	LuWWNDeviceID    string
-	FirmwareRevision string
-	ModelNumber      string
-	RotationRate     uint16
-	ATAMajorVersion  string
-	ATAMinorVersion  string
-	Transport        string
+	KernelName         string   // e.g. sda
+	DevPath            string   // sysfs devpath, e.g. /devices/pci0000:00/.../block/sda
+	MajorMinor         string   // e.g. 8:0
+	Bus                string   // e.g. ata, scsi, usb, virtio
+	Driver             string   // e.g. sd, virtio_blk
+	ByIDLinks          []string // /dev/disk/by-id aliases
+	ByPathLinks        []string // /dev/disk/by-path aliases
+	SCSIInquiry        InquiryResponse
+	VendorID           uint16
+	UserCapacity       uint64
+	TotalLogicalBlocks uint64
+	LBSize             uint16
+	PBSize             uint16
+	SerialNumber       string
+	LuWWNDeviceID      string // naa. format WWN
+	WWN                uint64 // raw 64-bit WWN, 0 if not reported
+	FirmwareRevision   string
+	FirmwareWarnings   []string // known issues of the model and firmware revision
+	ModelNumber        string
+	RotationRate       uint16 // RPM, 0 for solid state devices or when not reported
+	RotationRateStr    string
+	ATAMajorVersion    string
+	ATAMinorVersion    string
+	TransportMinor     string // transport minor version of ATA devices
+	Transport          string
+	SMARTSupported     bool
+	Virtual            bool
+	Hypervisor         string
+	Paths              []string // all device paths leading to the disk when it is multipathed
+	ISCSITargetIQN     string
+	ISCSIPortal        string
+	FCRemoteWWPN       string
+	FCRemoteWWNN       string
+	FCFabricName       string
+	NVMeNamespaceID    uint32           // namespace ID of NVMe namespaces
+	NVMeCapacity       uint64           // NCAP of NVMe namespaces, bytes which may be allocated
+	NVMeUtilization    uint64           // NUSE of NVMe namespaces, bytes currently allocated
+	NVMeHealth         *nvme.HealthInfo // SMART / Health Information log of NVMe devices, nil for others
 }
 
 func (e sgIOErr) Error() string {
-	return fmt.Sprintf("SCSI status: %#02x, host status: %#02x, driver status: %#02x",
-		e.scsiStatus, e.hostStatus, e.driverStatus)
+	return fmt.Sprintf("%s failed: %s", e.command, e.statusString())
 }
 
 // Dev is the top-level device interface. All supported device types must implement these methods.
+// The optional capabilities of a device are discovered by asserting it to the capability
+// interfaces, e.g. SMARTReader or SelfTester.
 type Dev interface {
 	Open() error
 	Close() error
-	PrintDiskInfo() error
-	GetDiskInfo() (DiskAttr, error)
+	WriteDiskInfo(w io.Writer) error
+	Identifier
+
+	// HealthStatus returns the overall health of the device, mapping the health information of
+	// its protocol to a single state
+	HealthStatus() (HealthStatus, error)
 }
 
-// SCSIDevice structure
+// SCSIDevice structure. An opened SCSIDevice may be used by several goroutines at once: the
+// commands sent to the device are serialized, multi-command sequences such as SCT commands
+// included. Open and the setters, e.g. SetTimeouts, must not be called concurrently with
+// other methods.
 type SCSIDevice struct {
-	Name string
-	fd   int
+	Name     string
+	fd       int
+	timeouts Timeouts
+	ctx      context.Context
+	executor Executor    // nil to send the commands directly to the device
+	cmdMu    *sync.Mutex // serializes the commands sent to the device, set by Open
+	leak     *leakTracker
+
+	allowPowerManagement bool
+	passThrough          PassThrough
+}
+
+// DetectOptions controls how the type of a SCSI device is detected
+type DetectOptions struct {
+	// ISCSIPassThrough allows ATA pass-through commands to disks attached over iSCSI.
+	// Most iSCSI targets reject them, so iSCSI disks are treated as plain SCSI by default.
+	ISCSIPassThrough bool
+
+	// Timeouts overrides the SG_IO timeouts of each command class
+	Timeouts Timeouts
+
+	// Context bounds the SG_IO timeouts of the commands sent to the device by its deadline
+	Context context.Context
+
+	// AllowPowerManagement allows changing the power management settings of the device, such
+	// as its standby timer. They are read-only by default.
+	AllowPowerManagement bool
+
+	// Executor executes the SG_IO requests of the device, e.g. in a privileged helper. The
+	// executor set by SetExecutor is used if nil.
+	Executor Executor
+
+	// PassThrough selects how ATA commands are sent to ATA disks, PassThroughAuto if empty
+	PassThrough PassThrough
+
+	// NonDisk returns the devices which are not disks, e.g. CD/DVD drives, tapes or enclosures,
+	// as plain SCSI devices instead of failing with a NotDiskError
+	NonDisk bool
 }
 
 // DetectSCSIType returns the type of SCSI device
 func DetectSCSIType(name string) (Dev, error) {
-	dev := SCSIDevice{Name: name}
+	return DetectSCSITypeWithOptions(name, DetectOptions{})
+}
+
+// DetectSCSITypeWithOptions returns the type of SCSI device, honouring the given options
+func DetectSCSITypeWithOptions(name string, opts DetectOptions) (Dev, error) {
+	return Detect(name, opts)
+}
+
+// detectSCSI returns a SATA device for ATA disks behind a SCSI/ATA translation layer, and a plain
+// SCSI device otherwise. Devices which are not disks are classified by their peripheral device
+// type, and never routed to ATA pass-through.
+func detectSCSI(name string, opts DetectOptions) (Dev, error) {
+	dev := SCSIDevice{
+		Name:                 name,
+		timeouts:             opts.Timeouts,
+		ctx:                  opts.Context,
+		allowPowerManagement: opts.AllowPowerManagement,
+		executor:             opts.Executor,
+		passThrough:          opts.PassThrough,
+	}
 
 	if err := dev.Open(); err != nil {
 		return nil, err
@@ -123,26 +223,84 @@ func DetectSCSIType(name string) (Dev, error) {
 
 	SCSIInquiry, err := dev.SCSIInquiry()
 	if err != nil {
+		dev.Close()
 		return nil, err
 	}
 
+	if !SCSIInquiry.IsDisk() {
+		if !opts.NonDisk {
+			dev.Close()
+			return nil, NotDiskError{Name: name, Inquiry: SCSIInquiry}
+		}
+		return &dev, nil
+	}
+
 	// Check if device is an ATA device (For an ATA device VendorIdentication value should be equal to ATA    )
 	if SCSIInquiry.VendorID == [8]byte{0x41, 0x54, 0x41, 0x20, 0x20, 0x20, 0x20, 0x20} {
-		return &SATA{dev}, nil
+		switch opts.PassThrough {
+		case PassThroughNone:
+		case PassThroughSAT16, PassThroughSAT12:
+			return &SATA{dev}, nil
+		default:
+			if opts.ISCSIPassThrough || !isISCSI(name) {
+				return &SATA{dev}, nil
+			}
+		}
 	}
 
 	return &dev, nil
 }
 
-// Open returns error if a SCSI device returns error when opened
+// Open returns error if a SCSI device returns error when opened. Devices whose commands go
+// through an Executor are not opened by this process.
 func (d *SCSIDevice) Open() (err error) {
+	if d.cmdMu == nil {
+		d.cmdMu = new(sync.Mutex)
+	}
+	if d.executor == nil {
+		d.executor = currentExecutor()
+	}
+	if d.remote() {
+		return nil
+	}
+
 	d.fd, err = unix.Open(d.Name, unix.O_RDWR, 0600)
+	if err == nil {
+		d.leak = newLeakTracker(d.Name)
+	}
 	return err
 }
 
-// Close returns error if a SCSI device is not closed
+// Close returns error if a SCSI device is not closed. It waits for the command in progress.
 func (d *SCSIDevice) Close() error {
-	return unix.Close(d.fd)
+	if d.remote() {
+		return nil
+	}
+
+	defer d.lock()()
+
+	// The descriptor is invalidated so that later commands cannot reach a reused descriptor
+	fd := d.fd
+	d.fd = -1
+	d.leak.closed()
+
+	return unix.Close(fd)
+}
+
+// lock acquires the command lock of the device and returns the function releasing it
+func (d *SCSIDevice) lock() func() {
+	if d.cmdMu == nil {
+		return func() {}
+	}
+
+	d.cmdMu.Lock()
+	return d.cmdMu.Unlock
+}
+
+// remote reports whether the commands of the device go through another process
+func (d *SCSIDevice) remote() bool {
+	_, local := d.executor.(LocalExecutor)
+	return d.executor != nil && !local
 }
 
 func (d *SCSIDevice) execSCSIGeneric(hdr *sgIOHeader) error {
@@ -164,42 +322,151 @@ func (d *SCSIDevice) execSCSIGeneric(hdr *sgIOHeader) error {
 }
 
 // SCSIInquiry sends a SCSI INQUIRY command to a device and returns an InquiryResponse struct.
+// The minimum response is requested first, as some devices reject other allocation lengths, and
+// the full response is requested again if the device reports more data.
 func (d *SCSIDevice) SCSIInquiry() (InquiryResponse, error) {
 	var response InquiryResponse
 
-	respBuf := make([]byte, INQRespLen)
+	respBuf, err := d.inquiry(INQRespLen)
+	if err != nil {
+		return response, err
+	}
+
+	if n := int(respBuf[4]) + 5; n > INQRespLen {
+		if n > 255 {
+			n = 255
+		}
+		// The minimum response is still usable if the device rejects the longer one
+		if full, err := d.inquiry(n); err == nil {
+			respBuf = full
+		}
+	}
+
+	if len(respBuf) < INQRespExtendedLen {
+		respBuf = append(respBuf, make([]byte, INQRespExtendedLen-len(respBuf))...)
+	}
+
+	// INQUIRY data is big-endian like all SCSI data
+	binary.Read(bytes.NewBuffer(respBuf), binary.BigEndian, &response)
+
+	return response, nil
+}
+
+// inquiry sends a SCSI INQUIRY command for the standard INQUIRY data with an allocation length
+func (d *SCSIDevice) inquiry(allocLen int) ([]byte, error) {
+	respBuf := make([]byte, allocLen)
 
 	cdb := CDB6{SCSIInquiry}
-	binary.BigEndian.PutUint16(cdb[3:], uint16(len(respBuf)))
+	cdb.SetAllocationLength(uint8(len(respBuf)))
 
 	if err := d.sendCDB(cdb[:], &respBuf); err != nil {
-		return response, err
+		return nil, err
 	}
 
-	binary.Read(bytes.NewBuffer(respBuf), utilities.NativeEndian, &response)
+	return respBuf, nil
+}
 
-	return response, nil
+// inquiryVPD sends a SCSI INQUIRY command for a vital product data page and returns the page,
+// trimmed to the page length reported by the device.
+func (d *SCSIDevice) inquiryVPD(pageNo uint8) ([]byte, error) {
+	respBuf := make([]byte, 252)
+
+	cdb := CDB6{SCSIInquiry}
+	cdb.SetVPDPage(pageNo)
+	cdb.SetAllocationLength(uint8(len(respBuf)))
+
+	if err := d.sendCDB(cdb[:], &respBuf); err != nil {
+		return nil, err
+	}
+
+	if respBuf[1] != pageNo {
+		return nil, fmt.Errorf("INQUIRY returned VPD page %#02x instead of %#02x", respBuf[1], pageNo)
+	}
+
+	pageLen := int(binary.BigEndian.Uint16(respBuf[2:])) + 4
+	if pageLen > len(respBuf) {
+		pageLen = len(respBuf)
+	}
+
+	return respBuf[:pageLen], nil
 }
 
 // sendCDB sends a SCSI Command Descriptor Block to the device and writes the response into the
 // supplied []byte pointer.
 func (d *SCSIDevice) sendCDB(cdb []byte, respBuf *[]byte) error {
+	return d.sendCDBDirection(cdb, SGDxferFromDev, respBuf)
+}
+
+// sendCDBDirection sends a SCSI Command Descriptor Block to the device, transferring the
+// supplied buffer in the given SG dxfer direction.
+func (d *SCSIDevice) sendCDBDirection(cdb []byte, direction int32, dataBuf *[]byte) error {
+	defer d.lock()()
+
+	return d.sendCDBLocked(cdb, direction, dataBuf)
+}
+
+// sendCDBLocked sends a CDB like sendCDBDirection, with the command lock of the device held by
+// the caller
+func (d *SCSIDevice) sendCDBLocked(cdb []byte, direction int32, dataBuf *[]byte) error {
+	timeout, err := d.commandTimeout(cdb)
+	if err != nil {
+		return err
+	}
+
+	if d.passThrough == PassThroughSAT12 && cdb[0] == SCSIATAPassThru16 {
+		if cdb, err = ataPassThru12(cdb); err != nil {
+			return err
+		}
+	}
+
+	l := currentTraceLogger()
+	if l == nil {
+		return d.sendCDBDirect(cdb, direction, dataBuf, timeout)
+	}
+
+	start := time.Now()
+	err = d.sendCDBDirect(cdb, direction, dataBuf, timeout)
+	traceCDB(l, d.Name, cdb, direction, *dataBuf, time.Since(start), err)
+
+	return err
+}
+
+// sendCDBDirect sends a CDB to the opened device or through the executor of the device
+func (d *SCSIDevice) sendCDBDirect(cdb []byte, direction int32, dataBuf *[]byte, timeout uint32) error {
+	if d.remote() {
+		return d.execRemote(cdb, direction, dataBuf, timeout)
+	}
+
+	return d.sendSG(cdb, direction, dataBuf, timeout)
+}
+
+// sendSG sends a SCSI Command Descriptor Block to the opened device with the SG_IO ioctl
+func (d *SCSIDevice) sendSG(cdb []byte, direction int32, dataBuf *[]byte, timeout uint32) error {
 	senseBuf := make([]byte, 32)
 
 	// Populate required fields of "sg_io_hdr_t" struct
 	header := sgIOHeader{
 		interfaceID:    'S',
-		dxferDirection: SGDxferFromDev,
-		timeout:        DefaultTimeout,
+		dxferDirection: direction,
+		timeout:        timeout,
 		cmdLen:         uint8(len(cdb)),
 		mxSBLen:        uint8(len(senseBuf)),
-		dxferLen:       uint32(len(*respBuf)),
-		dxferp:         uintptr(unsafe.Pointer(&(*respBuf)[0])),
+		dxferLen:       uint32(len(*dataBuf)),
 		cmdp:           uintptr(unsafe.Pointer(&cdb[0])),
 		sbp:            uintptr(unsafe.Pointer(&senseBuf[0])),
 	}
+	if len(*dataBuf) > 0 {
+		header.dxferp = uintptr(unsafe.Pointer(&(*dataBuf)[0]))
+	}
 
-	return d.execSCSIGeneric(&header)
+	err := d.execSCSIGeneric(&header)
+	if e, ok := err.(sgIOErr); ok {
+		e.command = CDBName(cdb)
+		copy(e.senseBuf[:], senseBuf)
+		return e
+	}
+
+	return err
 }
 
 // modeSense sends a SCSI MODE SENSE(6) command to a device.
@@ -207,9 +474,8 @@ func (d *SCSIDevice) modeSense(pageNo, subPageNo, pageCtrl uint8) ([]byte, error
 	respBuf := make([]byte, 64)
 
 	cdb := CDB6{SCSIModeSense6}
-	cdb[2] = (pageCtrl << 6) | (pageNo & 0x3f)
-	cdb[3] = subPageNo
-	cdb[4] = uint8(len(respBuf))
+	cdb.SetPage(pageCtrl, pageNo, subPageNo)
+	cdb.SetAllocationLength(uint8(len(respBuf)))
 
 	if err := d.sendCDB(cdb[:], &respBuf); err != nil {
 		return respBuf, err
@@ -218,51 +484,273 @@ func (d *SCSIDevice) modeSense(pageNo, subPageNo, pageCtrl uint8) ([]byte, error
 	return respBuf, nil
 }
 
-// readCapacity sends a SCSI READ CAPACITY(10) command to a device and returns the capacity in bytes.
-func (d *SCSIDevice) readCapacity() (uint64, error) {
+// ReadLogPage returns a log page of the device read with the SCSI LOG SENSE command
+func (d *SCSIDevice) ReadLogPage(pageNo, subPageNo uint8) ([]byte, error) {
+	return d.logSense(pageNo, subPageNo)
+}
+
+// logSense sends a SCSI LOG SENSE command to a device and returns the log page, trimmed to
+// the page length reported by the device.
+func (d *SCSIDevice) logSense(pageNo, subPageNo uint8) ([]byte, error) {
+	respBuf := make([]byte, 4096)
+
+	cdb := CDB10{SCSILogSense}
+	cdb.SetPage(LogPageControlCumulative, pageNo, subPageNo)
+	cdb.SetAllocationLength(uint16(len(respBuf)))
+
+	if err := d.sendCDB(cdb[:], &respBuf); err != nil {
+		return nil, err
+	}
+
+	if respBuf[0]&0x3f != pageNo {
+		return nil, fmt.Errorf("LOG SENSE returned page %#02x instead of %#02x", respBuf[0]&0x3f, pageNo)
+	}
+
+	pageLen := int(binary.BigEndian.Uint16(respBuf[2:])) + 4
+	if pageLen > len(respBuf) {
+		pageLen = len(respBuf)
+	}
+
+	return respBuf[:pageLen], nil
+}
+
+// modeSelect sends a SCSI MODE SELECT(6) command to a device with the supplied mode page.
+// If save is set, the device is asked to also store the page in its saved parameters.
+func (d *SCSIDevice) modeSelect(page []byte, save bool) error {
+	// Mode parameter header(6) with no block descriptors, followed by the page
+	paramBuf := make([]byte, 4+len(page))
+	copy(paramBuf[4:], page)
+	paramBuf[4] &^= 0x80 // PS bit is reserved for MODE SELECT
+
+	flags := uint8(0x10) // PF = 1
+	if save {
+		flags |= 0x01 // SP = 1
+	}
+
+	cdb := CDB6{SCSIModeSelect6}
+	cdb.SetFlags(flags)
+	cdb.SetAllocationLength(uint8(len(paramBuf)))
+
+	return d.sendCDBDirection(cdb[:], SGDxferToDev, &paramBuf)
+}
+
+// modePage returns the first mode page contained in a MODE SENSE(6) response.
+func modePage(response []byte) ([]byte, error) {
+	if len(response) < 4 {
+		return nil, fmt.Errorf("short MODE SENSE response (%d bytes)", len(response))
+	}
+
+	respLen := int(response[0]) + 1
+	offset := int(response[3]) + 4
+	if respLen > len(response) {
+		respLen = len(response)
+	}
+	if offset+4 > respLen {
+		return nil, fmt.Errorf("MODE SENSE response contains no mode page")
+	}
+
+	// Page length is one byte for page_0 format, two bytes for sub_page format (SPF bit set)
+	pageLen := int(response[offset+1]) + 2
+	if response[offset]&0x40 != 0 {
+		pageLen = int(binary.BigEndian.Uint16(response[offset+2:])) + 4
+	}
+	if offset+pageLen > respLen {
+		return nil, fmt.Errorf("truncated mode page %#02x", response[offset]&0x3f)
+	}
+
+	return response[offset : offset+pageLen], nil
+}
+
+// Capacity holds the capacity details of a device as reported by READ CAPACITY
+type Capacity struct {
+	LogicalBlocks     uint64 // total number of logical blocks
+	LogicalBlockSize  uint32 // logical block (i.e., sector) size in bytes
+	PhysicalBlockSize uint32 // physical block size in bytes
+}
+
+// Bytes returns the total capacity in bytes
+func (c Capacity) Bytes() uint64 {
+	return c.LogicalBlocks * uint64(c.LogicalBlockSize)
+}
+
+// ReadCapacity sends a SCSI READ CAPACITY(16) command to a device, falling back to READ CAPACITY(10)
+// for devices which don't support it, and returns the capacity details.
+func (d *SCSIDevice) ReadCapacity() (Capacity, error) {
+	respBuf := make([]byte, 32)
+
+	cdb := CDB16{SCSIServiceAction}
+	cdb.SetServiceAction(ReadCapacity16ServiceAction)
+	cdb.SetAllocationLength(uint32(len(respBuf)))
+
+	if err := d.sendCDB(cdb[:], &respBuf); err != nil {
+		return d.readCapacity10()
+	}
+
+	lastLBA := binary.BigEndian.Uint64(respBuf[0:]) // max. addressable LBA
+	LBsize := binary.BigEndian.Uint32(respBuf[8:])  // logical block (i.e., sector) size
+	exponent := respBuf[13] & 0x0f                  // logical blocks per physical block exponent
+
+	return Capacity{
+		LogicalBlocks:     lastLBA + 1,
+		LogicalBlockSize:  LBsize,
+		PhysicalBlockSize: LBsize << exponent,
+	}, nil
+}
+
+// readCapacity10 sends a SCSI READ CAPACITY(10) command to a device and returns the capacity details.
+func (d *SCSIDevice) readCapacity10() (Capacity, error) {
 	respBuf := make([]byte, 8)
 	cdb := CDB10{SCSIReadCapacity10}
 
 	if err := d.sendCDB(cdb[:], &respBuf); err != nil {
-		return 0, err
+		return Capacity{}, err
 	}
 
 	lastLBA := binary.BigEndian.Uint32(respBuf[0:]) // max. addressable LBA
 	LBsize := binary.BigEndian.Uint32(respBuf[4:])  // logical block (i.e., sector) size
-	capacity := (uint64(lastLBA) + 1) * uint64(LBsize)
 
-	return capacity, nil
+	return Capacity{
+		LogicalBlocks:     uint64(lastLBA) + 1,
+		LogicalBlockSize:  LBsize,
+		PhysicalBlockSize: LBsize,
+	}, nil
 }
 
-// PrintDiskInfo prints basic disk information
-// Regular SCSI (including SAS, but excluding SATA)
-func (d *SCSIDevice) PrintDiskInfo() error {
-	capacity, _ := d.readCapacity()
-	fmt.Printf("Capacity: %d bytes (%s)\n", capacity, utilities.ConvertBytes(capacity))
+// readCapacity returns the capacity of a device in bytes.
+func (d *SCSIDevice) readCapacity() (uint64, error) {
+	capacity, err := d.ReadCapacity()
+	if err != nil {
+		return 0, err
+	}
+
+	return capacity.Bytes(), nil
+}
+
+// WriteDiskInfo writes basic disk information to w. The information which could not be
+// gathered is reported by a MultiError once the rest is written.
+func (d *SCSIDevice) WriteDiskInfo(w io.Writer) error {
+	inqResp, err := d.SCSIInquiry()
+	if err != nil {
+		return fmt.Errorf("SgExecute INQUIRY: %w", err)
+	}
+
+	fmt.Fprintln(w, "SCSI INQUIRY:", inqResp)
+	fmt.Fprintf(w, "Device Type: %s\n", inqResp.DeviceTypeString())
+	fmt.Fprintf(w, "Removable: %v, Protection: %v, Command Queuing: %v\n",
+		inqResp.Removable(), inqResp.Protect(), inqResp.CommandQueuing())
+	if standards := inqResp.Standards(); len(standards) > 0 {
+		fmt.Fprintf(w, "Standards: %s\n", strings.Join(standards, ", "))
+	}
+
+	var errs MultiError
+
+	if capacity, err := d.ReadCapacity(); err == nil {
+		fmt.Fprintf(w, "Capacity: %d bytes (%s)\n", capacity.Bytes(), utilities.ConvertBytes(capacity.Bytes()))
+		fmt.Fprintf(w, "Sector Size: %d bytes logical, %d bytes physical\n", capacity.LogicalBlockSize, capacity.PhysicalBlockSize)
+	} else {
+		errs.add("READ CAPACITY", err)
+	}
+
+	if wwn, _, err := d.GetWWN(); err == nil {
+		fmt.Fprintln(w, "LU WWN Device Id:", wwn)
+	}
+
+	if _, rotationRate, err := d.RotationRate(); err == nil {
+		fmt.Fprintf(w, "Rotation Rate: %s\n", rotationRate)
+	}
+
+	if limits, err := d.BlockLimits(); err == nil {
+		fmt.Fprintf(w, "Transfer Length: %d blocks maximum, %d blocks optimal\n",
+			limits.MaxTransferLength, limits.OptimalTransferLength)
+		fmt.Fprintf(w, "Unmap Granularity: %d blocks\n", limits.OptimalUnmapGranularity)
+	}
+
+	var transportAttr DiskAttr
+	setTransportAttr(d.Name, &transportAttr)
+	if transportAttr.Transport != "" {
+		fmt.Fprintln(w, "Transport:", transportAttr.Transport)
+	}
+	if transportAttr.ISCSITargetIQN != "" {
+		fmt.Fprintf(w, "iSCSI Target: %s (portal %s)\n", transportAttr.ISCSITargetIQN, transportAttr.ISCSIPortal)
+	}
+	if transportAttr.FCRemoteWWPN != "" {
+		fmt.Fprintf(w, "FC Remote Port: WWPN %s, WWNN %s, fabric %s\n",
+			transportAttr.FCRemoteWWPN, transportAttr.FCRemoteWWNN, transportAttr.FCFabricName)
+	}
+
+	// Virtual disks do not emulate the geometry page or SMART, don't print garbage for them
+	if hypervisor := inqResp.Hypervisor(); hypervisor != "" {
+		fmt.Fprintf(w, "Virtual disk: %s\n", hypervisor)
+		fmt.Fprintf(w, "SMART support available: %v\n", false)
+		return errs.err()
+	}
 
 	// TODO : Fetch other disk attributes also such as serial no, vendor, etc
 	// WIP
-	response, _ := d.modeSense(RigidDiskDriveGeometryPage, 0, ModePageControlDefault)
-	fmt.Printf("MODE SENSE buf: % x\n", response)
+	response, err := d.modeSense(RigidDiskDriveGeometryPage, 0, ModePageControlDefault)
+	if err == nil && len(response) >= 4 && int(response[3])+4+22 <= len(response) {
+		fmt.Fprintf(w, "MODE SENSE buf: % x\n", response)
+
+		respLen := response[0] + 1
+		bdLen := response[3]
+		offset := bdLen + 4
+		fmt.Fprintf(w, "respLen: %d, bdLen: %d, offset: %d\n",
+			respLen, bdLen, offset)
+
+		fmt.Fprintf(w, "RPM: %d\n", binary.BigEndian.Uint16(response[offset+20:]))
+	} else if err != nil {
+		errs.add("MODE SENSE", err)
+	} else {
+		errs.add("MODE SENSE", fmt.Errorf("short rigid disk geometry page: %d bytes", len(response)))
+	}
 
-	respLen := response[0] + 1
-	bdLen := response[3]
-	offset := bdLen + 4
-	fmt.Printf("respLen: %d, bdLen: %d, offset: %d\n",
-		respLen, bdLen, offset)
+	if caching, err := d.Caching(); err == nil {
+		fmt.Fprintf(w, "Write Cache: %v, Read Cache: %v, Read-Ahead: %v\n",
+			caching.WriteCache, !caching.ReadCacheDisabled, !caching.ReadAheadDisabled)
+	}
 
-	fmt.Printf("RPM: %d\n", binary.BigEndian.Uint16(response[offset+20:]))
+	if phys, err := d.SASPhyCounters(); err == nil {
+		fmt.Fprintln(w, "\nSAS phy error counters :")
+		for _, phy := range phys {
+			fmt.Fprintf(w, "Port %d phy %d (%s): invalid dwords %d, disparity errors %d, loss of dword sync %d, phy reset problems %d\n",
+				phy.PortID, phy.PhyID, phy.NegotiatedLinkRate, phy.InvalidDwordCount,
+				phy.DisparityErrors, phy.LossOfDwordSync, phy.PhyResetProblems)
+		}
+	}
 
-	return nil
+	return errs.err()
 }
 
-// GetDiskInfo returns smart disk info as well as basic disk info
+// GetDiskInfo returns smart disk info as well as basic disk info. If some of the commands fail,
+// the attributes gathered by the others are returned along with a MultiError.
 func (d *SCSIDevice) GetDiskInfo() (DiskAttr, error) {
-	capacity, _ := d.readCapacity()
+	var errs MultiError
+
+	inqResp, err := d.SCSIInquiry()
+	errs.add("INQUIRY", err)
+
+	// READ CAPACITY is a block command, only disks implement it
+	var capacity Capacity
+	if err != nil || inqResp.IsDisk() {
+		capacity, err = d.ReadCapacity()
+		errs.add("READ CAPACITY", err)
+	}
 
 	// TODO : Return all the basic disk attributes available for a particular disk
 	DiskSmartAttr := DiskAttr{}
-	DiskSmartAttr.UserCapacity = capacity
-
-	return DiskSmartAttr, nil
+	DiskSmartAttr.SCSIInquiry = inqResp
+	DiskSmartAttr.UserCapacity = capacity.Bytes()
+	DiskSmartAttr.TotalLogicalBlocks = capacity.LogicalBlocks
+	DiskSmartAttr.LBSize = uint16(capacity.LogicalBlockSize)
+	DiskSmartAttr.PBSize = uint16(capacity.PhysicalBlockSize)
+	DiskSmartAttr.Hypervisor = inqResp.Hypervisor()
+	DiskSmartAttr.Virtual = DiskSmartAttr.Hypervisor != ""
+	// Devices without a NAA designator have no WWN, which is not a failure
+	DiskSmartAttr.LuWWNDeviceID, DiskSmartAttr.WWN, _ = d.GetWWN()
+	// SPC-3 and older devices have no Block Device Characteristics VPD page
+	DiskSmartAttr.RotationRate, DiskSmartAttr.RotationRateStr, _ = d.RotationRate()
+	setTransportAttr(d.Name, &DiskSmartAttr)
+	setIdentityAttr(d.Name, &DiskSmartAttr)
+
+	return DiskSmartAttr, errs.err()
 }
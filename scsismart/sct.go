@@ -0,0 +1,77 @@
+/*
+Copyright 2018 The OpenEBS Authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// SCT Command Transport over SMART READ LOG and SMART WRITE LOG.
+
+package scsismart
+
+import (
+	"fmt"
+
+	"github.com/openebs/smart/atasmart"
+)
+
+// smartWriteLog sends an ATA SMART WRITE LOG command via SCSI_ATA_PASSTHRU_16, writing a 512-byte
+// sector to a SMART log. The caller holds the command lock.
+func (d *SATA) smartWriteLog(logAddr uint8, sector []byte) error {
+	cdb16 := CDB16{SCSIATAPassThru16}
+	cdb16.SetATAProtocol(ATAProtocolPIODataOut, false)
+	cdb16.SetATATransfer(false, true, ATATLengthSectorCount)
+	cdb16.SetATARegisters(atasmart.AtaSmart, atasmart.SmartWriteLog, 1, smartLBA|uint64(logAddr), 0)
+
+	return d.sendCDBLocked(cdb16[:], SGDxferToDev, &sector)
+}
+
+// smartReadLog sends an ATA SMART READ LOG command via SCSI_ATA_PASSTHRU_16 and returns the first
+// 512-byte sector of a SMART log. The caller holds the command lock.
+func (d *SATA) smartReadLog(logAddr uint8) ([]byte, error) {
+	responseBuf := make([]byte, atasmart.SectorSize)
+
+	cdb16 := CDB16{SCSIATAPassThru16}
+	cdb16.SetATAProtocol(ATAProtocolPIODataIn, false)
+	cdb16.SetATATransfer(true, true, ATATLengthSectorCount)
+	cdb16.SetATARegisters(atasmart.AtaSmart, atasmart.SmartReadLog, 1, smartLBA|uint64(logAddr), 0)
+
+	if err := d.sendCDBLocked(cdb16[:], SGDxferFromDev, &responseBuf); err != nil {
+		return nil, err
+	}
+
+	return responseBuf, nil
+}
+
+// SCTTemperatureHistory reads the SCT temperature history data table of a SATA device which
+// supports the SCT Command Transport
+func (d *SATA) SCTTemperatureHistory() (atasmart.SCTTempHistory, error) {
+	caps, err := d.Capabilities()
+	if err != nil {
+		return atasmart.SCTTempHistory{}, err
+	}
+	if !caps.SCT {
+		return atasmart.SCTTempHistory{}, fmt.Errorf("device does not support SCT Command Transport")
+	}
+
+	// No other command may come between the SCT command and its data transfer
+	defer d.lock()()
+
+	key := atasmart.SCTDataTableCommand(atasmart.SCTTableTempHistory)
+	if err := d.smartWriteLog(atasmart.SCTCommandStatusLog, key); err != nil {
+		return atasmart.SCTTempHistory{}, fmt.Errorf("sendCDB SMART WRITE LOG SCT data table: %w", err)
+	}
+
+	responseBuf, err := d.smartReadLog(atasmart.SCTDataTransferLog)
+	if err != nil {
+		return atasmart.SCTTempHistory{}, fmt.Errorf("sendCDB SMART READ LOG SCT data transfer: %w", err)
+	}
+
+	return atasmart.ParseSCTTempHistory(responseBuf)
+}
@@ -0,0 +1,88 @@
+/*
+Copyright 2018 The OpenEBS Authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Device self-tests.
+
+package scsismart
+
+import (
+	"fmt"
+
+	"github.com/openebs/smart/atasmart"
+)
+
+// SelfTestType is the kind of self-test to run
+type SelfTestType int
+
+// Self-test types
+const (
+	SelfTestShort SelfTestType = iota
+	SelfTestExtended
+	SelfTestConveyance
+	SelfTestAbort // aborts the running self-test
+)
+
+// ataSelfTests maps the self-test types to SMART EXECUTE OFF-LINE IMMEDIATE subcommands
+var ataSelfTests = map[SelfTestType]uint8{
+	SelfTestShort:      atasmart.SelfTestShort,
+	SelfTestExtended:   atasmart.SelfTestExtended,
+	SelfTestConveyance: atasmart.SelfTestConveyance,
+	SelfTestAbort:      atasmart.SelfTestAbort,
+}
+
+// SelfTestStatus is the state of the last or running self-test
+type SelfTestStatus struct {
+	InProgress bool
+	Remaining  int   // percentage of the running self-test remaining to be done
+	Result     uint8 // self-test execution status value, 0 when the last self-test passed
+}
+
+// StartSelfTest sends an ATA SMART EXECUTE OFF-LINE IMMEDIATE command via SCSI_ATA_PASSTHRU_16
+// to start a self-test in the background
+func (d *SATA) StartSelfTest(test SelfTestType) error {
+	subcommand, ok := ataSelfTests[test]
+	if !ok {
+		return fmt.Errorf("unknown self-test type %d", test)
+	}
+
+	if err := d.ataNonData(atasmart.AtaSmart, atasmart.SmartExecuteOfflineImmed, 0, smartLBA|uint64(subcommand)); err != nil {
+		return fmt.Errorf("sendCDB SMART EXECUTE OFF-LINE IMMEDIATE: %w", err)
+	}
+
+	return nil
+}
+
+// SelfTestStatus returns the self-test execution status from the SMART data page
+func (d *SATA) SelfTestStatus() (SelfTestStatus, error) {
+	smartBuf, err := d.ReadSMARTData()
+	if err != nil {
+		return SelfTestStatus{}, err
+	}
+
+	return selfTestStatus(&smartBuf), nil
+}
+
+// selfTestStatus returns the self-test execution status of a SMART data page
+func selfTestStatus(smartBuf *atasmart.SmartPage) SelfTestStatus {
+	return SelfTestStatus{
+		InProgress: smartBuf.SelfTestInProgress(),
+		Remaining:  smartBuf.SelfTestRemaining(),
+		Result:     smartBuf.SelfTestStatus >> 4,
+	}
+}
+
+// Failed reports whether the last self-test completed with a failure, rather than passing or
+// being aborted or interrupted
+func (s SelfTestStatus) Failed() bool {
+	return !s.InProgress && s.Result >= 3 && s.Result <= 8
+}
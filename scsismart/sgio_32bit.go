@@ -0,0 +1,22 @@
+//go:build 386 || arm || mips || mipsle
+// +build 386 arm mips mipsle
+
+/*
+Copyright 2018 The OpenEBS Authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// SG_IO header layout on 32-bit architectures.
+
+package scsismart
+
+// sgIOHeaderSize is the size of struct sg_io_hdr with 32-bit pointers
+const sgIOHeaderSize = 64
@@ -0,0 +1,254 @@
+/*
+Copyright 2018 The OpenEBS Authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Decoding of the Linux SG host_status and driver_status values.
+// See <scsi/scsi.h> (DID_*) and <scsi/sg.h> (DRIVER_*).
+
+package scsismart
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// Host status values (DID_*) reported by the host adapter driver
+const (
+	DIDOk            = 0x00
+	DIDNoConnect     = 0x01
+	DIDBusBusy       = 0x02
+	DIDTimeOut       = 0x03
+	DIDBadTarget     = 0x04
+	DIDAbort         = 0x05
+	DIDParity        = 0x06
+	DIDError         = 0x07
+	DIDReset         = 0x08
+	DIDBadIntr       = 0x09
+	DIDPassthrough   = 0x0a
+	DIDSoftError     = 0x0b
+	DIDImmRetry      = 0x0c
+	DIDRequeue       = 0x0d
+	DIDTransportDisr = 0x0e
+	DIDTransportFail = 0x0f
+	DIDTargetFailure = 0x10
+	DIDNexusFailure  = 0x11
+	DIDAllocFailure  = 0x12
+	DIDMediumError   = 0x13
+	DIDTransportMarg = 0x14
+)
+
+// Driver status values (DRIVER_*) reported by the SCSI mid-level
+const (
+	DriverOk      = 0x00
+	DriverBusy    = 0x01
+	DriverSoft    = 0x02
+	DriverMedia   = 0x03
+	DriverError   = 0x04
+	DriverInvalid = 0x05
+	DriverTimeout = 0x06
+	DriverHard    = 0x07
+	DriverSense   = 0x08
+
+	// driverStatusMask selects the driver status, the upper bits hold obsolete suggestions
+	driverStatusMask = 0x0f
+)
+
+var hostStatusNames = map[uint16]string{
+	DIDOk:            "DID_OK",
+	DIDNoConnect:     "DID_NO_CONNECT",
+	DIDBusBusy:       "DID_BUS_BUSY",
+	DIDTimeOut:       "DID_TIME_OUT",
+	DIDBadTarget:     "DID_BAD_TARGET",
+	DIDAbort:         "DID_ABORT",
+	DIDParity:        "DID_PARITY",
+	DIDError:         "DID_ERROR",
+	DIDReset:         "DID_RESET",
+	DIDBadIntr:       "DID_BAD_INTR",
+	DIDPassthrough:   "DID_PASSTHROUGH",
+	DIDSoftError:     "DID_SOFT_ERROR",
+	DIDImmRetry:      "DID_IMM_RETRY",
+	DIDRequeue:       "DID_REQUEUE",
+	DIDTransportDisr: "DID_TRANSPORT_DISRUPTED",
+	DIDTransportFail: "DID_TRANSPORT_FAILFAST",
+	DIDTargetFailure: "DID_TARGET_FAILURE",
+	DIDNexusFailure:  "DID_NEXUS_FAILURE",
+	DIDAllocFailure:  "DID_ALLOC_FAILURE",
+	DIDMediumError:   "DID_MEDIUM_ERROR",
+	DIDTransportMarg: "DID_TRANSPORT_MARGINAL",
+}
+
+var driverStatusNames = map[uint16]string{
+	DriverOk:      "DRIVER_OK",
+	DriverBusy:    "DRIVER_BUSY",
+	DriverSoft:    "DRIVER_SOFT",
+	DriverMedia:   "DRIVER_MEDIA",
+	DriverError:   "DRIVER_ERROR",
+	DriverInvalid: "DRIVER_INVALID",
+	DriverTimeout: "DRIVER_TIMEOUT",
+	DriverHard:    "DRIVER_HARD",
+	DriverSense:   "DRIVER_SENSE",
+}
+
+// HostStatusName returns the name of a SG host_status value, e.g. DID_NO_CONNECT
+func HostStatusName(status uint16) string {
+	if name, ok := hostStatusNames[status]; ok {
+		return name
+	}
+
+	return fmt.Sprintf("host status %#02x", status)
+}
+
+// DriverStatusName returns the name of a SG driver_status value, e.g. DRIVER_TIMEOUT
+func DriverStatusName(status uint16) string {
+	if name, ok := driverStatusNames[status&driverStatusMask]; ok {
+		return name
+	}
+
+	return fmt.Sprintf("driver status %#02x", status)
+}
+
+// IsTimeout returns true if the command timed out in the host adapter or the SCSI mid-level
+func (e sgIOErr) IsTimeout() bool {
+	return e.hostStatus == DIDTimeOut || e.driverStatus&driverStatusMask == DriverTimeout
+}
+
+// IsTransportError returns true if the command failed because the device could not be reached,
+// rather than being rejected by the device itself
+func (e sgIOErr) IsTransportError() bool {
+	switch e.hostStatus {
+	case DIDNoConnect, DIDBusBusy, DIDBadTarget, DIDParity, DIDError, DIDReset,
+		DIDTransportDisr, DIDTransportFail, DIDNexusFailure, DIDTransportMarg:
+		return true
+	}
+
+	return false
+}
+
+// SCSI status and sense keys of unrecovered read errors and unsupported commands
+const (
+	SCSIStatusCheckCondition = 0x02
+	SenseKeyMediumError      = 0x03
+	SenseKeyIllegalRequest   = 0x05
+)
+
+// senseKey returns the sense key of the sense data returned with a CHECK CONDITION status
+func (e sgIOErr) senseKey() uint8 {
+	switch e.senseBuf[0] & 0x7f {
+	case 0x70, 0x71: // fixed format
+		return e.senseBuf[2] & 0x0f
+	case 0x72, 0x73: // descriptor format
+		return e.senseBuf[1] & 0x0f
+	}
+
+	return 0
+}
+
+// IsMediumError returns true if the command failed because the medium could not be read
+func (e sgIOErr) IsMediumError() bool {
+	return e.scsiStatus == SCSIStatusCheckCondition && e.senseKey() == SenseKeyMediumError
+}
+
+// statusString returns the decoded non-zero statuses of a failed command
+func (e sgIOErr) statusString() string {
+	var status []string
+
+	if e.scsiStatus != 0 {
+		status = append(status, fmt.Sprintf("SCSI status %#02x", e.scsiStatus))
+	}
+	if e.hostStatus != DIDOk {
+		status = append(status, HostStatusName(e.hostStatus))
+	}
+	if e.driverStatus&driverStatusMask != DriverOk {
+		status = append(status, DriverStatusName(e.driverStatus))
+	}
+	if len(status) == 0 {
+		return "unknown error"
+	}
+
+	return strings.Join(status, ", ")
+}
+
+// IsTimeout returns true if err, or an error it wraps, is a SCSI command failure caused by a
+// timeout
+func IsTimeout(err error) bool {
+	var e sgIOErr
+	return errors.As(err, &e) && e.IsTimeout()
+}
+
+// IsTransportError returns true if err, or an error it wraps, is a SCSI command failure caused
+// by the transport
+func IsTransportError(err error) bool {
+	var e sgIOErr
+	return errors.As(err, &e) && e.IsTransportError()
+}
+
+// IsMediumError returns true if err, or an error it wraps, is a SCSI command failure caused by
+// an unreadable medium
+func IsMediumError(err error) bool {
+	var e sgIOErr
+	return errors.As(err, &e) && e.IsMediumError()
+}
+
+// senseKeyNames are the names of the SCSI sense keys
+var senseKeyNames = [16]string{
+	"NO_SENSE", "RECOVERED_ERROR", "NOT_READY", "MEDIUM_ERROR", "HARDWARE_ERROR",
+	"ILLEGAL_REQUEST", "UNIT_ATTENTION", "DATA_PROTECT", "BLANK_CHECK", "VENDOR_SPECIFIC",
+	"COPY_ABORTED", "ABORTED_COMMAND", "RESERVED", "VOLUME_OVERFLOW", "MISCOMPARE", "COMPLETED",
+}
+
+// SenseKeyName returns the name of a SCSI sense key, e.g. ILLEGAL_REQUEST
+func SenseKeyName(key uint8) string {
+	return senseKeyNames[key&0x0f]
+}
+
+// CommandStatus is the status of a failed SCSI command with its decoded sense data
+type CommandStatus struct {
+	Command      string // name of the failed command, see CDBName
+	SCSIStatus   uint8
+	HostStatus   uint16
+	DriverStatus uint16
+	SenseKey     uint8 // valid if SCSIStatus is CHECK CONDITION
+	ASC          uint8 // additional sense code
+	ASCQ         uint8 // additional sense code qualifier
+}
+
+// senseCodes returns the additional sense code and qualifier of the sense data
+func (e sgIOErr) senseCodes() (uint8, uint8) {
+	switch e.senseBuf[0] & 0x7f {
+	case 0x70, 0x71: // fixed format
+		return e.senseBuf[12], e.senseBuf[13]
+	case 0x72, 0x73: // descriptor format
+		return e.senseBuf[2], e.senseBuf[3]
+	}
+
+	return 0, 0
+}
+
+// Status returns the status of a SCSI command failure, found in err or the errors it wraps
+func Status(err error) (CommandStatus, bool) {
+	var e sgIOErr
+	if !errors.As(err, &e) {
+		return CommandStatus{}, false
+	}
+
+	status := CommandStatus{
+		Command:      e.command,
+		SCSIStatus:   e.scsiStatus,
+		HostStatus:   e.hostStatus,
+		DriverStatus: e.driverStatus,
+		SenseKey:     e.senseKey(),
+	}
+	status.ASC, status.ASCQ = e.senseCodes()
+
+	return status, true
+}
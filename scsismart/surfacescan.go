@@ -0,0 +1,198 @@
+/*
+Copyright 2018 The OpenEBS Authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Read-only surface scan of the media of a device.
+
+package scsismart
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"github.com/openebs/smart/atasmart"
+)
+
+// DefaultScanChunk is the number of blocks verified by each command of a surface scan by default
+const DefaultScanChunk = 2048
+
+// VerifyBlocks sends a SCSI VERIFY(16) command to check that count blocks starting at lba can
+// be read, without transferring them
+func (d *SCSIDevice) VerifyBlocks(lba uint64, count uint32) error {
+	cdb := CDB16{SCSIVerify16}
+	cdb.SetLBA(lba)
+	cdb.SetAllocationLength(count)
+
+	// The error is returned as is so that medium errors can be told apart, see IsMediumError
+	var noData []byte
+	return d.sendCDBDirection(cdb[:], SGDxferNone, &noData)
+}
+
+// MaxVerifyBlocks returns the maximum number of blocks of a SCSI VERIFY(16) command
+func (d *SCSIDevice) MaxVerifyBlocks() uint32 {
+	return 0xffffffff
+}
+
+// VerifyBlocks sends an ATA READ VERIFY SECTORS EXT command via SCSI_ATA_PASSTHRU_16 to check that
+// count sectors starting at lba can be read, without transferring them
+func (d *SATA) VerifyBlocks(lba uint64, count uint32) error {
+	if count == 0 || count > d.MaxVerifyBlocks() {
+		return fmt.Errorf("invalid READ VERIFY SECTORS EXT count %d", count)
+	}
+
+	// A count of 0 verifies 65536 sectors. The error is returned as is so that medium errors can
+	// be told apart, see IsMediumError
+	return d.ataNonDataExt(atasmart.AtaReadVerifySectorsExt, 0, uint16(count), lba, true)
+}
+
+// MaxVerifyBlocks returns the maximum number of sectors of an ATA READ VERIFY SECTORS EXT command
+func (d *SATA) MaxVerifyBlocks() uint32 {
+	return 65536
+}
+
+// ScanProgress is the state of a surface scan reported after each verified chunk
+type ScanProgress struct {
+	Done    uint64 // blocks verified so far
+	Total   uint64 // blocks to verify
+	BadLBAs int    // unreadable blocks found so far
+}
+
+// ScanResult is the outcome of a surface scan
+type ScanResult struct {
+	Scanned uint64   // blocks verified, less than the range if the scan was interrupted
+	BadLBAs []uint64 // unreadable blocks
+}
+
+// SurfaceScan verifies a range of blocks of a device chunk by chunk, locating the unreadable
+// blocks of the chunks which fail. It only reads the media, which is left untouched.
+type SurfaceScan struct {
+	Start    uint64             // first block to verify
+	End      uint64             // block after the last one to verify
+	Chunk    uint32             // blocks verified per command, DefaultScanChunk if zero
+	Progress func(ScanProgress) // called after each chunk, may be nil
+
+	dev    Verifier
+	mu     sync.Mutex
+	resume chan struct{} // non-nil while the scan is paused
+}
+
+// NewSurfaceScan returns a surface scan of the blocks [0, totalBlocks) of a device
+func NewSurfaceScan(dev Verifier, totalBlocks uint64) *SurfaceScan {
+	return &SurfaceScan{End: totalBlocks, dev: dev}
+}
+
+// Pause suspends the scan after the chunk being verified
+func (s *SurfaceScan) Pause() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.resume == nil {
+		s.resume = make(chan struct{})
+	}
+}
+
+// Resume continues a paused scan
+func (s *SurfaceScan) Resume() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.resume != nil {
+		close(s.resume)
+		s.resume = nil
+	}
+}
+
+// waitResumed blocks while the scan is paused, giving up when ctx is done
+func (s *SurfaceScan) waitResumed(ctx context.Context) error {
+	s.mu.Lock()
+	resume := s.resume
+	s.mu.Unlock()
+
+	if resume == nil {
+		return ctx.Err()
+	}
+
+	select {
+	case <-resume:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// Run verifies the blocks of the scan range until all of them are verified, ctx is done or the
+// device fails with an error other than a medium error. The blocks verified so far and the bad
+// blocks found are returned in all cases.
+func (s *SurfaceScan) Run(ctx context.Context) (ScanResult, error) {
+	var result ScanResult
+
+	chunk := s.Chunk
+	if chunk == 0 {
+		chunk = DefaultScanChunk
+	}
+	if limit := s.dev.MaxVerifyBlocks(); chunk > limit {
+		chunk = limit
+	}
+
+	for lba := s.Start; lba < s.End; {
+		if err := s.waitResumed(ctx); err != nil {
+			return result, err
+		}
+
+		count := chunk
+		if remaining := s.End - lba; remaining < uint64(count) {
+			count = uint32(remaining)
+		}
+
+		err := s.dev.VerifyBlocks(lba, count)
+		if err != nil && !IsMediumError(err) {
+			return result, err
+		}
+		if err != nil {
+			bad, err := s.locateBadBlocks(ctx, lba, count)
+			result.BadLBAs = append(result.BadLBAs, bad...)
+			if err != nil {
+				return result, err
+			}
+		}
+
+		lba += uint64(count)
+		result.Scanned += uint64(count)
+
+		if s.Progress != nil {
+			s.Progress(ScanProgress{Done: result.Scanned, Total: s.End - s.Start, BadLBAs: len(result.BadLBAs)})
+		}
+	}
+
+	return result, nil
+}
+
+// locateBadBlocks verifies the blocks of a failed chunk one by one and returns the unreadable ones
+func (s *SurfaceScan) locateBadBlocks(ctx context.Context, lba uint64, count uint32) ([]uint64, error) {
+	var bad []uint64
+
+	for i := uint64(0); i < uint64(count); i++ {
+		if err := ctx.Err(); err != nil {
+			return bad, err
+		}
+
+		err := s.dev.VerifyBlocks(lba+i, 1)
+		if IsMediumError(err) {
+			bad = append(bad, lba+i)
+		} else if err != nil {
+			return bad, err
+		}
+	}
+
+	return bad, nil
+}
@@ -0,0 +1,162 @@
+/*
+Copyright 2018 The OpenEBS Authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// TapeAlert log page of sequential access (tape) devices.
+
+package scsismart
+
+import (
+	"encoding/binary"
+	"fmt"
+)
+
+// TapeAlertSeverity is the severity of a TapeAlert flag
+type TapeAlertSeverity int
+
+// TapeAlert severities
+const (
+	TapeAlertInformational TapeAlertSeverity = iota
+	TapeAlertWarning
+	TapeAlertCritical
+)
+
+func (s TapeAlertSeverity) String() string {
+	switch s {
+	case TapeAlertWarning:
+		return "warning"
+	case TapeAlertCritical:
+		return "critical"
+	}
+
+	return "informational"
+}
+
+// TapeAlertFlags is the number of flags of the TapeAlert log page
+const TapeAlertFlags = 64
+
+// tapeAlerts names the TapeAlert flags, indexed by flag number minus one. Obsolete and
+// reserved flags are only reported by their number.
+var tapeAlerts = [TapeAlertFlags]struct {
+	name     string
+	severity TapeAlertSeverity
+}{
+	{"read warning", TapeAlertWarning},
+	{"write warning", TapeAlertWarning},
+	{"hard error", TapeAlertWarning},
+	{"media", TapeAlertCritical},
+	{"read failure", TapeAlertCritical},
+	{"write failure", TapeAlertCritical},
+	{"media life", TapeAlertWarning},
+	{"not data grade", TapeAlertWarning},
+	{"write protect", TapeAlertCritical},
+	{"no removal", TapeAlertInformational},
+	{"cleaning media", TapeAlertInformational},
+	{"unsupported format", TapeAlertInformational},
+	{"recoverable mechanical cartridge failure", TapeAlertCritical},
+	{"unrecoverable mechanical cartridge failure", TapeAlertCritical},
+	{"memory chip in cartridge failure", TapeAlertWarning},
+	{"forced eject", TapeAlertCritical},
+	{"read only format", TapeAlertWarning},
+	{"tape directory corrupted on load", TapeAlertWarning},
+	{"nearing media life", TapeAlertInformational},
+	{"clean now", TapeAlertCritical},
+	{"clean periodic", TapeAlertWarning},
+	{"expired cleaning media", TapeAlertCritical},
+	{"invalid cleaning tape", TapeAlertCritical},
+	{"retension requested", TapeAlertWarning},
+	{"dual-port interface error", TapeAlertWarning},
+	{"cooling fan failure", TapeAlertWarning},
+	{"power supply failure", TapeAlertWarning},
+	{"power consumption", TapeAlertWarning},
+	{"drive maintenance", TapeAlertWarning},
+	{"hardware A", TapeAlertCritical},
+	{"hardware B", TapeAlertCritical},
+	{"interface", TapeAlertWarning},
+	{"eject media", TapeAlertCritical},
+	{"microcode update fail", TapeAlertWarning},
+	{"drive humidity", TapeAlertWarning},
+	{"drive temperature", TapeAlertWarning},
+	{"drive voltage", TapeAlertWarning},
+	{"predictive failure", TapeAlertCritical},
+	{"diagnostics required", TapeAlertWarning},
+	48: {"lost statistics", TapeAlertWarning},
+	{"tape directory invalid at unload", TapeAlertWarning},
+	{"tape system area write failure", TapeAlertCritical},
+	{"tape system area read failure", TapeAlertCritical},
+	{"no start of data", TapeAlertCritical},
+	{"loading failure", TapeAlertCritical},
+	{"unrecoverable unload failure", TapeAlertCritical},
+	{"automation interface failure", TapeAlertCritical},
+	{"microcode failure", TapeAlertWarning},
+	{"WORM medium integrity check failed", TapeAlertWarning},
+	{"WORM medium overwrite attempted", TapeAlertWarning},
+}
+
+// TapeAlert is a TapeAlert flag set by a tape drive
+type TapeAlert struct {
+	Flag     int // 1 to 64
+	Name     string
+	Severity TapeAlertSeverity
+}
+
+func (a TapeAlert) String() string {
+	return fmt.Sprintf("%d %s (%s)", a.Flag, a.Name, a.Severity)
+}
+
+// ParseTapeAlerts decodes the TapeAlert log page (2Eh), returning the flags which are set
+func ParseTapeAlerts(page []byte) ([]TapeAlert, error) {
+	if len(page) < 4 || page[0]&0x3f != TapeAlertLogPage {
+		return nil, fmt.Errorf("not a TapeAlert log page")
+	}
+
+	var alerts []TapeAlert
+	for offset := 4; offset+4 <= len(page); {
+		paramLen := int(page[offset+3])
+		if offset+4+paramLen > len(page) {
+			break
+		}
+
+		// each flag is a parameter whose code is the flag number, set when bit 0 of its value is
+		flag := int(binary.BigEndian.Uint16(page[offset:]))
+		if flag >= 1 && flag <= TapeAlertFlags && paramLen >= 1 && page[offset+4]&0x01 != 0 {
+			alert := TapeAlert{Flag: flag, Name: tapeAlerts[flag-1].name, Severity: tapeAlerts[flag-1].severity}
+			if alert.Name == "" {
+				alert.Name = fmt.Sprintf("flag %d", flag)
+			}
+			alerts = append(alerts, alert)
+		}
+
+		offset += 4 + paramLen
+	}
+
+	return alerts, nil
+}
+
+// TapeAlerts returns the TapeAlert flags set by a tape drive. Drives clear their flags once they
+// have been read, so each set flag is only returned once.
+func (d *SCSIDevice) TapeAlerts() ([]TapeAlert, error) {
+	inqResp, err := d.SCSIInquiry()
+	if err != nil {
+		return nil, fmt.Errorf("SgExecute INQUIRY: %w", err)
+	}
+	if inqResp.PeripheralDeviceType() != PeripheralSequential {
+		return nil, fmt.Errorf("%s is a %s, TapeAlert is only supported by tape drives", d.Name, inqResp.DeviceTypeString())
+	}
+
+	page, err := d.logSense(TapeAlertLogPage, 0)
+	if err != nil {
+		return nil, fmt.Errorf("SgExecute LOG SENSE TapeAlert: %w", err)
+	}
+
+	return ParseTapeAlerts(page)
+}
@@ -0,0 +1,195 @@
+/*
+Copyright 2018 The OpenEBS Authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Self-encrypting drive detection via TCG level 0 discovery.
+
+package scsismart
+
+import (
+	"encoding/binary"
+	"fmt"
+
+	"github.com/openebs/smart/atasmart"
+)
+
+// Security protocols of SECURITY PROTOCOL IN and ATA TRUSTED RECEIVE
+const (
+	SecurityProtocolInfo = 0x00 // security protocol information
+	SecurityProtocolTCG  = 0x01 // TCG, with level 0 discovery at ComID 0001h
+
+	level0DiscoveryComID = 0x0001
+)
+
+// TCG level 0 discovery feature codes
+const (
+	tcgFeatureTPer       = 0x0001
+	tcgFeatureLocking    = 0x0002
+	tcgFeatureEnterprise = 0x0100
+	tcgFeatureOpal1      = 0x0200
+	tcgFeatureOpal2      = 0x0203
+	tcgFeatureOpalite    = 0x0301
+	tcgFeaturePyrite1    = 0x0302
+	tcgFeaturePyrite2    = 0x0303
+	tcgFeatureRuby       = 0x0304
+)
+
+// sscNames maps the feature codes of the TCG Security Subsystem Classes to their names
+var sscNames = map[uint16]string{
+	tcgFeatureEnterprise: "Enterprise",
+	tcgFeatureOpal1:      "Opal 1.0",
+	tcgFeatureOpal2:      "Opal 2.0",
+	tcgFeatureOpalite:    "Opalite",
+	tcgFeaturePyrite1:    "Pyrite 1.0",
+	tcgFeaturePyrite2:    "Pyrite 2.0",
+	tcgFeatureRuby:       "Ruby",
+}
+
+// SEDInfo reports the self-encrypting drive features of a device, as returned by TCG level 0
+// discovery. The zero value describes a device which does not implement the TCG protocol.
+type SEDInfo struct {
+	TPer             bool     // TCG trusted peripheral
+	SSCs             []string // names of the supported Security Subsystem Classes
+	Opal2            bool
+	Enterprise       bool
+	LockingSupported bool
+	LockingEnabled   bool // a locking range is configured
+	Locked           bool // a locking range is locked
+	MediaEncryption  bool
+	MBREnabled       bool // shadow MBR enabled
+	MBRDone          bool
+}
+
+// securityReceiver transfers the data of a security protocol into a buffer
+type securityReceiver func(protocol uint8, spSpecific uint16, buf []byte) error
+
+// ParseLevel0Discovery parses a TCG level 0 discovery response
+func ParseLevel0Discovery(b []byte) (SEDInfo, error) {
+	var info SEDInfo
+
+	if len(b) < 48 {
+		return info, fmt.Errorf("level 0 discovery response too short: %d bytes", len(b))
+	}
+
+	// the length of the parameter data excludes the length field itself
+	end := int(binary.BigEndian.Uint32(b)) + 4
+	if end > len(b) {
+		end = len(b)
+	}
+
+	for off := 48; off+4 <= end; {
+		code := binary.BigEndian.Uint16(b[off:])
+		next := off + 4 + int(b[off+3])
+		if next > end {
+			next = end
+		}
+		desc := b[off+4 : next]
+
+		switch code {
+		case tcgFeatureTPer:
+			info.TPer = true
+		case tcgFeatureLocking:
+			if len(desc) > 0 {
+				info.LockingSupported = desc[0]&0x01 != 0
+				info.LockingEnabled = desc[0]&0x02 != 0
+				info.Locked = desc[0]&0x04 != 0
+				info.MediaEncryption = desc[0]&0x08 != 0
+				info.MBREnabled = desc[0]&0x10 != 0
+				info.MBRDone = desc[0]&0x20 != 0
+			}
+		case tcgFeatureOpal2:
+			info.Opal2 = true
+		case tcgFeatureEnterprise:
+			info.Enterprise = true
+		}
+		if name, ok := sscNames[code]; ok {
+			info.SSCs = append(info.SSCs, name)
+		}
+
+		off = next
+	}
+
+	return info, nil
+}
+
+// readSEDInfo lists the security protocols of a device and runs TCG level 0 discovery if it
+// supports the TCG protocol
+func readSEDInfo(recv securityReceiver) (SEDInfo, error) {
+	respBuf := make([]byte, 512)
+	if err := recv(SecurityProtocolInfo, 0, respBuf); err != nil {
+		return SEDInfo{}, err
+	}
+
+	// the supported security protocol list follows its 2-byte length at byte 6
+	listLen := int(binary.BigEndian.Uint16(respBuf[6:]))
+	if listLen > len(respBuf)-8 {
+		listLen = len(respBuf) - 8
+	}
+
+	for _, protocol := range respBuf[8 : 8+listLen] {
+		if protocol == SecurityProtocolTCG {
+			discovery := make([]byte, 2048)
+			if err := recv(SecurityProtocolTCG, level0DiscoveryComID, discovery); err != nil {
+				return SEDInfo{}, err
+			}
+			return ParseLevel0Discovery(discovery)
+		}
+	}
+
+	return SEDInfo{}, nil
+}
+
+// securityProtocolIn sends a SCSI SECURITY PROTOCOL IN command
+func (d *SCSIDevice) securityProtocolIn(protocol uint8, spSpecific uint16, buf []byte) error {
+	cdb := CDB12{SCSISecurityIn, protocol}
+	binary.BigEndian.PutUint16(cdb[2:], spSpecific)
+	cdb.SetAllocationLength(uint32(len(buf)))
+
+	return d.sendCDB(cdb[:], &buf)
+}
+
+// SEDInfo returns the self-encrypting drive features of a SCSI device, discovered with
+// SECURITY PROTOCOL IN
+func (d *SCSIDevice) SEDInfo() (SEDInfo, error) {
+	return readSEDInfo(d.securityProtocolIn)
+}
+
+// trustedReceive sends an ATA TRUSTED RECEIVE command via SCSI_ATA_PASSTHRU_16. The buffer
+// length must be a multiple of 512 bytes.
+func (d *SATA) trustedReceive(protocol uint8, spSpecific uint16, buf []byte) error {
+	blocks := len(buf) / atasmart.SectorSize
+
+	// The transfer length goes in COUNT 7:0 and LBA 7:0, the SP specific field in LBA 23:8
+	lba := uint64(blocks>>8&0xff) | uint64(spSpecific)<<8
+
+	cdb16 := CDB16{SCSIATAPassThru16}
+	cdb16.SetATAProtocol(ATAProtocolPIODataIn, false)
+	cdb16.SetATATransfer(true, true, ATATLengthSectorCount)
+	cdb16.SetATARegisters(atasmart.AtaTrustedReceive, uint16(protocol), uint16(blocks&0xff), lba, 0)
+
+	if err := d.sendCDB(cdb16[:], &buf); err != nil {
+		return fmt.Errorf("sendCDB TRUSTED RECEIVE: %w", err)
+	}
+
+	return nil
+}
+
+// SEDInfo returns the self-encrypting drive features of a SATA device, discovered with ATA
+// TRUSTED RECEIVE when IDENTIFY DEVICE reports the Trusted Computing feature set
+func (d *SATA) SEDInfo() (SEDInfo, error) {
+	caps, err := d.Capabilities()
+	if err != nil || !caps.Trusted {
+		return SEDInfo{}, err
+	}
+
+	return readSEDInfo(d.trustedReceive)
+}
@@ -0,0 +1,80 @@
+/*
+Copyright 2018 The OpenEBS Authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Current temperature of SCSI and SATA devices.
+
+package scsismart
+
+import (
+	"encoding/binary"
+	"fmt"
+
+	"github.com/openebs/smart/atasmart"
+)
+
+// ATA SMART attributes reporting the drive temperature, in order of preference
+var temperatureAttrs = []uint8{194, 190}
+
+// Temperature returns the current temperature in degrees Celsius of a SCSI device, from the
+// temperature parameter of its Temperature log page
+func (d *SCSIDevice) Temperature() (int, error) {
+	page, err := d.logSense(TemperatureLogPage, 0)
+	if err != nil {
+		return 0, fmt.Errorf("SgExecute LOG SENSE temperature: %w", err)
+	}
+
+	for offset := 4; offset+4 <= len(page); {
+		paramLen := int(page[offset+3])
+		if offset+4+paramLen > len(page) {
+			break
+		}
+
+		// parameter 0000h is the temperature, ffh if it is not available
+		if binary.BigEndian.Uint16(page[offset:]) == 0x0000 && paramLen >= 2 && page[offset+5] != 0xff {
+			return int(page[offset+5]), nil
+		}
+
+		offset += 4 + paramLen
+	}
+
+	return 0, fmt.Errorf("device does not report its temperature")
+}
+
+// AttrTemperature returns the current temperature in degrees Celsius reported by the SMART
+// attributes of a SATA device, false if none of them reports it
+func AttrTemperature(attrs atasmart.AttrCollection) (int, bool) {
+	for _, id := range temperatureAttrs {
+		if a, ok := attrs.Get(id); ok {
+			return int(a.Raw & 0xff), true
+		}
+	}
+
+	return 0, false
+}
+
+// Temperature returns the current temperature in degrees Celsius of a SATA device, from its
+// SMART temperature attribute. Callers which already read the attributes should use
+// AttrTemperature instead.
+func (d *SATA) Temperature() (int, error) {
+	// the temperature attribute does not need the thresholds
+	attrs, err := d.GetSMARTAttributes()
+	if err != nil && !IsPartial(err) {
+		return 0, err
+	}
+
+	if temp, ok := AttrTemperature(attrs); ok {
+		return temp, nil
+	}
+
+	return 0, fmt.Errorf("device does not report its temperature")
+}
@@ -0,0 +1,138 @@
+/*
+Copyright 2018 The OpenEBS Authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Per-command class SG_IO timeouts.
+
+package scsismart
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/openebs/smart/atasmart"
+)
+
+// Built-in SG_IO timeouts in millisecs for each command class
+const (
+	QuickTimeout = 5000    // identification and log/mode page reads
+	LongTimeout  = 3600000 // sanitize, format, verify and self-test related commands
+)
+
+// CommandClass groups commands with similar expected execution times
+type CommandClass int
+
+// Command classes
+const (
+	CommandClassQuick CommandClass = iota
+	CommandClassDefault
+	CommandClassLong
+)
+
+// Timeouts holds the SG_IO timeouts in millisecs for each command class. A zero value selects
+// the built-in timeout of the class.
+type Timeouts struct {
+	Quick   uint32 `json:"quick_ms,omitempty"`
+	Default uint32 `json:"default_ms,omitempty"`
+	Long    uint32 `json:"long_ms,omitempty"`
+}
+
+// commandClass returns the class of the command carried by a CDB
+func commandClass(cdb []byte) CommandClass {
+	switch cdb[0] {
+	case SCSITestUnitReady, SCSIInquiry, SCSIModeSense6, SCSIModeSelect6, SCSILogSense,
+		SCSIReadCapacity10, SCSIServiceAction, SCSIReportLUNs, SCSISecurityIn:
+		return CommandClassQuick
+	case SCSIFormatUnit, SCSIStartStopUnit, SCSISendDiagnostic, SCSISanitize,
+		SCSIVerify10, SCSIVerify12, SCSIVerify16:
+		return CommandClassLong
+	case SCSIATAPassThru16:
+		switch cdb[14] {
+		case atasmart.AtaIdentifyDevice, atasmart.AtaTrustedReceive:
+			return CommandClassQuick
+		case atasmart.AtaReadVerifySectors, atasmart.AtaReadVerifySectorsExt,
+			atasmart.AtaSanitizeDevice, atasmart.AtaSecurityEraseUnit:
+			return CommandClassLong
+		case atasmart.AtaSmart:
+			if cdb[4] == atasmart.SmartExecuteOfflineImmed {
+				return CommandClassLong
+			}
+		}
+	}
+
+	return CommandClassDefault
+}
+
+// timeout returns the SG_IO timeout in millisecs to use for a CDB
+func (t Timeouts) timeout(cdb []byte) uint32 {
+	switch commandClass(cdb) {
+	case CommandClassQuick:
+		if t.Quick != 0 {
+			return t.Quick
+		}
+		return QuickTimeout
+	case CommandClassLong:
+		if t.Long != 0 {
+			return t.Long
+		}
+		return LongTimeout
+	}
+
+	if t.Default != 0 {
+		return t.Default
+	}
+
+	return DefaultTimeout
+}
+
+// SetTimeouts sets the SG_IO timeouts used for the commands sent to the device
+func (d *SCSIDevice) SetTimeouts(t Timeouts) {
+	d.timeouts = t
+}
+
+// SetContext sets the context whose deadline bounds the SG_IO timeouts of the commands sent to
+// the device. Commands are not sent once the context is done.
+func (d *SCSIDevice) SetContext(ctx context.Context) {
+	d.ctx = ctx
+}
+
+// commandTimeout returns the SG_IO timeout in millisecs for a CDB, capped by the time left until
+// the deadline of the device context.
+func (d *SCSIDevice) commandTimeout(cdb []byte) (uint32, error) {
+	timeout := d.timeouts.timeout(cdb)
+	if d.ctx == nil {
+		return timeout, nil
+	}
+
+	if err := d.ctx.Err(); err != nil {
+		return 0, fmt.Errorf("%s not sent: %w", CDBName(cdb), err)
+	}
+
+	deadline, ok := d.ctx.Deadline()
+	if !ok {
+		return timeout, nil
+	}
+
+	remaining := time.Until(deadline)
+	if remaining <= 0 {
+		return 0, fmt.Errorf("%s not sent: %v", CDBName(cdb), context.DeadlineExceeded)
+	}
+
+	// Round up so that a deadline less than a millisec away does not select "no timeout"
+	ms := (remaining + time.Millisecond - 1) / time.Millisecond
+	if ms < time.Duration(timeout) {
+		timeout = uint32(ms)
+	}
+
+	return timeout, nil
+}
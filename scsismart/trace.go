@@ -0,0 +1,160 @@
+/*
+Copyright 2018 The OpenEBS Authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Tracing of the CDBs sent to the devices, with hexdumps of their data and sense buffers.
+
+package scsismart
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+// TraceEnv is the environment variable enabling the tracing of the CDBs to the standard logger
+// when set to a non-empty value other than 0
+const TraceEnv = "SMART_TRACE"
+
+// Logger is the destination of the traces, satisfied by *log.Logger
+type Logger interface {
+	Printf(format string, v ...interface{})
+}
+
+var (
+	traceMu     sync.RWMutex
+	traceLogger Logger
+)
+
+func init() {
+	if v := os.Getenv(TraceEnv); v != "" && v != "0" {
+		traceLogger = log.New(os.Stderr, "smart: ", log.LstdFlags|log.Lmicroseconds)
+	}
+}
+
+// SetTraceLogger logs every CDB sent to the devices, the data transferred and the sense data
+// returned as annotated hexdumps to l. A nil logger disables the tracing.
+func SetTraceLogger(l Logger) {
+	traceMu.Lock()
+	defer traceMu.Unlock()
+
+	traceLogger = l
+}
+
+// currentTraceLogger returns the logger of the traces, nil if tracing is disabled
+func currentTraceLogger() Logger {
+	traceMu.RLock()
+	defer traceMu.RUnlock()
+
+	return traceLogger
+}
+
+// directionNames are the names of the SG_IO data transfer directions
+var directionNames = map[int32]string{
+	SGDxferNone:      "no data",
+	SGDxferToDev:     "data out",
+	SGDxferFromDev:   "data in",
+	SGDxferToFromDev: "data in/out",
+}
+
+// traceCDB logs a command sent to a device along with its outcome
+func traceCDB(l Logger, device string, cdb []byte, direction int32, data []byte, elapsed time.Duration, err error) {
+	var b strings.Builder
+
+	fmt.Fprintf(&b, "%s: %s, %s, %d bytes, %v\n", device, CDBName(cdb), directionNames[direction], len(data), elapsed)
+	writeHexdump(&b, "cdb", cdb)
+	if direction == SGDxferToDev {
+		writeHexdump(&b, "data out", data)
+	}
+
+	if e, ok := err.(sgIOErr); ok {
+		fmt.Fprintf(&b, "  status: %s\n", e.statusString())
+		if e.scsiStatus == SCSIStatusCheckCondition {
+			asc, ascq := e.senseCodes()
+			fmt.Fprintf(&b, "  sense: %s, asc %#02x, ascq %#02x\n", SenseKeyName(e.senseKey()), asc, ascq)
+			writeHexdump(&b, "sense", e.senseBuf[:senseLength(e.senseBuf[:])])
+		}
+	} else if err != nil {
+		fmt.Fprintf(&b, "  error: %v\n", err)
+	}
+
+	if direction == SGDxferFromDev && err == nil {
+		writeHexdump(&b, "data in", data)
+	}
+
+	l.Printf("%s", strings.TrimSuffix(b.String(), "\n"))
+}
+
+// senseLength returns the length of the sense data in a sense buffer
+func senseLength(sense []byte) int {
+	n := len(sense)
+	switch sense[0] & 0x7f {
+	case 0x70, 0x71, 0x72, 0x73: // fixed and descriptor formats both hold the additional length in byte 7
+		n = 8 + int(sense[7])
+	}
+	if n > len(sense) {
+		n = len(sense)
+	}
+
+	return n
+}
+
+// writeHexdump writes a labelled hexdump of buf in the format of hexdump -C, collapsing the
+// repeated lines into a single *
+func writeHexdump(b *strings.Builder, label string, buf []byte) {
+	fmt.Fprintf(b, "  %s:\n", label)
+
+	var prev []byte
+	repeated := false
+	for off := 0; off < len(buf); off += 16 {
+		end := off + 16
+		if end > len(buf) {
+			end = len(buf)
+		}
+		line := buf[off:end]
+
+		if prev != nil && len(line) == 16 && string(line) == string(prev) {
+			if !repeated {
+				b.WriteString("  *\n")
+				repeated = true
+			}
+			continue
+		}
+		prev, repeated = line, false
+
+		fmt.Fprintf(b, "  %08x ", off)
+		for i := 0; i < 16; i++ {
+			if i == 8 {
+				b.WriteByte(' ')
+			}
+			if i < len(line) {
+				fmt.Fprintf(b, " %02x", line[i])
+			} else {
+				b.WriteString("   ")
+			}
+		}
+		b.WriteString("  |")
+		for _, c := range line {
+			if c < 0x20 || c > 0x7e {
+				c = '.'
+			}
+			b.WriteByte(c)
+		}
+		b.WriteString("|\n")
+	}
+	if repeated {
+		fmt.Fprintf(b, "  %08x\n", len(buf))
+	}
+}
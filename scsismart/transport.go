@@ -0,0 +1,139 @@
+/*
+Copyright 2018 The OpenEBS Authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Detection of the SCSI transport (iSCSI, Fibre Channel, ...) through sysfs.
+
+package scsismart
+
+import (
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/openebs/smart/internal/utilities"
+)
+
+// Transport names reported in DiskAttr for SCSI transports detected through sysfs
+const (
+	TransportISCSI = "iSCSI"
+	TransportFC    = "Fibre Channel"
+)
+
+var (
+	iscsiSessionRegexp = regexp.MustCompile(`^session[0-9]+$`)
+	fcRemotePortRegexp = regexp.MustCompile(`^rport-[0-9]+:[0-9]+-[0-9]+$`)
+	scsiHostRegexp     = regexp.MustCompile(`^host[0-9]+$`)
+)
+
+// sysfsDeviceDir returns the resolved sysfs directory of the SCSI device behind a block device,
+// e.g. /sys/devices/platform/host3/session1/target3:0:0/3:0:0:0
+func sysfsDeviceDir(name string) string {
+	dir, err := filepath.EvalSymlinks(filepath.Join(utilities.SysfsBlockDir(name), "device"))
+	if err != nil {
+		return ""
+	}
+
+	return dir
+}
+
+// sysfsAncestorDir returns the closest parent sysfs directory of the SCSI device behind a block
+// device whose name matches re, or an empty string if there is none.
+func sysfsAncestorDir(name string, re *regexp.Regexp) string {
+	dir := sysfsDeviceDir(name)
+
+	for dir != "" && dir != "/" && dir != "." {
+		if re.MatchString(filepath.Base(dir)) {
+			return dir
+		}
+		dir = filepath.Dir(dir)
+	}
+
+	return ""
+}
+
+// iscsiSessionDir returns the sysfs directory of the iSCSI session a device is attached through,
+// or an empty string if the device is not attached over iSCSI.
+func iscsiSessionDir(name string) string {
+	return sysfsAncestorDir(name, iscsiSessionRegexp)
+}
+
+// isISCSI checks whether a device is attached over iSCSI
+func isISCSI(name string) bool {
+	return iscsiSessionDir(name) != ""
+}
+
+// iscsiTarget returns the target IQN and portal address of the iSCSI session of a device
+func iscsiTarget(name string) (iqn string, portal string) {
+	sessionDir := iscsiSessionDir(name)
+	if sessionDir == "" {
+		return
+	}
+
+	session := filepath.Base(sessionDir)
+	iqn, _ = utilities.ReadSysfs(filepath.Join(sessionDir, "iscsi_session", session, "targetname"))
+
+	connections, _ := filepath.Glob(filepath.Join(sessionDir, "connection*", "iscsi_connection", "connection*"))
+	for _, conn := range connections {
+		address, err := utilities.ReadSysfs(filepath.Join(conn, "persistent_address"))
+		if err != nil {
+			continue
+		}
+		port, _ := utilities.ReadSysfs(filepath.Join(conn, "persistent_port"))
+
+		// IPv6 addresses are enclosed in brackets, like in iscsiadm output
+		if strings.Contains(address, ":") {
+			address = "[" + address + "]"
+		}
+		portal = address + ":" + port
+		break
+	}
+
+	return
+}
+
+// fcRemotePort returns the WWPN and WWNN of the Fibre Channel remote port a device is attached
+// through and the name of the fabric it is logged into.
+func fcRemotePort(name string) (wwpn string, wwnn string, fabric string) {
+	rportDir := sysfsAncestorDir(name, fcRemotePortRegexp)
+	if rportDir == "" {
+		return
+	}
+
+	rport := filepath.Join(rportDir, "fc_remote_ports", filepath.Base(rportDir))
+	wwpn, _ = utilities.ReadSysfs(filepath.Join(rport, "port_name"))
+	wwnn, _ = utilities.ReadSysfs(filepath.Join(rport, "node_name"))
+
+	if hostDir := sysfsAncestorDir(name, scsiHostRegexp); hostDir != "" {
+		fabric, _ = utilities.ReadSysfs(filepath.Join(hostDir, "fc_host", filepath.Base(hostDir), "fabric_name"))
+	}
+
+	return
+}
+
+// setTransportAttr fills the transport fields of a DiskAttr from sysfs for transports
+// which cannot be detected through SCSI commands.
+func setTransportAttr(name string, attr *DiskAttr) {
+	if iqn, portal := iscsiTarget(name); iqn != "" || portal != "" {
+		attr.Transport = TransportISCSI
+		attr.ISCSITargetIQN = iqn
+		attr.ISCSIPortal = portal
+		return
+	}
+
+	if wwpn, wwnn, fabric := fcRemotePort(name); wwpn != "" {
+		attr.Transport = TransportFC
+		attr.FCRemoteWWPN = wwpn
+		attr.FCRemoteWWNN = wwnn
+		attr.FCFabricName = fabric
+	}
+}
@@ -0,0 +1,119 @@
+/*
+Copyright 2018 The OpenEBS Authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Functions for virtio-blk devices, which have no SCSI or ATA pass-through.
+
+package scsismart
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"path/filepath"
+	"strings"
+
+	"github.com/openebs/smart/internal/utilities"
+)
+
+// ErrSMARTNotSupported is returned for SMART requests on devices that cannot report SMART data
+var ErrSMARTNotSupported = errors.New("SMART not supported by virtual device")
+
+// VirtioBlk is a paravirtualized virtio-blk device (/dev/vd*). Its attributes are read from sysfs,
+// since the device does not accept SG_IO commands.
+type VirtioBlk struct {
+	Name string
+}
+
+// isVirtioBlk checks whether a device path refers to a virtio-blk device
+func isVirtioBlk(name string) bool {
+	if driver := utilities.SysfsDriver(name); driver != "" {
+		return driver == "virtio_blk"
+	}
+
+	return strings.HasPrefix(filepath.Base(name), "vd")
+}
+
+// detectVirtioBlk returns a VirtioBlk device for virtio-blk devices, which do not support SG_IO
+// and so must not be opened as SCSI devices.
+func detectVirtioBlk(name string, opts DetectOptions) (Dev, error) {
+	if !isVirtioBlk(name) {
+		return nil, nil
+	}
+
+	dev := VirtioBlk{Name: name}
+	if err := dev.Open(); err != nil {
+		return nil, err
+	}
+
+	return &dev, nil
+}
+
+// Open checks that the device exists in sysfs, no file descriptor is held
+func (d *VirtioBlk) Open() error {
+	if _, err := utilities.ReadSysfs(filepath.Join(utilities.SysfsBlockDir(d.Name), "dev")); err != nil {
+		return fmt.Errorf("virtio device %s: %w", d.Name, err)
+	}
+
+	return nil
+}
+
+// Close is a no-op for virtio-blk devices
+func (d *VirtioBlk) Close() error {
+	return nil
+}
+
+// GetDiskInfo returns the serial number and capacity of a virtio-blk device. If the capacity
+// cannot be read, the other attributes are returned along with a MultiError.
+func (d *VirtioBlk) GetDiskInfo() (DiskAttr, error) {
+	var errs MultiError
+	sysDir := utilities.SysfsBlockDir(d.Name)
+
+	// The serial is taken from the virtio config space by the virtio_blk driver
+	serial, _ := utilities.ReadSysfs(filepath.Join(sysDir, "serial"))
+
+	// sysfs reports the size in 512-byte sectors regardless of the logical block size
+	sectors, err := utilities.ReadSysfsUint(filepath.Join(sysDir, "size"))
+	errs.add("sysfs size", err)
+
+	lbSize, _ := utilities.ReadSysfsUint(filepath.Join(sysDir, "queue", "logical_block_size"))
+	pbSize, _ := utilities.ReadSysfsUint(filepath.Join(sysDir, "queue", "physical_block_size"))
+
+	VirtioAttr := DiskAttr{}
+	VirtioAttr.UserCapacity = sectors * 512
+	if lbSize != 0 {
+		VirtioAttr.TotalLogicalBlocks = VirtioAttr.UserCapacity / lbSize
+	}
+	VirtioAttr.LBSize = uint16(lbSize)
+	VirtioAttr.PBSize = uint16(pbSize)
+	VirtioAttr.SerialNumber = serial
+	VirtioAttr.Transport = "virtio"
+	VirtioAttr.SMARTSupported = false
+	VirtioAttr.Virtual = true
+	setIdentityAttr(d.Name, &VirtioAttr)
+
+	return VirtioAttr, errs.err()
+}
+
+// WriteDiskInfo writes the available information for a virtio-blk device to w
+func (d *VirtioBlk) WriteDiskInfo(w io.Writer) error {
+	// The attributes which could be read are written even if some could not
+	diskAttr, err := d.GetDiskInfo()
+
+	fmt.Fprintf(w, "Serial Number: %s\n", diskAttr.SerialNumber)
+	fmt.Fprintf(w, "User Capacity:%v bytes (%v)\n", diskAttr.UserCapacity, utilities.ConvertBytes(diskAttr.UserCapacity))
+	fmt.Fprintf(w, "Sector Size: %d bytes logical, %d bytes physical\n", diskAttr.LBSize, diskAttr.PBSize)
+	fmt.Fprintln(w, "Transport:", diskAttr.Transport)
+	fmt.Fprintf(w, "SMART support available: %v (%v)\n", diskAttr.SMARTSupported, ErrSMARTNotSupported)
+
+	return err
+}
@@ -0,0 +1,58 @@
+/*
+Copyright 2018 The OpenEBS Authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Detection of virtual disks presented by hypervisors.
+
+package scsismart
+
+import "strings"
+
+// virtualVendors maps INQUIRY vendor identifications of virtual disks to their hypervisor
+var virtualVendors = map[string]string{
+	"VMware": "VMware",
+	"Msft":   "Hyper-V",
+	"XENSRC": "Xen",
+	"QEMU":   "QEMU",
+	"VBOX":   "VirtualBox",
+}
+
+// virtualModels maps model number prefixes of emulated ATA disks to their hypervisor
+var virtualModels = map[string]string{
+	"QEMU HARDDISK":  "QEMU",
+	"VBOX HARDDISK":  "VirtualBox",
+	"VMware Virtual": "VMware",
+	"Virtual HD":     "Hyper-V",
+}
+
+// virtualPlatform returns the hypervisor presenting a disk with the given vendor and model,
+// or an empty string for physical disks.
+func virtualPlatform(vendor, model string) string {
+	vendor = strings.TrimRight(vendor, " ,")
+	if hypervisor, ok := virtualVendors[vendor]; ok {
+		return hypervisor
+	}
+
+	model = strings.TrimSpace(model)
+	for prefix, hypervisor := range virtualModels {
+		if strings.HasPrefix(model, prefix) {
+			return hypervisor
+		}
+	}
+
+	return ""
+}
+
+// Hypervisor returns the hypervisor presenting the device, or an empty string for physical disks
+func (inquiry InquiryResponse) Hypervisor() string {
+	return virtualPlatform(string(inquiry.VendorID[:]), string(inquiry.ProductID[:]))
+}
@@ -0,0 +1,220 @@
+/*
+Copyright 2018 The OpenEBS Authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// SCSI vital product data (VPD) pages.
+
+package scsismart
+
+import (
+	"encoding/binary"
+	"fmt"
+
+	"github.com/openebs/smart/internal/utilities"
+)
+
+// Device Identification VPD page designator types and associations
+const (
+	designatorTypeNAA = 0x3
+	associationLU     = 0x0
+)
+
+// GetWWN returns the logical unit NAA designator from the Device Identification VPD page in the
+// canonical "naa." format, along with its raw 64-bit value (0 for 16-byte NAA designators).
+func (d *SCSIDevice) GetWWN() (string, uint64, error) {
+	page, err := d.inquiryVPD(VPDDeviceIdentification)
+	if err != nil {
+		return "", 0, fmt.Errorf("SgExecute INQUIRY device identification: %w", err)
+	}
+
+	for offset := 4; offset+4 <= len(page); {
+		desc := page[offset:]
+		descLen := int(desc[3])
+		if offset+4+descLen > len(page) {
+			break
+		}
+
+		association := (desc[1] >> 4) & 0x3
+		designatorType := desc[1] & 0x0f
+		designator := desc[4 : 4+descLen]
+
+		if association == associationLU && designatorType == designatorTypeNAA && len(designator) > 0 {
+			switch naa := designator[0] >> 4; {
+			case (naa == 0x2 || naa == 0x3 || naa == 0x5) && len(designator) == 8:
+				wwn := binary.BigEndian.Uint64(designator)
+				return fmt.Sprintf("naa.%016x", wwn), wwn, nil
+			case naa == 0x6 && len(designator) == 16:
+				return fmt.Sprintf("naa.%x", designator), 0, nil
+			}
+		}
+
+		offset += 4 + descLen
+	}
+
+	return "", 0, fmt.Errorf("device does not report a valid NAA designator")
+}
+
+// BlockLimits holds the Block Limits VPD page fields. Lengths and granularities are in logical
+// blocks, 0 means the device does not report a limit.
+type BlockLimits struct {
+	OptimalTransferGranularity uint16
+	MaxTransferLength          uint32
+	OptimalTransferLength      uint32
+	MaxUnmapLBACount           uint32
+	MaxUnmapDescriptorCount    uint32
+	OptimalUnmapGranularity    uint32
+	UnmapGranularityAlignment  uint32
+	UnmapAlignmentValid        bool
+	MaxWriteSameLength         uint64
+}
+
+// BlockLimits returns the transfer and unmap limits from the Block Limits VPD page
+func (d *SCSIDevice) BlockLimits() (BlockLimits, error) {
+	var limits BlockLimits
+
+	page, err := d.inquiryVPD(VPDBlockLimits)
+	if err != nil {
+		return limits, fmt.Errorf("SgExecute INQUIRY block limits: %w", err)
+	}
+
+	// SBC-2 devices only report the transfer lengths, the unmap fields were added in SBC-3
+	if len(page) < 16 {
+		return limits, fmt.Errorf("block limits VPD page too short (%d bytes)", len(page))
+	}
+
+	limits.OptimalTransferGranularity = binary.BigEndian.Uint16(page[6:])
+	limits.MaxTransferLength = binary.BigEndian.Uint32(page[8:])
+	limits.OptimalTransferLength = binary.BigEndian.Uint32(page[12:])
+
+	if len(page) >= 44 {
+		limits.MaxUnmapLBACount = binary.BigEndian.Uint32(page[20:])
+		limits.MaxUnmapDescriptorCount = binary.BigEndian.Uint32(page[24:])
+		limits.OptimalUnmapGranularity = binary.BigEndian.Uint32(page[28:])
+		alignment := binary.BigEndian.Uint32(page[32:])
+		limits.UnmapAlignmentValid = alignment&0x80000000 != 0
+		limits.UnmapGranularityAlignment = alignment & 0x7fffffff
+		limits.MaxWriteSameLength = binary.BigEndian.Uint64(page[36:])
+	}
+
+	return limits, nil
+}
+
+// RotationRate returns the nominal medium rotation rate in RPM from the Block Device
+// Characteristics VPD page, along with a human-readable form. It is encoded like the rotation
+// rate of ATA IDENTIFY: 0 when not reported and 1 for non-rotating media.
+func (d *SCSIDevice) RotationRate() (uint16, string, error) {
+	page, err := d.inquiryVPD(VPDBlockDeviceCharacteristics)
+	if err != nil {
+		return 0, "", fmt.Errorf("SgExecute INQUIRY block device characteristics: %w", err)
+	}
+
+	if len(page) < 6 {
+		return 0, "", fmt.Errorf("block device characteristics VPD page too short (%d bytes)", len(page))
+	}
+
+	rate, s := utilities.DecodeRotationRate(binary.BigEndian.Uint16(page[4:]))
+
+	return rate, s, nil
+}
+
+// Provisioning types of the Logical Block Provisioning VPD page
+const (
+	ProvisioningFull     = 0x0
+	ProvisioningResource = 0x1
+	ProvisioningThin     = 0x2
+)
+
+// LogicalBlockProvisioning holds the Logical Block Provisioning VPD page fields
+type LogicalBlockProvisioning struct {
+	Unmap            bool  // LBPU, UNMAP command supported
+	WriteSame16Unmap bool  // LBPWS, WRITE SAME(16) with the UNMAP bit supported
+	WriteSame10Unmap bool  // LBPWS10, WRITE SAME(10) with the UNMAP bit supported
+	ReadZeros        uint8 // LBPRZ, 0 if unmapped blocks may return any data
+	AnchorSupported  bool
+	Type             uint8 // provisioning type, ProvisioningFull, Resource or Thin
+}
+
+// LogicalBlockProvisioning returns the unmap support from the Logical Block Provisioning VPD page
+func (d *SCSIDevice) LogicalBlockProvisioning() (LogicalBlockProvisioning, error) {
+	var lbp LogicalBlockProvisioning
+
+	page, err := d.inquiryVPD(VPDLogicalBlockProvisioning)
+	if err != nil {
+		return lbp, fmt.Errorf("SgExecute INQUIRY logical block provisioning: %w", err)
+	}
+
+	if len(page) < 7 {
+		return lbp, fmt.Errorf("logical block provisioning VPD page too short (%d bytes)", len(page))
+	}
+
+	lbp.Unmap = page[5]&0x80 != 0
+	lbp.WriteSame16Unmap = page[5]&0x40 != 0
+	lbp.WriteSame10Unmap = page[5]&0x20 != 0
+	lbp.ReadZeros = page[5] >> 2 & 0x07
+	lbp.AnchorSupported = page[5]&0x02 != 0
+	lbp.Type = page[6] & 0x07
+
+	return lbp, nil
+}
+
+// Provisioning is the provisioning capabilities of a device, from its Logical Block
+// Provisioning and Block Limits VPD pages
+type Provisioning struct {
+	LogicalBlockProvisioning
+	Limits BlockLimits
+}
+
+// UnmapSupported reports whether the device accepts UNMAP commands. SBC-3 requires a non-zero
+// maximum unmap LBA count from devices which support UNMAP.
+func (p Provisioning) UnmapSupported() bool {
+	return p.Unmap && p.Limits.MaxUnmapLBACount != 0
+}
+
+// String describes the provisioning type and the unmap commands supported, e.g.
+// "thin, UNMAP, WRITE SAME(16) UNMAP"
+func (p Provisioning) String() string {
+	s := "full"
+	switch p.Type {
+	case ProvisioningResource:
+		s = "resource"
+	case ProvisioningThin:
+		s = "thin"
+	}
+
+	if p.UnmapSupported() {
+		s += ", UNMAP"
+	}
+	if p.WriteSame16Unmap {
+		s += ", WRITE SAME(16) UNMAP"
+	}
+	if p.WriteSame10Unmap {
+		s += ", WRITE SAME(10) UNMAP"
+	}
+	if p.ReadZeros != 0 {
+		s += ", unmapped blocks read as zeros"
+	}
+
+	return s
+}
+
+// Provisioning returns the provisioning capabilities of a device. Devices which do not report
+// the Block Limits VPD page are left without unmap limits.
+func (d *SCSIDevice) Provisioning() (Provisioning, error) {
+	lbp, err := d.LogicalBlockProvisioning()
+	if err != nil {
+		return Provisioning{}, err
+	}
+
+	limits, _ := d.BlockLimits()
+
+	return Provisioning{LogicalBlockProvisioning: lbp, Limits: limits}, nil
+}
@@ -0,0 +1,197 @@
+/*
+Copyright 2018 The OpenEBS Authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Graphite plaintext and StatsD sinks.
+
+package sink
+
+import (
+	"bytes"
+	"fmt"
+	"net"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+func init() {
+	Register("graphite", openGraphite)
+	Register("statsd", openStatsD)
+}
+
+// Default ports of the Graphite plaintext and StatsD protocols
+const (
+	GraphitePort = "2003"
+	StatsDPort   = "8125"
+)
+
+// MetricPrefix is the first element of the Graphite and StatsD metric paths
+var MetricPrefix = "smart"
+
+// dialTimeout bounds the time taken to connect to Graphite
+const dialTimeout = 10 * time.Second
+
+// withDefaultPort adds a port to a host if it has none
+func withDefaultPort(target, port string) string {
+	if _, _, err := net.SplitHostPort(target); err == nil {
+		return target
+	}
+
+	return net.JoinHostPort(target, port)
+}
+
+// pathElement replaces the characters which are special in metric paths
+func pathElement(s string) string {
+	return strings.Map(func(r rune) rune {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9', r == '-', r == '_':
+			return r
+		}
+		return '_'
+	}, s)
+}
+
+// metricPath returns the dotted path of a field of a point, e.g. smart.sda.smart_attribute.Power_On_Hours.raw
+func metricPath(p Point, field string) string {
+	elems := []string{MetricPrefix, pathElement(strings.TrimPrefix(p.Tags["device"], "/dev/")), pathElement(p.Measurement)}
+	if name := p.Tags["name"]; name != "" {
+		elems = append(elems, pathElement(name))
+	}
+
+	return strings.Join(append(elems, pathElement(field)), ".")
+}
+
+// numericFields returns the numeric fields of a point as strings, sorted by field name. Booleans
+// are reported as 0 or 1 and strings are skipped.
+func numericFields(p Point) ([]string, []string) {
+	var names, values []string
+
+	for name := range p.Fields {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	keep := names[:0]
+	for _, name := range names {
+		var value string
+		switch v := p.Fields[name].(type) {
+		case int64:
+			value = strconv.FormatInt(v, 10)
+		case uint64:
+			value = strconv.FormatUint(v, 10)
+		case float64:
+			value = strconv.FormatFloat(v, 'g', -1, 64)
+		case bool:
+			value = "0"
+			if v {
+				value = "1"
+			}
+		default:
+			continue
+		}
+		keep = append(keep, name)
+		values = append(values, value)
+	}
+
+	return keep, values
+}
+
+// Graphite writes points to a Graphite server using the plaintext protocol
+type Graphite struct {
+	conn net.Conn
+}
+
+// openGraphite opens a Graphite sink, the target is the host[:port] of the server
+func openGraphite(target string) (Sink, error) {
+	conn, err := net.DialTimeout("tcp", withDefaultPort(target, GraphitePort), dialTimeout)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Graphite{conn: conn}, nil
+}
+
+// Write sends points to the Graphite server, one line per numeric field
+func (s *Graphite) Write(points []Point) error {
+	var buf bytes.Buffer
+
+	for _, p := range points {
+		t := p.Time
+		if t.IsZero() {
+			t = time.Now()
+		}
+
+		names, values := numericFields(p)
+		for i, name := range names {
+			fmt.Fprintf(&buf, "%s %s %d\n", metricPath(p, name), values[i], t.Unix())
+		}
+	}
+
+	_, err := s.conn.Write(buf.Bytes())
+	return err
+}
+
+// Close closes the connection to the Graphite server
+func (s *Graphite) Close() error {
+	return s.conn.Close()
+}
+
+// StatsD writes points to a StatsD server as gauges
+type StatsD struct {
+	conn net.Conn
+}
+
+// openStatsD opens a StatsD sink, the target is the host[:port] of the server
+func openStatsD(target string) (Sink, error) {
+	conn, err := net.Dial("udp", withDefaultPort(target, StatsDPort))
+	if err != nil {
+		return nil, err
+	}
+
+	return &StatsD{conn: conn}, nil
+}
+
+// statsDMaxPacket keeps StatsD datagrams below the usual MTU
+const statsDMaxPacket = 1400
+
+// Write sends points to the StatsD server, one gauge per numeric field
+func (s *StatsD) Write(points []Point) error {
+	var buf bytes.Buffer
+
+	for _, p := range points {
+		names, values := numericFields(p)
+		for i, name := range names {
+			line := metricPath(p, name) + ":" + values[i] + "|g\n"
+			if buf.Len()+len(line) > statsDMaxPacket && buf.Len() > 0 {
+				if _, err := s.conn.Write(buf.Bytes()); err != nil {
+					return err
+				}
+				buf.Reset()
+			}
+			buf.WriteString(line)
+		}
+	}
+
+	if buf.Len() == 0 {
+		return nil
+	}
+
+	_, err := s.conn.Write(buf.Bytes())
+	return err
+}
+
+// Close closes the StatsD socket
+func (s *StatsD) Close() error {
+	return s.conn.Close()
+}
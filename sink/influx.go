@@ -0,0 +1,202 @@
+/*
+Copyright 2018 The OpenEBS Authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// InfluxDB line protocol sink.
+
+package sink
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"math"
+	"net/http"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+func init() {
+	Register("influx", openInflux)
+}
+
+// influxClient is the HTTP client of the InfluxDB sinks, whose timeout bounds a write so that an
+// unresponsive server does not block the events
+var influxClient = &http.Client{Timeout: 30 * time.Second}
+
+// openInflux opens an InfluxDB line protocol sink. The target is "-" for stdout, an http(s) URL
+// of a write endpoint, e.g. http://localhost:8086/write?db=smart, or a file path to append to.
+func openInflux(target string) (Sink, error) {
+	switch {
+	case target == "" || target == "-":
+		return NewInfluxWriter(nopCloser{os.Stdout}), nil
+	case strings.HasPrefix(target, "http://") || strings.HasPrefix(target, "https://"):
+		return &InfluxHTTP{URL: target, Client: influxClient}, nil
+	}
+
+	f, err := os.OpenFile(target, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0644)
+	if err != nil {
+		return nil, err
+	}
+
+	return NewInfluxWriter(f), nil
+}
+
+// nopCloser is a writer whose Close does nothing, to avoid closing stdout
+type nopCloser struct {
+	io.Writer
+}
+
+func (nopCloser) Close() error { return nil }
+
+var (
+	measurementEscaper = strings.NewReplacer(",", `\,`, " ", `\ `)
+	tagEscaper         = strings.NewReplacer(",", `\,`, "=", `\=`, " ", `\ `)
+	stringEscaper      = strings.NewReplacer(`"`, `\"`, `\`, `\\`)
+)
+
+// AppendLineProtocol appends a point to buf in InfluxDB line protocol, with sorted tags and fields
+func AppendLineProtocol(buf []byte, p Point) []byte {
+	buf = append(buf, measurementEscaper.Replace(p.Measurement)...)
+
+	keys := make([]string, 0, len(p.Tags))
+	for k := range p.Tags {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		if p.Tags[k] == "" {
+			continue
+		}
+		buf = append(buf, ',')
+		buf = append(buf, tagEscaper.Replace(k)...)
+		buf = append(buf, '=')
+		buf = append(buf, tagEscaper.Replace(p.Tags[k])...)
+	}
+
+	keys = keys[:0]
+	for k := range p.Fields {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for i, k := range keys {
+		if i == 0 {
+			buf = append(buf, ' ')
+		} else {
+			buf = append(buf, ',')
+		}
+		buf = append(buf, tagEscaper.Replace(k)...)
+		buf = append(buf, '=')
+		buf = appendFieldValue(buf, p.Fields[k])
+	}
+
+	if !p.Time.IsZero() {
+		buf = append(buf, ' ')
+		buf = strconv.AppendInt(buf, p.Time.UnixNano(), 10)
+	}
+
+	return append(buf, '\n')
+}
+
+// appendFieldValue appends a field value in line protocol syntax
+func appendFieldValue(buf []byte, v interface{}) []byte {
+	switch v := v.(type) {
+	case int64:
+		return append(strconv.AppendInt(buf, v, 10), 'i')
+	case uint64:
+		// Unsigned fields are not supported by InfluxDB 1.x, only use them when needed
+		if v <= math.MaxInt64 {
+			return append(strconv.AppendInt(buf, int64(v), 10), 'i')
+		}
+		return append(strconv.AppendUint(buf, v, 10), 'u')
+	case float64:
+		return strconv.AppendFloat(buf, v, 'g', -1, 64)
+	case bool:
+		return strconv.AppendBool(buf, v)
+	case string:
+		return append(append(append(buf, '"'), stringEscaper.Replace(v)...), '"')
+	}
+
+	return append(append(append(buf, '"'), stringEscaper.Replace(fmt.Sprint(v))...), '"')
+}
+
+// InfluxWriter writes points in InfluxDB line protocol to a writer, e.g. a file or stdout
+type InfluxWriter struct {
+	w io.WriteCloser
+}
+
+// NewInfluxWriter returns a sink writing points in InfluxDB line protocol to w
+func NewInfluxWriter(w io.WriteCloser) *InfluxWriter {
+	return &InfluxWriter{w: w}
+}
+
+// Write writes points to the writer
+func (s *InfluxWriter) Write(points []Point) error {
+	var buf []byte
+	for _, p := range points {
+		buf = AppendLineProtocol(buf, p)
+	}
+
+	_, err := s.w.Write(buf)
+	return err
+}
+
+// Close closes the writer
+func (s *InfluxWriter) Close() error {
+	return s.w.Close()
+}
+
+// InfluxHTTP posts points in InfluxDB line protocol to a write endpoint
+type InfluxHTTP struct {
+	URL    string // e.g. http://localhost:8086/write?db=smart
+	Client *http.Client
+	Token  string // sent as an InfluxDB 2.x authorization token if set
+}
+
+// Write posts points to the write endpoint
+func (s *InfluxHTTP) Write(points []Point) error {
+	var buf []byte
+	for _, p := range points {
+		buf = AppendLineProtocol(buf, p)
+	}
+
+	req, err := http.NewRequest(http.MethodPost, s.URL, bytes.NewReader(buf))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "text/plain; charset=utf-8")
+	if s.Token != "" {
+		req.Header.Set("Authorization", "Token "+s.Token)
+	}
+
+	resp, err := s.Client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode/100 != 2 {
+		body, _ := ioutil.ReadAll(io.LimitReader(resp.Body, 512))
+		return fmt.Errorf("influx write %s: %s: %s", s.URL, resp.Status, bytes.TrimSpace(body))
+	}
+
+	return nil
+}
+
+// Close does nothing, the HTTP client is shared
+func (s *InfluxHTTP) Close() error {
+	return nil
+}
@@ -0,0 +1,90 @@
+/*
+Copyright 2018 The OpenEBS Authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Metric points describing the health of a device.
+
+package sink
+
+import (
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/openebs/smart/atasmart"
+	"github.com/openebs/smart/scsismart"
+)
+
+// Point is a metric sample: a measurement with its tags, field values and time
+type Point struct {
+	Measurement string
+	Tags        map[string]string
+	Fields      map[string]interface{} // int64, uint64, float64, bool or string values
+	Time        time.Time
+}
+
+// deviceTags returns the tags identifying a device
+func deviceTags(device string, attr scsismart.DiskAttr) map[string]string {
+	tags := map[string]string{"device": device}
+
+	if model := strings.TrimSpace(attr.ModelNumber); model != "" {
+		tags["model"] = model
+	}
+	if serial := strings.TrimSpace(attr.SerialNumber); serial != "" {
+		tags["serial"] = serial
+	}
+	if attr.LuWWNDeviceID != "" {
+		tags["wwn"] = attr.LuWWNDeviceID
+	}
+
+	return tags
+}
+
+// DevicePoints returns the metric points of a device: one smart_device point with its capacity
+// and SMART status, and one smart_attribute point per SMART attribute
+func DevicePoints(device string, attr scsismart.DiskAttr, attrs atasmart.AttrCollection, t time.Time) []Point {
+	tags := deviceTags(device, attr)
+
+	points := []Point{{
+		Measurement: "smart_device",
+		Tags:        tags,
+		Fields: map[string]interface{}{
+			"capacity_bytes":  attr.UserCapacity,
+			"smart_supported": attr.SMARTSupported,
+			"failing":         len(attrs.Failing()) > 0,
+		},
+		Time: t,
+	}}
+
+	for _, a := range attrs {
+		attrTags := make(map[string]string, len(tags)+2)
+		for k, v := range tags {
+			attrTags[k] = v
+		}
+		attrTags["id"] = strconv.Itoa(int(a.ID))
+		attrTags["name"] = a.Name
+
+		points = append(points, Point{
+			Measurement: "smart_attribute",
+			Tags:        attrTags,
+			Fields: map[string]interface{}{
+				"value":     int64(a.Value),
+				"worst":     int64(a.Worst),
+				"threshold": int64(a.Threshold),
+				"raw":       a.Raw,
+			},
+			Time: t,
+		})
+	}
+
+	return points
+}
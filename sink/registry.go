@@ -0,0 +1,71 @@
+/*
+Copyright 2018 The OpenEBS Authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Registry of the metric sinks.
+
+package sink
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+)
+
+// Sink writes metric points to a monitoring system
+type Sink interface {
+	Write(points []Point) error
+	Close() error
+}
+
+// Factory opens a sink writing to a target, whose format depends on the sink
+type Factory func(target string) (Sink, error)
+
+var (
+	factoriesMu sync.RWMutex
+	factories   = make(map[string]Factory)
+)
+
+// Register registers a sink under a name, replacing any sink already registered under it
+func Register(name string, factory Factory) {
+	factoriesMu.Lock()
+	defer factoriesMu.Unlock()
+
+	factories[name] = factory
+}
+
+// Open opens the sink registered under a name, writing to target
+func Open(name, target string) (Sink, error) {
+	factoriesMu.RLock()
+	factory, ok := factories[name]
+	factoriesMu.RUnlock()
+
+	if !ok {
+		return nil, fmt.Errorf("unknown sink %q", name)
+	}
+
+	return factory(target)
+}
+
+// Names returns the names of the registered sinks
+func Names() []string {
+	factoriesMu.RLock()
+	defer factoriesMu.RUnlock()
+
+	names := make([]string, 0, len(factories))
+	for name := range factories {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	return names
+}
@@ -0,0 +1,206 @@
+/*
+Copyright 2018 The OpenEBS Authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Cross-validation of the library's values against smartctl's.
+
+package smartctl
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/openebs/smart/render"
+)
+
+// volatileAttrs are the IDs of the attributes whose raw value changes while a disk is in
+// use, such as hour counters and temperatures, which may differ between two reads
+var volatileAttrs = map[uint8]bool{
+	// error rates and hardware ECC recovered, counting the reads
+	1: true, 7: true, 195: true,
+	// power-on, start/stop, power cycle, load cycle and head flying counters
+	4: true, 9: true, 12: true, 193: true, 240: true,
+	// temperatures
+	190: true, 194: true, 231: true,
+	// total LBAs written and read
+	241: true, 242: true,
+}
+
+// Discrepancy is a value the library and smartctl disagree on
+type Discrepancy struct {
+	Field    string // e.g. serial_number or attribute 5 raw
+	Library  string
+	Smartctl string
+
+	// Volatile is set for the values changing over time, whose discrepancy may come from the
+	// two programs reading them at different times
+	Volatile bool
+}
+
+func (d Discrepancy) String() string {
+	s := fmt.Sprintf("%s: library %q, smartctl %q", d.Field, d.Library, d.Smartctl)
+	if d.Volatile {
+		s += " (volatile)"
+	}
+	return s
+}
+
+// comparison accumulates the discrepancies between the report and the smartctl output
+type comparison []Discrepancy
+
+// strings compares two strings ignoring surrounding and repeated white space, if smartctl
+// reported the value
+func (c *comparison) strings(field, library, smartctl string) {
+	library = strings.Join(strings.Fields(library), " ")
+	smartctl = strings.Join(strings.Fields(smartctl), " ")
+	if smartctl != "" && library != smartctl {
+		*c = append(*c, Discrepancy{Field: field, Library: library, Smartctl: smartctl})
+	}
+}
+
+// uints compares two numbers rendered with format, if smartctl reported the value
+func (c *comparison) uints(field, format string, library, smartctl uint64) {
+	if smartctl != 0 && library != smartctl {
+		*c = append(*c, Discrepancy{
+			Field:    field,
+			Library:  fmt.Sprintf(format, library),
+			Smartctl: fmt.Sprintf(format, smartctl),
+		})
+	}
+}
+
+// Compare returns the values of a report that differ from the ones smartctl reported for the
+// same device. Values smartctl did not report are not compared.
+func Compare(r render.Report, out *Output) []Discrepancy {
+	var c comparison
+	attr := r.Attr
+
+	c.strings("model_name", attr.ModelNumber, out.ModelName)
+	c.strings("serial_number", attr.SerialNumber, out.SerialNumber)
+	c.strings("firmware_version", attr.FirmwareRevision, out.FirmwareVersion)
+	c.strings("scsi_vendor", string(attr.SCSIInquiry.VendorID[:]), out.SCSIVendor)
+	c.strings("scsi_product", string(attr.SCSIInquiry.ProductID[:]), out.SCSIProduct)
+	c.strings("scsi_revision", string(attr.SCSIInquiry.ProductRev[:]), out.SCSIRevision)
+	c.uints("wwn", "%#x", attr.WWN, out.WWNValue())
+	c.uints("user_capacity", "%d", attr.UserCapacity, out.UserCapacity.Bytes)
+	c.uints("logical_block_size", "%d", uint64(attr.LBSize), out.LogicalBlockSize)
+	c.uints("physical_block_size", "%d", uint64(attr.PBSize), out.PhysicalBlockSize)
+	c.strings("ata_version", attr.ATAMinorVersion, out.ATAVersion.String)
+	c.strings("sata_version", sataVersion(attr.Transport), sataVersion(out.SATAVersion.String))
+
+	if out.RotationRate != nil && int(attr.RotationRate) != *out.RotationRate {
+		c = append(c, Discrepancy{
+			Field:    "rotation_rate",
+			Library:  fmt.Sprint(attr.RotationRate),
+			Smartctl: fmt.Sprint(*out.RotationRate),
+		})
+	}
+	if out.SMARTSupport != nil && attr.SMARTSupported != out.SMARTSupport.Available {
+		c = append(c, Discrepancy{
+			Field:    "smart_support",
+			Library:  fmt.Sprint(attr.SMARTSupported),
+			Smartctl: fmt.Sprint(out.SMARTSupport.Available),
+		})
+	}
+
+	if out.ATASMARTData != nil && r.SelfTest != nil {
+		status := out.ATASMARTData.SelfTest.Status
+		if r.SelfTest.Result != status.Value>>4 {
+			c = append(c, Discrepancy{
+				Field:    "self_test status",
+				Library:  fmt.Sprintf("%#x", r.SelfTest.Result),
+				Smartctl: fmt.Sprintf("%#x", status.Value>>4),
+				Volatile: true,
+			})
+		}
+	}
+
+	if out.ATASMARTAttributes != nil {
+		c.attributes(r, out.ATASMARTAttributes.Table)
+	}
+
+	return c
+}
+
+// sataVersion returns the SATA revision of a transport description, e.g. SATA 3.2 for the
+// library's "Serial ATA SATA 3.2" or smartctl's "SATA 3.2, 6.0 Gb/s"
+func sataVersion(s string) string {
+	s = strings.TrimPrefix(strings.TrimSpace(s), "Serial ATA ")
+	if i := strings.Index(s, ","); i >= 0 {
+		s = s[:i]
+	}
+	return s
+}
+
+// rawValue returns the 48-bit raw value of an attribute, as smartctl reports it whatever the
+// raw format of the attribute
+func rawValue(raw [6]byte) uint64 {
+	var v uint64
+	for i := len(raw) - 1; i >= 0; i-- {
+		v = v<<8 | uint64(raw[i])
+	}
+	return v
+}
+
+// attributes compares the SMART attribute tables
+func (c *comparison) attributes(r render.Report, table []Attribute) {
+	seen := make(map[uint8]bool)
+	for _, want := range table {
+		seen[want.ID] = true
+		field := fmt.Sprintf("attribute %d", want.ID)
+
+		got, ok := r.Attrs.Get(want.ID)
+		if !ok {
+			*c = append(*c, Discrepancy{Field: field, Library: "missing", Smartctl: want.Name})
+			continue
+		}
+
+		c.strings(field+" name", got.Name, want.Name)
+		volatile := volatileAttrs[want.ID]
+		for _, v := range []struct {
+			name           string
+			library, other uint64
+		}{
+			{"value", uint64(got.Value), uint64(want.Value)},
+			{"worst", uint64(got.Worst), uint64(want.Worst)},
+			{"thresh", uint64(got.Threshold), uint64(want.Thresh)},
+			{"raw", rawValue(got.RawBytes), want.Raw.Value},
+		} {
+			if v.library != v.other {
+				*c = append(*c, Discrepancy{
+					Field:    field + " " + v.name,
+					Library:  fmt.Sprint(v.library),
+					Smartctl: fmt.Sprint(v.other),
+					Volatile: volatile,
+				})
+			}
+		}
+		if got.RawString != want.Raw.String {
+			*c = append(*c, Discrepancy{
+				Field:    field + " raw string",
+				Library:  got.RawString,
+				Smartctl: want.Raw.String,
+				Volatile: volatile,
+			})
+		}
+	}
+
+	for _, got := range r.Attrs {
+		if !seen[got.ID] {
+			*c = append(*c, Discrepancy{
+				Field:    fmt.Sprintf("attribute %d", got.ID),
+				Library:  got.Name,
+				Smartctl: "missing",
+			})
+		}
+	}
+}
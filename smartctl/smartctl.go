@@ -0,0 +1,153 @@
+/*
+Copyright 2018 The OpenEBS Authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Import of the JSON output of smartmontools' smartctl.
+
+package smartctl
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os/exec"
+)
+
+// Exit status bits of smartctl after which its output holds no device data
+const (
+	ExitCommandLine = 1 << 0 // the command line did not parse
+	ExitOpenFailed  = 1 << 1 // the device could not be opened or identified
+)
+
+// Output is the subset of the output of smartctl --json -a compared to the library's values
+type Output struct {
+	Smartctl struct {
+		ExitStatus int `json:"exit_status"`
+		Messages   []struct {
+			String   string `json:"string"`
+			Severity string `json:"severity"`
+		} `json:"messages"`
+	} `json:"smartctl"`
+
+	Device struct {
+		Name     string `json:"name"`
+		Type     string `json:"type"`
+		Protocol string `json:"protocol"` // ATA, SCSI or NVMe
+	} `json:"device"`
+
+	ModelName       string `json:"model_name"`
+	SerialNumber    string `json:"serial_number"`
+	FirmwareVersion string `json:"firmware_version"`
+	SCSIVendor      string `json:"scsi_vendor"`
+	SCSIProduct     string `json:"scsi_product"`
+	SCSIRevision    string `json:"scsi_revision"`
+
+	WWN *struct {
+		NAA uint64 `json:"naa"`
+		OUI uint64 `json:"oui"`
+		ID  uint64 `json:"id"`
+	} `json:"wwn"`
+
+	UserCapacity struct {
+		Blocks uint64 `json:"blocks"`
+		Bytes  uint64 `json:"bytes"`
+	} `json:"user_capacity"`
+	LogicalBlockSize  uint64 `json:"logical_block_size"`
+	PhysicalBlockSize uint64 `json:"physical_block_size"`
+	RotationRate      *int   `json:"rotation_rate"` // 0 for solid state devices
+
+	ATAVersion struct {
+		String string `json:"string"`
+	} `json:"ata_version"`
+	SATAVersion struct {
+		String string `json:"string"`
+	} `json:"sata_version"`
+
+	SMARTSupport *struct {
+		Available bool `json:"available"`
+		Enabled   bool `json:"enabled"`
+	} `json:"smart_support"`
+
+	ATASMARTData *struct {
+		SelfTest struct {
+			Status struct {
+				Value            uint8 `json:"value"`
+				RemainingPercent *int  `json:"remaining_percent"`
+			} `json:"status"`
+		} `json:"self_test"`
+	} `json:"ata_smart_data"`
+
+	ATASMARTAttributes *struct {
+		Table []Attribute `json:"table"`
+	} `json:"ata_smart_attributes"`
+
+	Temperature *struct {
+		Current int `json:"current"`
+	} `json:"temperature"`
+}
+
+// Attribute is an entry of the ATA SMART attribute table of smartctl
+type Attribute struct {
+	ID     uint8  `json:"id"`
+	Name   string `json:"name"`
+	Value  uint8  `json:"value"`
+	Worst  uint8  `json:"worst"`
+	Thresh uint8  `json:"thresh"`
+	Raw    struct {
+		Value  uint64 `json:"value"`
+		String string `json:"string"`
+	} `json:"raw"`
+}
+
+// WWNValue returns the 64-bit WWN reported by smartctl, 0 if none
+func (o *Output) WWNValue() uint64 {
+	if o.WWN == nil {
+		return 0
+	}
+	return o.WWN.NAA<<60 | o.WWN.OUI<<36 | o.WWN.ID
+}
+
+// Parse decodes the output of smartctl --json. The output of a failed smartctl is returned
+// with an error holding its messages.
+func Parse(r io.Reader) (*Output, error) {
+	var out Output
+	if err := json.NewDecoder(r).Decode(&out); err != nil {
+		return nil, fmt.Errorf("failed to decode smartctl output: %v", err)
+	}
+
+	if out.Smartctl.ExitStatus&(ExitCommandLine|ExitOpenFailed) != 0 {
+		msg := fmt.Sprintf("smartctl failed with status %#x", out.Smartctl.ExitStatus)
+		for _, m := range out.Smartctl.Messages {
+			msg += ": " + m.String
+		}
+		return &out, errors.New(msg)
+	}
+
+	return &out, nil
+}
+
+// Run runs smartctl --json -a on a device and parses its output. smartctl reports the health
+// of the device in its exit status, which is not an error as long as its output was written.
+func Run(device string) (*Output, error) {
+	var stdout bytes.Buffer
+	cmd := exec.Command("smartctl", "--json", "-a", device)
+	cmd.Stdout = &stdout
+
+	err := cmd.Run()
+	if _, ok := err.(*exec.ExitError); err != nil && !ok {
+		return nil, err
+	}
+
+	return Parse(&stdout)
+}
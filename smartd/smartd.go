@@ -0,0 +1,189 @@
+/*
+Copyright 2018 The OpenEBS Authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Parser of the configuration files of smartmontools' smartd.
+
+package smartd
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+)
+
+// Special device names of smartd.conf
+const (
+	DeviceScan = "DEVICESCAN" // monitors all the devices found during a scan
+	Default    = "DEFAULT"    // sets the directives of the entries that follow it
+)
+
+// argDirectives are the directives taking an argument, the others are flags
+var argDirectives = map[string]bool{
+	"-B": true, "-c": true, "-C": true, "-d": true, "-e": true, "-F": true, "-i": true,
+	"-I": true, "-l": true, "-m": true, "-M": true, "-n": true, "-o": true, "-P": true,
+	"-r": true, "-R": true, "-s": true, "-S": true, "-T": true, "-U": true, "-v": true,
+	"-W": true,
+}
+
+// Directive is a directive of a smartd.conf entry, e.g. -s with its regex as argument
+type Directive struct {
+	Name string
+	Arg  string // empty for the directives without argument
+}
+
+// Entry is a device line of smartd.conf
+type Entry struct {
+	Line       int    // line number the entry starts on
+	Device     string // device path or DeviceScan
+	Directives []Directive
+}
+
+// Scan reports whether the entry monitors the devices found during a scan
+func (e Entry) Scan() bool {
+	return e.Device == DeviceScan
+}
+
+// Lookup returns the arguments of all the occurrences of a directive in the entry
+func (e Entry) Lookup(name string) []string {
+	var args []string
+	for _, d := range e.Directives {
+		if d.Name == name {
+			args = append(args, d.Arg)
+		}
+	}
+	return args
+}
+
+// Has reports whether the entry has a directive
+func (e Entry) Has(name string) bool {
+	for _, d := range e.Directives {
+		if d.Name == name {
+			return true
+		}
+	}
+	return false
+}
+
+// Config is a parsed smartd.conf
+type Config struct {
+	// Entries are the device entries in file order, with the directives of the preceding
+	// DEFAULT entries prepended. Like smartd, the entries following DEVICESCAN are dropped.
+	Entries []Entry
+}
+
+// ParseFile parses a smartd.conf file, e.g. /etc/smartd.conf
+func ParseFile(path string) (*Config, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	config, err := Parse(f)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %v", path, err)
+	}
+	return config, nil
+}
+
+// Parse parses the content of a smartd.conf file. Comments start with # and a line ending
+// with a backslash continues on the next one.
+func Parse(r io.Reader) (*Config, error) {
+	config := &Config{}
+	var defaults []Directive
+	scanned := false
+
+	scanner := bufio.NewScanner(r)
+	lineNo, start := 0, 0
+	var line strings.Builder
+	for scanner.Scan() {
+		lineNo++
+		text := scanner.Text()
+		if i := strings.IndexByte(text, '#'); i >= 0 {
+			text = text[:i]
+		}
+		text = strings.TrimSpace(text)
+
+		if line.Len() == 0 {
+			start = lineNo
+		}
+		if strings.HasSuffix(text, `\`) {
+			line.WriteString(strings.TrimSuffix(text, `\`))
+			line.WriteByte(' ')
+			continue
+		}
+		line.WriteString(text)
+
+		fields := strings.Fields(line.String())
+		line.Reset()
+		if len(fields) == 0 || scanned {
+			continue
+		}
+
+		directives, err := parseDirectives(fields[1:])
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %v", start, err)
+		}
+
+		switch fields[0] {
+		case Default:
+			defaults = directives
+		default:
+			if !strings.HasPrefix(fields[0], "/") && fields[0] != DeviceScan {
+				return nil, fmt.Errorf("line %d: %q is not a device path", start, fields[0])
+			}
+			config.Entries = append(config.Entries, Entry{
+				Line:       start,
+				Device:     fields[0],
+				Directives: append(append([]Directive(nil), defaults...), directives...),
+			})
+			scanned = fields[0] == DeviceScan
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	if line.Len() > 0 {
+		return nil, fmt.Errorf("line %d: continued past the end of the file", start)
+	}
+
+	return config, nil
+}
+
+// parseDirectives parses the directives following the device name of an entry
+func parseDirectives(fields []string) ([]Directive, error) {
+	var directives []Directive
+	for i := 0; i < len(fields); i++ {
+		name := fields[i]
+		if !strings.HasPrefix(name, "-") || len(name) < 2 {
+			return nil, fmt.Errorf("unexpected %q, expecting a directive", name)
+		}
+
+		// -H and -A accept their argument attached, e.g. -H0x1
+		directive := Directive{Name: name[:2]}
+		if len(name) > 2 {
+			directive.Arg = name[2:]
+		} else if argDirectives[name] {
+			if i+1 >= len(fields) {
+				return nil, fmt.Errorf("directive %s requires an argument", name)
+			}
+			i++
+			directive.Arg = fields[i]
+		}
+		directives = append(directives, directive)
+	}
+
+	return directives, nil
+}
@@ -0,0 +1,303 @@
+/*
+Copyright 2018 The OpenEBS Authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Translation of smartd.conf directives to the monitor options.
+
+package smartd
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/openebs/smart/monitor"
+	"github.com/openebs/smart/scsismart"
+	"github.com/openebs/smart/smartinfo"
+)
+
+// Translation is the monitor configuration equivalent to a smartd.conf
+type Translation struct {
+	Options monitor.Options
+
+	// MailTo are the addresses of the -m directives. The monitor does not send mail, its
+	// events must be forwarded to them by a sink.
+	MailTo []string
+
+	// Warnings describe the directives that were ignored or only approximated
+	Warnings []string
+}
+
+// warnf adds a warning about the directive of an entry
+func (t *Translation) warnf(e Entry, format string, args ...interface{}) {
+	t.Warnings = append(t.Warnings, fmt.Sprintf("line %d: ", e.Line)+fmt.Sprintf(format, args...))
+}
+
+// ignoredDirectives are the directives without equivalent, with the reason they are ignored
+var ignoredDirectives = map[string]string{
+	"-o": "automatic offline testing is left to the device",
+	"-S": "attribute autosave is left to the device",
+	"-n": "devices are polled whatever their power mode",
+	"-M": "no mail is sent",
+	"-i": "all the attributes are monitored",
+	"-r": "raw values are always reported",
+	"-R": "raw values are always reported",
+	"-e": "device settings are not changed",
+	"-B": "the drive database is built in",
+	"-P": "the drive database is built in",
+	"-v": "the drive database is built in",
+	"-F": "firmware bug workarounds are built in",
+	"-c": "the check interval is a monitor option",
+}
+
+// coveredDirectives are the directives whose checks the monitor always does, -a standing for
+// -H -f -t -l error -l selftest -C 197 -U 198
+var coveredDirectives = map[string]bool{
+	"-a": true, "-H": true, "-f": true, "-p": true, "-u": true, "-t": true, "-l": true,
+	"-C": true, "-U": true, "-T": true, "-A": true,
+}
+
+// Translate maps the entries of a smartd.conf to the monitor options. The monitor applies the
+// same settings to every device, so the directives of the entries are merged and a warning is
+// given when they conflict.
+func Translate(config *Config) (*Translation, error) {
+	t := &Translation{}
+	var (
+		scan      bool
+		schedule  string
+		temp      string
+		mailTo    = make(map[string]bool)
+		scheduleE Entry
+	)
+
+	for _, e := range config.Entries {
+		if e.Scan() {
+			scan = true
+		} else if types := e.Lookup("-d"); len(types) > 0 && types[len(types)-1] == "ignore" {
+			t.Options.Scan.Ignore = append(t.Options.Scan.Ignore, smartinfo.DeviceMatch{Path: e.Device})
+			continue
+		}
+
+		override := smartinfo.DeviceOverride{Match: smartinfo.DeviceMatch{Path: e.Device}}
+		if e.Scan() {
+			override.Match.Path = ""
+		}
+
+		for _, d := range e.Directives {
+			switch d.Name {
+			case "-d":
+				override.PassThrough = translateDeviceType(t, e, d.Arg)
+			case "-I":
+				id, err := strconv.ParseUint(d.Arg, 10, 8)
+				if err != nil || id == 0 {
+					t.warnf(e, "-I %s ignored, invalid attribute ID", d.Arg)
+					continue
+				}
+				override.IgnoreAttrs = append(override.IgnoreAttrs, int(id))
+			case "-s":
+				if schedule != "" && schedule != d.Arg {
+					t.warnf(e, "-s %s differs from the schedule of line %d, which is used for all the devices", d.Arg, scheduleE.Line)
+					continue
+				}
+				schedule, scheduleE = d.Arg, e
+			case "-W":
+				if temp != "" && temp != d.Arg {
+					t.warnf(e, "-W %s differs from a previous -W %s, which is used for all the devices", d.Arg, temp)
+					continue
+				}
+				temp = d.Arg
+			case "-m":
+				for _, addr := range strings.Split(d.Arg, ",") {
+					if addr != "<nomailer>" && !mailTo[addr] {
+						mailTo[addr] = true
+						t.MailTo = append(t.MailTo, addr)
+					}
+				}
+			default:
+				if reason, ok := ignoredDirectives[d.Name]; ok {
+					t.warnf(e, "%s ignored, %s", d.Name, reason)
+				} else if !coveredDirectives[d.Name] {
+					t.warnf(e, "unknown directive %s ignored", d.Name)
+				}
+			}
+		}
+
+		if !e.Scan() {
+			t.Options.Devices = append(t.Options.Devices, e.Device)
+		}
+		if override.PassThrough != scsismart.PassThroughAuto || len(override.IgnoreAttrs) > 0 {
+			t.Options.Overrides = append(t.Options.Overrides, override)
+		}
+	}
+
+	// Like smartd, DEVICESCAN monitors the devices not listed before it as well, except those
+	// with -d ignore
+	if scan {
+		t.Options.Devices = nil
+	}
+	if len(t.MailTo) > 0 {
+		t.Warnings = append(t.Warnings, "mail is not sent, forward the monitor events to "+strings.Join(t.MailTo, ", "))
+	}
+
+	if schedule != "" {
+		if err := translateSchedule(t, scheduleE, schedule); err != nil {
+			return nil, fmt.Errorf("line %d: -s %s: %v", scheduleE.Line, schedule, err)
+		}
+	}
+	if temp != "" {
+		if err := translateTemperature(t, temp); err != nil {
+			return nil, fmt.Errorf("-W %s: %v", temp, err)
+		}
+	}
+
+	return t, nil
+}
+
+// translateDeviceType maps the -d directive of an entry to the pass-through type of its device,
+// the other device types being detected
+func translateDeviceType(t *Translation, e Entry, arg string) scsismart.PassThrough {
+	fields := strings.SplitN(arg, ",", 2)
+	switch fields[0] {
+	case "sat":
+		if len(fields) == 2 && fields[1] == "12" {
+			return scsismart.PassThroughSAT12
+		}
+		return scsismart.PassThroughSAT16
+	case "scsi":
+		return scsismart.PassThroughNone
+	case "auto", "ata", "nvme", "removable", "test", "ignore":
+	default:
+		t.warnf(e, "-d %s ignored, the device type is detected", arg)
+	}
+
+	return scsismart.PassThroughAuto
+}
+
+// selfTestAlternative matches an alternative of a -s regex, T/MM/DD/d/HH
+var selfTestAlternative = regexp.MustCompile(`^([A-Za-z])/([0-9.]{2})/([0-9.]{2})/([1-7.]|\[[1-7-]+\])/([0-9]{2})$`)
+
+// translateSchedule maps the long self-tests of a -s regex to maintenance windows starting at
+// the scheduled hours and to a self-test interval, from the shortest period of the schedule
+func translateSchedule(t *Translation, e Entry, schedule string) error {
+	regex := schedule
+	if strings.HasPrefix(regex, "(") && strings.HasSuffix(regex, ")") {
+		regex = regex[1 : len(regex)-1]
+	}
+
+	var interval time.Duration
+	for _, alt := range strings.Split(regex, "|") {
+		m := selfTestAlternative.FindStringSubmatch(alt)
+		if m == nil {
+			return fmt.Errorf("unsupported alternative %q, expecting T/MM/DD/d/HH", alt)
+		}
+		if m[1] != "L" {
+			t.warnf(e, "self-test %s of -s ignored, only extended (L) self-tests are scheduled", alt)
+			continue
+		}
+
+		hour, _ := strconv.Atoi(m[5])
+		if hour > 23 {
+			return fmt.Errorf("invalid hour in %q", alt)
+		}
+		window := monitor.Window{
+			Start: time.Duration(hour) * time.Hour,
+			End:   time.Duration(hour+1) * time.Hour,
+		}
+		period := 24 * time.Hour
+
+		if m[4] != "." {
+			days, err := parseWeekdays(m[4])
+			if err != nil {
+				return fmt.Errorf("%q: %v", alt, err)
+			}
+			window.Weekdays = days
+			period = 7 * 24 * time.Hour / time.Duration(len(days))
+		}
+		if m[3] != ".." {
+			period = 30 * 24 * time.Hour
+			t.warnf(e, "day of month of %s approximated as every 30 days", alt)
+		}
+		if m[2] != ".." {
+			period = 365 * 24 * time.Hour
+			t.warnf(e, "month of %s approximated as every year", alt)
+		}
+
+		t.Options.Maintenance.Windows = append(t.Options.Maintenance.Windows, window)
+		if interval == 0 || period < interval {
+			interval = period
+		}
+	}
+
+	// The monitor waits slightly less than the period, so that a test started late in its
+	// window does not push the next one out of its window
+	if interval > 0 {
+		t.Options.LongSelfTestInterval = interval - time.Hour
+	}
+	return nil
+}
+
+// parseWeekdays parses the day of week field of a -s regex, a digit or a class like [1-5],
+// smartd numbering the days from Monday (1) to Sunday (7)
+func parseWeekdays(field string) ([]time.Weekday, error) {
+	field = strings.TrimSuffix(strings.TrimPrefix(field, "["), "]")
+
+	var days []time.Weekday
+	for i := 0; i < len(field); i++ {
+		first, last := field[i], field[i]
+		if i+2 < len(field) && field[i+1] == '-' {
+			last = field[i+2]
+			i += 2
+		}
+		if first < '1' || last > '7' || first > last {
+			return nil, fmt.Errorf("invalid day of week %q", field)
+		}
+		for day := first; day <= last; day++ {
+			days = append(days, time.Weekday((day-'0')%7))
+		}
+	}
+
+	return days, nil
+}
+
+// translateTemperature maps -W DIFF,INFO,CRIT to the temperature thresholds of all the device
+// classes. 0 keeps the default threshold, the monitor not reporting temperature changes.
+func translateTemperature(t *Translation, arg string) error {
+	var limits [3]int
+	for i, field := range strings.Split(arg, ",") {
+		if i >= len(limits) {
+			return fmt.Errorf("too many fields")
+		}
+		v, err := strconv.Atoi(field)
+		if err != nil || v < 0 {
+			return fmt.Errorf("invalid temperature %q", field)
+		}
+		limits[i] = v
+	}
+	if limits[0] != 0 {
+		t.Warnings = append(t.Warnings, "-W temperature change reports ignored, only the thresholds are alerted on")
+	}
+
+	t.Options.Temperature = make(map[monitor.DeviceClass]monitor.TemperatureThresholds)
+	for class, thresholds := range monitor.DefaultTemperatureThresholds {
+		if limits[1] != 0 {
+			thresholds.Warning = limits[1]
+		}
+		if limits[2] != 0 {
+			thresholds.Critical = limits[2]
+		}
+		t.Options.Temperature[class] = thresholds
+	}
+
+	return nil
+}
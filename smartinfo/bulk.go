@@ -0,0 +1,79 @@
+/*
+Copyright 2018 The OpenEBS Authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Bulk query of the details of all the disks found during a scan.
+
+package smartinfo
+
+import (
+	"context"
+	"sync"
+
+	"github.com/openebs/smart/scsismart"
+)
+
+// GetAllDisksInfo discover the scsi devices and query the details of each of them concurrently.
+// It returns the details of the devices which could be queried and the reason the others could
+// not, both keyed by device path. A device whose details could only partly be queried is in
+// both. The deadline of ctx bounds the commands sent to the devices.
+func GetAllDisksInfo(ctx context.Context) (map[string]scsismart.DiskAttr, map[string]error, error) {
+	devices, err := ScanDevicesE(ScanOptions{})
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var (
+		mu    sync.Mutex
+		wg    sync.WaitGroup
+		attrs = make(map[string]scsismart.DiskAttr)
+		errs  = make(map[string]error)
+	)
+
+	for _, device := range devices {
+		if device.Status != DeviceStatusOK {
+			errs[device.Name] = device.Err
+			continue
+		}
+
+		wg.Add(1)
+		go func(name string) {
+			defer wg.Done()
+
+			attr, err := deviceDetailContext(ctx, name)
+
+			mu.Lock()
+			defer mu.Unlock()
+			if err != nil {
+				errs[name] = err
+				if !scsismart.IsPartial(err) {
+					return
+				}
+			}
+			attrs[name] = attr
+		}(device.Name)
+	}
+
+	wg.Wait()
+
+	return attrs, errs, nil
+}
+
+// deviceDetailContext detects the type of a device and returns its details, sending the commands
+// to the device within the deadline of ctx.
+func deviceDetailContext(ctx context.Context, name string) (scsismart.DiskAttr, error) {
+	if err := ctx.Err(); err != nil {
+		return scsismart.DiskAttr{}, err
+	}
+
+	return DiskDetailWithOptions(name, scsismart.DetectOptions{Context: ctx})
+}
@@ -0,0 +1,110 @@
+/*
+Copyright 2018 The OpenEBS Authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Caching of the identification data of disks between health polls.
+
+package smartinfo
+
+import (
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/openebs/smart/internal/utilities"
+	"github.com/openebs/smart/scsismart"
+)
+
+// DefaultIdentityTTL is the time the identification data of a disk is cached for by default
+const DefaultIdentityTTL = 10 * time.Minute
+
+// identityEntry is the cached identification data of a disk
+type identityEntry struct {
+	fingerprint string
+	attr        scsismart.DiskAttr
+	expires     time.Time
+}
+
+// IdentityCache caches the details of disks returned by the INQUIRY and IDENTIFY sequence, so
+// that frequent polls only send the commands returning changing data such as SMART attributes.
+// An entry is dropped when its TTL expires or when another disk shows up at the same path.
+type IdentityCache struct {
+	ttl     time.Duration
+	mu      sync.Mutex
+	entries map[string]identityEntry
+}
+
+// NewIdentityCache returns a cache keeping the identification data of disks for ttl. A zero ttl
+// selects DefaultIdentityTTL.
+func NewIdentityCache(ttl time.Duration) *IdentityCache {
+	if ttl <= 0 {
+		ttl = DefaultIdentityTTL
+	}
+
+	return &IdentityCache{ttl: ttl, entries: make(map[string]identityEntry)}
+}
+
+// DiskDetail returns the details of a disk, querying the disk only if they are not cached yet
+// or have expired.
+func (c *IdentityCache) DiskDetail(device string) (scsismart.DiskAttr, error) {
+	return c.DiskDetailWithOptions(device, scsismart.DetectOptions{})
+}
+
+// DiskDetailWithOptions returns the details of a disk like DiskDetail, detecting the type of
+// the disk with the given options when they are not cached. Partial details, see
+// scsismart.IsPartial, are returned along with their error but not cached.
+func (c *IdentityCache) DiskDetailWithOptions(device string, opts scsismart.DetectOptions) (scsismart.DiskAttr, error) {
+	fingerprint := sysfsFingerprint(device)
+
+	c.mu.Lock()
+	entry, ok := c.entries[device]
+	c.mu.Unlock()
+
+	if ok && entry.fingerprint == fingerprint && time.Now().Before(entry.expires) {
+		return entry.attr, nil
+	}
+
+	attr, err := DiskDetailWithOptions(device, opts)
+	if err != nil {
+		c.Invalidate(device)
+		return attr, err
+	}
+
+	c.mu.Lock()
+	c.entries[device] = identityEntry{fingerprint: fingerprint, attr: attr, expires: time.Now().Add(c.ttl)}
+	c.mu.Unlock()
+
+	return attr, nil
+}
+
+// Invalidate drops the cached details of a disk
+func (c *IdentityCache) Invalidate(device string) {
+	c.mu.Lock()
+	delete(c.entries, device)
+	c.mu.Unlock()
+}
+
+// Purge drops the cached details of all disks
+func (c *IdentityCache) Purge() {
+	c.mu.Lock()
+	c.entries = make(map[string]identityEntry)
+	c.mu.Unlock()
+}
+
+// sysfsFingerprint returns the identity of the disk at a device path as reported by sysfs,
+// which is cheap to read and changes when the disk behind the path is replaced.
+func sysfsFingerprint(device string) string {
+	name := filepath.Base(device)
+	dev, _ := utilities.ReadSysfs(filepath.Join(utilities.SysBlockPath, name, "dev"))
+
+	return dev + "/" + sysfsWWID(name)
+}
@@ -0,0 +1,101 @@
+/*
+Copyright 2018 The OpenEBS Authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Classification of block devices found during a scan.
+
+package smartinfo
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/openebs/smart/internal/utilities"
+)
+
+// Block device classes
+const (
+	ClassDisk          = "disk"
+	ClassLoop          = "loop"
+	ClassRAM           = "ram"
+	ClassZRAM          = "zram"
+	ClassDeviceMapper  = "dm"
+	ClassMD            = "md"
+	ClassVirtualDevice = "virtual" // any other device without backing hardware
+
+	// Classes of the devices found behind RAID controllers, which are not block devices
+	ClassRAIDMember     = "raid member"
+	ClassRAIDController = "raid controller"
+)
+
+// ScanOptions controls which block devices are returned by a scan
+type ScanOptions struct {
+	// IncludePseudo also returns loop, ram, zram, device-mapper and md devices
+	IncludePseudo bool
+
+	// IncludeRAID also enumerates the physical drives behind RAID controllers, which sends
+	// commands to the controllers themselves
+	IncludeRAID bool
+
+	// Ignore leaves out the devices matching any of its entries, e.g. a bridge which hangs on
+	// ATA pass-through
+	Ignore []DeviceMatch
+}
+
+// Ignored reports whether a device is left out by the ignore list
+func (opts ScanOptions) Ignored(device string) bool {
+	if len(opts.Ignore) == 0 {
+		return false
+	}
+
+	id := readSysfsIdentity(device)
+	for _, m := range opts.Ignore {
+		if m.matches(id) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// BlockDeviceClass classifies a block device by its sysfs entry, e.g. sda or dm-0
+func BlockDeviceClass(name string) string {
+	sysDir := filepath.Join(utilities.SysBlockPath, name)
+
+	exists := func(elem string) bool {
+		_, err := os.Stat(filepath.Join(sysDir, elem))
+		return err == nil
+	}
+
+	switch {
+	case exists("dm") || strings.HasPrefix(name, "dm-"):
+		return ClassDeviceMapper
+	case exists("md") || strings.HasPrefix(name, "md"):
+		return ClassMD
+	case exists("loop") || strings.HasPrefix(name, "loop"):
+		return ClassLoop
+	case strings.HasPrefix(name, "zram"):
+		return ClassZRAM
+	case strings.HasPrefix(name, "ram"):
+		return ClassRAM
+	case !exists("device"):
+		return ClassVirtualDevice
+	}
+
+	return ClassDisk
+}
+
+// IsPseudoDevice returns true for device classes which are not backed by a disk
+func IsPseudoDevice(class string) bool {
+	return class != ClassDisk
+}
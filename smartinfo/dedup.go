@@ -0,0 +1,122 @@
+/*
+Copyright 2018 The OpenEBS Authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Deduplication of scan results for drives reachable through several device nodes.
+
+package smartinfo
+
+import (
+	"strings"
+
+	"github.com/openebs/smart/scsismart"
+)
+
+// LogicalDevice is a drive found during a scan, with all the device nodes it is reachable through
+type LogicalDevice struct {
+	MultipathDevice                    // paths grouped by dm-multipath map or WWID, see ScanMultipath
+	Key             string             // WWID of the drive, or model and serial number if it reports none
+	Attr            scsismart.DiskAttr // details queried through the first path
+	Err             error              // reason the details could not be queried
+}
+
+// identityKey returns the key used to deduplicate a drive, preferring its WWN over its serial number
+func identityKey(attr scsismart.DiskAttr) string {
+	if attr.LuWWNDeviceID != "" {
+		return attr.LuWWNDeviceID
+	}
+
+	serial := strings.TrimSpace(attr.SerialNumber)
+	if serial == "" {
+		return ""
+	}
+
+	return "serial." + strings.TrimSpace(attr.ModelNumber) + "_" + serial
+}
+
+// ScanLogicalDevices discover the scsi devices and deduplicate them, so that a drive reachable
+// through several nodes (multipath, SAS dual-port, dm aliases) is reported once with all its
+// access paths. The paths are grouped like ScanMultipath does, querying one path of each LUN,
+// and the LUNs without a WWID are merged by WWN or serial number.
+func ScanLogicalDevices(opts ScanOptions) ([]LogicalDevice, error) {
+	devices, err := ScanDevicesE(opts)
+	if err != nil {
+		return nil, err
+	}
+
+	var names []string
+	for _, device := range devices {
+		if device.Status == DeviceStatusOK {
+			names = append(names, device.Name)
+		}
+	}
+
+	var logical []LogicalDevice
+	byKey := make(map[string]int)
+
+	// merge adds the paths of a LUN to the drive already found with the same key
+	merge := func(key string, lun MultipathDevice) bool {
+		i, ok := byKey[key]
+		if !ok || key == "" {
+			return false
+		}
+		logical[i].Paths = appendUnique(logical[i].Paths, lun.Paths...)
+		if logical[i].MapName == "" {
+			logical[i].MapName = lun.MapName
+		}
+		return true
+	}
+
+	for _, lun := range groupPaths(names) {
+		key := lun.WWID
+		if merge(key, lun) {
+			continue
+		}
+
+		attr, err := lun.DiskDetail()
+		if key == "" && (err == nil || scsismart.IsPartial(err)) {
+			key = identityKey(attr)
+			if merge(key, lun) {
+				continue
+			}
+		}
+
+		if key != "" {
+			byKey[key] = len(logical)
+		}
+		logical = append(logical, LogicalDevice{MultipathDevice: lun, Key: key, Attr: attr, Err: err})
+	}
+
+	for i := range logical {
+		logical[i].Attr.Paths = logical[i].Paths
+	}
+
+	return logical, nil
+}
+
+// appendUnique appends the elements of add to list which are not already in it
+func appendUnique(list []string, add ...string) []string {
+	for _, s := range add {
+		found := false
+		for _, existing := range list {
+			if existing == s {
+				found = true
+				break
+			}
+		}
+		if !found {
+			list = append(list, s)
+		}
+	}
+
+	return list
+}
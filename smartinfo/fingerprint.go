@@ -0,0 +1,44 @@
+/*
+Copyright 2018 The OpenEBS Authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Stable identifiers of drives for use as storage keys and metrics labels.
+
+package smartinfo
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"strings"
+
+	"github.com/openebs/smart/scsismart"
+)
+
+// Fingerprint returns a stable identifier of a drive, which does not change when the drive is
+// reached through another path or moved to another host. It is derived from the WWN of the drive,
+// falling back to its model and serial number. Drives reporting neither are identified by their
+// model, firmware revision and device path, which is only stable as long as the path is.
+// The firmware revision is left out whenever possible so that an update keeps the identifier.
+func Fingerprint(attr scsismart.DiskAttr) string {
+	key := identityKey(attr)
+	if key == "" {
+		key = "path." + strings.Join([]string{
+			strings.TrimSpace(attr.ModelNumber),
+			strings.TrimSpace(attr.FirmwareRevision),
+			attr.DevPath,
+		}, "_")
+	}
+
+	sum := sha256.Sum256([]byte(key))
+
+	return hex.EncodeToString(sum[:16])
+}
@@ -0,0 +1,163 @@
+/*
+Copyright 2018 The OpenEBS Authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// dm-multipath awareness, grouping the SCSI paths which lead to the same LUN.
+
+package smartinfo
+
+import (
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/openebs/smart/internal/utilities"
+	"github.com/openebs/smart/scsismart"
+)
+
+// MultipathDevice is a LUN which may be reachable through several SCSI paths
+type MultipathDevice struct {
+	MapName string   // dm-multipath map, e.g. /dev/dm-0, empty when not managed by dm-multipath
+	WWID    string   // LUN identifier reported by the kernel, e.g. naa.5000c500a1b2c3d4
+	Paths   []string // SCSI device paths leading to the LUN, e.g. /dev/sdb, /dev/sdf
+}
+
+// sysfsWWID returns the LUN identifier of a SCSI disk as reported by the kernel
+func sysfsWWID(name string) string {
+	wwid, _ := utilities.ReadSysfs(filepath.Join(utilities.SysBlockPath, name, "device", "wwid"))
+	return wwid
+}
+
+// multipathMaps returns the dm-multipath maps keyed on map name with their slave devices
+func multipathMaps() map[string][]string {
+	maps := make(map[string][]string)
+
+	entries, err := ioutil.ReadDir(utilities.SysBlockPath)
+	if err != nil {
+		return maps
+	}
+
+	for _, entry := range entries {
+		name := entry.Name()
+		if !strings.HasPrefix(name, "dm-") {
+			continue
+		}
+
+		// dm-multipath maps have a "mpath-<wwid>" device-mapper uuid
+		uuid, _ := utilities.ReadSysfs(filepath.Join(utilities.SysBlockPath, name, "dm", "uuid"))
+		if !strings.HasPrefix(uuid, "mpath-") {
+			continue
+		}
+
+		slaves, err := ioutil.ReadDir(filepath.Join(utilities.SysBlockPath, name, "slaves"))
+		if err != nil {
+			continue
+		}
+
+		for _, slave := range slaves {
+			maps[name] = append(maps[name], slave.Name())
+		}
+	}
+
+	return maps
+}
+
+// ScanMultipath discover the scsi devices and group the paths which lead to the same LUN,
+// either through a dm-multipath map or a shared WWID, so that each LUN is reported once.
+func ScanMultipath() []MultipathDevice {
+	var devices []string
+	for _, device := range ScanDevices() {
+		devices = append(devices, device.Name)
+	}
+
+	return groupPaths(devices)
+}
+
+// groupPaths groups the device paths which lead to the same LUN, either through a dm-multipath
+// map or a shared WWID. The LUNs of the maps come first, in the order of the map names.
+func groupPaths(devices []string) []MultipathDevice {
+	var luns []MultipathDevice
+
+	scanned := make(map[string]bool)
+	for _, device := range devices {
+		scanned[filepath.Base(device)] = true
+	}
+
+	maps := multipathMaps()
+	mapNames := make([]string, 0, len(maps))
+	for mapName := range maps {
+		mapNames = append(mapNames, mapName)
+	}
+	sort.Strings(mapNames)
+
+	grouped := make(map[string]bool)
+	for _, mapName := range mapNames {
+		lun := MultipathDevice{MapName: filepath.Join("/dev", mapName)}
+		for _, slave := range maps[mapName] {
+			if !scanned[slave] {
+				continue
+			}
+			if lun.WWID == "" {
+				lun.WWID = sysfsWWID(slave)
+			}
+			lun.Paths = append(lun.Paths, filepath.Join("/dev", slave))
+			grouped[slave] = true
+		}
+		if len(lun.Paths) > 0 {
+			luns = append(luns, lun)
+		}
+	}
+
+	byWWID := make(map[string]int)
+	for _, device := range devices {
+		name := filepath.Base(device)
+		if grouped[name] {
+			continue
+		}
+
+		wwid := sysfsWWID(name)
+		if i, ok := byWWID[wwid]; ok && wwid != "" {
+			luns[i].Paths = append(luns[i].Paths, device)
+			continue
+		}
+
+		byWWID[wwid] = len(luns)
+		luns = append(luns, MultipathDevice{WWID: wwid, Paths: []string{device}})
+	}
+
+	return luns
+}
+
+// DiskDetail returns the details of a LUN, querying only its first path. Details which could only
+// partly be queried are returned along with a scsismart.MultiError.
+func (lun MultipathDevice) DiskDetail() (scsismart.DiskAttr, error) {
+	if len(lun.Paths) == 0 {
+		return scsismart.DiskAttr{}, fmt.Errorf("multipath device %s has no paths", lun.MapName)
+	}
+
+	d, err := scsismart.DetectSCSIType(lun.Paths[0])
+	if err != nil {
+		return scsismart.DiskAttr{}, err
+	}
+	defer d.Close()
+
+	diskDetails, err := d.GetDiskInfo()
+	if err != nil && !scsismart.IsPartial(err) {
+		return diskDetails, err
+	}
+
+	diskDetails.Paths = lun.Paths
+
+	return diskDetails, err
+}
@@ -0,0 +1,298 @@
+/*
+Copyright 2018 The OpenEBS Authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Per-device overrides of the options used to query badly behaved devices.
+
+package smartinfo
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/openebs/smart/atasmart"
+	"github.com/openebs/smart/internal/utilities"
+	"github.com/openebs/smart/scsismart"
+)
+
+// Probes which can be disabled on a device
+const (
+	ProbeSMART                   = "smart"       // SMART attributes
+	ProbeSelfTest                = "selftest"    // self-test status and scheduled self-tests
+	ProbeTemperature             = "temperature" // current temperature
+	ProbeInformationalExceptions = "ie"          // SCSI informational exceptions
+)
+
+// DeviceMatch selects devices by path, WWN, serial number or model, read from sysfs so that a
+// device is matched before any command is sent to it. The path, serial number and model may be
+// shell patterns. Empty fields match any device, all the fields set must match.
+//
+// Paths match through symbolic links, e.g. /dev/disk/by-id/ata-* matches /dev/sda and the
+// other way round. The sysfs model of the ATA disks attached to libata is the product
+// identification of their SCSI INQUIRY data, i.e. only the first 16 characters of their model
+// number, so a pattern of a longer model should end with a *.
+type DeviceMatch struct {
+	Path   string `json:"path,omitempty"`
+	WWN    string `json:"wwn,omitempty"` // e.g. 0x5000c500a1b2c3d4 or naa.5000c500a1b2c3d4
+	Serial string `json:"serial,omitempty"`
+	Model  string `json:"model,omitempty"`
+}
+
+// ParseDeviceMatch parses a comma-separated list of path, wwn, serial and model fields, e.g.
+// "model=JMicron*,path=/dev/sd*". A bare value is a path.
+func ParseDeviceMatch(s string) (DeviceMatch, error) {
+	var m DeviceMatch
+	for _, field := range strings.Split(s, ",") {
+		key, value := "path", field
+		if i := strings.Index(field, "="); i >= 0 {
+			key, value = field[:i], field[i+1:]
+		}
+		if value == "" {
+			continue
+		}
+
+		switch key {
+		case "path":
+			m.Path = value
+		case "wwn":
+			m.WWN = value
+		case "serial":
+			m.Serial = value
+		case "model":
+			m.Model = value
+		default:
+			return m, fmt.Errorf("unknown device match field %q", key)
+		}
+	}
+
+	if m == (DeviceMatch{}) {
+		return m, fmt.Errorf("device match %q selects every device", s)
+	}
+	return m, nil
+}
+
+// Matches reports whether the device at a path matches
+func (m DeviceMatch) Matches(device string) bool {
+	return m.matches(readSysfsIdentity(device))
+}
+
+// DeviceOverride overrides how the devices it matches are queried
+type DeviceOverride struct {
+	Match          DeviceMatch           `json:"match"`
+	Timeouts       scsismart.Timeouts    `json:"timeouts"`
+	PassThrough    scsismart.PassThrough `json:"pass_through,omitempty"`
+	DisabledProbes []string              `json:"disabled_probes,omitempty"` // Probe values
+	IgnoreAttrs    []int                 `json:"ignore_attrs,omitempty"`    // SMART attribute IDs, 1 to 255
+}
+
+// Overrides are the per-device overrides of a fleet, applied in order
+type Overrides []DeviceOverride
+
+// ParseOverridesFile reads overrides from a JSON file holding an array of DeviceOverride
+func ParseOverridesFile(path string) (Overrides, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var overrides Overrides
+	if err := json.NewDecoder(f).Decode(&overrides); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+
+	for i, o := range overrides {
+		if _, err := scsismart.ParsePassThrough(string(o.PassThrough)); err != nil {
+			return nil, fmt.Errorf("%s: override %d: %w", path, i, err)
+		}
+		for _, id := range o.IgnoreAttrs {
+			if id < 1 || id > 255 {
+				return nil, fmt.Errorf("%s: override %d: invalid attribute ID %d", path, i, id)
+			}
+		}
+	}
+
+	return overrides, nil
+}
+
+// For returns the override of a device, merging the overrides matching it. The timeouts and
+// pass-through type set by a later override win, the probes and attributes add up.
+func (o Overrides) For(device string) DeviceOverride {
+	var merged DeviceOverride
+	if len(o) == 0 {
+		return merged
+	}
+
+	id := readSysfsIdentity(device)
+	for _, override := range o {
+		if !override.Match.matches(id) {
+			continue
+		}
+
+		merged.Timeouts = mergeTimeouts(merged.Timeouts, override.Timeouts)
+		if override.PassThrough != scsismart.PassThroughAuto {
+			merged.PassThrough = override.PassThrough
+		}
+		merged.DisabledProbes = append(merged.DisabledProbes, override.DisabledProbes...)
+		merged.IgnoreAttrs = append(merged.IgnoreAttrs, override.IgnoreAttrs...)
+	}
+
+	return merged
+}
+
+// DetectOptions applies the timeouts and pass-through type of an override to detect options
+func (o DeviceOverride) DetectOptions(opts scsismart.DetectOptions) scsismart.DetectOptions {
+	opts.Timeouts = mergeTimeouts(opts.Timeouts, o.Timeouts)
+	if o.PassThrough != scsismart.PassThroughAuto {
+		opts.PassThrough = o.PassThrough
+	}
+
+	return opts
+}
+
+// mergeTimeouts overrides the timeouts of t by those set in with
+func mergeTimeouts(t, with scsismart.Timeouts) scsismart.Timeouts {
+	if with.Quick != 0 {
+		t.Quick = with.Quick
+	}
+	if with.Default != 0 {
+		t.Default = with.Default
+	}
+	if with.Long != 0 {
+		t.Long = with.Long
+	}
+
+	return t
+}
+
+// ProbeDisabled reports whether a probe is disabled on the device
+func (o DeviceOverride) ProbeDisabled(probe string) bool {
+	for _, p := range o.DisabledProbes {
+		if p == probe {
+			return true
+		}
+	}
+
+	return false
+}
+
+// FilterAttrs drops the ignored attributes from a collection
+func (o DeviceOverride) FilterAttrs(attrs atasmart.AttrCollection) atasmart.AttrCollection {
+	if len(o.IgnoreAttrs) == 0 {
+		return attrs
+	}
+
+	return attrs.Filter(func(a atasmart.Attr) bool {
+		for _, id := range o.IgnoreAttrs {
+			if int(a.ID) == id {
+				return false
+			}
+		}
+		return true
+	})
+}
+
+// sysfsIdentity is the identity of a device read from sysfs
+type sysfsIdentity struct {
+	path     string
+	resolved string // path with the symbolic links resolved
+	dir      string // sysfs directory of the device
+	wwn      string // lower case, without prefix
+	serial   string
+	model    string
+}
+
+// readSysfsIdentity reads the identity of the device at a path, which may be a symbolic link
+// such as /dev/disk/by-id/...
+func readSysfsIdentity(device string) sysfsIdentity {
+	resolved, err := filepath.EvalSymlinks(device)
+	if err != nil {
+		resolved = device
+	}
+	name := filepath.Base(resolved)
+	dir := filepath.Join(utilities.SysBlockPath, name, "device")
+	if _, err := os.Stat(dir); err != nil {
+		// SCSI generic devices without a block device, e.g. the disks behind a RAID controller
+		dir = filepath.Join(sysClassSCSIGeneric, name, "device")
+	}
+
+	id := sysfsIdentity{path: device, resolved: resolved, dir: dir, wwn: normalizeWWN(sysfsWWID(name))}
+	if serial, err := utilities.ReadSysfs(filepath.Join(dir, "serial")); err == nil {
+		id.serial = serial
+	} else if page, err := ioutil.ReadFile(filepath.Join(dir, "vpd_pg80")); err == nil && len(page) > 4 {
+		// the Unit Serial Number VPD page of SCSI devices
+		id.serial = strings.TrimSpace(string(page[4:]))
+	}
+	if model, err := utilities.ReadSysfs(filepath.Join(dir, "model")); err == nil {
+		id.model = model
+	} else if name, err := utilities.ReadSysfs(filepath.Join(dir, "name")); err == nil {
+		// the product name of SD cards and eMMC devices
+		id.model = name
+	}
+
+	return id
+}
+
+// normalizeWWN strips the prefixes of the notations of a WWN
+func normalizeWWN(wwn string) string {
+	wwn = strings.ToLower(strings.TrimSpace(wwn))
+	for _, prefix := range []string{"wwn-", "naa.", "eui.", "0x"} {
+		wwn = strings.TrimPrefix(wwn, prefix)
+	}
+
+	return wwn
+}
+
+// matches reports whether a device matches
+func (m DeviceMatch) matches(id sysfsIdentity) bool {
+	if m.Path != "" && !pathMatch(m.Path, id) {
+		return false
+	}
+	if m.WWN != "" && normalizeWWN(m.WWN) != id.wwn {
+		return false
+	}
+	if m.Serial != "" && !globMatch(m.Serial, id.serial) {
+		return false
+	}
+	if m.Model != "" && !globMatch(m.Model, id.model) {
+		return false
+	}
+
+	return true
+}
+
+// pathMatch reports whether the path of a device matches a shell pattern, either as given or
+// with the symbolic links resolved, or is the target of one of the paths the pattern matches
+func pathMatch(pattern string, id sysfsIdentity) bool {
+	if globMatch(pattern, id.path) || globMatch(pattern, id.resolved) {
+		return true
+	}
+
+	paths, _ := filepath.Glob(pattern)
+	for _, path := range paths {
+		if resolved, err := filepath.EvalSymlinks(path); err == nil && resolved == id.resolved {
+			return true
+		}
+	}
+
+	return false
+}
+
+// globMatch reports whether a value matches a shell pattern, an invalid pattern matching nothing
+func globMatch(pattern, value string) bool {
+	ok, err := filepath.Match(pattern, value)
+	return err == nil && ok
+}
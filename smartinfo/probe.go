@@ -0,0 +1,121 @@
+/*
+Copyright 2018 The OpenEBS Authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Probing of the monitoring capabilities of the scanned devices.
+
+package smartinfo
+
+import (
+	"path/filepath"
+	"regexp"
+
+	"github.com/openebs/smart/internal/utilities"
+	"github.com/openebs/smart/scsismart"
+)
+
+// raidHostDrivers maps the SCSI host drivers of RAID controllers, which expose logical volumes
+// instead of their physical disks, to the controller family
+var raidHostDrivers = map[string]string{
+	"megaraid_sas": "MegaRAID",
+	"hpsa":         "HPE Smart Array",
+	"smartpqi":     "Microchip SmartPQI",
+	"aacraid":      "Adaptec",
+	"3w-9xxx":      "3ware",
+	"3w-sas":       "3ware",
+	"arcmsr":       "Areca",
+}
+
+var scsiHostName = regexp.MustCompile(`^host\d+$`)
+
+// scsiHostDriver returns the driver of the SCSI host adapter a block device is attached to,
+// e.g. megaraid_sas, or "" if it is not attached to a SCSI host
+func scsiHostDriver(name string) string {
+	devPath, err := filepath.EvalSymlinks(filepath.Join(utilities.SysBlockPath, name, "device"))
+	if err != nil {
+		return ""
+	}
+
+	for dir := devPath; dir != "/" && dir != "."; dir = filepath.Dir(dir) {
+		if host := filepath.Base(dir); scsiHostName.MatchString(host) {
+			driver, _ := utilities.ReadSysfs(filepath.Join("/sys/class/scsi_host", host, "proc_name"))
+			return driver
+		}
+	}
+
+	return ""
+}
+
+// ProbeResult is what can be monitored on a device found during a scan
+type ProbeResult struct {
+	Device
+	Transport    string
+	Model        string
+	SMART        bool // SMART, or informational exceptions for SCSI devices, supported
+	SMARTEnabled bool
+	SelfTest     bool
+
+	// RAIDController is the family of the RAID controller the device is a logical volume of,
+	// whose physical disks can only be monitored through controller specific pass-through
+	RAIDController string
+}
+
+// ProbeDevices scans the devices and reports which of them can be monitored and how. Devices
+// which cannot be opened or identified are returned with the status of the failure.
+func ProbeDevices(opts ScanOptions) ([]ProbeResult, error) {
+	devices, err := ScanDevicesE(opts)
+	if err != nil {
+		return nil, err
+	}
+
+	results := make([]ProbeResult, 0, len(devices))
+	for _, device := range devices {
+		result := ProbeResult{Device: device}
+		result.RAIDController = raidHostDrivers[scsiHostDriver(filepath.Base(device.Name))]
+
+		if device.Status == DeviceStatusOK {
+			result.Status, result.Err = probeCapabilities(&result)
+		}
+		results = append(results, result)
+	}
+
+	return results, nil
+}
+
+// probeCapabilities identifies a device and fills in the capabilities of a probe result,
+// returning the resulting status
+func probeCapabilities(result *ProbeResult) (string, error) {
+	d, err := scsismart.DetectSCSIType(result.Name)
+	if err != nil {
+		return DeviceStatusProbeFailed, err
+	}
+	defer d.Close()
+
+	attr, err := d.GetDiskInfo()
+	if err != nil && !scsismart.IsPartial(err) {
+		return DeviceStatusProbeFailed, err
+	}
+	result.Transport = attr.Transport
+	result.Model = attr.ModelNumber
+
+	if reporter, ok := d.(scsismart.CapabilityReporter); ok {
+		caps, err := reporter.Capabilities()
+		if err != nil {
+			return DeviceStatusProbeFailed, err
+		}
+		result.SMART = caps.SMART
+		result.SMARTEnabled = caps.SMARTEnabled
+		result.SelfTest = caps.SelfTest
+	}
+
+	return DeviceStatusOK, nil
+}
@@ -0,0 +1,160 @@
+/*
+Copyright 2018 The OpenEBS Authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Enumeration of the physical drives hidden behind RAID controllers.
+
+package smartinfo
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/openebs/smart/internal/utilities"
+	"github.com/openebs/smart/scsismart"
+)
+
+const (
+	sysClassSCSIHost    = "/sys/class/scsi_host"
+	sysClassSCSIGeneric = "/sys/class/scsi_generic"
+)
+
+// hiddenDiskHostDrivers are the host drivers which expose the physical drives of their logical
+// volumes as SCSI generic devices without a block device
+var hiddenDiskHostDrivers = map[string]bool{
+	"hpsa":     true,
+	"smartpqi": true,
+	"aacraid":  true,
+}
+
+// legacyRAIDNodes are the patterns of the device nodes of the RAID controllers whose physical
+// drives cannot be enumerated, one node per controller, along with the controller family
+var legacyRAIDNodes = []struct {
+	family  string
+	pattern string
+}{
+	{"HP Smart Array (cciss)", "/dev/cciss/c*d0"},
+	{"3ware 6000/7000/8000", "/dev/twe*"},
+	{"3ware 9000", "/dev/twa*"},
+	{"3ware 9750", "/dev/twl*"},
+}
+
+// scanRAIDDrives returns the physical drives behind the RAID controllers. Controllers whose
+// drives cannot be enumerated are returned as a device with DeviceStatusUnsupported, so that
+// their drives are not silently left out. The drives ignored by opts, and all the drives of the
+// controllers it ignores, are left out before any command is sent to them.
+func scanRAIDDrives(opts ScanOptions) []Device {
+	var devices []Device
+
+	hosts, _ := ioutil.ReadDir(sysClassSCSIHost)
+	for _, entry := range hosts {
+		host := entry.Name()
+		driver, _ := utilities.ReadSysfs(filepath.Join(sysClassSCSIHost, host, "proc_name"))
+		family, ok := raidHostDrivers[driver]
+		if !ok || opts.Ignored(filepath.Join(sysClassSCSIHost, host)) {
+			continue
+		}
+
+		switch {
+		case driver == "megaraid_sas":
+			devices = append(devices, megaraidDrives(host, opts)...)
+		case hiddenDiskHostDrivers[driver]:
+			devices = append(devices, hiddenDisks(host, opts)...)
+		default:
+			devices = append(devices, Device{
+				Name:   filepath.Join(sysClassSCSIHost, host),
+				Class:  ClassRAIDController,
+				Status: DeviceStatusUnsupported,
+				Err:    fmt.Errorf("physical drives of %s controllers cannot be enumerated", family),
+			})
+		}
+	}
+
+	for _, legacy := range legacyRAIDNodes {
+		// Glob returns the nodes sorted, e.g. twa0 before twa1
+		nodes, _ := filepath.Glob(legacy.pattern)
+		for _, node := range nodes {
+			if opts.Ignored(node) {
+				continue
+			}
+			devices = append(devices, Device{
+				Name:   node,
+				Class:  ClassRAIDController,
+				Status: DeviceStatusUnsupported,
+				Err:    fmt.Errorf("physical drives of %s controllers cannot be enumerated", legacy.family),
+			})
+		}
+	}
+
+	return devices
+}
+
+// megaraidDrives returns the physical drives of a MegaRAID controller, e.g. host0, not ignored
+// by opts
+func megaraidDrives(host string, opts ScanOptions) []Device {
+	hostNo, _ := strconv.Atoi(strings.TrimPrefix(host, "host"))
+
+	ids, err := scsismart.MegaRAIDDrives(hostNo)
+	if err != nil {
+		return []Device{{
+			Name:   filepath.Join(sysClassSCSIHost, host),
+			Class:  ClassRAIDController,
+			Status: DeviceStatusProbeFailed,
+			Err:    err,
+		}}
+	}
+
+	devices := make([]Device, 0, len(ids))
+	for _, id := range ids {
+		device := Device{Name: scsismart.MegaRAIDName(hostNo, id), Class: ClassRAIDMember}
+		if opts.Ignored(device.Name) {
+			continue
+		}
+		device.Status, device.Err = probeDevice(device.Name)
+		devices = append(devices, device)
+	}
+
+	return devices
+}
+
+// hiddenDisks returns the SCSI generic devices of a host, e.g. host0, which are disks with no
+// block device attached, not ignored by opts
+func hiddenDisks(host string, opts ScanOptions) []Device {
+	var devices []Device
+
+	entries, _ := ioutil.ReadDir(sysClassSCSIGeneric)
+	for _, entry := range entries {
+		sysDev, err := filepath.EvalSymlinks(filepath.Join(sysClassSCSIGeneric, entry.Name(), "device"))
+		if err != nil || !strings.Contains(sysDev+"/", "/"+host+"/") {
+			continue
+		}
+		if _, err := os.Stat(filepath.Join(sysDev, "block")); err == nil {
+			continue
+		}
+		if t, _ := utilities.ReadSysfs(filepath.Join(sysDev, "type")); t != strconv.Itoa(scsismart.PeripheralDirectAccess) {
+			continue
+		}
+
+		device := Device{Name: filepath.Join("/dev", entry.Name()), Class: ClassRAIDMember}
+		if opts.Ignored(device.Name) {
+			continue
+		}
+		device.Status, device.Err = probeDevice(device.Name)
+		devices = append(devices, device)
+	}
+
+	return devices
+}
@@ -17,69 +17,150 @@ package smartinfo
 
 import (
 	"fmt"
-	"os"
+	"io/ioutil"
 	"path/filepath"
-	"runtime"
+	"strings"
 
-	"github.com/openebs/smart/ioctl"
+	"github.com/openebs/smart/internal/utilities"
 	"github.com/openebs/smart/scsismart"
 )
 
 // ScanDevices discover and return the list of scsi devices.
+// Pseudo block devices such as loop, ram, zram, device-mapper and md nodes are skipped.
 func ScanDevices() []scsismart.SCSIDevice {
+	return ScanDevicesWithOptions(ScanOptions{})
+}
+
+// ScanDevicesWithOptions discover and return the list of scsi devices, classifying each
+// block device in sysfs to decide whether it is returned.
+func ScanDevicesWithOptions(opts ScanOptions) []scsismart.SCSIDevice {
 	var devices []scsismart.SCSIDevice
 
-	// Find all SCSI disk devices
-	files, err := filepath.Glob("/dev/sd*[^0-9]")
+	names, err := scanBlockDevices(opts)
 	if err != nil {
 		return devices
 	}
 
-	for _, file := range files {
-		devices = append(devices, scsismart.SCSIDevice{Name: file})
+	for _, name := range names {
+		devices = append(devices, scsismart.SCSIDevice{Name: filepath.Join("/dev", name)})
 	}
 
 	return devices
 }
 
-// Scan prints the list of SCSI devices
-func Scan() {
-	for _, device := range ScanDevices() {
-		fmt.Printf("%#v\n", device)
+// scanBlockDevices returns the kernel names of the block devices in sysfs selected by opts
+func scanBlockDevices(opts ScanOptions) ([]string, error) {
+	var names []string
+
+	entries, err := ioutil.ReadDir(utilities.SysBlockPath)
+	if err != nil {
+		return nil, fmt.Errorf("scan %s: %w", utilities.SysBlockPath, err)
 	}
 
+	for _, entry := range entries {
+		name := entry.Name()
+
+		if IsPseudoDevice(BlockDeviceClass(name)) {
+			if !opts.IncludePseudo {
+				continue
+			}
+		} else if !strings.HasPrefix(name, "sd") && !strings.HasPrefix(name, "vd") && !isMMCDisk(name) {
+			// Only SCSI, virtio and MMC disks are supported
+			continue
+		}
+
+		if opts.Ignored(filepath.Join("/dev", name)) {
+			continue
+		}
+
+		names = append(names, name)
+	}
+
+	return names, nil
 }
 
-// DiskDetail returs details(disk attributes and their values such as vendor,serialno,etc) of a disk
-func DiskDetail(device string) scsismart.DiskAttr {
-	fmt.Println("Openebs Smart GO Implementation")
-	fmt.Printf("Built with %s on %s (%s)\n\n", runtime.Version(), runtime.GOOS, runtime.GOARCH)
+// isMMCDisk checks whether a block device is the user area of an SD card or eMMC device, rather
+// than one of the boot or RPMB partitions of an eMMC device
+func isMMCDisk(name string) bool {
+	return strings.HasPrefix(name, "mmcblk") && !strings.Contains(name, "boot") && !strings.HasSuffix(name, "rpmb")
+}
 
-	ioctl.CapabilitiesCheck()
+// Device probe status
+const (
+	DeviceStatusOK          = "ok"
+	DeviceStatusOpenFailed  = "open failed"
+	DeviceStatusProbeFailed = "probe failed"
+	DeviceStatusNotDisk     = "not a disk"  // e.g. a CD/DVD drive, a tape or an enclosure
+	DeviceStatusUnsupported = "unsupported" // e.g. a RAID controller whose drives cannot be reached
+)
 
-	var diskDetails scsismart.DiskAttr
+// Device is a device found during a scan along with the outcome of probing it
+type Device struct {
+	Name   string // device path, e.g. /dev/sda, or name of a drive behind a RAID controller
+	Class  string // block device class, e.g. disk or loop
+	Status string // one of the DeviceStatus values
+	Err    error  // reason the device could not be opened or probed
+}
 
-	if device != "" {
-		var (
-			d   scsismart.Dev // interface
-			err error
-		)
+// ScanDevicesE discover the scsi devices and probe each of them. Unlike ScanDevices, it returns
+// an error if the scan itself failed, so that "no disks" can be told apart from "scan failed".
+// The physical drives behind RAID controllers are also returned if opts.IncludeRAID is set.
+func ScanDevicesE(opts ScanOptions) ([]Device, error) {
+	names, err := scanBlockDevices(opts)
+	if err != nil {
+		return nil, err
+	}
 
-		d, err = scsismart.DetectSCSIType(device)
+	devices := make([]Device, 0, len(names))
+	for _, name := range names {
+		device := Device{Name: filepath.Join("/dev", name), Class: BlockDeviceClass(name)}
+		device.Status, device.Err = probeDevice(device.Name)
+		devices = append(devices, device)
+	}
 
-		if err != nil {
-			fmt.Println(err)
-			os.Exit(1)
-		}
+	if opts.IncludeRAID {
+		devices = append(devices, scanRAIDDrives(opts)...)
+	}
 
-		defer d.Close()
+	return devices, nil
+}
 
-		diskDetails, err = d.GetDiskInfo()
-		if err != nil {
-			fmt.Println(err)
-			os.Exit(1)
+// probeDevice opens a device and detects its type, returning the resulting status
+func probeDevice(name string) (string, error) {
+	// Drives behind MegaRAID controllers have no device node of their own
+	if _, _, ok := scsismart.ParseMegaRAIDName(name); !ok {
+		dev := scsismart.SCSIDevice{Name: name}
+		if err := dev.Open(); err != nil {
+			return DeviceStatusOpenFailed, err
 		}
+		dev.Close()
+	}
+
+	d, err := scsismart.DetectSCSIType(name)
+	if _, ok := err.(scsismart.NotDiskError); ok {
+		return DeviceStatusNotDisk, err
+	} else if err != nil {
+		return DeviceStatusProbeFailed, err
+	}
+	d.Close()
+
+	return DeviceStatusOK, nil
+}
 
+// DiskDetail returns the details of a disk, such as its vendor and serial number. The
+// details gathered are returned along with a MultiError when some could not be.
+func DiskDetail(device string) (scsismart.DiskAttr, error) {
+	return DiskDetailWithOptions(device, scsismart.DetectOptions{})
+}
+
+// DiskDetailWithOptions returns the details of a disk like DiskDetail, detecting the type of the
+// disk with the given options
+func DiskDetailWithOptions(device string, opts scsismart.DetectOptions) (scsismart.DiskAttr, error) {
+	d, err := scsismart.DetectSCSITypeWithOptions(device, opts)
+	if err != nil {
+		return scsismart.DiskAttr{}, err
 	}
-	return diskDetails
+	defer d.Close()
+
+	return d.GetDiskInfo()
 }
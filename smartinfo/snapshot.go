@@ -0,0 +1,373 @@
+/*
+Copyright 2018 The OpenEBS Authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Health snapshots of a fleet of ATA, SCSI, NVMe and MMC devices collected in one pass.
+
+package smartinfo
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/openebs/smart/health"
+	"github.com/openebs/smart/internal/utilities"
+	"github.com/openebs/smart/nvme"
+	"github.com/openebs/smart/scsismart"
+)
+
+// Protocol of the device a health snapshot was collected from
+const (
+	ProtocolATA    = "ata"
+	ProtocolSCSI   = "scsi"
+	ProtocolNVMe   = "nvme"
+	ProtocolMMC    = "mmc"
+	ProtocolVirtio = "virtio"
+)
+
+// Verdict of a health snapshot
+const (
+	VerdictPassed  = "passed"
+	VerdictWarning = "warning"
+	VerdictFailed  = "failed"
+	VerdictUnknown = "unknown" // the device reports no health information
+)
+
+// HealthSnapshot is the health of a device in the same form whatever its protocol
+type HealthSnapshot struct {
+	Device   string
+	Protocol string // one of the Protocol values
+	Model    string
+	Serial   string
+	Firmware string
+	Capacity uint64 // bytes
+	Time     time.Time
+
+	Verdict string   // one of the Verdict values
+	Reasons []string // why the verdict is not passed
+	Score   int      // see health.Score, 0 if the verdict is unknown
+	AFR     float64  // estimated annualized failure rate, see health.FailureModel
+
+	Temperature     int // degrees Celsius, valid if HasTemperature
+	HasTemperature  bool
+	PowerOnHours    uint64
+	LifeUsedPercent int    // percent of the rated endurance used, -1 if not reported
+	MediaErrors     uint64 // pending and uncorrectable sectors, or unrecovered media errors
+
+	Err error // reason the snapshot is incomplete, nil if it is complete
+}
+
+// CollectOptions tunes the collection of health snapshots
+type CollectOptions struct {
+	Detect       scsismart.DetectOptions // Context is set from the ctx of the collection
+	Weights      *health.Weights         // health.DefaultWeights if nil
+	FailureModel health.FailureModel     // health.DefaultFailureModel if nil
+	Overrides    Overrides               // per-device timeouts, pass-through types and probes
+	Workers      int                     // devices queried at once, DefaultCollectWorkers if 0
+}
+
+// DefaultCollectWorkers is the default number of devices whose health is queried at once
+const DefaultCollectWorkers = 8
+
+// CollectHealth queries the health of devices concurrently, opts.Workers at once, sending each
+// of them only the commands its protocol needs: IDENTIFY, SMART status, data and thresholds for
+// ATA, the temperature and informational exceptions log pages for SCSI, IDENTIFY and the SMART /
+// Health Information log for NVMe and the wear estimates for MMC. The identity of the other
+// devices is read from sysfs. The snapshots are returned in the order of devices.
+func CollectHealth(ctx context.Context, devices []string, opts CollectOptions) []HealthSnapshot {
+	if opts.Weights == nil {
+		opts.Weights = &health.DefaultWeights
+	}
+	if opts.FailureModel == nil {
+		opts.FailureModel = health.DefaultFailureModel
+	}
+	if opts.Workers <= 0 {
+		opts.Workers = DefaultCollectWorkers
+	}
+	opts.Detect.Context = ctx
+
+	var wg sync.WaitGroup
+	snapshots := make([]HealthSnapshot, len(devices))
+	workers := make(chan struct{}, opts.Workers)
+
+	for i, device := range devices {
+		wg.Add(1)
+		workers <- struct{}{}
+		go func(i int, name string) {
+			defer func() {
+				<-workers
+				wg.Done()
+			}()
+			snapshots[i] = collectHealth(ctx, name, opts)
+		}(i, device)
+	}
+
+	wg.Wait()
+
+	return snapshots
+}
+
+// ScanHealth discovers the disks, including the NVMe namespaces, and collects their health
+func ScanHealth(ctx context.Context, scan ScanOptions, opts CollectOptions) ([]HealthSnapshot, error) {
+	devices, err := ScanDevicesE(scan)
+	if err != nil {
+		return nil, err
+	}
+
+	var names []string
+	for _, device := range devices {
+		if device.Status == DeviceStatusOK {
+			names = append(names, device.Name)
+		}
+	}
+
+	namespaces, err := ScanNVMeNamespaces(scan)
+	if err != nil {
+		return nil, err
+	}
+
+	return CollectHealth(ctx, append(names, namespaces...), opts), nil
+}
+
+// nvmeNamespaceRe matches the NVMe namespaces in sysfs, leaving out the hidden per-controller
+// paths of multipathed namespaces, e.g. nvme0c0n1
+var nvmeNamespaceRe = regexp.MustCompile(`^nvme[0-9]+n[0-9]+$`)
+
+// ScanNVMeNamespaces returns the device paths of the NVMe namespaces not ignored by opts, which
+// ScanDevicesE leaves out
+func ScanNVMeNamespaces(opts ScanOptions) ([]string, error) {
+	entries, err := ioutil.ReadDir(utilities.SysBlockPath)
+	if err != nil {
+		return nil, fmt.Errorf("scan %s: %w", utilities.SysBlockPath, err)
+	}
+
+	var names []string
+	for _, entry := range entries {
+		name := filepath.Join("/dev", entry.Name())
+		if nvmeNamespaceRe.MatchString(entry.Name()) && !opts.Ignored(name) {
+			names = append(names, name)
+		}
+	}
+
+	return names, nil
+}
+
+// collectHealth dispatches the health query of a device according to its protocol
+func collectHealth(ctx context.Context, name string, opts CollectOptions) HealthSnapshot {
+	s := HealthSnapshot{Device: name, Time: time.Now(), Verdict: VerdictUnknown, LifeUsedPercent: -1}
+
+	if err := ctx.Err(); err != nil {
+		s.Err = err
+		return s
+	}
+
+	if nvmeNamespaceRe.MatchString(filepath.Base(name)) {
+		s.Protocol = ProtocolNVMe
+		s.Err = collectNVMe(&s, name, opts)
+		return s
+	}
+
+	override := opts.Overrides.For(name)
+	d, err := scsismart.DetectSCSITypeWithOptions(name, override.DetectOptions(opts.Detect))
+	if err != nil {
+		s.Err = err
+		return s
+	}
+	defer d.Close()
+
+	s.setSysfsIdentity(name, d)
+
+	switch d := d.(type) {
+	case *scsismart.SATA:
+		s.Protocol = ProtocolATA
+		err = collectATA(&s, d, override, opts)
+	case *scsismart.SCSIDevice:
+		s.Protocol = ProtocolSCSI
+		err = collectSCSI(&s, d, override)
+	case *scsismart.MMCBlk:
+		s.Protocol = ProtocolMMC
+		err = collectMMC(&s, d)
+	case *scsismart.VirtioBlk:
+		// virtual disks report no health, their health is the host's
+		s.Protocol = ProtocolVirtio
+		var status scsismart.HealthStatus
+		status, err = d.HealthStatus()
+		s.setStatus(status)
+	}
+	s.Err = err
+
+	return s
+}
+
+// setSysfsIdentity sets the identity and capacity of a snapshot from sysfs, which takes no
+// command, falling back to READ CAPACITY for the devices which are not block devices. The
+// identity of ATA devices is replaced with the one of ATA IDENTIFY, see collectATA.
+func (s *HealthSnapshot) setSysfsIdentity(name string, d scsismart.Dev) {
+	id := readSysfsIdentity(name)
+	s.Model, s.Serial = id.model, id.serial
+	for _, file := range []string{"rev", "fwrev"} {
+		if rev, err := utilities.ReadSysfs(filepath.Join(id.dir, file)); err == nil {
+			s.Firmware = rev
+			break
+		}
+	}
+
+	// sysfs reports the size of block devices in 512-byte sectors whatever their block size
+	if sectors, err := utilities.ReadSysfsUint(filepath.Join(utilities.SysfsBlockDir(name), "size")); err == nil {
+		s.Capacity = sectors * 512
+	} else if reader, ok := d.(scsismart.CapacityReader); ok {
+		if capacity, err := reader.ReadCapacity(); err == nil {
+			s.Capacity = capacity.Bytes()
+		}
+	}
+}
+
+// collectATA assesses the health of an ATA device from its SMART status, attributes and last
+// self-test, see scsismart.ATAHealthStatus
+func collectATA(s *HealthSnapshot, d *scsismart.SATA, override DeviceOverride, opts CollectOptions) error {
+	if override.ProbeDisabled(ProbeSMART) {
+		return nil
+	}
+	h, err := d.ReadHealth()
+	if err != nil && !scsismart.IsPartial(err) {
+		return err
+	}
+	h.Attrs = override.FilterAttrs(h.Attrs)
+	if override.ProbeDisabled(ProbeSelfTest) {
+		h.SelfTest = nil
+	}
+
+	if h.Model != "" {
+		s.Model = strings.TrimSpace(h.Model)
+		s.Serial = strings.TrimSpace(h.Serial)
+		s.Firmware = strings.TrimSpace(h.Firmware)
+	}
+
+	s.setStatus(scsismart.ATAHealthStatus(h))
+	s.MediaErrors = h.MediaErrors()
+	powerOn, _ := h.Attrs.Get(9)
+	s.PowerOnHours = powerOn.Raw
+	if !override.ProbeDisabled(ProbeTemperature) {
+		s.Temperature, s.HasTemperature = scsismart.AttrTemperature(h.Attrs)
+	}
+	s.score(health.Input{Attrs: h.Attrs, SelfTest: h.SelfTest}, opts)
+
+	return err
+}
+
+// collectSCSI assesses the health of a SCSI device from its informational exceptions, see
+// scsismart.SCSIHealthStatus
+func collectSCSI(s *HealthSnapshot, d *scsismart.SCSIDevice, override DeviceOverride) error {
+	if !override.ProbeDisabled(ProbeTemperature) {
+		if temp, err := d.Temperature(); err == nil {
+			s.Temperature, s.HasTemperature = temp, true
+		}
+	}
+
+	if override.ProbeDisabled(ProbeInformationalExceptions) {
+		return nil
+	}
+	ie, err := d.InformationalExceptions()
+	if err != nil {
+		return err
+	}
+
+	s.setStatus(scsismart.SCSIHealthStatus(ie))
+	if !s.HasTemperature && ie.Temperature >= 0 && !override.ProbeDisabled(ProbeTemperature) {
+		s.Temperature, s.HasTemperature = ie.Temperature, true
+	}
+
+	// SCSI devices report no attributes to score, only whether they predict their failure
+	if s.Verdict == VerdictPassed {
+		s.Score = 100
+	}
+
+	return nil
+}
+
+// collectMMC assesses the health of an SD card or eMMC device from its wear estimates, see
+// scsismart.MMCHealthStatus
+func collectMMC(s *HealthSnapshot, d *scsismart.MMCBlk) error {
+	h, err := d.Health()
+	if err != nil {
+		return err
+	}
+
+	s.setStatus(scsismart.MMCHealthStatus(h))
+	s.LifeUsedPercent = scsismart.MMCLifeUsed(h)
+	if s.Verdict == VerdictPassed {
+		s.Score = 100 - s.LifeUsedPercent/10
+	}
+
+	return nil
+}
+
+// collectNVMe assesses the health of an NVMe device from its SMART / Health Information log
+func collectNVMe(s *HealthSnapshot, name string, opts CollectOptions) error {
+	d := nvme.NVMeDevice{Name: name}
+	if err := d.Open(); err != nil {
+		return err
+	}
+	defer d.Close()
+
+	id, err := d.IdentifyController()
+	if err != nil {
+		return err
+	}
+	s.Model = strings.TrimSpace(string(id.ModelNumber[:]))
+	s.Serial = strings.TrimSpace(string(id.SerialNumber[:]))
+	s.Firmware = strings.TrimSpace(string(id.FirmwareRev[:]))
+	if sectors, err := utilities.ReadSysfsUint(filepath.Join(utilities.SysfsBlockDir(name), "size")); err == nil {
+		s.Capacity = sectors * 512
+	}
+
+	info, err := d.HealthInfo()
+	if err != nil {
+		return err
+	}
+
+	s.setStatus(scsismart.NVMeHealthStatus(info))
+	s.MediaErrors = info.MediaErrors
+	s.LifeUsedPercent = int(info.PercentUsed)
+
+	s.Temperature, s.HasTemperature = info.Temperature, true
+	s.PowerOnHours = info.PowerOnHours
+	s.score(health.Input{CriticalWarning: info.CriticalWarning}, opts)
+
+	return nil
+}
+
+// verdicts are the verdicts of the health states of the devices
+var verdicts = map[scsismart.HealthState]string{
+	scsismart.HealthUnknown: VerdictUnknown,
+	scsismart.HealthOK:      VerdictPassed,
+	scsismart.HealthWarning: VerdictWarning,
+	scsismart.HealthFailing: VerdictFailed,
+}
+
+// setStatus sets the verdict of a snapshot and its reasons from the health of the device
+func (s *HealthSnapshot) setStatus(status scsismart.HealthStatus) {
+	s.Verdict = verdicts[status.State]
+	s.Reasons = status.Reasons
+}
+
+// score sets the health score and failure rate of a snapshot
+func (s *HealthSnapshot) score(input health.Input, opts CollectOptions) {
+	s.Score = health.Score(input, *opts.Weights)
+	s.AFR = opts.FailureModel.AnnualizedFailureRate(input)
+}
@@ -0,0 +1,109 @@
+/*
+Copyright 2018 The OpenEBS Authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Collection of the temperature history kept by devices.
+
+package thermal
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/openebs/smart/atasmart"
+	"github.com/openebs/smart/nvme"
+	"github.com/openebs/smart/scsismart"
+)
+
+// SCTSeries converts an SCT temperature history read at a given time to a series. The most
+// recent entry is taken as read at that time.
+func SCTSeries(h atasmart.SCTTempHistory, readAt time.Time) Series {
+	interval := time.Duration(h.Interval) * time.Minute
+	if interval == 0 {
+		interval = time.Duration(h.SamplingPeriod) * time.Minute
+	}
+	if interval == 0 {
+		interval = time.Minute
+	}
+
+	var series Series
+	for i, temp := range h.Entries {
+		if temp == atasmart.SCTTempNone {
+			continue
+		}
+		age := time.Duration(len(h.Entries)-1-i) * interval
+		series = append(series, Sample{Time: readAt.Add(-age), Celsius: int(temp), Source: SourceSCT})
+	}
+
+	return series
+}
+
+// NVMeSeries converts the thermal data of an NVMe controller read at a given time to a series
+// of the composite temperature and of each implemented sensor
+func NVMeSeries(t nvme.ThermalData, readAt time.Time) Series {
+	series := Series{{Time: readAt, Celsius: t.Composite, Source: SourceNVMe}}
+
+	for sensor := 1; sensor <= 8; sensor++ {
+		if temp, ok := t.Sensors[sensor]; ok {
+			source := fmt.Sprintf("%s sensor %d", SourceNVMe, sensor)
+			series = append(series, Sample{Time: readAt, Celsius: temp, Source: source})
+		}
+	}
+
+	return series
+}
+
+// ReadDevice returns the temperature history a device keeps, along with its current
+// temperature: the SCT temperature history of SATA devices, the current temperature of SCSI
+// devices and the thermal data of NVMe devices.
+func ReadDevice(devPath string) (Series, error) {
+	now := time.Now()
+
+	if strings.HasPrefix(filepath.Base(devPath), "nvme") {
+		d := nvme.NVMeDevice{Name: devPath}
+		if err := d.Open(); err != nil {
+			return nil, err
+		}
+		defer d.Close()
+
+		t, err := d.ThermalData()
+		if err != nil {
+			return nil, err
+		}
+		return NVMeSeries(t, now), nil
+	}
+
+	d, err := scsismart.DetectSCSITypeWithOptions(devPath, scsismart.DetectOptions{})
+	if err != nil {
+		return nil, err
+	}
+	defer d.Close()
+
+	if reader, ok := d.(scsismart.TemperatureHistoryReader); ok {
+		if h, err := reader.SCTTemperatureHistory(); err == nil {
+			return SCTSeries(h, now), nil
+		}
+	}
+
+	reader, ok := d.(scsismart.TemperatureReader)
+	if !ok {
+		return nil, fmt.Errorf("%s does not report its temperature", devPath)
+	}
+	temp, err := reader.Temperature()
+	if err != nil {
+		return nil, err
+	}
+
+	return Series{{Time: now, Celsius: temp, Source: SourceCurrent}}, nil
+}
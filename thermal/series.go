@@ -0,0 +1,110 @@
+/*
+Copyright 2018 The OpenEBS Authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Temperature time series and their CSV and JSON export.
+
+package thermal
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"io"
+	"sort"
+	"strconv"
+	"time"
+)
+
+// Sources of temperature samples
+const (
+	SourceSCT     = "sct"     // ATA SCT temperature history
+	SourceNVMe    = "nvme"    // NVMe composite temperature
+	SourceMonitor = "monitor" // samples taken by a monitor.Monitor
+	SourceCurrent = "current" // current temperature reported by a SCSI or SATA device
+)
+
+// Sample is a temperature reading of a device
+type Sample struct {
+	Time    time.Time `json:"time"`
+	Celsius int       `json:"celsius"`
+	Source  string    `json:"source"` // e.g. SourceSCT, or "nvme sensor 2" for NVMe sensors
+}
+
+// Series is a temperature time series, ordered by time
+type Series []Sample
+
+// Merge returns the samples of several series as a single series ordered by time
+func Merge(series ...Series) Series {
+	var merged Series
+	for _, s := range series {
+		merged = append(merged, s...)
+	}
+
+	sort.SliceStable(merged, func(i, j int) bool { return merged[i].Time.Before(merged[j].Time) })
+
+	return merged
+}
+
+// Filter returns the samples of a series for which keep returns true
+func (s Series) Filter(keep func(Sample) bool) Series {
+	var filtered Series
+	for _, sample := range s {
+		if keep(sample) {
+			filtered = append(filtered, sample)
+		}
+	}
+
+	return filtered
+}
+
+// Between returns the samples taken from from, inclusive, to to, exclusive. A zero time leaves
+// the range open on its side.
+func (s Series) Between(from, to time.Time) Series {
+	return s.Filter(func(sample Sample) bool {
+		return (from.IsZero() || !sample.Time.Before(from)) && (to.IsZero() || sample.Time.Before(to))
+	})
+}
+
+// FromSource returns the samples of a series which come from a source
+func (s Series) FromSource(source string) Series {
+	return s.Filter(func(sample Sample) bool { return sample.Source == source })
+}
+
+// WriteCSV writes a series as CSV with a time,celsius,source header, times in RFC 3339 format
+func (s Series) WriteCSV(w io.Writer) error {
+	cw := csv.NewWriter(w)
+
+	if err := cw.Write([]string{"time", "celsius", "source"}); err != nil {
+		return err
+	}
+	for _, sample := range s {
+		record := []string{sample.Time.Format(time.RFC3339), strconv.Itoa(sample.Celsius), sample.Source}
+		if err := cw.Write(record); err != nil {
+			return err
+		}
+	}
+
+	cw.Flush()
+	return cw.Error()
+}
+
+// WriteJSON writes a series as a JSON array of samples
+func (s Series) WriteJSON(w io.Writer) error {
+	if s == nil {
+		s = Series{}
+	}
+
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+
+	return enc.Encode(s)
+}